@@ -21,8 +21,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 	"url-analyzer/docs"
 	"url-analyzer/internal/database"
 	"url-analyzer/internal/handlers"
@@ -43,7 +51,6 @@ func main() {
 	if err := database.InitDB(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDB()
 
 	if err := database.ValidateSchema(); err != nil {
 		log.Fatalf("Database schema validation failed: %v", err)
@@ -55,12 +62,33 @@ func main() {
 
 	repo := database.GetRepository()
 	crawlerService := services.NewCrawlerService(repo)
+	crawlerService.SetBrowserPath(getEnv("CRAWLER_BROWSER_PATH", ""))
+
+	// Webhooks are told how every crawl ends
+	webhookService := services.NewWebhookService(repo, repo)
+	crawlerService.SetNotifier(webhookService)
+	webhookService.Start()
+
+	workers, err := strconv.Atoi(getEnv("CRAWLER_WORKERS", "4"))
+	if err != nil {
+		log.Fatalf("Invalid CRAWLER_WORKERS value: %v", err)
+	}
+	if err := crawlerService.Start(workers); err != nil {
+		log.Fatalf("Failed to start crawler workers: %v", err)
+	}
+
+	schedulerService := services.NewSchedulerService(repo, crawlerService)
+	schedulerService.Start()
 
 	urlHandler := handlers.NewURLHandler(repo, crawlerService)
 	systemHandler := handlers.NewSystemHandler(repo, crawlerService)
+	scheduleHandler := handlers.NewScheduleHandler(repo, repo)
+	webhookHandler := handlers.NewWebhookHandler(repo, repo)
+	ruleHandler := handlers.NewRuleHandler(repo, repo)
+	sitemapHandler := handlers.NewSitemapHandler(repo, crawlerService)
 
 	// Setup Gin router
-	router := setupRouter(repo, urlHandler, systemHandler)
+	router := setupRouter(repo, urlHandler, systemHandler, scheduleHandler, webhookHandler, ruleHandler, sitemapHandler)
 
 	// Get server configuration
 	port := getEnv("SERVER_PORT", "8000")
@@ -71,13 +99,61 @@ func main() {
 	log.Printf("Swagger documentation: http://%s:%s/swagger/index.html", host, port)
 	log.Printf("Health check: http://%s:%s/api/health", host, port)
 
+	// Event streams never finish on their own; shutting down cancels their requests' context
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        host + ":" + port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+	server.RegisterOnShutdown(cancelRequests)
+
 	// Start server
-	if err := router.Run(host + ":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for a shutdown signal, e.g. from a deploy
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	schedulerService.Stop()
+
+	// Running crawls are cancelled and handed back to the queue for another instance or the next boot
+	stopped := make(chan struct{})
+	go func() {
+		crawlerService.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		// Workers still running may use the database until the process exits, so it stays open.
+		// Their jobs are recovered once their leases expire.
+		log.Println("Crawl workers did not stop in time")
+		return
+	}
+
+	// Deliveries not sent yet stay pending and are sent after the next start
+	webhookService.Stop()
+
+	if err := database.CloseDB(); err != nil {
+		log.Printf("Failed to close database: %v", err)
 	}
 }
 
-func setupRouter(repo database.RepositoryInterface, urlHandler *handlers.URLHandler, systemHandler *handlers.SystemHandler) *gin.Engine {
+func setupRouter(repo database.RepositoryInterface, urlHandler *handlers.URLHandler, systemHandler *handlers.SystemHandler, scheduleHandler *handlers.ScheduleHandler, webhookHandler *handlers.WebhookHandler, ruleHandler *handlers.RuleHandler, sitemapHandler *handlers.SitemapHandler) *gin.Engine {
 	// Set Gin mode based on environment
 	if getEnv("GIN_MODE", "debug") == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -110,18 +186,57 @@ func setupRouter(repo database.RepositoryInterface, urlHandler *handlers.URLHand
 		protected.POST("/urls", urlHandler.CreateURL)
 		protected.GET("/urls", urlHandler.ListURLs)
 		protected.GET("/urls/:id", urlHandler.GetURL)
+		protected.GET("/urls/:id/pages", urlHandler.GetSitePages)
+		protected.GET("/urls/:id/history", urlHandler.GetURLHistory)
+		protected.GET("/urls/:id/diff", urlHandler.GetURLDiff)
+		protected.GET("/urls/:id/links", urlHandler.GetURLLinks)
+		protected.GET("/urls/:id/forms", urlHandler.GetURLForms)
+		protected.GET("/urls/:id/resources", urlHandler.GetURLResources)
+		protected.GET("/urls/:id/findings", urlHandler.GetURLFindings)
+		protected.GET("/urls/:id/rules", urlHandler.GetURLRuleResults)
+		protected.GET("/urls/:id/security", urlHandler.GetURLSecurity)
+		protected.GET("/certificates/expiring", urlHandler.ListExpiringCertificates)
 		protected.DELETE("/urls/:id", urlHandler.DeleteURL)
 		protected.DELETE("/urls", urlHandler.DeleteURLs) // Bulk delete
+		protected.POST("/urls/batch", urlHandler.CreateURLsBatch)
+		protected.POST("/sitemaps", sitemapHandler.ImportSitemap)
 
 		// Crawl control
 		protected.PUT("/urls/:id/start", urlHandler.StartCrawl)
 		protected.PUT("/urls/:id/stop", urlHandler.StopCrawl)
 		protected.PUT("/urls/:id/restart", urlHandler.RestartCrawl)
+		protected.PUT("/urls/:id/crawl-site", urlHandler.StartSiteCrawl)
+		protected.PUT("/urls/batch/start", urlHandler.StartURLsBatch)
+		protected.PUT("/urls/batch/stop", urlHandler.StopURLsBatch)
+		protected.PUT("/urls/batch/restart", urlHandler.RestartURLsBatch)
 		protected.GET("/urls/:id/status", urlHandler.GetCrawlStatus)
+		protected.GET("/urls/:id/events", urlHandler.StreamURLEvents)
+
+		// Recurring crawls
+		protected.POST("/schedules", scheduleHandler.CreateSchedule)
+		protected.GET("/schedules", scheduleHandler.ListSchedules)
+		protected.GET("/schedules/:id", scheduleHandler.GetSchedule)
+		protected.PUT("/schedules/:id", scheduleHandler.UpdateSchedule)
+		protected.DELETE("/schedules/:id", scheduleHandler.DeleteSchedule)
+
+		// Crawl notifications
+		protected.POST("/webhooks", webhookHandler.CreateWebhook)
+		protected.GET("/webhooks", webhookHandler.ListWebhooks)
+		protected.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		protected.GET("/webhooks/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+
+		// Custom page checks
+		protected.POST("/rules", ruleHandler.CreateRule)
+		protected.GET("/rules", ruleHandler.ListRules)
+		protected.GET("/rules/:id", ruleHandler.GetRule)
+		protected.PUT("/rules/:id", ruleHandler.UpdateRule)
+		protected.DELETE("/rules/:id", ruleHandler.DeleteRule)
 
 		// System and monitoring
 		protected.GET("/stats", systemHandler.Stats)
+		protected.GET("/analyzers", systemHandler.ListAnalyzers)
 		protected.GET("/jobs", systemHandler.GetActiveJobs)
+		protected.GET("/jobs/events", systemHandler.StreamJobEvents)
 		protected.POST("/jobs/cleanup", systemHandler.CleanupJobs)
 	}
 
@@ -24,6 +24,122 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/analyzers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns every registered analyzer in the order crawls run them, and whether crawls run it unless their options say otherwise. An analyzer's name is the category of its findings and the key to turn it on or off in the \"analyzers\" crawl option.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "List the analyzers of the crawler pipeline",
+                "responses": {
+                    "200": {
+                        "description": "Registered analyzers",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Verify if the user is authenticated and return user details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Verify authentication",
+                "responses": {
+                    "200": {
+                        "description": "User is authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/certificates/expiring": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the URLs whose latest crawl found a TLS certificate that expires within the given number of days, or has already expired, soonest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "List URLs with expiring TLS certificates",
+                "parameters": [
+                    {
+                        "maximum": 365,
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Days until expiry",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Expiring certificates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/health": {
             "get": {
                 "description": "Get the health status of the API and its dependencies",
@@ -121,50 +237,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/stats": {
+        "/jobs/events": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get detailed statistics about the system, database, and crawler",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Server-sent \"progress\" events for every status, message or progress change of any crawl job on this server. The current state of every job is sent first. Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed, or the current state again when the server restarted meanwhile. EventSource clients may pass the API key as the api_key query parameter.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "System"
                 ],
-                "summary": "Get system statistics",
+                "summary": "Stream the progress of all crawl jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resume after this event ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "System statistics",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "Stream of progress events",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/url-analyzer_internal_models.JobEvent"
                         }
                     }
                 }
             }
         },
-        "/urls": {
+        "/rules": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get a paginated list of URLs with optional filtering and sorting",
+                "description": "List the rules of the calling user, optionally only those evaluated against one URL: its own and those without a URL",
                 "consumes": [
                     "application/json"
                 ],
@@ -172,66 +285,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Rules"
                 ],
-                "summary": "List URLs with pagination and filtering",
+                "summary": "List custom rules",
                 "parameters": [
                     {
                         "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "queued",
-                            "running",
-                            "completed",
-                            "error"
-                        ],
-                        "type": "string",
-                        "description": "Filter by status",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search in URL or title",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "created_at",
-                        "description": "Sort field",
-                        "name": "sort_by",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "asc",
-                            "desc"
-                        ],
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Sort order",
-                        "name": "sort_order",
+                        "description": "Only rules evaluated against this URL",
+                        "name": "url_id",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of URLs",
+                        "description": "Rules",
                         "schema": {
-                            "$ref": "#/definitions/url-analyzer_internal_models.PaginatedResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -256,7 +326,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add a new URL to be crawled and analyzed",
+                "description": "Define a check evaluated against every crawled page: selector_exists and selector_absent take a CSS selector, element_count a selector, a count and an operator (eq, ne, lt, lte, gt, gte; default eq), title_matches a regular expression and text_contains and text_absent a text looked up in the visible text, ignoring case. Without url_id the rule applies to every URL the calling user added, with url_id only to that URL, which must be one of them. Rules take effect on the next crawl.",
                 "consumes": [
                     "application/json"
                 ],
@@ -264,37 +334,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Rules"
                 ],
-                "summary": "Add a new URL for analysis",
+                "summary": "Create a custom rule",
                 "parameters": [
                     {
-                        "description": "URL to add",
+                        "description": "Rule to create",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/url-analyzer_internal_models.CreateURLRequest"
+                            "$ref": "#/definitions/url-analyzer_internal_models.RuleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "URL added successfully",
+                        "description": "Rule created",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid request format",
+                    "403": {
+                        "description": "URL added by another user",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "409": {
-                        "description": "URL already exists",
+                    "404": {
+                        "description": "URL not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -308,14 +384,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/rules/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete multiple URLs by their IDs",
+                "description": "Get a rule of the calling user",
                 "consumes": [
                     "application/json"
                 ],
@@ -323,30 +401,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Rules"
                 ],
-                "summary": "Delete multiple URLs",
+                "summary": "Get a custom rule",
                 "parameters": [
                     {
-                        "description": "List of URL IDs to delete",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_handlers.DeleteURLsRequest"
-                        }
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "URLs deleted successfully",
+                        "description": "Rule",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid request format",
+                    "404": {
+                        "description": "Rule not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -360,16 +442,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/urls/{id}": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get detailed information about a specific URL including crawl results and broken links",
+                "description": "Replace the definition of a rule of the calling user, or enable or disable it. Results of earlier crawls are kept.",
                 "consumes": [
                     "application/json"
                 ],
@@ -377,35 +457,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Rules"
                 ],
-                "summary": "Get detailed information about a URL",
+                "summary": "Replace a custom rule",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "URL ID",
+                        "description": "Rule ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.RuleRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "URL details with crawl results",
+                        "description": "Rule updated",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule ID, request format or rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid URL ID",
+                    "403": {
+                        "description": "URL added by another user",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "URL not found",
+                        "description": "Rule or URL not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -426,7 +521,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a specific URL and all its associated data",
+                "description": "Delete a rule of the calling user. Its results on earlier crawls are kept under its name.",
                 "consumes": [
                     "application/json"
                 ],
@@ -434,13 +529,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Rules"
                 ],
-                "summary": "Delete a URL",
+                "summary": "Delete a custom rule",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "URL ID",
+                        "description": "Rule ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -448,21 +543,21 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "URL deleted successfully",
+                        "description": "Rule deleted successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid URL ID",
+                        "description": "Invalid rule ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "URL not found",
+                        "description": "Rule not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -478,14 +573,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/urls/{id}/restart": {
-            "put": {
+        "/schedules": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Restart the crawling process for a specific URL",
+                "description": "List crawl schedules, optionally only those of one URL",
                 "consumes": [
                     "application/json"
                 ],
@@ -493,28 +588,78 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Crawl Control"
+                    "Schedules"
                 ],
-                "summary": "Restart a crawl for a URL",
+                "summary": "List crawl schedules",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "URL ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only schedules of this URL",
+                        "name": "url_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Crawl restarted successfully",
+                        "description": "Schedules",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid URL ID",
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Crawl a URL on a five-field cron expression (UTC) or every interval_seconds. Runs are skipped while a crawl of the URL is still in progress.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Schedule recurring crawls of a URL",
+                "parameters": [
+                    {
+                        "description": "URL and schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CreateScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Schedule created",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CrawlSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -537,14 +682,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/urls/{id}/start": {
-            "put": {
+        "/schedules/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Start the crawling process for a specific URL",
+                "description": "Get a crawl schedule including its next run and the outcome of the latest run",
                 "consumes": [
                     "application/json"
                 ],
@@ -552,13 +697,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Crawl Control"
+                    "Schedules"
                 ],
-                "summary": "Start crawling a URL",
+                "summary": "Get a crawl schedule",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "URL ID",
+                        "description": "Schedule ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -566,28 +711,20 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Crawl started successfully",
+                        "description": "Schedule",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/url-analyzer_internal_models.CrawlSchedule"
                         }
                     },
                     "400": {
-                        "description": "Invalid URL ID",
+                        "description": "Invalid schedule ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "URL not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "409": {
-                        "description": "Crawl already in progress",
+                        "description": "Schedule not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -601,16 +738,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/urls/{id}/status": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get the current status of a crawl job for a specific URL",
+                "description": "Replace the cron expression or interval of a schedule and enable or disable it. The next run is computed from now.",
                 "consumes": [
                     "application/json"
                 ],
@@ -618,35 +753,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Crawl Control"
+                    "Schedules"
                 ],
-                "summary": "Get the status of a crawl job",
+                "summary": "Change a crawl schedule",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "URL ID",
+                        "description": "Schedule ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.ScheduleRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Crawl job status",
+                        "description": "Schedule updated",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/url-analyzer_internal_models.CrawlSchedule"
                         }
                     },
                     "400": {
-                        "description": "Invalid URL ID",
+                        "description": "Invalid schedule ID or request format",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "No active crawl job found",
+                        "description": "Schedule not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -660,16 +803,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/urls/{id}/stop": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Stop the crawling process for a specific URL",
+                "description": "Delete a crawl schedule. Crawls it already started keep running.",
                 "consumes": [
                     "application/json"
                 ],
@@ -677,13 +818,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Crawl Control"
+                    "Schedules"
                 ],
-                "summary": "Stop crawling a URL",
+                "summary": "Delete a crawl schedule",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "URL ID",
+                        "description": "Schedule ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -691,28 +832,93 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Crawl stopped successfully",
+                        "description": "Schedule deleted successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid URL ID",
+                        "description": "Invalid schedule ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "No active crawl job found",
+                        "description": "Schedule not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "409": {
-                        "description": "Crawl job already finished",
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemaps": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Read a sitemap or sitemap index, given by its URL or uploaded as the multipart file \"file\" (XML or gzip compressed), and add every page it lists as a URL. The sitemaps an index lists are read too. Pages added before are left alone, so importing a sitemap again only adds its new pages. With start_crawl a crawl of every added URL is queued.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Add the pages of a sitemap",
+                "parameters": [
+                    {
+                        "description": "Sitemap URL and import options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.SitemapImportRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Sitemap file, XML or gzip compressed",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Nothing new to add",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.SitemapImportResult"
+                        }
+                    },
+                    "201": {
+                        "description": "URLs added",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.SitemapImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "The sitemap could not be read",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -727,51 +933,2934 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_handlers.DeleteURLsRequest": {
-            "type": "object",
-            "required": [
-                "ids"
-            ],
-            "properties": {
-                "ids": {
-                    "type": "array",
-                    "minItems": 1,
-                    "items": {
-                        "type": "integer"
-                    }
-                }
-            }
         },
-        "url-analyzer_internal_models.CreateURLRequest": {
-            "type": "object",
-            "required": [
-                "url"
-            ],
-            "properties": {
-                "url": {
-                    "type": "string"
+        "/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get detailed statistics about the system, database, and crawler",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Get system statistics",
+                "responses": {
+                    "200": {
+                        "description": "System statistics",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 }
             }
         },
-        "url-analyzer_internal_models.PaginatedResponse": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "page": {
-                    "type": "integer"
-                },
-                "page_size": {
-                    "type": "integer"
-                },
-                "total": {
-                    "type": "integer"
-                },
-                "total_pages": {
-                    "type": "integer"
-                }
+        "/urls": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a paginated list of URLs with optional filtering and sorting",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "List URLs with pagination and filtering",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "queued",
+                            "running",
+                            "completed",
+                            "error",
+                            "blocked"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search in URL or title",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "passing",
+                            "failing"
+                        ],
+                        "type": "string",
+                        "description": "Filter by the outcome of the custom rules on the latest crawl: failing has at least one failed rule, passing has none",
+                        "name": "rules",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Apply the rules filter to this rule only; requires rules",
+                        "name": "rule_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort field",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order",
+                        "name": "sort_order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of URLs",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.PaginatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a new URL to be crawled and analyzed. The URL is crawled as given; one differing from a URL added before only in letter case of the host, a default port, a fragment, a trailing slash or the order of its query parameters is reported as existing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Add a new URL for analysis",
+                "parameters": [
+                    {
+                        "description": "URL to add, optionally with crawl option overrides",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CreateURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "URL added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "URL already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete multiple URLs by their IDs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Delete multiple URLs",
+                "parameters": [
+                    {
+                        "description": "List of URL IDs to delete",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.DeleteURLsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URLs deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add up to 10000 URLs given as a JSON array, a JSON object with urls and options, CSV (the first column, after an optional \"url\" header) or newline-delimited text (blank lines and lines starting with # are skipped). Every entry is reported as created, duplicate or invalid, in the order given. The URLs are added in a single transaction, and URLs added before are reported as duplicates, so sending the same batch again changes nothing. With start_crawl a crawl of every added URL is queued.",
+                "consumes": [
+                    "application/json",
+                    "text/plain",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Add many URLs at once",
+                "parameters": [
+                    {
+                        "description": "URLs to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CreateURLsBatchRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue a crawl of every added URL",
+                        "name": "start_crawl",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Nothing new to add",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CreateURLsBatchResult"
+                        }
+                    },
+                    "201": {
+                        "description": "URLs added",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CreateURLsBatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/batch/restart": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stop any running crawl and start a new one for every URL given by its ID or matched by a filter, at most 10000 of them, and report the outcome URL by URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Restart crawls of many URLs",
+                "parameters": [
+                    {
+                        "description": "IDs or filter of the URLs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Outcome per URL: restarted, not_found or failed",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/batch/start": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start a crawl of every URL given by its ID or matched by a filter, at most 10000 of them, and report the outcome URL by URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Start crawls of many URLs",
+                "parameters": [
+                    {
+                        "description": "IDs or filter of the URLs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Outcome per URL: started, already_running, not_found or failed",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/batch/stop": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stop the crawl of every URL given by its ID or matched by a filter, at most 10000 of them, and report the outcome URL by URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Stop crawls of many URLs",
+                "parameters": [
+                    {
+                        "description": "IDs or filter of the URLs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Outcome per URL: stopped, not_running, not_found or failed",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get detailed information about a specific URL including crawl results with SEO metadata, structured data and the redirect chain, broken links and findings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get detailed information about a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URL details with crawl results",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a specific URL and all its associated data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Delete a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URL deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/crawl-site": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Crawl the site behind a URL breadth-first, following internal links up to a depth and page budget",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Start crawling a whole site",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Depth and page budget (defaults apply when omitted)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.SiteCrawlRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Site crawl started successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Crawl already in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Report what changed between two crawl results of a URL: title, heading and link counts, newly broken links, links found working again and broken links no longer on the page. Without from and to the latest crawl is compared with the one before it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Compare two crawls of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the older crawl result",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the newer crawl result",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changes between the two crawls",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CrawlDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-sent \"progress\" events for every status, message or progress change of the URL's crawl jobs on this server. The current state is sent first. Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed, or the current state again when the server restarted meanwhile. EventSource clients may pass the API key as the api_key query parameter.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Stream the progress of a URL's crawls",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume after this event ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of progress events",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.JobEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/findings": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the findings the analyzers reported about the crawled page, most severe first: SEO, accessibility, structured data, broken resource, security, mixed content and redirect findings, and those of registered custom analyzers. Accessibility and mixed content findings carry the CSS path of the element they are about. Without result_id the latest crawl is used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the findings of a crawl of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the crawl result, e.g. a page of a site crawl",
+                        "name": "result_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category, the name of a registered analyzer such as seo, accessibility, structured_data, resources, security, mixed_content or redirects",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "info",
+                            "warning",
+                            "error"
+                        ],
+                        "type": "string",
+                        "description": "Filter by severity",
+                        "name": "severity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Findings of the crawl",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/forms": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the forms found on the crawled page, each classified as login, signup, password_reset, search or other, with where it submits to and the signals behind its type. Without result_id the latest crawl is used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the forms found by a crawl of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the crawl result, e.g. a page of a site crawl",
+                        "name": "result_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Forms of the crawl",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the past crawl results of a URL, newest first, each with the broken links it found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the crawl history of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Past crawl results",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.PaginatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/links": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get every link found on the crawled page with its anchor text, rel attribute, check outcome and the redirects followed when it was checked. Without result_id the latest crawl is used, for site crawls that is the root page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the links found by a crawl of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the crawl result, e.g. a page of a site crawl",
+                        "name": "result_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "internal",
+                            "external"
+                        ],
+                        "type": "string",
+                        "description": "Only internal or external links",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ok",
+                            "broken",
+                            "blocked",
+                            "unchecked",
+                            "unverified"
+                        ],
+                        "type": "string",
+                        "description": "Filter by check status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ok",
+                            "broken",
+                            "forbidden",
+                            "rate_limited",
+                            "timeout",
+                            "dns_failure",
+                            "tls_error",
+                            "connection_error",
+                            "ignored"
+                        ],
+                        "type": "string",
+                        "description": "Filter by check category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only links that redirected, or only those that did not",
+                        "name": "redirected",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Links of the crawl",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.PaginatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/pages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the pages visited by the latest crawl of a URL as a tree, each page nested under the page it was discovered on",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the pages of the latest site crawl",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page tree of the latest crawl",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "No crawl result found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/resources": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the scripts, stylesheets, images, fonts and iframes of the crawled page with their check outcome and size, and a summary with counts per type, total bytes, third-party hosts and broken resources. Without result_id the latest crawl is used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the resources referenced by a crawl of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the crawl result, e.g. a page of a site crawl",
+                        "name": "result_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "script",
+                            "stylesheet",
+                            "image",
+                            "font",
+                            "iframe"
+                        ],
+                        "type": "string",
+                        "description": "Filter by resource type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ok",
+                            "broken",
+                            "blocked",
+                            "unchecked",
+                            "unverified"
+                        ],
+                        "type": "string",
+                        "description": "Filter by check status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ok",
+                            "broken",
+                            "forbidden",
+                            "rate_limited",
+                            "timeout",
+                            "dns_failure",
+                            "tls_error",
+                            "connection_error",
+                            "ignored"
+                        ],
+                        "type": "string",
+                        "description": "Filter by check category",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resources of the crawl",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/restart": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restart the crawling process for a specific URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Restart a crawl for a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Crawl restarted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/rules": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get whether the crawled page passed each custom rule evaluated against it, failures first. Results keep the name of their rule after it is deleted. Without result_id the latest crawl is used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the outcome of the custom rules on a crawl of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the crawl result, e.g. a page of a site crawl",
+                        "name": "result_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only passed or only failed rules",
+                        "name": "passed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rule results of the crawl",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/security": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the graded security posture of the crawled page: HSTS, CSP, X-Frame-Options, X-Content-Type-Options, Referrer-Policy and Permissions-Policy headers, cookie flags, and the TLS version and certificate of the connection. The score is out of 100, with warnings earning half a check's weight. Without result_id the latest crawl is used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get the security report of a crawl of a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the crawl result, e.g. a page of a site crawl",
+                        "name": "result_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Security report of the crawl",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.SecurityReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Crawl result or security report not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/start": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start the crawling process for a specific URL. Crawl option overrides in the body are used by this crawl and, once it is queued, saved on the URL for later crawls.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Start crawling a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Crawl option overrides",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.StartCrawlRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Crawl started successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID or request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Crawl already in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the current status of a crawl job for a specific URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Get the status of a crawl job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Crawl job status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "No active crawl job found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/urls/{id}/stop": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stop the crawling process for a specific URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Crawl Control"
+                ],
+                "summary": "Stop crawling a URL",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "URL ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Crawl stopped successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "No active crawl job found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Crawl job already finished",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the webhooks registered by the calling user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Have crawl.completed, crawl.failed and crawl.blocked events POSTed to a URL, optionally only for one analyzed URL. Each request carries X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers; the signature is \"sha256=\" followed by the hex HMAC-SHA256 of \"\u003ctimestamp\u003e.\u003cbody\u003e\" keyed with the secret. The secret is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook to register",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Webhook created with its secret",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "URL not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a webhook of the calling user together with its delivery log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the events sent or still to be sent to a webhook, newest first, with their attempts, response codes and errors. Failed attempts are retried with exponential backoff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get the delivery log of a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by delivery status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "$ref": "#/definitions/url-analyzer_internal_models.PaginatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID or query parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_handlers.DeleteURLsRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "url-analyzer_internal_models.BatchCrawlItem": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlStatus"
+                },
+                "url_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.BatchCrawlRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.URLFilter"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "url-analyzer_internal_models.BatchCrawlResult": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.BatchCrawlItem"
+                    }
+                },
+                "succeeded": {
+                    "description": "URLs started, stopped or restarted",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.BatchCrawlStatus": {
+            "type": "string",
+            "enum": [
+                "started",
+                "stopped",
+                "restarted",
+                "already_running",
+                "not_running",
+                "not_found",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "BatchCrawlStarted",
+                "BatchCrawlStopped",
+                "BatchCrawlRestarted",
+                "BatchCrawlAlreadyRunning",
+                "BatchCrawlNotRunning",
+                "BatchCrawlNotFound",
+                "BatchCrawlFailed"
+            ]
+        },
+        "url-analyzer_internal_models.BatchURLResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "why the entry is invalid or its crawl did not start",
+                    "type": "string"
+                },
+                "input": {
+                    "description": "the entry as given",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.BatchURLStatus"
+                },
+                "url": {
+                    "description": "the URL added, the entry without surrounding whitespace",
+                    "type": "string"
+                },
+                "url_id": {
+                    "description": "the added URL, or the one it duplicates",
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.BatchURLStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "duplicate",
+                "invalid"
+            ],
+            "x-enum-comments": {
+                "BatchURLDuplicate": "added before, or listed earlier in the batch"
+            },
+            "x-enum-varnames": [
+                "BatchURLCreated",
+                "BatchURLDuplicate",
+                "BatchURLInvalid"
+            ]
+        },
+        "url-analyzer_internal_models.BrokenLink": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "description": "nil for links found before categories were recorded",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url-analyzer_internal_models.LinkCheckCategory"
+                        }
+                    ]
+                },
+                "crawl_result_id": {
+                    "type": "integer"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_internal": {
+                    "type": "boolean"
+                },
+                "link_text": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "url-analyzer_internal_models.CookieReport": {
+            "type": "object",
+            "properties": {
+                "http_only": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "same_site": {
+                    "description": "\"Strict\", \"Lax\", \"None\" or empty when not set",
+                    "type": "string"
+                },
+                "secure": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "url-analyzer_internal_models.CrawlDiff": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.ValueChange"
+                    }
+                },
+                "from_crawled_at": {
+                    "type": "string"
+                },
+                "from_result_id": {
+                    "type": "integer"
+                },
+                "newly_broken": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.BrokenLink"
+                    }
+                },
+                "newly_fixed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.BrokenLink"
+                    }
+                },
+                "no_longer_linked": {
+                    "description": "broken before, no longer on the page",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.BrokenLink"
+                    }
+                },
+                "to_crawled_at": {
+                    "type": "string"
+                },
+                "to_result_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.CrawlOptionsOverride": {
+            "type": "object",
+            "properties": {
+                "analyzers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "broken_categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.LinkCheckCategory"
+                    }
+                },
+                "check_broken_links": {
+                    "type": "boolean"
+                },
+                "check_resources": {
+                    "type": "boolean"
+                },
+                "concurrent_checks": {
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1
+                },
+                "fetcher": {
+                    "type": "string",
+                    "enum": [
+                        "http",
+                        "browser"
+                    ]
+                },
+                "link_host_rules": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.LinkHostRule"
+                    }
+                },
+                "max_links_to_check": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                },
+                "rate_limit_retries": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 0
+                },
+                "redirect_hop_limit": {
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1
+                },
+                "render_wait_seconds": {
+                    "type": "integer",
+                    "maximum": 30,
+                    "minimum": 0
+                },
+                "timeout_seconds": {
+                    "type": "integer",
+                    "maximum": 120,
+                    "minimum": 1
+                },
+                "user_agent": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                }
+            }
+        },
+        "url-analyzer_internal_models.CrawlSchedule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "cron_expr": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interval_seconds": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.CrawlStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "started",
+                "fetching",
+                "parsing",
+                "analyzing",
+                "checking_links",
+                "completed",
+                "failed",
+                "blocked"
+            ],
+            "x-enum-varnames": [
+                "CrawlStatusQueued",
+                "CrawlStatusStarted",
+                "CrawlStatusFetching",
+                "CrawlStatusParsing",
+                "CrawlStatusAnalyzing",
+                "CrawlStatusChecking",
+                "CrawlStatusCompleted",
+                "CrawlStatusFailed",
+                "CrawlStatusBlocked"
+            ]
+        },
+        "url-analyzer_internal_models.CreateScheduleRequest": {
+            "type": "object",
+            "required": [
+                "url_id"
+            ],
+            "properties": {
+                "cron_expr": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "interval_seconds": {
+                    "type": "integer",
+                    "minimum": 60
+                },
+                "url_id": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "url-analyzer_internal_models.CreateURLRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "options": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.CrawlOptionsOverride"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "url-analyzer_internal_models.CreateURLsBatchRequest": {
+            "type": "object",
+            "properties": {
+                "options": {
+                    "description": "crawl options of the URLs the batch adds",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CrawlOptionsOverride"
+                        }
+                    ]
+                },
+                "start_crawl": {
+                    "description": "queue a crawl of every URL the batch adds",
+                    "type": "boolean"
+                },
+                "urls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "url-analyzer_internal_models.CreateURLsBatchResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "description": "crawls started for the added URLs",
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.BatchURLResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "target_url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 16
+                },
+                "target_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "url_id": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "url-analyzer_internal_models.FindingSeverity": {
+            "type": "string",
+            "enum": [
+                "info",
+                "warning",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "SeverityInfo",
+                "SeverityWarning",
+                "SeverityError"
+            ]
+        },
+        "url-analyzer_internal_models.JobEvent": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "progress": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.CrawlStatus"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "url_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.LinkCheckCategory": {
+            "type": "string",
+            "enum": [
+                "ok",
+                "broken",
+                "forbidden",
+                "rate_limited",
+                "timeout",
+                "dns_failure",
+                "tls_error",
+                "connection_error",
+                "ignored"
+            ],
+            "x-enum-comments": {
+                "LinkCheckBroken": "404, 410, 5xx and other error statuses, too many redirects",
+                "LinkCheckConnectionError": "refused, reset or another transport error",
+                "LinkCheckForbidden": "401, 403 or 999, the server turns the crawler away",
+                "LinkCheckIgnored": "not requested because of a host rule",
+                "LinkCheckRateLimited": "429, still after retrying"
+            },
+            "x-enum-varnames": [
+                "LinkCheckOK",
+                "LinkCheckBroken",
+                "LinkCheckForbidden",
+                "LinkCheckRateLimited",
+                "LinkCheckTimeout",
+                "LinkCheckDNSFailure",
+                "LinkCheckTLSError",
+                "LinkCheckConnectionError",
+                "LinkCheckIgnored"
+            ]
+        },
+        "url-analyzer_internal_models.LinkHostAction": {
+            "type": "string",
+            "enum": [
+                "ignore",
+                "allow"
+            ],
+            "x-enum-varnames": [
+                "LinkHostIgnore",
+                "LinkHostAllow"
+            ]
+        },
+        "url-analyzer_internal_models.LinkHostRule": {
+            "type": "object",
+            "required": [
+                "action",
+                "host"
+            ],
+            "properties": {
+                "action": {
+                    "enum": [
+                        "ignore",
+                        "allow"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url-analyzer_internal_models.LinkHostAction"
+                        }
+                    ]
+                },
+                "host": {
+                    "type": "string"
+                }
+            }
+        },
+        "url-analyzer_internal_models.PaginatedResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.Rule": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "selector": {
+                    "type": "string"
+                },
+                "severity": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.FindingSeverity"
+                },
+                "type": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.RuleType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.RuleRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "type"
+            ],
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "operator": {
+                    "type": "string",
+                    "enum": [
+                        "eq",
+                        "ne",
+                        "lt",
+                        "lte",
+                        "gt",
+                        "gte"
+                    ]
+                },
+                "pattern": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "selector": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "severity": {
+                    "enum": [
+                        "info",
+                        "warning",
+                        "error"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url-analyzer_internal_models.FindingSeverity"
+                        }
+                    ]
+                },
+                "type": {
+                    "enum": [
+                        "selector_exists",
+                        "selector_absent",
+                        "element_count",
+                        "title_matches",
+                        "text_contains",
+                        "text_absent"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url-analyzer_internal_models.RuleType"
+                        }
+                    ]
+                },
+                "url_id": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "url-analyzer_internal_models.RuleType": {
+            "type": "string",
+            "enum": [
+                "selector_exists",
+                "selector_absent",
+                "element_count",
+                "title_matches",
+                "text_contains",
+                "text_absent"
+            ],
+            "x-enum-comments": {
+                "RuleElementCount": "the number of elements the selector matches compares to the count",
+                "RuleSelectorAbsent": "the selector matches no element",
+                "RuleSelectorExists": "the selector matches at least one element",
+                "RuleTextAbsent": "the visible text does not contain the pattern, ignoring case",
+                "RuleTextContains": "the visible text contains the pattern, ignoring case",
+                "RuleTitleMatches": "the title matches the regular expression of the pattern"
+            },
+            "x-enum-varnames": [
+                "RuleSelectorExists",
+                "RuleSelectorAbsent",
+                "RuleElementCount",
+                "RuleTitleMatches",
+                "RuleTextContains",
+                "RuleTextAbsent"
+            ]
+        },
+        "url-analyzer_internal_models.ScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "cron_expr": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "interval_seconds": {
+                    "type": "integer",
+                    "minimum": 60
+                }
+            }
+        },
+        "url-analyzer_internal_models.SecurityCheck": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.SecurityCheckStatus"
+                },
+                "value": {
+                    "description": "the header value or setting the check looked at",
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "url-analyzer_internal_models.SecurityCheckStatus": {
+            "type": "string",
+            "enum": [
+                "pass",
+                "warn",
+                "fail"
+            ],
+            "x-enum-varnames": [
+                "SecurityCheckPass",
+                "SecurityCheckWarn",
+                "SecurityCheckFail"
+            ]
+        },
+        "url-analyzer_internal_models.SecurityReport": {
+            "type": "object",
+            "properties": {
+                "cert_expires_at": {
+                    "type": "string"
+                },
+                "cert_issuer": {
+                    "type": "string"
+                },
+                "cert_subject": {
+                    "type": "string"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.SecurityCheck"
+                    }
+                },
+                "cookies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/url-analyzer_internal_models.CookieReport"
+                    }
+                },
+                "crawl_result_id": {
+                    "type": "integer"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "tls_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "url-analyzer_internal_models.SiteCrawlRequest": {
+            "type": "object",
+            "properties": {
+                "max_depth": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "max_pages": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                }
+            }
+        },
+        "url-analyzer_internal_models.SitemapImportRequest": {
+            "type": "object",
+            "properties": {
+                "max_urls": {
+                    "description": "default 1000",
+                    "type": "integer",
+                    "maximum": 50000,
+                    "minimum": 0
+                },
+                "options": {
+                    "description": "crawl options of the URLs the import adds",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url-analyzer_internal_models.CrawlOptionsOverride"
+                        }
+                    ]
+                },
+                "start_crawl": {
+                    "description": "queue a crawl of every URL the import adds",
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "url-analyzer_internal_models.SitemapImportResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "URLs added",
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "description": "URLs listed more than once",
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "sitemaps that could not be read and URLs that could not be added or crawled",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "existing": {
+                    "description": "URLs that had been added before",
+                    "type": "integer"
+                },
+                "found": {
+                    "description": "URLs listed in them",
+                    "type": "integer"
+                },
+                "invalid": {
+                    "description": "entries that are not absolute http or https URLs of at most 768 characters",
+                    "type": "integer"
+                },
+                "queued": {
+                    "description": "crawls started for the added URLs",
+                    "type": "integer"
+                },
+                "sitemaps": {
+                    "description": "the sitemap files read",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "truncated": {
+                    "description": "max_urls, or the limit on sitemap files, cut the import short",
+                    "type": "boolean"
+                },
+                "url_ids": {
+                    "description": "IDs of the added URLs",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "url-analyzer_internal_models.StartCrawlRequest": {
+            "type": "object",
+            "properties": {
+                "options": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.CrawlOptionsOverride"
+                }
+            }
+        },
+        "url-analyzer_internal_models.URLFilter": {
+            "type": "object",
+            "properties": {
+                "rule_id": {
+                    "description": "narrows rules to the outcome of one rule, so it needs rules",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "rules": {
+                    "description": "the outcome of the custom rules on the latest crawl",
+                    "type": "string",
+                    "enum": [
+                        "passing",
+                        "failing"
+                    ]
+                },
+                "search": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/url-analyzer_internal_models.URLStatus"
+                }
+            }
+        },
+        "url-analyzer_internal_models.URLStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "completed",
+                "error",
+                "blocked"
+            ],
+            "x-enum-varnames": [
+                "StatusQueued",
+                "StatusRunning",
+                "StatusCompleted",
+                "StatusError",
+                "StatusBlocked"
+            ]
+        },
+        "url-analyzer_internal_models.ValueChange": {
+            "type": "object",
+            "properties": {
+                "from": {},
+                "to": {}
             }
         }
     },
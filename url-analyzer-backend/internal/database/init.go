@@ -46,7 +46,7 @@ func ClearTestData() error {
 
 // validates that all required tables exist
 func ValidateSchema() error {
-	requiredTables := []string{"urls", "crawl_results", "broken_links", "users"}
+	requiredTables := []string{"urls", "crawl_results", "broken_links", "users", "crawl_jobs", "crawl_schedules", "webhooks", "webhook_deliveries", "links", "forms", "findings", "page_resources", "security_reports", "rules", "rule_results"}
 	
 	for _, table := range requiredTables {
 		var exists bool
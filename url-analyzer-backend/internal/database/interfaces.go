@@ -1,25 +1,72 @@
 package database
 
-import "url-analyzer/internal/models"
+import (
+	"time"
+	"url-analyzer/internal/models"
+)
 
 // defines the contract for database operations
 type RepositoryInterface interface {
 	// URL operations
-	CreateURL(url string) (*models.URL, error)
+	CreateURL(userID int, url string, crawlOptions *models.CrawlOptionsOverride) (*models.URL, error)
+	CreateURLs(userID int, urls []string, crawlOptions *models.CrawlOptionsOverride) ([]models.BatchURLResult, error)
 	GetURLByID(id int) (*models.URL, error)
 	GetURLByURL(urlStr string) (*models.URL, error)
 	ListURLs(filter models.URLFilter) ([]models.URLWithResult, int, error)
+	ListURLIDs(filter models.URLFilter, limit int) ([]int, error)
 	UpdateURLStatus(id int, status models.URLStatus, errorMessage *string) error
+	UpdateURLCrawlOptions(id int, crawlOptions *models.CrawlOptionsOverride) error
 	DeleteURL(id int) error
 	DeleteURLs(ids []int) error
 	
 	// Crawl Result operations
 	CreateCrawlResult(result *models.CrawlResult) error
 	GetCrawlResultByURLID(urlID int) (*models.CrawlResult, error)
+	GetCrawlResultByID(id int) (*models.CrawlResult, error)
+	ListCrawlResultsByURLID(urlID int, filter models.HistoryFilter) ([]models.CrawlResult, int, error)
+	GetSitePages(rootResultID int) ([]models.CrawlResult, error)
 	
 	// Broken Links operations
 	CreateBrokenLinks(crawlResultID int, brokenLinks []models.BrokenLink) error
 	GetBrokenLinksByURLID(urlID int) ([]models.BrokenLink, error)
+	GetBrokenLinksByCrawlResultID(crawlResultID int) ([]models.BrokenLink, error)
+	GetBrokenLinksByCrawlResultIDs(crawlResultIDs []int) (map[int][]models.BrokenLink, error)
+	
+	// Link inventory operations
+	CreateLinks(crawlResultID int, links []models.Link) error
+	ListLinksByCrawlResultID(crawlResultID int, filter models.LinkFilter) ([]models.Link, int, error)
+	GetLinksByURLs(crawlResultID int, urls []string) ([]models.Link, error)
+	
+	// Page resource operations
+	CreateResources(crawlResultID int, resources []models.PageResource) error
+	GetResourcesByCrawlResultID(crawlResultID int, filter models.ResourceFilter) ([]models.PageResource, error)
+	
+	// Security report operations
+	CreateSecurityReport(report *models.SecurityReport) error
+	GetSecurityReportByCrawlResultID(crawlResultID int) (*models.SecurityReport, error)
+	ListExpiringCertificates(before time.Time) ([]models.ExpiringCertificate, error)
+	
+	// Form operations
+	CreateForms(crawlResultID int, forms []models.Form) error
+	GetFormsByCrawlResultID(crawlResultID int) ([]models.Form, error)
+	
+	// Finding operations
+	CreateFindings(crawlResultID int, findings []models.Finding) error
+	GetFindingsByCrawlResultID(crawlResultID int, filter models.FindingFilter) ([]models.Finding, error)
+	
+	// Custom rule evaluation operations
+	ListRulesForURL(urlID int) ([]models.Rule, error)
+	CreateRuleResults(crawlResultID int, results []models.RuleResult) error
+	GetRuleResultsByCrawlResultID(crawlResultID int, filter models.RuleResultFilter) ([]models.RuleResult, error)
+	
+	// Crawl Job queue operations
+	EnqueueCrawlJob(urlID int, options models.CrawlJobOptions) (*models.CrawlJobRecord, error)
+	ClaimNextCrawlJob(workerID string, lease time.Duration) (*models.CrawlJobRecord, error)
+	HeartbeatCrawlJob(id int, workerID string, lease time.Duration) error
+	FinishCrawlJob(id int, workerID string, outcome models.CrawlJobOutcome, save func(CrawlResultWriter) error) error
+	CancelCrawlJobs(urlID int) (int, error)
+	ReleaseCrawlJob(id int, workerID string) error
+	RecoverCrawlJobs(maxAttempts int) (int, error)
 	
 	// User/Auth operations
 	GetUserByAPIKey(apiKey string) (*models.User, error)
@@ -28,5 +75,63 @@ type RepositoryInterface interface {
 	Ping() error
 }
 
+// defines the contract for saving the results of a crawl, and the webhook deliveries telling
+// about it, directly or as part of finishing its job
+type CrawlResultWriter interface {
+	CreateCrawlResult(result *models.CrawlResult) error
+	CreateBrokenLinks(crawlResultID int, brokenLinks []models.BrokenLink) error
+	CreateLinks(crawlResultID int, links []models.Link) error
+	CreateResources(crawlResultID int, resources []models.PageResource) error
+	CreateSecurityReport(report *models.SecurityReport) error
+	CreateForms(crawlResultID int, forms []models.Form) error
+	CreateFindings(crawlResultID int, findings []models.Finding) error
+	CreateRuleResults(crawlResultID int, results []models.RuleResult) error
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+}
+
+// defines the contract for crawl schedule storage
+type ScheduleRepositoryInterface interface {
+	CreateSchedule(schedule *models.CrawlSchedule) error
+	GetScheduleByID(id int) (*models.CrawlSchedule, error)
+	ListSchedules(filter models.ScheduleFilter) ([]models.CrawlSchedule, error)
+	UpdateSchedule(schedule *models.CrawlSchedule) error
+	DeleteSchedule(id int) error
+	ListDueSchedules(now time.Time, limit int) ([]models.CrawlSchedule, error)
+	AdvanceSchedule(id int, dueAt time.Time, nextRunAt time.Time) (bool, error)
+	RecordScheduleRun(id int, lastError *string) error
+}
+
+// defines the contract for webhook and delivery log storage
+type WebhookRepositoryInterface interface {
+	CreateWebhook(webhook *models.Webhook) error
+	GetWebhookByID(id int) (*models.Webhook, error)
+	ListWebhooksByUserID(userID int) ([]models.Webhook, error)
+	DeleteWebhook(id int) error
+	ListWebhooksForEvent(event string, urlID int) ([]models.Webhook, error)
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+	ListDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	ClaimWebhookDelivery(id int, dueAt time.Time, leaseUntil time.Time) (bool, error)
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(webhookID int, filter models.DeliveryFilter) ([]models.WebhookDelivery, int, error)
+}
+
+// defines the contract for custom rule storage
+type RuleRepositoryInterface interface {
+	CreateRule(rule *models.Rule) error
+	GetRuleByID(id int) (*models.Rule, error)
+	ListRulesByUserID(userID int, filter models.RuleFilter) ([]models.Rule, error)
+	UpdateRule(rule *models.Rule) error
+	DeleteRule(id int) error
+}
+
 // Ensures Repository implement RepositoryInterface
-var _ RepositoryInterface = (*Repository)(nil)
\ No newline at end of file
+var _ RepositoryInterface = (*Repository)(nil)
+
+// Ensures Repository implement ScheduleRepositoryInterface
+var _ ScheduleRepositoryInterface = (*Repository)(nil)
+
+// Ensures Repository implement WebhookRepositoryInterface
+var _ WebhookRepositoryInterface = (*Repository)(nil)
+
+// Ensures Repository implement RuleRepositoryInterface
+var _ RuleRepositoryInterface = (*Repository)(nil)
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 	"url-analyzer/internal/models"
 
 	"github.com/jmoiron/sqlx"
@@ -21,14 +22,19 @@ func NewRepository(db *sqlx.DB) *Repository {
 
 // URL operations
 
-// creates a new URL record
-func (r *Repository) CreateURL(url string) (*models.URL, error) {
+// creates a new URL record owned by the given user
+func (r *Repository) CreateURL(userID int, url string, crawlOptions *models.CrawlOptionsOverride) (*models.URL, error) {
+	key, err := URLKey(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create URL: %w", err)
+	}
+	
 	query := `
-		INSERT INTO urls (url, status) 
-		VALUES (?, ?)
+		INSERT INTO urls (user_id, url, url_key, status, crawl_options) 
+		VALUES (?, ?, ?, ?, ?)
 	`
 	
-	result, err := r.db.Exec(query, url, models.StatusQueued)
+	result, err := r.db.Exec(query, userID, url, key, models.StatusQueued, crawlOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create URL: %w", err)
 	}
@@ -41,11 +47,73 @@ func (r *Repository) CreateURL(url string) (*models.URL, error) {
 	return r.GetURLByID(int(id))
 }
 
+// adds a batch of URLs in a single transaction. URLs that exist already, or an equivalent of them,
+// are left alone and reported as duplicates with their ID, so adding the same batch again changes nothing.
+// New URLs are owned by the given user. Either every URL is added or, on error, none is.
+func (r *Repository) CreateURLs(userID int, urls []string, crawlOptions *models.CrawlOptionsOverride) ([]models.BatchURLResult, error) {
+	results := make([]models.BatchURLResult, 0, len(urls))
+	if len(urls) == 0 {
+		return results, nil
+	}
+	
+	// LAST_INSERT_ID(id) makes the ID of an existing URL the insert ID
+	query := `
+		INSERT INTO urls (user_id, url, url_key, status, crawl_options) 
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)
+	`
+	
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare URL insert: %w", err)
+	}
+	defer stmt.Close()
+	
+	for _, url := range urls {
+		key, err := URLKey(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create URL %s: %w", url, err)
+		}
+		
+		result, err := stmt.Exec(userID, url, key, models.StatusQueued, crawlOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create URL %s: %w", url, err)
+		}
+		
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		
+		status := models.BatchURLCreated
+		if affected == 0 {
+			status = models.BatchURLDuplicate
+		}
+		results = append(results, models.BatchURLResult{Input: url, URL: url, Status: status, URLID: int(id)})
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit URLs: %w", err)
+	}
+	
+	return results, nil
+}
+
 // retrieves a URL by its ID
 func (r *Repository) GetURLByID(id int) (*models.URL, error) {
 	var url models.URL
 	query := `
-		SELECT id, url, status, error_message, created_at, updated_at 
+		SELECT id, user_id, url, status, error_message, crawl_options, created_at, updated_at 
 		FROM urls 
 		WHERE id = ?
 	`
@@ -61,16 +129,21 @@ func (r *Repository) GetURLByID(id int) (*models.URL, error) {
 	return &url, nil
 }
 
-// retrieves a URL by its URL string
+// retrieves a URL by its URL string, or by any URL equivalent to it
 func (r *Repository) GetURLByURL(urlStr string) (*models.URL, error) {
+	key, err := URLKey(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("URL not found")
+	}
+	
 	var url models.URL
 	query := `
-		SELECT id, url, status, error_message, created_at, updated_at 
+		SELECT id, user_id, url, status, error_message, crawl_options, created_at, updated_at 
 		FROM urls 
-		WHERE url = ?
+		WHERE url_key = ?
 	`
 	
-	err := r.db.Get(&url, query, urlStr)
+	err = r.db.Get(&url, query, key)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("URL not found")
@@ -81,9 +154,8 @@ func (r *Repository) GetURLByURL(urlStr string) (*models.URL, error) {
 	return &url, nil
 }
 
-// retrieves URLs with pagination and filtering
-func (r *Repository) ListURLs(filter models.URLFilter) ([]models.URLWithResult, int, error) {
-	// Build WHERE clause
+// builds the WHERE clause selecting the URLs a filter matches, over urls u joined with their latest root crawl result cr
+func urlFilterWhere(filter models.URLFilter) (string, []interface{}) {
 	var whereClauses []string
 	var args []interface{}
 	
@@ -98,10 +170,38 @@ func (r *Repository) ListURLs(filter models.URLFilter) ([]models.URLWithResult,
 		args = append(args, searchTerm, searchTerm)
 	}
 	
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
+	// Custom rule outcomes on any page of the latest crawl
+	if filter.Rules != "" {
+		ruleClause := ""
+		var ruleArgs []interface{}
+		if filter.RuleID != 0 {
+			ruleClause = " AND rr.rule_id = ?"
+			ruleArgs = append(ruleArgs, filter.RuleID)
+		}
+		latestRuleResults := fmt.Sprintf(`
+			SELECT 1 FROM rule_results rr
+			JOIN crawl_results rc ON rc.id = rr.crawl_result_id
+			WHERE (rc.id = %[1]s OR rc.root_id = %[1]s)%[2]s`, latestRootCrawlResultID, ruleClause)
+		
+		if filter.Rules == "failing" {
+			whereClauses = append(whereClauses, "EXISTS ("+latestRuleResults+" AND rr.passed = FALSE)")
+			args = append(args, ruleArgs...)
+		} else {
+			whereClauses = append(whereClauses, "EXISTS ("+latestRuleResults+") AND NOT EXISTS ("+latestRuleResults+" AND rr.passed = FALSE)")
+			args = append(args, ruleArgs...)
+			args = append(args, ruleArgs...)
+		}
+	}
+	
+	if len(whereClauses) == 0 {
+		return "", args
 	}
+	return "WHERE " + strings.Join(whereClauses, " AND "), args
+}
+
+// retrieves URLs with pagination and filtering
+func (r *Repository) ListURLs(filter models.URLFilter) ([]models.URLWithResult, int, error) {
+	whereClause, args := urlFilterWhere(filter)
 	
 	// Build ORDER BY clause
 	orderBy := "u.created_at DESC"
@@ -129,7 +229,7 @@ func (r *Repository) ListURLs(filter models.URLFilter) ([]models.URLWithResult,
 	countQuery := fmt.Sprintf(`
 		SELECT COUNT(DISTINCT u.id)
 		FROM urls u
-		LEFT JOIN crawl_results cr ON u.id = cr.url_id
+		LEFT JOIN crawl_results cr ON u.id = cr.url_id AND cr.root_id IS NULL
 		%s
 	`, whereClause)
 	
@@ -144,9 +244,9 @@ func (r *Repository) ListURLs(filter models.URLFilter) ([]models.URLWithResult,
 	
 	// get the URLs
 	urlQuery := fmt.Sprintf(`
-		SELECT DISTINCT u.id, u.url, u.status, u.error_message, u.created_at, u.updated_at
+		SELECT DISTINCT u.id, u.user_id, u.url, u.status, u.error_message, u.crawl_options, u.created_at, u.updated_at
 		FROM urls u
-		LEFT JOIN crawl_results cr ON u.id = cr.url_id
+		LEFT JOIN crawl_results cr ON u.id = cr.url_id AND cr.root_id IS NULL
 		%s
 		ORDER BY %s
 		LIMIT ? OFFSET ?
@@ -177,6 +277,30 @@ func (r *Repository) ListURLs(filter models.URLFilter) ([]models.URLWithResult,
 	return results, total, nil
 }
 
+// returns the IDs of the URLs a filter matches, at most limit of them in ascending order.
+// Paging and sorting of the filter are ignored.
+func (r *Repository) ListURLIDs(filter models.URLFilter, limit int) ([]int, error) {
+	whereClause, args := urlFilterWhere(filter)
+	
+	query := fmt.Sprintf(`
+		SELECT DISTINCT u.id
+		FROM urls u
+		LEFT JOIN crawl_results cr ON u.id = cr.url_id AND cr.root_id IS NULL
+		%s
+		ORDER BY u.id
+		LIMIT ?
+	`, whereClause)
+	args = append(args, limit)
+	
+	ids := []int{}
+	err := r.db.Select(&ids, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URL IDs: %w", err)
+	}
+	
+	return ids, nil
+}
+
 // updates the status of a URL
 func (r *Repository) UpdateURLStatus(id int, status models.URLStatus, errorMessage *string) error {
 	query := `
@@ -193,6 +317,22 @@ func (r *Repository) UpdateURLStatus(id int, status models.URLStatus, errorMessa
 	return nil
 }
 
+// replaces the crawl option overrides of a URL
+func (r *Repository) UpdateURLCrawlOptions(id int, crawlOptions *models.CrawlOptionsOverride) error {
+	query := `
+		UPDATE urls 
+		SET crawl_options = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?
+	`
+	
+	_, err := r.db.Exec(query, crawlOptions, id)
+	if err != nil {
+		return fmt.Errorf("failed to update URL crawl options: %w", err)
+	}
+	
+	return nil
+}
+
 // deletes a URL and its related data
 func (r *Repository) DeleteURL(id int) error {
 	query := `DELETE FROM urls WHERE id = ?`
@@ -240,49 +380,55 @@ func (r *Repository) DeleteURLs(ids []int) error {
 
 // Crawl Result operations
 
+// selects the ID of the latest crawl result of the URL u that is not a page of a site crawl
+const latestRootCrawlResultID = `(
+	SELECT latest.id FROM crawl_results latest
+	WHERE latest.url_id = u.id AND latest.root_id IS NULL
+	ORDER BY latest.crawled_at DESC, latest.id DESC
+	LIMIT 1
+)`
+
 // creates a new crawl result
 func (r *Repository) CreateCrawlResult(result *models.CrawlResult) error {
+	return (&resultWriter{ex: r.db}).CreateCrawlResult(result)
+}
+
+// retrieves the latest root crawl result for a URL
+func (r *Repository) GetCrawlResultByURLID(urlID int) (*models.CrawlResult, error) {
+	var result models.CrawlResult
 	query := `
-		INSERT INTO crawl_results (
-			url_id, title, html_version, h1_count, h2_count, h3_count, 
-			h4_count, h5_count, h6_count, internal_links, external_links, 
-			broken_links_count, has_login_form
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		SELECT id, url_id, page_url, title, html_version, doctype, document_mode, h1_count, h2_count, h3_count, 
+			   h4_count, h5_count, h6_count, internal_links, external_links, 
+			   broken_links_count, links_blocked_by_robots, has_login_form, seo_metadata, structured_data, resource_summary, redirect_chain, analyzer_runs, rules_passed, rules_failed, fetcher, sitemap_coverage, depth, parent_id, root_id, crawled_at
+		FROM crawl_results 
+		WHERE url_id = ? AND root_id IS NULL
+		ORDER BY crawled_at DESC, id DESC
+		LIMIT 1
 	`
 	
-	execResult, err := r.db.Exec(query,
-		result.URLID, result.Title, result.HTMLVersion, result.H1Count,
-		result.H2Count, result.H3Count, result.H4Count, result.H5Count,
-		result.H6Count, result.InternalLinks, result.ExternalLinks,
-		result.BrokenLinksCount, result.HasLoginForm,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create crawl result: %w", err)
-	}
-	
-	id, err := execResult.LastInsertId()
+	err := r.db.Get(&result, query, urlID)
 	if err != nil {
-		return fmt.Errorf("failed to get last insert ID: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("crawl result not found")
+		}
+		return nil, fmt.Errorf("failed to get crawl result: %w", err)
 	}
 	
-	result.ID = int(id)
-	return nil
+	return &result, nil
 }
 
-// retrieves the crawl result for a URL
-func (r *Repository) GetCrawlResultByURLID(urlID int) (*models.CrawlResult, error) {
+// retrieves a crawl result by its ID
+func (r *Repository) GetCrawlResultByID(id int) (*models.CrawlResult, error) {
 	var result models.CrawlResult
 	query := `
-		SELECT id, url_id, title, html_version, h1_count, h2_count, h3_count, 
+		SELECT id, url_id, page_url, title, html_version, doctype, document_mode, h1_count, h2_count, h3_count, 
 			   h4_count, h5_count, h6_count, internal_links, external_links, 
-			   broken_links_count, has_login_form, crawled_at
+			   broken_links_count, links_blocked_by_robots, has_login_form, seo_metadata, structured_data, resource_summary, redirect_chain, analyzer_runs, rules_passed, rules_failed, fetcher, sitemap_coverage, depth, parent_id, root_id, crawled_at
 		FROM crawl_results 
-		WHERE url_id = ?
-		ORDER BY crawled_at DESC
-		LIMIT 1
+		WHERE id = ?
 	`
 	
-	err := r.db.Get(&result, query, urlID)
+	err := r.db.Get(&result, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("crawl result not found")
@@ -293,39 +439,119 @@ func (r *Repository) GetCrawlResultByURLID(urlID int) (*models.CrawlResult, erro
 	return &result, nil
 }
 
+// retrieves the past crawl results of a URL, newest first. Pages below the root of a site crawl are left out.
+func (r *Repository) ListCrawlResultsByURLID(urlID int, filter models.HistoryFilter) ([]models.CrawlResult, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM crawl_results WHERE url_id = ? AND root_id IS NULL`
+	err := r.db.Get(&total, countQuery, urlID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count crawl results: %w", err)
+	}
+	
+	offset := (filter.Page - 1) * filter.PageSize
+	query := `
+		SELECT id, url_id, page_url, title, html_version, doctype, document_mode, h1_count, h2_count, h3_count, 
+			   h4_count, h5_count, h6_count, internal_links, external_links, 
+			   broken_links_count, links_blocked_by_robots, has_login_form, seo_metadata, structured_data, resource_summary, redirect_chain, analyzer_runs, rules_passed, rules_failed, fetcher, sitemap_coverage, depth, parent_id, root_id, crawled_at
+		FROM crawl_results 
+		WHERE url_id = ? AND root_id IS NULL
+		ORDER BY crawled_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`
+	
+	results := []models.CrawlResult{}
+	err = r.db.Select(&results, query, urlID, filter.PageSize, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list crawl results: %w", err)
+	}
+	
+	return results, total, nil
+}
+
+// retrieves the root page of a site crawl and every page crawled beneath it
+func (r *Repository) GetSitePages(rootResultID int) ([]models.CrawlResult, error) {
+	query := `
+		SELECT id, url_id, page_url, title, html_version, doctype, document_mode, h1_count, h2_count, h3_count, 
+			   h4_count, h5_count, h6_count, internal_links, external_links, 
+			   broken_links_count, links_blocked_by_robots, has_login_form, seo_metadata, structured_data, resource_summary, redirect_chain, analyzer_runs, rules_passed, rules_failed, fetcher, sitemap_coverage, depth, parent_id, root_id, crawled_at
+		FROM crawl_results 
+		WHERE id = ? OR root_id = ?
+		ORDER BY depth, id
+	`
+	
+	var pages []models.CrawlResult
+	err := r.db.Select(&pages, query, rootResultID, rootResultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get site pages: %w", err)
+	}
+	
+	return pages, nil
+}
+
 // Broken Links operations
 
 // creates multiple broken link records
 func (r *Repository) CreateBrokenLinks(crawlResultID int, brokenLinks []models.BrokenLink) error {
-	if len(brokenLinks) == 0 {
-		return nil
-	}
-	
+	return r.writeResults(func(w *resultWriter) error {
+		return w.CreateBrokenLinks(crawlResultID, brokenLinks)
+	})
+}
+
+// retrieves the broken links found by a single crawl result
+func (r *Repository) GetBrokenLinksByCrawlResultID(crawlResultID int) ([]models.BrokenLink, error) {
 	query := `
-		INSERT INTO broken_links (crawl_result_id, url, status_code, error_message) 
-		VALUES (?, ?, ?, ?)
+		SELECT id, crawl_result_id, url, status_code, category, error_message
+		FROM broken_links
+		WHERE crawl_result_id = ?
+		ORDER BY status_code, url
 	`
 	
-	tx, err := r.db.Beginx()
+	brokenLinks := []models.BrokenLink{}
+	err := r.db.Select(&brokenLinks, query, crawlResultID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to get broken links: %w", err)
+	}
+	
+	return brokenLinks, nil
+}
+
+// retrieves the broken links found by several crawl results with one query, keyed by crawl result ID
+func (r *Repository) GetBrokenLinksByCrawlResultIDs(crawlResultIDs []int) (map[int][]models.BrokenLink, error) {
+	linksByResult := make(map[int][]models.BrokenLink, len(crawlResultIDs))
+	if len(crawlResultIDs) == 0 {
+		return linksByResult, nil
+	}
+	
+	placeholders := strings.Repeat("?,", len(crawlResultIDs)-1) + "?"
+	query := fmt.Sprintf(`
+		SELECT id, crawl_result_id, url, status_code, category, error_message
+		FROM broken_links
+		WHERE crawl_result_id IN (%s)
+		ORDER BY status_code, url
+	`, placeholders)
+	
+	args := make([]interface{}, len(crawlResultIDs))
+	for i, id := range crawlResultIDs {
+		args[i] = id
+	}
+	
+	var brokenLinks []models.BrokenLink
+	err := r.db.Select(&brokenLinks, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broken links: %w", err)
 	}
-	defer tx.Rollback()
 	
 	for _, link := range brokenLinks {
-		_, err := tx.Exec(query, crawlResultID, link.URL, link.StatusCode, link.ErrorMessage)
-		if err != nil {
-			return fmt.Errorf("failed to create broken link: %w", err)
-		}
+		linksByResult[link.CrawlResultID] = append(linksByResult[link.CrawlResultID], link)
 	}
 	
-	return tx.Commit()
+	return linksByResult, nil
 }
 
 // retrieves broken links for a URL
 func (r *Repository) GetBrokenLinksByURLID(urlID int) ([]models.BrokenLink, error) {
 	query := `
-		SELECT bl.id, bl.crawl_result_id, bl.url, bl.status_code, bl.error_message
+		SELECT bl.id, bl.crawl_result_id, bl.url, bl.status_code, bl.category, bl.error_message
 		FROM broken_links bl
 		JOIN crawl_results cr ON bl.crawl_result_id = cr.id
 		WHERE cr.url_id = ?
@@ -341,6 +567,1147 @@ func (r *Repository) GetBrokenLinksByURLID(urlID int) ([]models.BrokenLink, erro
 	return brokenLinks, nil
 }
 
+// Link inventory operations
+
+// the size of links.anchor_text, counted in characters
+const maxAnchorTextLength = 1024
+
+// cuts text to at most max characters, so it fits a VARCHAR(max) column without splitting a UTF-8 character
+func truncateText(text string, max int) string {
+	count := 0
+	for i := range text {
+		if count == max {
+			return text[:i]
+		}
+		count++
+	}
+	return text
+}
+
+// saves every link found by a crawl result
+func (r *Repository) CreateLinks(crawlResultID int, links []models.Link) error {
+	return r.writeResults(func(w *resultWriter) error {
+		return w.CreateLinks(crawlResultID, links)
+	})
+}
+
+// lists the links found by a crawl result, optionally only internal or external ones or those with a check status
+func (r *Repository) ListLinksByCrawlResultID(crawlResultID int, filter models.LinkFilter) ([]models.Link, int, error) {
+	whereClause := "WHERE crawl_result_id = ?"
+	args := []interface{}{crawlResultID}
+	
+	switch filter.Type {
+	case "internal":
+		whereClause += " AND is_internal = TRUE"
+	case "external":
+		whereClause += " AND is_internal = FALSE"
+	}
+	
+	if filter.Status != nil {
+		whereClause += " AND check_status = ?"
+		args = append(args, *filter.Status)
+	}
+	
+	if filter.Category != nil {
+		whereClause += " AND check_category = ?"
+		args = append(args, *filter.Category)
+	}
+	
+	if filter.Redirected != nil {
+		if *filter.Redirected {
+			whereClause += " AND JSON_LENGTH(redirects) > 0"
+		} else {
+			whereClause += " AND (redirects IS NULL OR JSON_LENGTH(redirects) = 0)"
+		}
+	}
+	
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM links %s", whereClause)
+	err := r.db.Get(&total, countQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count links: %w", err)
+	}
+	
+	offset := (filter.Page - 1) * filter.PageSize
+	query := fmt.Sprintf(`
+		SELECT id, crawl_result_id, url, anchor_text, rel, is_internal, check_status, 
+			   check_category, status_code, error_message, redirects
+		FROM links 
+		%s
+		ORDER BY id
+		LIMIT ? OFFSET ?
+	`, whereClause)
+	args = append(args, filter.PageSize, offset)
+	
+	links := []models.Link{}
+	err = r.db.Select(&links, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list links: %w", err)
+	}
+	
+	return links, total, nil
+}
+
+// retrieves the links of a crawl result that point to any of the given URLs
+func (r *Repository) GetLinksByURLs(crawlResultID int, urls []string) ([]models.Link, error) {
+	links := []models.Link{}
+	if len(urls) == 0 {
+		return links, nil
+	}
+	
+	placeholders := strings.Repeat("?,", len(urls)-1) + "?"
+	query := fmt.Sprintf(`
+		SELECT id, crawl_result_id, url, anchor_text, rel, is_internal, check_status, 
+			   check_category, status_code, error_message, redirects
+		FROM links 
+		WHERE crawl_result_id = ? AND url IN (%s)
+		ORDER BY id
+	`, placeholders)
+	
+	args := make([]interface{}, 0, len(urls)+1)
+	args = append(args, crawlResultID)
+	for _, url := range urls {
+		args = append(args, url)
+	}
+	
+	err := r.db.Select(&links, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links: %w", err)
+	}
+	
+	return links, nil
+}
+
+// Page resource operations
+
+// saves the resources referenced by a crawl result
+func (r *Repository) CreateResources(crawlResultID int, resources []models.PageResource) error {
+	return r.writeResults(func(w *resultWriter) error {
+		return w.CreateResources(crawlResultID, resources)
+	})
+}
+
+// retrieves the resources referenced by a crawl result, optionally only those of a type or with a check status
+func (r *Repository) GetResourcesByCrawlResultID(crawlResultID int, filter models.ResourceFilter) ([]models.PageResource, error) {
+	whereClause := "WHERE crawl_result_id = ?"
+	args := []interface{}{crawlResultID}
+	
+	if filter.Type != nil {
+		whereClause += " AND type = ?"
+		args = append(args, *filter.Type)
+	}
+	
+	if filter.Status != nil {
+		whereClause += " AND check_status = ?"
+		args = append(args, *filter.Status)
+	}
+	
+	if filter.Category != nil {
+		whereClause += " AND check_category = ?"
+		args = append(args, *filter.Category)
+	}
+	
+	query := fmt.Sprintf(`
+		SELECT id, crawl_result_id, url, type, is_third_party, check_status, check_category, status_code, 
+			   size, content_type, error_message
+		FROM page_resources 
+		%s
+		ORDER BY id
+	`, whereClause)
+	
+	resources := []models.PageResource{}
+	err := r.db.Select(&resources, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+	
+	return resources, nil
+}
+
+// Security report operations
+
+// saves the security report of a crawl result
+func (r *Repository) CreateSecurityReport(report *models.SecurityReport) error {
+	return (&resultWriter{ex: r.db}).CreateSecurityReport(report)
+}
+
+// retrieves the security report of a crawl result
+func (r *Repository) GetSecurityReportByCrawlResultID(crawlResultID int) (*models.SecurityReport, error) {
+	var report models.SecurityReport
+	query := `
+		SELECT id, crawl_result_id, score, grade, checks, cookies, tls_version, cert_issuer, cert_subject, cert_expires_at
+		FROM security_reports 
+		WHERE crawl_result_id = ?
+	`
+	
+	err := r.db.Get(&report, query, crawlResultID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("security report not found")
+		}
+		return nil, fmt.Errorf("failed to get security report: %w", err)
+	}
+	
+	return &report, nil
+}
+
+// lists the URLs whose latest crawl found a certificate expiring before the given time, soonest first
+func (r *Repository) ListExpiringCertificates(before time.Time) ([]models.ExpiringCertificate, error) {
+	query := `
+		SELECT u.id AS url_id, u.url, cr.id AS crawl_result_id, sr.cert_issuer, sr.cert_subject, sr.cert_expires_at
+		FROM urls u
+		JOIN crawl_results cr ON cr.id = (
+			SELECT latest.id FROM crawl_results latest
+			WHERE latest.url_id = u.id AND latest.root_id IS NULL
+			ORDER BY latest.crawled_at DESC, latest.id DESC
+			LIMIT 1
+		)
+		JOIN security_reports sr ON sr.crawl_result_id = cr.id
+		WHERE sr.cert_expires_at IS NOT NULL AND sr.cert_expires_at < ?
+		ORDER BY sr.cert_expires_at, u.id
+	`
+	
+	certificates := []models.ExpiringCertificate{}
+	err := r.db.Select(&certificates, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring certificates: %w", err)
+	}
+	
+	return certificates, nil
+}
+
+// Form operations
+
+// saves the forms found by a crawl result
+func (r *Repository) CreateForms(crawlResultID int, forms []models.Form) error {
+	return r.writeResults(func(w *resultWriter) error {
+		return w.CreateForms(crawlResultID, forms)
+	})
+}
+
+// retrieves the forms found by a single crawl result, in page order
+func (r *Repository) GetFormsByCrawlResultID(crawlResultID int) ([]models.Form, error) {
+	query := `
+		SELECT id, crawl_result_id, form_type, score, action, method, posts_over_http, 
+			   password_fields, oauth_providers, signals
+		FROM forms
+		WHERE crawl_result_id = ?
+		ORDER BY id
+	`
+	
+	forms := []models.Form{}
+	err := r.db.Select(&forms, query, crawlResultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forms: %w", err)
+	}
+	
+	return forms, nil
+}
+
+// Finding operations
+
+// saves the findings about a crawl result
+func (r *Repository) CreateFindings(crawlResultID int, findings []models.Finding) error {
+	return r.writeResults(func(w *resultWriter) error {
+		return w.CreateFindings(crawlResultID, findings)
+	})
+}
+
+// retrieves the findings about a single crawl result, most severe first, optionally
+// narrowed to a category and severity
+func (r *Repository) GetFindingsByCrawlResultID(crawlResultID int, filter models.FindingFilter) ([]models.Finding, error) {
+	whereClause := "WHERE crawl_result_id = ?"
+	args := []interface{}{crawlResultID}
+	
+	if filter.Category != "" {
+		whereClause += " AND category = ?"
+		args = append(args, filter.Category)
+	}
+	
+	if filter.Severity != nil {
+		whereClause += " AND severity = ?"
+		args = append(args, *filter.Severity)
+	}
+	
+	query := fmt.Sprintf(`
+		SELECT id, crawl_result_id, category, code, severity, message, selector
+		FROM findings
+		%s
+		ORDER BY FIELD(severity, 'error', 'warning', 'info'), category, id
+	`, whereClause)
+	
+	findings := []models.Finding{}
+	err := r.db.Select(&findings, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get findings: %w", err)
+	}
+	
+	return findings, nil
+}
+
+// Rule result operations
+
+// saves the outcomes of the custom rules on a crawl result
+func (r *Repository) CreateRuleResults(crawlResultID int, results []models.RuleResult) error {
+	return r.writeResults(func(w *resultWriter) error {
+		return w.CreateRuleResults(crawlResultID, results)
+	})
+}
+
+// retrieves the rule outcomes of a single crawl result, failures first, optionally only the passed or failed ones
+func (r *Repository) GetRuleResultsByCrawlResultID(crawlResultID int, filter models.RuleResultFilter) ([]models.RuleResult, error) {
+	whereClause := "WHERE crawl_result_id = ?"
+	args := []interface{}{crawlResultID}
+	
+	if filter.Passed != nil {
+		whereClause += " AND passed = ?"
+		args = append(args, *filter.Passed)
+	}
+	
+	query := fmt.Sprintf(`
+		SELECT id, crawl_result_id, rule_id, rule_name, passed, severity, message
+		FROM rule_results
+		%s
+		ORDER BY passed, FIELD(severity, 'error', 'warning', 'info'), id
+	`, whereClause)
+	
+	results := []models.RuleResult{}
+	err := r.db.Select(&results, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule results: %w", err)
+	}
+	
+	return results, nil
+}
+
+// lists the enabled rules of the user who added a URL that apply to it: its own and those without a URL
+func (r *Repository) ListRulesForURL(urlID int) ([]models.Rule, error) {
+	query := `
+		SELECT r.id, r.user_id, r.url_id, r.name, r.type, r.selector, r.pattern, r.operator, r.expected_count, r.severity, r.enabled, r.created_at, r.updated_at
+		FROM rules r
+		JOIN urls u ON u.id = ? AND r.user_id = u.user_id
+		WHERE (r.url_id IS NULL OR r.url_id = u.id) AND r.enabled = TRUE
+		ORDER BY r.id
+	`
+	
+	rules := []models.Rule{}
+	err := r.db.Select(&rules, query, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules for URL: %w", err)
+	}
+	
+	return rules, nil
+}
+
+// Crawl Job queue operations
+
+// adds a crawl job for a URL to the queue unless the URL already has a queued or running one,
+// in which case ErrActiveCrawlJob is returned. Locking the URL's row makes the check and the
+// insert atomic, so concurrent starts of the same URL cannot both queue a job.
+func (r *Repository) EnqueueCrawlJob(urlID int, options models.CrawlJobOptions) (*models.CrawlJobRecord, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	var locked int
+	err = tx.Get(&locked, `SELECT id FROM urls WHERE id = ? FOR UPDATE`, urlID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("URL not found")
+		}
+		return nil, fmt.Errorf("failed to lock URL: %w", err)
+	}
+	
+	var active int
+	err = tx.Get(&active, `
+		SELECT COUNT(*) FROM crawl_jobs 
+		WHERE url_id = ? AND status IN ('queued', 'running')
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active crawl jobs: %w", err)
+	}
+	if active > 0 {
+		return nil, ErrActiveCrawlJob
+	}
+	
+	query := `
+		INSERT INTO crawl_jobs (url_id, status, options) 
+		VALUES (?, ?, ?)
+	`
+	
+	result, err := tx.Exec(query, urlID, models.JobStatusQueued, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue crawl job: %w", err)
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit crawl job: %w", err)
+	}
+	
+	return r.GetCrawlJobByID(int(id))
+}
+
+// retrieves a crawl job by its ID
+func (r *Repository) GetCrawlJobByID(id int) (*models.CrawlJobRecord, error) {
+	var job models.CrawlJobRecord
+	query := `
+		SELECT id, url_id, status, options, attempts, worker_id, lease_expires_at, 
+			   heartbeat_at, error_message, created_at, started_at, finished_at
+		FROM crawl_jobs 
+		WHERE id = ?
+	`
+	
+	err := r.db.Get(&job, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("crawl job not found")
+		}
+		return nil, fmt.Errorf("failed to get crawl job: %w", err)
+	}
+	
+	return &job, nil
+}
+
+// claims the oldest queued crawl job for a worker, leasing it for the given duration.
+// Returns nil without an error when the queue is empty.
+func (r *Repository) ClaimNextCrawlJob(workerID string, lease time.Duration) (*models.CrawlJobRecord, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	// SKIP LOCKED lets several workers claim different jobs concurrently
+	var id int
+	err = tx.Get(&id, `
+		SELECT id FROM crawl_jobs 
+		WHERE status = 'queued' 
+		ORDER BY id 
+		LIMIT 1 
+		FOR UPDATE SKIP LOCKED
+	`)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to select crawl job: %w", err)
+	}
+	
+	_, err = tx.Exec(`
+		UPDATE crawl_jobs 
+		SET status = 'running', worker_id = ?, attempts = attempts + 1, 
+			lease_expires_at = DATE_ADD(NOW(), INTERVAL ? SECOND), 
+			heartbeat_at = NOW(), started_at = NOW()
+		WHERE id = ?
+	`, workerID, int(lease.Seconds()), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim crawl job: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit crawl job claim: %w", err)
+	}
+	
+	return r.GetCrawlJobByID(id)
+}
+
+// extends the lease of a running crawl job held by a worker
+func (r *Repository) HeartbeatCrawlJob(id int, workerID string, lease time.Duration) error {
+	query := `
+		UPDATE crawl_jobs 
+		SET lease_expires_at = DATE_ADD(NOW(), INTERVAL ? SECOND), heartbeat_at = NOW()
+		WHERE id = ? AND worker_id = ? AND status = 'running'
+	`
+	
+	result, err := r.db.Exec(query, int(lease.Seconds()), id, workerID)
+	if err != nil {
+		return fmt.Errorf("failed to extend crawl job lease: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return ErrCrawlJobLeaseLost
+	}
+	
+	return nil
+}
+
+// records how a running crawl job ended. save, when given, first writes the crawl's results, then the job
+// and its URL get their final status, all in one transaction that holds the job's row. Nothing is written
+// and ErrCrawlJobLeaseLost is returned when workerID no longer holds the job: it was cancelled, or
+// requeued after its lease expired, meanwhile.
+func (r *Repository) FinishCrawlJob(id int, workerID string, outcome models.CrawlJobOutcome, save func(CrawlResultWriter) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	// The lock keeps cancellation and lease recovery from taking the job over before the commit
+	var urlID int
+	err = tx.Get(&urlID, `
+		SELECT url_id FROM crawl_jobs 
+		WHERE id = ? AND worker_id = ? AND status = 'running' 
+		FOR UPDATE
+	`, id, workerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCrawlJobLeaseLost
+		}
+		return fmt.Errorf("failed to lock crawl job: %w", err)
+	}
+	
+	if save != nil {
+		if err := save(&resultWriter{ex: tx}); err != nil {
+			return err
+		}
+	}
+	
+	_, err = tx.Exec(`
+		UPDATE urls 
+		SET status = ?, error_message = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?
+	`, outcome.URLStatus, outcome.ErrorMessage, urlID)
+	if err != nil {
+		return fmt.Errorf("failed to update URL status: %w", err)
+	}
+	
+	_, err = tx.Exec(`
+		UPDATE crawl_jobs 
+		SET status = ?, error_message = ?, finished_at = NOW(), lease_expires_at = NULL
+		WHERE id = ?
+	`, outcome.JobStatus, outcome.ErrorMessage, id)
+	if err != nil {
+		return fmt.Errorf("failed to finish crawl job: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit crawl job: %w", err)
+	}
+	
+	return nil
+}
+
+// cancels the queued and running crawl jobs of a URL
+func (r *Repository) CancelCrawlJobs(urlID int) (int, error) {
+	query := `
+		UPDATE crawl_jobs 
+		SET status = 'cancelled', error_message = 'Cancelled by user', finished_at = NOW(), lease_expires_at = NULL
+		WHERE url_id = ? AND status IN ('queued', 'running')
+	`
+	
+	result, err := r.db.Exec(query, urlID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel crawl jobs: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return int(rowsAffected), nil
+}
+
+// hands a running crawl job held by a worker back to the queue, e.g. because its instance is shutting
+// down, so another worker runs it without waiting for the lease to expire. The attempt does not count
+// as a failure. Returns ErrCrawlJobLeaseLost when the worker no longer holds the job.
+func (r *Repository) ReleaseCrawlJob(id int, workerID string) error {
+	query := `
+		UPDATE crawl_jobs 
+		SET status = 'queued', worker_id = NULL, lease_expires_at = NULL, attempts = GREATEST(attempts - 1, 0)
+		WHERE id = ? AND worker_id = ? AND status = 'running'
+	`
+	
+	result, err := r.db.Exec(query, id, workerID)
+	if err != nil {
+		return fmt.Errorf("failed to release crawl job: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return ErrCrawlJobLeaseLost
+	}
+	
+	return nil
+}
+
+// requeues running crawl jobs whose lease expired, failing those out of attempts,
+// and queues a job for every URL left in the running state without one.
+// Returns the number of jobs put back on the queue.
+func (r *Repository) RecoverCrawlJobs(maxAttempts int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	var expired []models.CrawlJobRecord
+	err = tx.Select(&expired, `
+		SELECT id, url_id, status, options, attempts, worker_id, lease_expires_at, 
+			   heartbeat_at, error_message, created_at, started_at, finished_at
+		FROM crawl_jobs 
+		WHERE status = 'running' AND lease_expires_at < NOW()
+		FOR UPDATE
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find orphaned crawl jobs: %w", err)
+	}
+	
+	requeued := 0
+	for _, job := range expired {
+		if job.Attempts >= maxAttempts {
+			message := "Crawl abandoned after repeated worker failures"
+			if _, err := tx.Exec(`
+				UPDATE crawl_jobs 
+				SET status = 'failed', error_message = ?, finished_at = NOW(), worker_id = NULL, lease_expires_at = NULL 
+				WHERE id = ?
+			`, message, job.ID); err != nil {
+				return 0, fmt.Errorf("failed to fail crawl job: %w", err)
+			}
+			if _, err := tx.Exec(`UPDATE urls SET status = ?, error_message = ? WHERE id = ?`, models.StatusError, message, job.URLID); err != nil {
+				return 0, fmt.Errorf("failed to update URL status: %w", err)
+			}
+			continue
+		}
+		
+		if _, err := tx.Exec(`
+			UPDATE crawl_jobs 
+			SET status = 'queued', worker_id = NULL, lease_expires_at = NULL 
+			WHERE id = ?
+		`, job.ID); err != nil {
+			return 0, fmt.Errorf("failed to requeue crawl job: %w", err)
+		}
+		requeued++
+	}
+	
+	// URLs stuck in running without any job, e.g. crawls started before the queue existed
+	result, err := tx.Exec(`
+		INSERT INTO crawl_jobs (url_id, status)
+		SELECT u.id, 'queued' FROM urls u
+		WHERE u.status = 'running' AND NOT EXISTS (
+			SELECT 1 FROM crawl_jobs j WHERE j.url_id = u.id AND j.status IN ('queued', 'running')
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue orphaned URLs: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err == nil {
+		requeued += int(inserted)
+	}
+	
+	// Every URL waiting on a queued job is queued again
+	_, err = tx.Exec(`
+		UPDATE urls u SET u.status = 'queued'
+		WHERE u.status = 'running' 
+		  AND EXISTS (SELECT 1 FROM crawl_jobs j WHERE j.url_id = u.id AND j.status = 'queued')
+		  AND NOT EXISTS (SELECT 1 FROM crawl_jobs j WHERE j.url_id = u.id AND j.status = 'running')
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset orphaned URL status: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit crawl job recovery: %w", err)
+	}
+	
+	return requeued, nil
+}
+
+// Crawl Schedule operations
+
+// creates a crawl schedule, setting its ID
+func (r *Repository) CreateSchedule(schedule *models.CrawlSchedule) error {
+	query := `
+		INSERT INTO crawl_schedules (url_id, cron_expr, interval_seconds, enabled, next_run_at) 
+		VALUES (?, ?, ?, ?, ?)
+	`
+	
+	result, err := r.db.Exec(query, schedule.URLID, schedule.CronExpr, schedule.IntervalSeconds, schedule.Enabled, schedule.NextRunAt)
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	
+	schedule.ID = int(id)
+	return nil
+}
+
+// retrieves a crawl schedule by its ID
+func (r *Repository) GetScheduleByID(id int) (*models.CrawlSchedule, error) {
+	var schedule models.CrawlSchedule
+	query := `
+		SELECT id, url_id, cron_expr, interval_seconds, enabled, next_run_at, 
+			   last_run_at, last_error, created_at, updated_at
+		FROM crawl_schedules 
+		WHERE id = ?
+	`
+	
+	err := r.db.Get(&schedule, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("schedule not found")
+		}
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	
+	return &schedule, nil
+}
+
+// lists crawl schedules, limited to one URL when filter.URLID is set
+func (r *Repository) ListSchedules(filter models.ScheduleFilter) ([]models.CrawlSchedule, error) {
+	query := `
+		SELECT id, url_id, cron_expr, interval_seconds, enabled, next_run_at, 
+			   last_run_at, last_error, created_at, updated_at
+		FROM crawl_schedules
+	`
+	args := []interface{}{}
+	
+	if filter.URLID > 0 {
+		query += " WHERE url_id = ?"
+		args = append(args, filter.URLID)
+	}
+	query += " ORDER BY next_run_at, id"
+	
+	schedules := []models.CrawlSchedule{}
+	err := r.db.Select(&schedules, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	
+	return schedules, nil
+}
+
+// updates the timing and state of a crawl schedule
+func (r *Repository) UpdateSchedule(schedule *models.CrawlSchedule) error {
+	query := `
+		UPDATE crawl_schedules 
+		SET cron_expr = ?, interval_seconds = ?, enabled = ?, next_run_at = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?
+	`
+	
+	result, err := r.db.Exec(query, schedule.CronExpr, schedule.IntervalSeconds, schedule.Enabled, schedule.NextRunAt, schedule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("schedule not found")
+	}
+	
+	return nil
+}
+
+// deletes a crawl schedule
+func (r *Repository) DeleteSchedule(id int) error {
+	result, err := r.db.Exec(`DELETE FROM crawl_schedules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("schedule not found")
+	}
+	
+	return nil
+}
+
+// lists enabled schedules due at the given time, oldest first
+func (r *Repository) ListDueSchedules(now time.Time, limit int) ([]models.CrawlSchedule, error) {
+	query := `
+		SELECT id, url_id, cron_expr, interval_seconds, enabled, next_run_at, 
+			   last_run_at, last_error, created_at, updated_at
+		FROM crawl_schedules 
+		WHERE enabled = TRUE AND next_run_at <= ?
+		ORDER BY next_run_at, id
+		LIMIT ?
+	`
+	
+	schedules := []models.CrawlSchedule{}
+	err := r.db.Select(&schedules, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due schedules: %w", err)
+	}
+	
+	return schedules, nil
+}
+
+// moves a due schedule to its next run. Only the caller that still sees the schedule
+// due at dueAt succeeds, so concurrent schedulers never start the same run twice.
+func (r *Repository) AdvanceSchedule(id int, dueAt time.Time, nextRunAt time.Time) (bool, error) {
+	query := `
+		UPDATE crawl_schedules 
+		SET next_run_at = ?, last_run_at = NOW() 
+		WHERE id = ? AND next_run_at = ?
+	`
+	
+	result, err := r.db.Exec(query, nextRunAt, id, dueAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance schedule: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return rowsAffected == 1, nil
+}
+
+// records the outcome of the latest scheduled run, nil meaning it was started
+func (r *Repository) RecordScheduleRun(id int, lastError *string) error {
+	_, err := r.db.Exec(`UPDATE crawl_schedules SET last_error = ? WHERE id = ?`, lastError, id)
+	if err != nil {
+		return fmt.Errorf("failed to record schedule run: %w", err)
+	}
+	
+	return nil
+}
+
+// Webhook operations
+
+// creates a webhook, setting its ID
+func (r *Repository) CreateWebhook(webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (user_id, url_id, target_url, secret, events) 
+		VALUES (?, ?, ?, ?, ?)
+	`
+	
+	result, err := r.db.Exec(query, webhook.UserID, webhook.URLID, webhook.TargetURL, webhook.Secret, webhook.Events)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	
+	webhook.ID = int(id)
+	return nil
+}
+
+// retrieves a webhook by its ID
+func (r *Repository) GetWebhookByID(id int) (*models.Webhook, error) {
+	var webhook models.Webhook
+	query := `
+		SELECT id, user_id, url_id, target_url, secret, events, created_at
+		FROM webhooks 
+		WHERE id = ?
+	`
+	
+	err := r.db.Get(&webhook, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	
+	return &webhook, nil
+}
+
+// lists the webhooks registered by a user
+func (r *Repository) ListWebhooksByUserID(userID int) ([]models.Webhook, error) {
+	query := `
+		SELECT id, user_id, url_id, target_url, secret, events, created_at
+		FROM webhooks 
+		WHERE user_id = ?
+		ORDER BY id
+	`
+	
+	webhooks := []models.Webhook{}
+	err := r.db.Select(&webhooks, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	
+	return webhooks, nil
+}
+
+// deletes a webhook together with its delivery log
+func (r *Repository) DeleteWebhook(id int) error {
+	result, err := r.db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	
+	return nil
+}
+
+// lists the webhooks of every user subscribed to an event about a URL
+func (r *Repository) ListWebhooksForEvent(event string, urlID int) ([]models.Webhook, error) {
+	query := `
+		SELECT id, user_id, url_id, target_url, secret, events, created_at
+		FROM webhooks 
+		WHERE (url_id IS NULL OR url_id = ?) AND JSON_CONTAINS(events, JSON_QUOTE(?))
+		ORDER BY id
+	`
+	
+	webhooks := []models.Webhook{}
+	err := r.db.Select(&webhooks, query, urlID, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks for event: %w", err)
+	}
+	
+	return webhooks, nil
+}
+
+// creates a pending webhook delivery, setting its ID
+func (r *Repository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return (&resultWriter{ex: r.db}).CreateWebhookDelivery(delivery)
+}
+
+// lists pending deliveries whose next attempt is due at the given time, oldest first
+func (r *Repository) ListDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, 
+			   response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries 
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`
+	
+	deliveries := []models.WebhookDelivery{}
+	err := r.db.Select(&deliveries, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+	
+	return deliveries, nil
+}
+
+// pushes the next attempt of a due delivery to leaseUntil while it is being sent. Only the
+// caller that still sees the delivery due at dueAt succeeds, so concurrent senders never
+// send the same attempt twice, and a sender that dies leaves the delivery to be retried.
+func (r *Repository) ClaimWebhookDelivery(id int, dueAt time.Time, leaseUntil time.Time) (bool, error) {
+	query := `
+		UPDATE webhook_deliveries 
+		SET next_attempt_at = ? 
+		WHERE id = ? AND status = 'pending' AND next_attempt_at = ?
+	`
+	
+	result, err := r.db.Exec(query, leaseUntil, id, dueAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return rowsAffected == 1, nil
+}
+
+// records the outcome of a delivery attempt
+func (r *Repository) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries 
+		SET status = ?, attempts = ?, next_attempt_at = ?, response_code = ?, last_error = ?, delivered_at = ? 
+		WHERE id = ?
+	`
+	
+	_, err := r.db.Exec(query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.ResponseCode, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	
+	return nil
+}
+
+// lists the deliveries of a webhook, newest first
+func (r *Repository) ListWebhookDeliveries(webhookID int, filter models.DeliveryFilter) ([]models.WebhookDelivery, int, error) {
+	whereClause := "WHERE webhook_id = ?"
+	args := []interface{}{webhookID}
+	
+	if filter.Status != nil {
+		whereClause += " AND status = ?"
+		args = append(args, *filter.Status)
+	}
+	
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM webhook_deliveries %s", whereClause)
+	err := r.db.Get(&total, countQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	
+	offset := (filter.Page - 1) * filter.PageSize
+	query := fmt.Sprintf(`
+		SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, 
+			   response_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries 
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, whereClause)
+	args = append(args, filter.PageSize, offset)
+	
+	deliveries := []models.WebhookDelivery{}
+	err = r.db.Select(&deliveries, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	
+	return deliveries, total, nil
+}
+
+// Rule operations
+
+// creates a custom rule, setting its ID
+func (r *Repository) CreateRule(rule *models.Rule) error {
+	query := `
+		INSERT INTO rules (user_id, url_id, name, type, selector, pattern, operator, expected_count, severity, enabled) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	
+	result, err := r.db.Exec(query, rule.UserID, rule.URLID, rule.Name, rule.Type, rule.Selector, rule.Pattern,
+		rule.Operator, rule.Count, rule.Severity, rule.Enabled)
+	if err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	
+	rule.ID = int(id)
+	return nil
+}
+
+// retrieves a custom rule by its ID
+func (r *Repository) GetRuleByID(id int) (*models.Rule, error) {
+	var rule models.Rule
+	query := `
+		SELECT id, user_id, url_id, name, type, selector, pattern, operator, expected_count, severity, enabled, created_at, updated_at
+		FROM rules 
+		WHERE id = ?
+	`
+	
+	err := r.db.Get(&rule, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rule not found")
+		}
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+	
+	return &rule, nil
+}
+
+// lists the custom rules of a user, optionally only those of one URL
+func (r *Repository) ListRulesByUserID(userID int, filter models.RuleFilter) ([]models.Rule, error) {
+	whereClause := "WHERE user_id = ?"
+	args := []interface{}{userID}
+	
+	if filter.URLID != 0 {
+		whereClause += " AND url_id = ?"
+		args = append(args, filter.URLID)
+	}
+	
+	query := fmt.Sprintf(`
+		SELECT id, user_id, url_id, name, type, selector, pattern, operator, expected_count, severity, enabled, created_at, updated_at
+		FROM rules 
+		%s
+		ORDER BY id
+	`, whereClause)
+	
+	rules := []models.Rule{}
+	err := r.db.Select(&rules, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+	
+	return rules, nil
+}
+
+// replaces the definition of a custom rule
+func (r *Repository) UpdateRule(rule *models.Rule) error {
+	query := `
+		UPDATE rules 
+		SET url_id = ?, name = ?, type = ?, selector = ?, pattern = ?, operator = ?, expected_count = ?, severity = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?
+	`
+	
+	result, err := r.db.Exec(query, rule.URLID, rule.Name, rule.Type, rule.Selector, rule.Pattern, rule.Operator,
+		rule.Count, rule.Severity, rule.Enabled, rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("rule not found")
+	}
+	
+	return nil
+}
+
+// deletes a custom rule. Its past results are kept under its name.
+func (r *Repository) DeleteRule(id int) error {
+	result, err := r.db.Exec(`DELETE FROM rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("rule not found")
+	}
+	
+	return nil
+}
+
 // User/Auth operations
 
 // retrieves a user by API key
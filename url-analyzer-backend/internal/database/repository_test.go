@@ -1,7 +1,9 @@
 package database
 
 import (
+	"strings"
 	"testing"
+	"time"
 	"url-analyzer/internal/models"
 
 	"github.com/joho/godotenv"
@@ -9,6 +11,9 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// the default user of the initial schema
+const testUserID = 1
+
 // sets up a test database connection
 func setupTestDB(t *testing.T) *Repository {
 	
@@ -28,6 +33,18 @@ func setupTestDB(t *testing.T) *Repository {
 	return repo
 }
 
+func TestTruncateText(t *testing.T) {
+	assert.Equal(t, "short", truncateText("short", 10))
+	assert.Equal(t, "abc", truncateText("abcdef", 3))
+	
+	// Multi-byte characters count once and are never split
+	assert.Equal(t, "héé", truncateText("héééé", 3))
+	assert.Equal(t, "日本", truncateText("日本語", 2))
+	
+	long := strings.Repeat("ü", maxAnchorTextLength+10)
+	assert.Equal(t, strings.Repeat("ü", maxAnchorTextLength), truncateText(long, maxAnchorTextLength))
+}
+
 func TestRepository_CreateURL(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping database tests in short mode")
@@ -38,7 +55,7 @@ func TestRepository_CreateURL(t *testing.T) {
 	testURL := "https://test-example.com"
 	
 	// Test creating a URL
-	url, err := repo.CreateURL(testURL)
+	url, err := repo.CreateURL(testUserID, testURL, nil)
 	require.NoError(t, err)
 	assert.NotNil(t, url)
 	assert.Equal(t, testURL, url.URL)
@@ -46,7 +63,7 @@ func TestRepository_CreateURL(t *testing.T) {
 	assert.Greater(t, url.ID, 0)
 	
 	// Test duplicate URL (should fail)
-	_, err = repo.CreateURL(testURL)
+	_, err = repo.CreateURL(testUserID, testURL, nil)
 	assert.Error(t, err)
 	assert.True(t, IsUniqueConstraintError(err))
 	
@@ -54,6 +71,68 @@ func TestRepository_CreateURL(t *testing.T) {
 	repo.DeleteURL(url.ID)
 }
 
+func TestRepository_CreateURLs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+	
+	repo := setupTestDB(t)
+	
+	existing, err := repo.CreateURL(testUserID, "https://test-batch-1.com", nil)
+	require.NoError(t, err)
+	
+	urls := []string{"https://test-batch-1.com", "https://test-batch-2.com"}
+	results, err := repo.CreateURLs(testUserID, urls, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, models.BatchURLDuplicate, results[0].Status)
+	assert.Equal(t, existing.ID, results[0].URLID)
+	assert.Equal(t, models.BatchURLCreated, results[1].Status)
+	assert.Greater(t, results[1].URLID, 0)
+	
+	// Adding the same batch again changes nothing
+	again, err := repo.CreateURLs(testUserID, urls, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.BatchURLDuplicate, again[1].Status)
+	assert.Equal(t, results[1].URLID, again[1].URLID)
+	
+	// A URL the column cannot hold rolls back the whole batch
+	_, err = repo.CreateURLs(testUserID, []string{"https://test-batch-3.com", "https://test-" + strings.Repeat("a", 800) + ".com"}, nil)
+	assert.Error(t, err)
+	_, err = repo.GetURLByURL("https://test-batch-3.com")
+	assert.True(t, IsNotFoundError(err))
+	
+	// Clean up
+	repo.DeleteURLs([]int{existing.ID, results[1].URLID})
+}
+
+func TestRepository_CreateURL_EquivalentURLs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+	
+	repo := setupTestDB(t)
+	
+	// Kept and crawled as given
+	url, err := repo.CreateURL(testUserID, "https://test-key.com/shop/?b=2&a=1", nil)
+	require.NoError(t, err)
+	defer repo.DeleteURL(url.ID)
+	assert.Equal(t, "https://test-key.com/shop/?b=2&a=1", url.URL)
+	
+	// An equivalent URL finds it, whichever way it is added
+	found, err := repo.GetURLByURL("https://TEST-KEY.com:443/shop?a=1&b=2#top")
+	require.NoError(t, err)
+	assert.Equal(t, url.ID, found.ID)
+	
+	_, err = repo.CreateURL(testUserID, "https://test-key.com/shop?a=1&b=2", nil)
+	assert.True(t, IsUniqueConstraintError(err))
+	
+	results, err := repo.CreateURLs(testUserID, []string{"https://test-key.com/shop?a=1&b=2"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.BatchURLDuplicate, results[0].Status)
+	assert.Equal(t, url.ID, results[0].URLID)
+}
+
 func TestRepository_GetURLByID(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping database tests in short mode")
@@ -64,7 +143,7 @@ func TestRepository_GetURLByID(t *testing.T) {
 	testURL := "https://test-get-by-id.com"
 	
 	// Create a URL first
-	createdURL, err := repo.CreateURL(testURL)
+	createdURL, err := repo.CreateURL(testUserID, testURL, nil)
 	require.NoError(t, err)
 	
 	// Test getting URL by ID
@@ -92,7 +171,7 @@ func TestRepository_UpdateURLStatus(t *testing.T) {
 	testURL := "https://test-update-status.com"
 	
 	// Create a URL first
-	createdURL, err := repo.CreateURL(testURL)
+	createdURL, err := repo.CreateURL(testUserID, testURL, nil)
 	require.NoError(t, err)
 	
 	// Update status to running
@@ -136,7 +215,7 @@ func TestRepository_ListURLs(t *testing.T) {
 	
 	var createdURLs []*models.URL
 	for _, testURL := range testURLs {
-		url, err := repo.CreateURL(testURL)
+		url, err := repo.CreateURL(testUserID, testURL, nil)
 		require.NoError(t, err)
 		createdURLs = append(createdURLs, url)
 	}
@@ -186,7 +265,7 @@ func TestRepository_CreateCrawlResult(t *testing.T) {
 	testURL := "https://test-crawl-result.com"
 	
 	// Create a URL first
-	createdURL, err := repo.CreateURL(testURL)
+	createdURL, err := repo.CreateURL(testUserID, testURL, nil)
 	require.NoError(t, err)
 	
 	// Create crawl result
@@ -221,4 +300,139 @@ func TestRepository_CreateCrawlResult(t *testing.T) {
 	
 	// Clean up
 	repo.DeleteURL(createdURL.ID)
-}
\ No newline at end of file
+}
+
+func TestRepository_EnqueueCrawlJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+	
+	repo := setupTestDB(t)
+	
+	url, err := repo.CreateURL(testUserID, "https://test-enqueue.com", nil)
+	require.NoError(t, err)
+	defer repo.DeleteURL(url.ID)
+	
+	// Concurrent starts queue a single job
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := repo.EnqueueCrawlJob(url.ID, models.CrawlJobOptions{})
+			errs <- err
+		}()
+	}
+	
+	queued := 0
+	for i := 0; i < 5; i++ {
+		err := <-errs
+		if err == nil {
+			queued++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrActiveCrawlJob)
+	}
+	assert.Equal(t, 1, queued)
+}
+
+func TestRepository_FinishCrawlJob_LeaseLost(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+	
+	repo := setupTestDB(t)
+	
+	url, err := repo.CreateURL(testUserID, "https://test-finish-lease.com", nil)
+	require.NoError(t, err)
+	defer repo.DeleteURL(url.ID)
+	
+	_, err = repo.EnqueueCrawlJob(url.ID, models.CrawlJobOptions{})
+	require.NoError(t, err)
+	
+	record, err := repo.ClaimNextCrawlJob("test-worker", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	require.Equal(t, url.ID, record.URLID)
+	
+	// A stop from another instance takes the job away from its worker
+	_, err = repo.CancelCrawlJobs(url.ID)
+	require.NoError(t, err)
+	
+	saved := false
+	err = repo.FinishCrawlJob(record.ID, "test-worker", models.CrawlJobOutcome{
+		JobStatus: models.JobStatusCompleted,
+		URLStatus: models.StatusCompleted,
+	}, func(w CrawlResultWriter) error {
+		saved = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrCrawlJobLeaseLost)
+	assert.False(t, saved)
+	
+	current, err := repo.GetURLByID(url.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, models.StatusCompleted, current.Status)
+}
+
+func TestRepository_ReleaseCrawlJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+	
+	repo := setupTestDB(t)
+	
+	url, err := repo.CreateURL(testUserID, "https://test-release-job.com", nil)
+	require.NoError(t, err)
+	defer repo.DeleteURL(url.ID)
+	
+	_, err = repo.EnqueueCrawlJob(url.ID, models.CrawlJobOptions{})
+	require.NoError(t, err)
+	
+	record, err := repo.ClaimNextCrawlJob("test-worker", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	
+	// Only the worker holding the job can hand it back
+	assert.ErrorIs(t, repo.ReleaseCrawlJob(record.ID, "other-worker"), ErrCrawlJobLeaseLost)
+	require.NoError(t, repo.ReleaseCrawlJob(record.ID, "test-worker"))
+	
+	// Queued again right away, without the attempt counting
+	released, err := repo.GetCrawlJobByID(record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusQueued, released.Status)
+	assert.Nil(t, released.WorkerID)
+	assert.Nil(t, released.LeaseExpiresAt)
+	assert.Equal(t, 0, released.Attempts)
+	
+	assert.ErrorIs(t, repo.ReleaseCrawlJob(record.ID, "test-worker"), ErrCrawlJobLeaseLost)
+}
+
+func TestRepository_ListRulesForURL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+	
+	repo := setupTestDB(t)
+	
+	result, err := DB.Exec("INSERT INTO users (username, api_key) VALUES ('test-rules-user', 'test-rules-api-key')")
+	require.NoError(t, err)
+	otherUserID, err := result.LastInsertId()
+	require.NoError(t, err)
+	defer DB.Exec("DELETE FROM users WHERE id = ?", otherUserID)
+	
+	url, err := repo.CreateURL(testUserID, "https://test-rules.com", nil)
+	require.NoError(t, err)
+	defer repo.DeleteURL(url.ID)
+	
+	own := &models.Rule{UserID: testUserID, Name: "test-own", Type: models.RuleSelectorExists, Selector: "h1", Severity: models.SeverityWarning, Enabled: true}
+	require.NoError(t, repo.CreateRule(own))
+	defer repo.DeleteRule(own.ID)
+	
+	other := &models.Rule{UserID: int(otherUserID), Name: "test-other", Type: models.RuleSelectorExists, Selector: "h1", Severity: models.SeverityWarning, Enabled: true}
+	require.NoError(t, repo.CreateRule(other))
+	
+	// Only the rules of the user who added the URL are evaluated against it
+	rules, err := repo.ListRulesForURL(url.ID)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, own.ID, rules[0].ID)
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"url-analyzer/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// runs statements either directly on the database or inside a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Preparex(query string) (*sqlx.Stmt, error)
+}
+
+// saves the results of a crawl through an execer. FinishCrawlJob hands the crawl one bound to the
+// transaction that finishes its job, so results are only kept while the crawl still holds the job.
+type resultWriter struct {
+	ex execer
+}
+
+// Ensures resultWriter implements CrawlResultWriter
+var _ CrawlResultWriter = (*resultWriter)(nil)
+
+// runs fn with a result writer inside a transaction that is committed when fn succeeds
+func (r *Repository) writeResults(fn func(w *resultWriter) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if err := fn(&resultWriter{ex: tx}); err != nil {
+		return err
+	}
+	
+	return tx.Commit()
+}
+
+// creates a new crawl result
+func (w *resultWriter) CreateCrawlResult(result *models.CrawlResult) error {
+	query := `
+		INSERT INTO crawl_results (
+			url_id, page_url, title, html_version, doctype, document_mode, h1_count, h2_count, h3_count, 
+			h4_count, h5_count, h6_count, internal_links, external_links, 
+			broken_links_count, links_blocked_by_robots, has_login_form, seo_metadata, structured_data, resource_summary, redirect_chain, analyzer_runs, rules_passed, rules_failed, fetcher, sitemap_coverage, depth, parent_id, root_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	
+	execResult, err := w.ex.Exec(query,
+		result.URLID, result.PageURL, result.Title, result.HTMLVersion, result.Doctype, result.DocumentMode, result.H1Count,
+		result.H2Count, result.H3Count, result.H4Count, result.H5Count,
+		result.H6Count, result.InternalLinks, result.ExternalLinks,
+		result.BrokenLinksCount, result.LinksBlockedByRobots, result.HasLoginForm, result.SEO, result.StructuredData, result.Resources, result.RedirectChain, result.Analyzers, result.RulesPassed, result.RulesFailed, result.Fetcher, result.Sitemap, result.Depth,
+		result.ParentID, result.RootID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create crawl result: %w", err)
+	}
+	
+	id, err := execResult.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	
+	result.ID = int(id)
+	return nil
+}
+
+// creates multiple broken link records
+func (w *resultWriter) CreateBrokenLinks(crawlResultID int, brokenLinks []models.BrokenLink) error {
+	if len(brokenLinks) == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO broken_links (crawl_result_id, url, status_code, category, error_message) 
+		VALUES (?, ?, ?, ?, ?)
+	`
+	
+	for _, link := range brokenLinks {
+		_, err := w.ex.Exec(query, crawlResultID, link.URL, link.StatusCode, link.Category, link.ErrorMessage)
+		if err != nil {
+			return fmt.Errorf("failed to create broken link: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// saves every link found by a crawl result
+func (w *resultWriter) CreateLinks(crawlResultID int, links []models.Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO links (crawl_result_id, url, anchor_text, rel, is_internal, check_status, check_category, status_code, error_message, redirects) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	
+	stmt, err := w.ex.Preparex(query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare link insert: %w", err)
+	}
+	defer stmt.Close()
+	
+	for _, link := range links {
+		_, err := stmt.Exec(crawlResultID, link.URL, truncateText(link.AnchorText, maxAnchorTextLength), link.Rel, link.IsInternal, link.Status, link.Category, link.StatusCode, link.ErrorMessage, link.Redirects)
+		if err != nil {
+			return fmt.Errorf("failed to create link: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// saves the resources referenced by a crawl result
+func (w *resultWriter) CreateResources(crawlResultID int, resources []models.PageResource) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO page_resources (crawl_result_id, url, type, is_third_party, check_status, check_category, status_code, size, content_type, error_message) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	
+	stmt, err := w.ex.Preparex(query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare resource insert: %w", err)
+	}
+	defer stmt.Close()
+	
+	for _, resource := range resources {
+		_, err := stmt.Exec(crawlResultID, resource.URL, resource.Type, resource.IsThirdParty, resource.Status, resource.Category,
+			resource.StatusCode, resource.Size, resource.ContentType, resource.ErrorMessage)
+		if err != nil {
+			return fmt.Errorf("failed to create resource: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// saves the security report of a crawl result
+func (w *resultWriter) CreateSecurityReport(report *models.SecurityReport) error {
+	query := `
+		INSERT INTO security_reports (crawl_result_id, score, grade, checks, cookies, tls_version, cert_issuer, cert_subject, cert_expires_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	
+	result, err := w.ex.Exec(query, report.CrawlResultID, report.Score, report.Grade, report.Checks, report.Cookies,
+		report.TLSVersion, report.CertIssuer, report.CertSubject, report.CertExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create security report: %w", err)
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get security report ID: %w", err)
+	}
+	
+	report.ID = int(id)
+	return nil
+}
+
+// saves the forms found by a crawl result
+func (w *resultWriter) CreateForms(crawlResultID int, forms []models.Form) error {
+	if len(forms) == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO forms (crawl_result_id, form_type, score, action, method, posts_over_http, password_fields, oauth_providers, signals) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	
+	for _, form := range forms {
+		_, err := w.ex.Exec(query, crawlResultID, form.Type, form.Score, form.Action, form.Method,
+			form.PostsOverHTTP, form.PasswordFields, form.OAuthProviders, form.Signals)
+		if err != nil {
+			return fmt.Errorf("failed to create form: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// saves the findings about a crawl result
+func (w *resultWriter) CreateFindings(crawlResultID int, findings []models.Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO findings (crawl_result_id, category, code, severity, message, selector) 
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	
+	for _, finding := range findings {
+		_, err := w.ex.Exec(query, crawlResultID, finding.Category, finding.Code, finding.Severity, finding.Message, finding.Selector)
+		if err != nil {
+			return fmt.Errorf("failed to create finding: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// saves the outcomes of the custom rules on a crawl result
+func (w *resultWriter) CreateRuleResults(crawlResultID int, results []models.RuleResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	
+	query := `
+		INSERT INTO rule_results (crawl_result_id, rule_id, rule_name, passed, severity, message) 
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	
+	for _, result := range results {
+		_, err := w.ex.Exec(query, crawlResultID, result.RuleID, result.RuleName, result.Passed, result.Severity, result.Message)
+		if err != nil {
+			return fmt.Errorf("failed to create rule result: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// creates a pending webhook delivery, setting its ID
+func (w *resultWriter) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at) 
+		VALUES (?, ?, ?, ?, ?)
+	`
+	
+	result, err := w.ex.Exec(query, delivery.WebhookID, delivery.Event, string(delivery.Payload), delivery.Status, delivery.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	
+	delivery.ID = int(id)
+	return nil
+}
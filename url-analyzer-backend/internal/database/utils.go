@@ -5,8 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"url-analyzer/pkg/crawler"
 )
 
+// returned when a crawl job is queued for a URL that already has a queued or running one
+var ErrActiveCrawlJob = errors.New("crawl job already active")
+
+// returned when a worker extends or finishes a crawl job it no longer holds, because the job
+// was cancelled or its lease expired and it was requeued
+var ErrCrawlJobLeaseLost = errors.New("crawl job lease lost")
+
+// returns the key a URL is told apart from others by. URLs are stored and crawled as given,
+// equivalent ones share a key, so the same page is not added twice.
+func URLKey(url string) (string, error) {
+	return crawler.NormalizeURL(url)
+}
+
 func IsUniqueConstraintError(err error) bool {
 	if err == nil {
 		return false
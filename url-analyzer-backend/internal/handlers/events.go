@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"io"
+	"time"
+	"url-analyzer/internal/models"
+	"url-analyzer/internal/services"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// how often a comment is sent on idle event streams so that proxies keep them open
+const eventStreamKeepAlive = 15 * time.Second
+
+// StreamJobEvents handles GET /api/jobs/events
+// @Summary Stream the progress of all crawl jobs
+// @Description Server-sent "progress" events for every status, message or progress change of any crawl job on this server. The current state of every job is sent first. Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed, or the current state again when the server restarted meanwhile. EventSource clients may pass the API key as the api_key query parameter.
+// @Tags System
+// @Produce text/event-stream
+// @Param last_event_id query string false "Resume after this event ID"
+// @Success 200 {object} models.JobEvent "Stream of progress events"
+// @Security ApiKeyAuth
+// @Router /jobs/events [get]
+func (h *SystemHandler) StreamJobEvents(c *gin.Context) {
+	streamJobEvents(c, h.crawlerService, 0, lastEventID(c), nil)
+}
+
+// reads the ID of the last event a reconnecting client saw, from the Last-Event-ID header
+// browsers send on reconnect or from the last_event_id query parameter. Empty means none.
+func lastEventID(c *gin.Context) string {
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		return value
+	}
+	return c.Query("last_event_id")
+}
+
+// streams the job events of a URL, or of every URL when urlID is 0, as server-sent "progress"
+// events until the client disconnects. Events missed since resumeAfter are sent first. Clients
+// that cannot resume, e.g. because their last event is from before a restart, get the current
+// state of the jobs on this server instead, or fallback when there are none.
+func streamJobEvents(c *gin.Context, crawlerService services.CrawlerServiceInterface, urlID int, resumeAfter string, fallback *models.JobEvent) {
+	sub, events, resumed := crawlerService.SubscribeJobEvents(urlID, resumeAfter)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	// Snapshots carry no ID so that reconnecting clients resume from the last real event
+	for _, event := range events {
+		renderJobEvent(c, event)
+	}
+	if !resumed && len(events) == 0 && fallback != nil {
+		renderJobEvent(c, *fallback)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Fell too far behind; the client reconnects and resumes from its last event
+				return false
+			}
+			renderJobEvent(c, event)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// writes a job event as a server-sent event
+func renderJobEvent(c *gin.Context, event models.JobEvent) {
+	c.Render(-1, sse.Event{
+		Id:    event.ID,
+		Event: "progress",
+		Data:  event,
+	})
+}
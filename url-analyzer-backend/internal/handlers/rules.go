@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handles custom rule HTTP requests. Rules belong to the user whose API key created them and are
+// evaluated against every crawl of their URL, or of every URL the user added when they have none.
+type RuleHandler struct {
+	repo  database.RepositoryInterface
+	rules database.RuleRepositoryInterface
+}
+
+// creates a new rule handler
+func NewRuleHandler(repo database.RepositoryInterface, rules database.RuleRepositoryInterface) *RuleHandler {
+	return &RuleHandler{
+		repo:  repo,
+		rules: rules,
+	}
+}
+
+// CreateRule handles POST /api/rules
+// @Summary Create a custom rule
+// @Description Define a check evaluated against every crawled page: selector_exists and selector_absent take a CSS selector, element_count a selector, a count and an operator (eq, ne, lt, lte, gt, gte; default eq), title_matches a regular expression and text_contains and text_absent a text looked up in the visible text, ignoring case. Without url_id the rule applies to every URL the calling user added, with url_id only to that URL, which must be one of them. Rules take effect on the next crawl.
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param request body models.RuleRequest true "Rule to create"
+// @Success 201 {object} models.Rule "Rule created"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or rule"
+// @Failure 403 {object} map[string]interface{} "URL added by another user"
+// @Failure 404 {object} map[string]interface{} "URL not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /rules [post]
+func (h *RuleHandler) CreateRule(c *gin.Context) {
+	req, ok := h.bindRuleRequest(c)
+	if !ok {
+		return
+	}
+
+	rule := &models.Rule{UserID: c.GetInt("user_id")}
+	req.Apply(rule)
+
+	if err := h.rules.CreateRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules handles GET /api/rules
+// @Summary List custom rules
+// @Description List the rules of the calling user, optionally only those evaluated against one URL: its own and those without a URL
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param url_id query int false "Only rules evaluated against this URL"
+// @Success 200 {object} map[string]interface{} "Rules"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /rules [get]
+func (h *RuleHandler) ListRules(c *gin.Context) {
+	var filter models.RuleFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	rules, err := h.rules.ListRulesByUserID(c.GetInt("user_id"), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// GetRule handles GET /api/rules/:id
+// @Summary Get a custom rule
+// @Description Get a rule of the calling user
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} models.Rule "Rule"
+// @Failure 400 {object} map[string]interface{} "Invalid rule ID"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /rules/{id} [get]
+func (h *RuleHandler) GetRule(c *gin.Context) {
+	rule, ok := h.ruleFromPath(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule handles PUT /api/rules/:id
+// @Summary Replace a custom rule
+// @Description Replace the definition of a rule of the calling user, or enable or disable it. Results of earlier crawls are kept.
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param request body models.RuleRequest true "New rule"
+// @Success 200 {object} models.Rule "Rule updated"
+// @Failure 400 {object} map[string]interface{} "Invalid rule ID, request format or rule"
+// @Failure 403 {object} map[string]interface{} "URL added by another user"
+// @Failure 404 {object} map[string]interface{} "Rule or URL not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /rules/{id} [put]
+func (h *RuleHandler) UpdateRule(c *gin.Context) {
+	rule, ok := h.ruleFromPath(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindRuleRequest(c)
+	if !ok {
+		return
+	}
+	req.Apply(rule)
+
+	if err := h.rules.UpdateRule(rule); err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule handles DELETE /api/rules/:id
+// @Summary Delete a custom rule
+// @Description Delete a rule of the calling user. Its results on earlier crawls are kept under its name.
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} map[string]interface{} "Rule deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid rule ID"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /rules/{id} [delete]
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	rule, ok := h.ruleFromPath(c)
+	if !ok {
+		return
+	}
+
+	err := h.rules.DeleteRule(rule.ID)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted successfully"})
+}
+
+// binds and validates a rule request and checks that its URL exists and was added by the calling user,
+// since rules are only evaluated against the URLs of their user, writing the error response otherwise
+func (h *RuleHandler) bindRuleRequest(c *gin.Context) (models.RuleRequest, bool) {
+	var req models.RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return req, false
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule", "details": err.Error()})
+		return req, false
+	}
+
+	if req.URLID != nil {
+		url, err := h.repo.GetURLByID(*req.URLID)
+		if err != nil {
+			if database.IsNotFoundError(err) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+				return req, false
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch URL", "details": err.Error()})
+			return req, false
+		}
+		if url.UserID == nil || *url.UserID != c.GetInt("user_id") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "URL added by another user", "details": "rules are only evaluated against the URLs you added"})
+			return req, false
+		}
+	}
+
+	return req, true
+}
+
+// fetches the calling user's rule named by the :id path parameter, writing the error response otherwise.
+// Rules of other users are reported as not found.
+func (h *RuleHandler) ruleFromPath(c *gin.Context) (*models.Rule, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return nil, false
+	}
+
+	rule, err := h.rules.GetRuleByID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rule", "details": err.Error()})
+		return nil, false
+	}
+
+	if rule.UserID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return nil, false
+	}
+
+	return rule, true
+}
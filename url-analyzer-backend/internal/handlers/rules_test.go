@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRuleRepository for testing
+type MockRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRuleRepository) CreateRule(rule *models.Rule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) GetRuleByID(id int) (*models.Rule, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Rule), args.Error(1)
+}
+
+func (m *MockRuleRepository) ListRulesByUserID(userID int, filter models.RuleFilter) ([]models.Rule, error) {
+	args := m.Called(userID, filter)
+	return args.Get(0).([]models.Rule), args.Error(1)
+}
+
+func (m *MockRuleRepository) UpdateRule(rule *models.Rule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockRuleRepository) DeleteRule(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func setupRuleTestRouter(repo *MockRepository, rules *MockRuleRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	handler := NewRuleHandler(repo, rules)
+
+	// Stand in for the auth middleware
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	})
+
+	api := router.Group("/api")
+	{
+		api.POST("/rules", handler.CreateRule)
+		api.GET("/rules", handler.ListRules)
+		api.GET("/rules/:id", handler.GetRule)
+		api.PUT("/rules/:id", handler.UpdateRule)
+		api.DELETE("/rules/:id", handler.DeleteRule)
+	}
+
+	return router
+}
+
+func TestCreateRule_ElementCount(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRules := new(MockRuleRepository)
+	router := setupRuleTestRouter(mockRepo, mockRules)
+
+	userID := 1
+	mockRepo.On("GetURLByID", 2).Return(&models.URL{ID: 2, UserID: &userID, URL: "https://example.com"}, nil)
+	mockRules.On("CreateRule", mock.MatchedBy(func(rule *models.Rule) bool {
+		// The operator, severity and enabled flag get their defaults
+		return rule.UserID == 1 && rule.URLID != nil && *rule.URLID == 2 &&
+			rule.Type == models.RuleElementCount && rule.Selector == "h1" && rule.Count == 1 &&
+			rule.Operator == models.RuleOperatorEqual && rule.Severity == models.SeverityWarning && rule.Enabled
+	})).Return(nil)
+
+	jsonBody := []byte(`{"name": "One h1", "type": "element_count", "selector": "h1", "count": 1, "url_id": 2}`)
+	req, _ := http.NewRequest("POST", "/api/rules", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var rule models.Rule
+	err := json.Unmarshal(w.Body.Bytes(), &rule)
+	require.NoError(t, err)
+	assert.Equal(t, "One h1", rule.Name)
+
+	mockRepo.AssertExpectations(t)
+	mockRules.AssertExpectations(t)
+}
+
+func TestCreateRule_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown type", `{"name": "Fast", "type": "load_time"}`},
+		{"missing selector", `{"name": "Footer", "type": "selector_exists"}`},
+		{"invalid selector", `{"name": "Footer", "type": "selector_exists", "selector": "footer[["}`},
+		{"missing count", `{"name": "One h1", "type": "element_count", "selector": "h1"}`},
+		{"invalid operator", `{"name": "One h1", "type": "element_count", "selector": "h1", "count": 1, "operator": "about"}`},
+		{"invalid pattern", `{"name": "Brand", "type": "title_matches", "pattern": "(Acme"}`},
+		{"missing text", `{"name": "No lorem", "type": "text_absent", "pattern": "  "}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRules := new(MockRuleRepository)
+			router := setupRuleTestRouter(mockRepo, mockRules)
+
+			req, _ := http.NewRequest("POST", "/api/rules", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockRules.AssertNotCalled(t, "CreateRule", mock.Anything)
+		})
+	}
+}
+
+func TestCreateRule_URLNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRules := new(MockRuleRepository)
+	router := setupRuleTestRouter(mockRepo, mockRules)
+
+	mockRepo.On("GetURLByID", 99).Return(nil, fmt.Errorf("URL not found"))
+
+	jsonBody := []byte(`{"name": "Footer", "type": "selector_exists", "selector": "footer", "url_id": 99}`)
+	req, _ := http.NewRequest("POST", "/api/rules", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRules.AssertNotCalled(t, "CreateRule", mock.Anything)
+}
+
+func TestCreateRule_URLOfAnotherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRules := new(MockRuleRepository)
+	router := setupRuleTestRouter(mockRepo, mockRules)
+
+	// The rule would never be evaluated against a URL added by someone else
+	otherUserID := 2
+	mockRepo.On("GetURLByID", 5).Return(&models.URL{ID: 5, UserID: &otherUserID, URL: "https://example.com"}, nil)
+
+	jsonBody := []byte(`{"name": "Footer", "type": "selector_exists", "selector": "footer", "url_id": 5}`)
+	req, _ := http.NewRequest("POST", "/api/rules", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockRules.AssertNotCalled(t, "CreateRule", mock.Anything)
+}
+
+func TestListRules_ForURL(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRules := new(MockRuleRepository)
+	router := setupRuleTestRouter(mockRepo, mockRules)
+
+	rules := []models.Rule{
+		{ID: 3, UserID: 1, Name: "Has a footer", Type: models.RuleSelectorExists, Selector: "footer", Severity: models.SeverityWarning, Enabled: true},
+	}
+	mockRules.On("ListRulesByUserID", 1, models.RuleFilter{URLID: 2}).Return(rules, nil)
+
+	req, _ := http.NewRequest("GET", "/api/rules?url_id=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Rules []models.Rule `json:"rules"`
+		Count int           `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Count)
+
+	mockRules.AssertExpectations(t)
+}
+
+func TestUpdateRule_Disable(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRules := new(MockRuleRepository)
+	router := setupRuleTestRouter(mockRepo, mockRules)
+
+	existing := &models.Rule{ID: 3, UserID: 1, Name: "Brand", Type: models.RuleTitleMatches, Pattern: "Acme", Severity: models.SeverityError, Enabled: true}
+	mockRules.On("GetRuleByID", 3).Return(existing, nil)
+	mockRules.On("UpdateRule", mock.MatchedBy(func(rule *models.Rule) bool {
+		return rule.ID == 3 && rule.Pattern == "^Acme" && rule.Severity == models.SeverityError && !rule.Enabled
+	})).Return(nil)
+
+	jsonBody := []byte(`{"name": "Brand", "type": "title_matches", "pattern": "^Acme", "severity": "error", "enabled": false}`)
+	req, _ := http.NewRequest("PUT", "/api/rules/3", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRules.AssertExpectations(t)
+}
+
+func TestRule_OtherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRules := new(MockRuleRepository)
+	router := setupRuleTestRouter(mockRepo, mockRules)
+
+	mockRules.On("GetRuleByID", 3).Return(&models.Rule{ID: 3, UserID: 2, Name: "Theirs"}, nil)
+
+	for _, method := range []string{"GET", "DELETE"} {
+		req, _ := http.NewRequest(method, "/api/rules/3", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+	}
+	mockRules.AssertNotCalled(t, "DeleteRule", mock.Anything)
+}
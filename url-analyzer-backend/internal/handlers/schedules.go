@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handles crawl schedule HTTP requests
+type ScheduleHandler struct {
+	repo      database.RepositoryInterface
+	schedules database.ScheduleRepositoryInterface
+}
+
+// creates a new schedule handler
+func NewScheduleHandler(repo database.RepositoryInterface, schedules database.ScheduleRepositoryInterface) *ScheduleHandler {
+	return &ScheduleHandler{
+		repo:      repo,
+		schedules: schedules,
+	}
+}
+
+// CreateSchedule handles POST /api/schedules
+// @Summary Schedule recurring crawls of a URL
+// @Description Crawl a URL on a five-field cron expression (UTC) or every interval_seconds. Runs are skipped while a crawl of the URL is still in progress.
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param request body models.CreateScheduleRequest true "URL and schedule"
+// @Success 201 {object} models.CrawlSchedule "Schedule created"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 404 {object} map[string]interface{} "URL not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /schedules [post]
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req models.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule", "details": err.Error()})
+		return
+	}
+
+	_, err := h.repo.GetURLByID(req.URLID)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch URL", "details": err.Error()})
+		return
+	}
+
+	schedule := &models.CrawlSchedule{
+		URLID:   req.URLID,
+		Enabled: true,
+	}
+	if !applyScheduleRequest(c, schedule, req.ScheduleRequest) {
+		return
+	}
+
+	if err := h.schedules.CreateSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// ListSchedules handles GET /api/schedules
+// @Summary List crawl schedules
+// @Description List crawl schedules, optionally only those of one URL
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param url_id query int false "Only schedules of this URL"
+// @Success 200 {object} map[string]interface{} "Schedules"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /schedules [get]
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	var filter models.ScheduleFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	schedules, err := h.schedules.ListSchedules(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+		"count":     len(schedules),
+	})
+}
+
+// GetSchedule handles GET /api/schedules/:id
+// @Summary Get a crawl schedule
+// @Description Get a crawl schedule including its next run and the outcome of the latest run
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} models.CrawlSchedule "Schedule"
+// @Failure 400 {object} map[string]interface{} "Invalid schedule ID"
+// @Failure 404 {object} map[string]interface{} "Schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /schedules/{id} [get]
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	schedule, ok := h.scheduleFromPath(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule handles PUT /api/schedules/:id
+// @Summary Change a crawl schedule
+// @Description Replace the cron expression or interval of a schedule and enable or disable it. The next run is computed from now.
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param request body models.ScheduleRequest true "New schedule"
+// @Success 200 {object} models.CrawlSchedule "Schedule updated"
+// @Failure 400 {object} map[string]interface{} "Invalid schedule ID or request format"
+// @Failure 404 {object} map[string]interface{} "Schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /schedules/{id} [put]
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	schedule, ok := h.scheduleFromPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule", "details": err.Error()})
+		return
+	}
+
+	if !applyScheduleRequest(c, schedule, req) {
+		return
+	}
+
+	if err := h.schedules.UpdateSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule handles DELETE /api/schedules/:id
+// @Summary Delete a crawl schedule
+// @Description Delete a crawl schedule. Crawls it already started keep running.
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]interface{} "Schedule deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid schedule ID"
+// @Failure 404 {object} map[string]interface{} "Schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /schedules/{id} [delete]
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	err = h.schedules.DeleteSchedule(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// fetches the schedule named by the :id path parameter, writing the error response otherwise
+func (h *ScheduleHandler) scheduleFromPath(c *gin.Context) (*models.CrawlSchedule, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return nil, false
+	}
+
+	schedule, err := h.schedules.GetScheduleByID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule", "details": err.Error()})
+		return nil, false
+	}
+
+	return schedule, true
+}
+
+// copies a validated schedule request onto a schedule and computes its next run,
+// writing the error response when the schedule never runs
+func applyScheduleRequest(c *gin.Context, schedule *models.CrawlSchedule, req models.ScheduleRequest) bool {
+	schedule.CronExpr = req.CronExpr
+	schedule.IntervalSeconds = req.IntervalSeconds
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+
+	nextRunAt, err := schedule.NextRunAfter(time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule", "details": err.Error()})
+		return false
+	}
+	schedule.NextRunAt = nextRunAt
+
+	return true
+}
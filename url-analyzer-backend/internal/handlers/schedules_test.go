@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockScheduleRepository for testing
+type MockScheduleRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduleRepository) CreateSchedule(schedule *models.CrawlSchedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) GetScheduleByID(id int) (*models.CrawlSchedule, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlSchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) ListSchedules(filter models.ScheduleFilter) ([]models.CrawlSchedule, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.CrawlSchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) UpdateSchedule(schedule *models.CrawlSchedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) DeleteSchedule(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) ListDueSchedules(now time.Time, limit int) ([]models.CrawlSchedule, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.CrawlSchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) AdvanceSchedule(id int, dueAt time.Time, nextRunAt time.Time) (bool, error) {
+	args := m.Called(id, dueAt, nextRunAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockScheduleRepository) RecordScheduleRun(id int, lastError *string) error {
+	args := m.Called(id, lastError)
+	return args.Error(0)
+}
+
+func setupScheduleTestRouter(repo *MockRepository, schedules *MockScheduleRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	handler := NewScheduleHandler(repo, schedules)
+
+	api := router.Group("/api")
+	{
+		api.POST("/schedules", handler.CreateSchedule)
+		api.GET("/schedules", handler.ListSchedules)
+		api.GET("/schedules/:id", handler.GetSchedule)
+		api.PUT("/schedules/:id", handler.UpdateSchedule)
+		api.DELETE("/schedules/:id", handler.DeleteSchedule)
+	}
+
+	return router
+}
+
+func TestCreateSchedule_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockSchedules := new(MockScheduleRepository)
+	router := setupScheduleTestRouter(mockRepo, mockSchedules)
+
+	mockRepo.On("GetURLByID", 1).Return(&models.URL{ID: 1, URL: "https://example.com"}, nil)
+	mockSchedules.On("CreateSchedule", mock.MatchedBy(func(schedule *models.CrawlSchedule) bool {
+		return schedule.URLID == 1 && schedule.Enabled && *schedule.CronExpr == "0 2 * * *" &&
+			schedule.NextRunAt.Hour() == 2 && schedule.NextRunAt.After(time.Now())
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.CrawlSchedule).ID = 7
+	}).Return(nil)
+
+	jsonBody := []byte(`{"url_id": 1, "cron_expr": "0 2 * * *"}`)
+	req, _ := http.NewRequest("POST", "/api/schedules", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.CrawlSchedule
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, 7, response.ID)
+
+	mockRepo.AssertExpectations(t)
+	mockSchedules.AssertExpectations(t)
+}
+
+func TestCreateSchedule_InvalidSchedule(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockSchedules := new(MockScheduleRepository)
+	router := setupScheduleTestRouter(mockRepo, mockSchedules)
+
+	testCases := []string{
+		// Neither timing
+		`{"url_id": 1}`,
+		// Both timings
+		`{"url_id": 1, "cron_expr": "0 2 * * *", "interval_seconds": 3600}`,
+		// Unparsable cron expression
+		`{"url_id": 1, "cron_expr": "every day"}`,
+		// Interval below the minimum
+		`{"url_id": 1, "interval_seconds": 5}`,
+	}
+
+	for _, body := range testCases {
+		req, _ := http.NewRequest("POST", "/api/schedules", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	mockSchedules.AssertNotCalled(t, "CreateSchedule", mock.Anything)
+}
+
+func TestCreateSchedule_URLNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockSchedules := new(MockScheduleRepository)
+	router := setupScheduleTestRouter(mockRepo, mockSchedules)
+
+	mockRepo.On("GetURLByID", 99).Return((*models.URL)(nil), errors.New("URL not found"))
+
+	jsonBody := []byte(`{"url_id": 99, "interval_seconds": 3600}`)
+	req, _ := http.NewRequest("POST", "/api/schedules", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSchedules.AssertNotCalled(t, "CreateSchedule", mock.Anything)
+}
+
+func TestUpdateSchedule_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockSchedules := new(MockScheduleRepository)
+	router := setupScheduleTestRouter(mockRepo, mockSchedules)
+
+	cronExpr := "0 2 * * *"
+	mockSchedules.On("GetScheduleByID", 7).Return(&models.CrawlSchedule{ID: 7, URLID: 1, CronExpr: &cronExpr, Enabled: true}, nil)
+	mockSchedules.On("UpdateSchedule", mock.MatchedBy(func(schedule *models.CrawlSchedule) bool {
+		return schedule.ID == 7 && schedule.CronExpr == nil && *schedule.IntervalSeconds == 3600 && !schedule.Enabled
+	})).Return(nil)
+
+	jsonBody := []byte(`{"interval_seconds": 3600, "enabled": false}`)
+	req, _ := http.NewRequest("PUT", "/api/schedules/7", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSchedules.AssertExpectations(t)
+}
+
+func TestDeleteSchedule_NotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockSchedules := new(MockScheduleRepository)
+	router := setupScheduleTestRouter(mockRepo, mockSchedules)
+
+	mockSchedules.On("DeleteSchedule", 7).Return(errors.New("schedule not found"))
+
+	req, _ := http.NewRequest("DELETE", "/api/schedules/7", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSchedules.AssertExpectations(t)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+	"url-analyzer/internal/services"
+	"url-analyzer/pkg/sitemap"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// how many URLs an import adds when the request does not say
+	defaultSitemapImportURLs = 1000
+	// how many sitemap files an import reads, indexes included
+	maxSitemapImportFiles = 50
+	// the longest URL the urls table holds
+	maxURLLength = 768
+)
+
+// handles sitemap imports, which add the pages a sitemap lists as URLs
+type SitemapHandler struct {
+	repo           database.RepositoryInterface
+	crawlerService services.CrawlerServiceInterface
+	loader         sitemap.Loader
+}
+
+// creates a new sitemap handler
+func NewSitemapHandler(repo database.RepositoryInterface, crawlerService services.CrawlerServiceInterface) *SitemapHandler {
+	return &SitemapHandler{
+		repo:           repo,
+		crawlerService: crawlerService,
+		loader: sitemap.Loader{
+			Client:      &http.Client{Timeout: 60 * time.Second},
+			UserAgent:   models.DefaultCrawlOptions().UserAgent,
+			MaxSitemaps: maxSitemapImportFiles,
+		},
+	}
+}
+
+// ImportSitemap handles POST /api/sitemaps
+// @Summary Add the pages of a sitemap
+// @Description Read a sitemap or sitemap index, given by its URL or uploaded as the multipart file "file" (XML or gzip compressed), and add every page it lists as a URL. The sitemaps an index lists are read too. Pages added before are left alone, so importing a sitemap again only adds its new pages. With start_crawl a crawl of every added URL is queued.
+// @Tags URLs
+// @Accept json,mpfd
+// @Produce json
+// @Param request body models.SitemapImportRequest false "Sitemap URL and import options"
+// @Param file formData file false "Sitemap file, XML or gzip compressed"
+// @Success 200 {object} models.SitemapImportResult "Nothing new to add"
+// @Success 201 {object} models.SitemapImportResult "URLs added"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 422 {object} map[string]interface{} "The sitemap could not be read"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /sitemaps [post]
+func (h *SitemapHandler) ImportSitemap(c *gin.Context) {
+	var req models.SitemapImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	file, _ := c.FormFile("file")
+	if (req.URL == "") == (file == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give either the url of a sitemap or upload it as file"})
+		return
+	}
+
+	loader := h.loader
+	loader.MaxURLs = req.MaxURLs
+	if loader.MaxURLs == 0 {
+		loader.MaxURLs = defaultSitemapImportURLs
+	}
+
+	var result *sitemap.Result
+	var err error
+	if file != nil {
+		upload, openErr := file.Open()
+		if openErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file", "details": openErr.Error()})
+			return
+		}
+		defer upload.Close()
+		result, err = loader.Read(c.Request.Context(), upload, file.Filename)
+	} else {
+		result, err = loader.Load(c.Request.Context(), req.URL)
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to read sitemap", "details": err.Error()})
+		return
+	}
+
+	summary, err := h.importURLs(c.GetInt("user_id"), result, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add URLs", "details": err.Error(), "import": summary})
+		return
+	}
+
+	status := http.StatusOK
+	if summary.Created > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, summary)
+}
+
+// adds the pages of a sitemap that have not been added yet on behalf of a user. Only a failing database
+// stops the import, pages that cannot be added or crawled are reported in the summary's errors.
+func (h *SitemapHandler) importURLs(userID int, result *sitemap.Result, req models.SitemapImportRequest) (*models.SitemapImportResult, error) {
+	summary := &models.SitemapImportResult{
+		Sitemaps:  result.Sitemaps,
+		Found:     len(result.URLs),
+		URLIDs:    []int{},
+		Truncated: result.Truncated,
+		Errors:    append([]string{}, result.Errors...),
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range result.URLs {
+		if !importableURL(entry.Loc) {
+			summary.Invalid++
+			continue
+		}
+		// Pages are added as listed, equivalent ones are told apart by their key like in the database
+		key, err := database.URLKey(entry.Loc)
+		if err != nil || len(key) > maxURLLength {
+			summary.Invalid++
+			continue
+		}
+		if seen[key] {
+			summary.Duplicates++
+			continue
+		}
+		seen[key] = true
+
+		_, err = h.repo.GetURLByURL(entry.Loc)
+		if err == nil {
+			summary.Existing++
+			continue
+		}
+		if !database.IsNotFoundError(err) {
+			return summary, err
+		}
+
+		created, err := h.repo.CreateURL(userID, entry.Loc, req.Options)
+		if err != nil {
+			// Added at the same time by someone else
+			if database.IsUniqueConstraintError(err) {
+				summary.Existing++
+				continue
+			}
+			return summary, err
+		}
+		summary.Created++
+		summary.URLIDs = append(summary.URLIDs, created.ID)
+
+		if req.StartCrawl {
+			if err := h.crawlerService.StartCrawl(created.ID); err != nil {
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: failed to start crawl: %v", entry.Loc, err))
+				continue
+			}
+			summary.Queued++
+		}
+	}
+
+	return summary, nil
+}
+
+// reports whether a sitemap entry can be added as a URL: absolute, http or https and short enough to store
+func importableURL(loc string) bool {
+	if len(loc) > maxURLLength {
+		return false
+	}
+	parsed, err := url.Parse(loc)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
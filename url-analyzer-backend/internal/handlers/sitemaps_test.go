@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSitemapTestRouter(repo *MockRepository, crawlerService *MockCrawlerService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	handler := NewSitemapHandler(repo, crawlerService)
+
+	// Stand in for the auth middleware
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	})
+	router.POST("/api/sitemaps", handler.ImportSitemap)
+	return router
+}
+
+func postSitemapImport(router *gin.Engine, req models.SitemapImportRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	httpReq, _ := http.NewRequest("POST", "/api/sitemaps", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+	return w
+}
+
+func TestImportSitemap_URL(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<sitemapindex><sitemap><loc>` + server.URL + `/pages.xml</loc></sitemap></sitemapindex>`))
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<urlset>
+  <url><loc>https://shop.test/</loc></url>
+  <url><loc>https://shop.test/products</loc></url>
+  <url><loc>https://SHOP.test/products#reviews</loc></url>
+  <url><loc>https://shop.test:443/about/</loc></url>
+  <url><loc>/relative</loc></url>
+  <url><loc>ftp://shop.test/catalog.pdf</loc></url>
+</urlset>`))
+	})
+
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupSitemapTestRouter(mockRepo, mockCrawler)
+
+	timeout := 60
+	options := &models.CrawlOptionsOverride{TimeoutSeconds: &timeout}
+	mockRepo.On("GetURLByURL", "https://shop.test/").Return(&models.URL{ID: 1, URL: "https://shop.test/"}, nil)
+	mockRepo.On("GetURLByURL", "https://shop.test/products").Return((*models.URL)(nil), sql.ErrNoRows)
+	mockRepo.On("CreateURL", 1, "https://shop.test/products", options).Return(&models.URL{ID: 7}, nil)
+	// Pages are looked up and added as listed, equivalent ones are only imported once
+	mockRepo.On("GetURLByURL", "https://shop.test:443/about/").Return((*models.URL)(nil), sql.ErrNoRows)
+	mockRepo.On("CreateURL", 1, "https://shop.test:443/about/", options).Return(&models.URL{ID: 8}, nil)
+	mockCrawler.On("StartCrawl", 7).Return(nil)
+	mockCrawler.On("StartCrawl", 8).Return(errors.New("crawl already in progress"))
+
+	w := postSitemapImport(router, models.SitemapImportRequest{URL: server.URL + "/sitemap_index.xml", StartCrawl: true, Options: options})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var result models.SitemapImportResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []string{server.URL + "/sitemap_index.xml", server.URL + "/pages.xml"}, result.Sitemaps)
+	assert.Equal(t, 6, result.Found)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Existing)
+	assert.Equal(t, 1, result.Duplicates)
+	assert.Equal(t, 2, result.Invalid)
+	assert.Equal(t, 1, result.Queued)
+	assert.Equal(t, []int{7, 8}, result.URLIDs)
+	assert.Equal(t, []string{"https://shop.test:443/about/: failed to start crawl: crawl already in progress"}, result.Errors)
+	assert.False(t, result.Truncated)
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestImportSitemap_Upload(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupSitemapTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("GetURLByURL", "https://blog.test/").Return(&models.URL{ID: 3}, nil)
+	mockRepo.On("GetURLByURL", "https://blog.test/posts/1").Return(&models.URL{ID: 4}, nil)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`<urlset><url><loc>https://blog.test/</loc></url><url><loc>https://blog.test/posts/1</loc></url><url><loc>https://blog.test/posts/2</loc></url></urlset>`))
+	gz.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "sitemap.xml.gz")
+	part.Write(compressed.Bytes())
+	form.WriteField("max_urls", "2")
+	form.Close()
+
+	req, _ := http.NewRequest("POST", "/api/sitemaps", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Nothing new to add
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.SitemapImportResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []string{"sitemap.xml.gz"}, result.Sitemaps)
+	assert.Equal(t, 2, result.Found)
+	assert.Equal(t, 2, result.Existing)
+	assert.Equal(t, 0, result.Created)
+	assert.Empty(t, result.URLIDs)
+	assert.True(t, result.Truncated)
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertNotCalled(t, "StartCrawl")
+}
+
+func TestImportSitemap_InvalidRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>Welcome</body></html>`))
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupSitemapTestRouter(mockRepo, mockCrawler)
+
+	testCases := []struct {
+		name   string
+		req    models.SitemapImportRequest
+		status int
+		error  string
+	}{
+		{"no sitemap", models.SitemapImportRequest{}, http.StatusBadRequest, "Give either the url of a sitemap or upload it as file"},
+		{"invalid url", models.SitemapImportRequest{URL: "not a url"}, http.StatusBadRequest, "Invalid request format"},
+		{"too many urls", models.SitemapImportRequest{URL: server.URL, MaxURLs: 100000}, http.StatusBadRequest, "Invalid request format"},
+		{"not a sitemap", models.SitemapImportRequest{URL: server.URL + "/sitemap.xml"}, http.StatusUnprocessableEntity, "Failed to read sitemap"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postSitemapImport(router, tc.req)
+			assert.Equal(t, tc.status, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tc.error, response["error"])
+		})
+	}
+
+	mockRepo.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportSitemap_DatabaseError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<urlset><url><loc>https://shop.test/</loc></url><url><loc>https://shop.test/about</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupSitemapTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("GetURLByURL", "https://shop.test/").Return((*models.URL)(nil), sql.ErrNoRows)
+	mockRepo.On("CreateURL", 1, "https://shop.test/", (*models.CrawlOptionsOverride)(nil)).Return(nil, errors.New("connection refused"))
+
+	w := postSitemapImport(router, models.SitemapImportRequest{URL: server.URL})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "connection refused")
+	mockRepo.AssertNotCalled(t, "GetURLByURL", "https://shop.test/about")
+}
@@ -63,14 +63,16 @@ func (h *SystemHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, health)
 }
 
-// handles GET /api/verify
+// handles GET /api/auth/verify
 // @Summary Verify authentication
 // @Description Verify if the user is authenticated and return user details
 // @Tags System
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{} "User is authenticated"
-// @Failure 401 {object} map[string]interface{} "Unauthorized
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Security ApiKeyAuth
+// @Router /auth/verify [get]
 func (h *SystemHandler) VerifyAuth(c *gin.Context) {
 	userID := c.MustGet("user_id").(int)
 	username := c.MustGet("username").(string)
@@ -139,6 +141,23 @@ func (h *SystemHandler) GetActiveJobs(c *gin.Context) {
 	})
 }
 
+// ListAnalyzers handles GET /api/analyzers
+// @Summary List the analyzers of the crawler pipeline
+// @Description Returns every registered analyzer in the order crawls run them, and whether crawls run it unless their options say otherwise. An analyzer's name is the category of its findings and the key to turn it on or off in the "analyzers" crawl option.
+// @Tags System
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Registered analyzers"
+// @Security ApiKeyAuth
+// @Router /analyzers [get]
+func (h *SystemHandler) ListAnalyzers(c *gin.Context) {
+	analyzers := h.crawlerService.GetAnalyzers()
+	
+	c.JSON(http.StatusOK, gin.H{
+		"analyzers": analyzers,
+		"count":     len(analyzers),
+	})
+}
+
 // handles POST /api/jobs/cleanup
 // @Summary Clean up old/finished jobs
 // @Description Cleans up completed or stale jobs from the system
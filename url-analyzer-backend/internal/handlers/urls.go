@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 	"url-analyzer/internal/database"
 	"url-analyzer/internal/models"
 	"url-analyzer/internal/services"
@@ -27,11 +29,11 @@ func NewURLHandler(repo database.RepositoryInterface, crawlerService services.Cr
 
 // CreateURL handles POST /api/urls
 // @Summary Add a new URL for analysis
-// @Description Add a new URL to be crawled and analyzed
+// @Description Add a new URL to be crawled and analyzed. The URL is crawled as given; one differing from a URL added before only in letter case of the host, a default port, a fragment, a trailing slash or the order of its query parameters is reported as existing.
 // @Tags URLs
 // @Accept json
 // @Produce json
-// @Param request body models.CreateURLRequest true "URL to add"
+// @Param request body models.CreateURLRequest true "URL to add, optionally with crawl option overrides"
 // @Success 201 {object} map[string]interface{} "URL added successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request format"
 // @Failure 409 {object} map[string]interface{} "URL already exists"
@@ -44,7 +46,12 @@ func (h *URLHandler) CreateURL(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
 		return
 	}
+	if key, err := database.URLKey(req.URL); err != nil || len(req.URL) > maxURLLength || len(key) > maxURLLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": fmt.Sprintf("url must be at most %d characters, also once normalized", maxURLLength)})
+		return
+	}
 
+	// An equivalent URL added before, e.g. by a batch or sitemap import, is the same URL
 	existingURL, err := h.repo.GetURLByURL(req.URL)
 	if err == nil {
 		c.JSON(http.StatusConflict, gin.H{
@@ -55,7 +62,7 @@ func (h *URLHandler) CreateURL(c *gin.Context) {
 		return
 	}
 
-	url, err := h.repo.CreateURL(req.URL)
+	url, err := h.repo.CreateURL(c.GetInt("user_id"), req.URL, req.Options)
 	if err != nil {
 		if database.IsUniqueConstraintError(err) {
 			c.JSON(http.StatusConflict, gin.H{"error": "URL already exists"})
@@ -79,8 +86,10 @@ func (h *URLHandler) CreateURL(c *gin.Context) {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Items per page" default(10)
-// @Param status query string false "Filter by status" Enums(queued, running, completed, error)
+// @Param status query string false "Filter by status" Enums(queued, running, completed, error, blocked)
 // @Param search query string false "Search in URL or title"
+// @Param rules query string false "Filter by the outcome of the custom rules on the latest crawl: failing has at least one failed rule, passing has none" Enums(passing, failing)
+// @Param rule_id query int false "Apply the rules filter to this rule only; requires rules"
 // @Param sort_by query string false "Sort field" default(created_at)
 // @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Success 200 {object} models.PaginatedResponse "List of URLs"
@@ -130,7 +139,7 @@ func (h *URLHandler) ListURLs(c *gin.Context) {
 
 // GetURL handles GET /api/urls/:id
 // @Summary Get detailed information about a URL
-// @Description Get detailed information about a specific URL including crawl results and broken links
+// @Description Get detailed information about a specific URL including crawl results with SEO metadata, structured data and the redirect chain, broken links and findings
 // @Tags URLs
 // @Accept json
 // @Produce json
@@ -165,20 +174,27 @@ func (h *URLHandler) GetURL(c *gin.Context) {
 		return
 	}
 
-	// Get broken links if crawl result exists
+	// Get broken links and findings if crawl result exists
 	var brokenLinks []models.BrokenLink
+	var findings []models.Finding
 	if crawlResult != nil {
-		brokenLinks, err = h.repo.GetBrokenLinksByURLID(id)
+		brokenLinks, err = h.repo.GetBrokenLinksByCrawlResultID(crawlResult.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch broken links", "details": err.Error()})
 			return
 		}
+		findings, err = h.repo.GetFindingsByCrawlResultID(crawlResult.ID, models.FindingFilter{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch findings", "details": err.Error()})
+			return
+		}
 	}
 
 	response := gin.H{
 		"url":          url,
 		"crawl_result": crawlResult,
 		"broken_links": brokenLinks,
+		"findings":     findings,
 	}
 
 	// Add job status if currently crawling
@@ -191,13 +207,14 @@ func (h *URLHandler) GetURL(c *gin.Context) {
 
 // StartCrawl handles PUT /api/urls/:id/start
 // @Summary Start crawling a URL
-// @Description Start the crawling process for a specific URL
+// @Description Start the crawling process for a specific URL. Crawl option overrides in the body are used by this crawl and, once it is queued, saved on the URL for later crawls.
 // @Tags Crawl Control
 // @Accept json
 // @Produce json
 // @Param id path int true "URL ID"
+// @Param request body models.StartCrawlRequest false "Crawl option overrides"
 // @Success 200 {object} map[string]interface{} "Crawl started successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid URL ID"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or request format"
 // @Failure 404 {object} map[string]interface{} "URL not found"
 // @Failure 409 {object} map[string]interface{} "Crawl already in progress"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -210,6 +227,14 @@ func (h *URLHandler) StartCrawl(c *gin.Context) {
 		return
 	}
 
+	var req models.StartCrawlRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+	}
+
 	// Check if URL exists
 	_, err = h.repo.GetURLByID(id)
 	if err != nil {
@@ -221,10 +246,14 @@ func (h *URLHandler) StartCrawl(c *gin.Context) {
 		return
 	}
 
-	// Start crawling
-	err = h.crawlerService.StartCrawl(id)
+	// Start crawling. Overrides are kept for later crawls of the URL only when this one starts.
+	if req.Options != nil {
+		err = h.crawlerService.StartCrawlWithOptions(id, req.Options)
+	} else {
+		err = h.crawlerService.StartCrawl(id)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "already in progress") {
+		if errors.Is(err, services.ErrCrawlInProgress) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Crawl already in progress for this URL"})
 			return
 		}
@@ -235,6 +264,646 @@ func (h *URLHandler) StartCrawl(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Crawl started successfully"})
 }
 
+// StartSiteCrawl handles PUT /api/urls/:id/crawl-site
+// @Summary Start crawling a whole site
+// @Description Crawl the site behind a URL breadth-first, following internal links up to a depth and page budget
+// @Tags Crawl Control
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param request body models.SiteCrawlRequest false "Depth and page budget (defaults apply when omitted)"
+// @Success 200 {object} map[string]interface{} "Site crawl started successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or request format"
+// @Failure 404 {object} map[string]interface{} "URL not found"
+// @Failure 409 {object} map[string]interface{} "Crawl already in progress"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/crawl-site [put]
+func (h *URLHandler) StartSiteCrawl(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var req models.SiteCrawlRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+	}
+
+	_, err = h.repo.GetURLByID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch URL", "details": err.Error()})
+		return
+	}
+
+	err = h.crawlerService.StartSiteCrawl(id, req)
+	if err != nil {
+		if errors.Is(err, services.ErrCrawlInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Crawl already in progress for this URL"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start site crawl", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Site crawl started successfully"})
+}
+
+// GetSitePages handles GET /api/urls/:id/pages
+// @Summary Get the pages of the latest site crawl
+// @Description Get the pages visited by the latest crawl of a URL as a tree, each page nested under the page it was discovered on
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Success 200 {object} map[string]interface{} "Page tree of the latest crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID"
+// @Failure 404 {object} map[string]interface{} "No crawl result found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/pages [get]
+func (h *URLHandler) GetSitePages(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	crawlResult, err := h.repo.GetCrawlResultByURLID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No crawl result found for this URL"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crawl result", "details": err.Error()})
+		return
+	}
+
+	pages, err := h.repo.GetSitePages(crawlResult.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch site pages", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"root":  models.BuildCrawlResultTree(pages),
+		"count": len(pages),
+	})
+}
+
+// GetURLHistory handles GET /api/urls/:id/history
+// @Summary Get the crawl history of a URL
+// @Description Get the past crawl results of a URL, newest first, each with the broken links it found
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResponse "Past crawl results"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "URL not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/history [get]
+func (h *URLHandler) GetURLHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.HistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	// Set defaults
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 100 {
+		filter.PageSize = 10
+	}
+
+	_, err = h.repo.GetURLByID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch URL", "details": err.Error()})
+		return
+	}
+
+	results, total, err := h.repo.ListCrawlResultsByURLID(id, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crawl history", "details": err.Error()})
+		return
+	}
+
+	resultIDs := make([]int, len(results))
+	for i, result := range results {
+		resultIDs[i] = result.ID
+	}
+
+	// One query for the broken links of the whole page
+	brokenLinks, err := h.repo.GetBrokenLinksByCrawlResultIDs(resultIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch broken links", "details": err.Error()})
+		return
+	}
+
+	history := make([]models.CrawlHistoryEntry, 0, len(results))
+	for _, result := range results {
+		links := brokenLinks[result.ID]
+		if links == nil {
+			links = []models.BrokenLink{}
+		}
+		history = append(history, models.CrawlHistoryEntry{CrawlResult: result, BrokenLinks: links})
+	}
+
+	totalPages := (total + filter.PageSize - 1) / filter.PageSize
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:       history,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
+// GetURLDiff handles GET /api/urls/:id/diff
+// @Summary Compare two crawls of a URL
+// @Description Report what changed between two crawl results of a URL: title, heading and link counts, newly broken links, links found working again and broken links no longer on the page. Without from and to the latest crawl is compared with the one before it.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param from query int false "ID of the older crawl result"
+// @Param to query int false "ID of the newer crawl result"
+// @Success 200 {object} models.CrawlDiff "Changes between the two crawls"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/diff [get]
+func (h *URLHandler) GetURLDiff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.DiffFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	if (filter.From == 0) != (filter.To == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be given together"})
+		return
+	}
+
+	// Compare the two latest runs unless told otherwise
+	if filter.From == 0 {
+		latest, _, err := h.repo.ListCrawlResultsByURLID(id, models.HistoryFilter{Page: 1, PageSize: 2})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crawl history", "details": err.Error()})
+			return
+		}
+		if len(latest) < 2 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "At least two crawls are needed for a diff"})
+			return
+		}
+		filter.To = latest[0].ID
+		filter.From = latest[1].ID
+	}
+
+	from, ok := h.crawlResultForURL(c, id, filter.From)
+	if !ok {
+		return
+	}
+	to, ok := h.crawlResultForURL(c, id, filter.To)
+	if !ok {
+		return
+	}
+
+	fromLinks, err := h.repo.GetBrokenLinksByCrawlResultID(from.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch broken links", "details": err.Error()})
+		return
+	}
+	toLinks, err := h.repo.GetBrokenLinksByCrawlResultID(to.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch broken links", "details": err.Error()})
+		return
+	}
+	// How the newer crawl found the links that were broken before
+	recheckedLinks, err := h.repo.GetLinksByURLs(to.ID, models.BrokenLinkURLs(fromLinks))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch links", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DiffCrawlResults(from, to, fromLinks, toLinks, recheckedLinks))
+}
+
+// GetURLLinks handles GET /api/urls/:id/links
+// @Summary Get the links found by a crawl of a URL
+// @Description Get every link found on the crawled page with its anchor text, rel attribute, check outcome and the redirects followed when it was checked. Without result_id the latest crawl is used, for site crawls that is the root page.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param result_id query int false "ID of the crawl result, e.g. a page of a site crawl"
+// @Param type query string false "Only internal or external links" Enums(internal, external)
+// @Param status query string false "Filter by check status" Enums(ok, broken, blocked, unchecked, unverified)
+// @Param category query string false "Filter by check category" Enums(ok, broken, forbidden, rate_limited, timeout, dns_failure, tls_error, connection_error, ignored)
+// @Param redirected query bool false "Only links that redirected, or only those that did not"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(50)
+// @Success 200 {object} models.PaginatedResponse "Links of the crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/links [get]
+func (h *URLHandler) GetURLLinks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.LinkFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	// Set defaults
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 500 {
+		filter.PageSize = 50
+	}
+
+	crawlResult, ok := h.crawlResultOrLatest(c, id, filter.ResultID)
+	if !ok {
+		return
+	}
+
+	links, total, err := h.repo.ListLinksByCrawlResultID(crawlResult.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch links", "details": err.Error()})
+		return
+	}
+
+	totalPages := (total + filter.PageSize - 1) / filter.PageSize
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:       links,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
+// GetURLResources handles GET /api/urls/:id/resources
+// @Summary Get the resources referenced by a crawl of a URL
+// @Description Get the scripts, stylesheets, images, fonts and iframes of the crawled page with their check outcome and size, and a summary with counts per type, total bytes, third-party hosts and broken resources. Without result_id the latest crawl is used.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param result_id query int false "ID of the crawl result, e.g. a page of a site crawl"
+// @Param type query string false "Filter by resource type" Enums(script, stylesheet, image, font, iframe)
+// @Param status query string false "Filter by check status" Enums(ok, broken, blocked, unchecked, unverified)
+// @Param category query string false "Filter by check category" Enums(ok, broken, forbidden, rate_limited, timeout, dns_failure, tls_error, connection_error, ignored)
+// @Success 200 {object} map[string]interface{} "Resources of the crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/resources [get]
+func (h *URLHandler) GetURLResources(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.ResourceFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	crawlResult, ok := h.crawlResultOrLatest(c, id, filter.ResultID)
+	if !ok {
+		return
+	}
+
+	resources, err := h.repo.GetResourcesByCrawlResultID(crawlResult.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch resources", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": crawlResult.ID,
+		"summary":         crawlResult.Resources,
+		"resources":       resources,
+		"count":           len(resources),
+	})
+}
+
+// GetURLForms handles GET /api/urls/:id/forms
+// @Summary Get the forms found by a crawl of a URL
+// @Description Get the forms found on the crawled page, each classified as login, signup, password_reset, search or other, with where it submits to and the signals behind its type. Without result_id the latest crawl is used.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param result_id query int false "ID of the crawl result, e.g. a page of a site crawl"
+// @Success 200 {object} map[string]interface{} "Forms of the crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/forms [get]
+func (h *URLHandler) GetURLForms(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.CrawlResultFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	crawlResult, ok := h.crawlResultOrLatest(c, id, filter.ResultID)
+	if !ok {
+		return
+	}
+
+	forms, err := h.repo.GetFormsByCrawlResultID(crawlResult.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch forms", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": crawlResult.ID,
+		"forms":           forms,
+		"count":           len(forms),
+	})
+}
+
+// GetURLSecurity handles GET /api/urls/:id/security
+// @Summary Get the security report of a crawl of a URL
+// @Description Get the graded security posture of the crawled page: HSTS, CSP, X-Frame-Options, X-Content-Type-Options, Referrer-Policy and Permissions-Policy headers, cookie flags, and the TLS version and certificate of the connection. The score is out of 100, with warnings earning half a check's weight. Without result_id the latest crawl is used.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param result_id query int false "ID of the crawl result, e.g. a page of a site crawl"
+// @Success 200 {object} models.SecurityReport "Security report of the crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result or security report not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/security [get]
+func (h *URLHandler) GetURLSecurity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.CrawlResultFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	crawlResult, ok := h.crawlResultOrLatest(c, id, filter.ResultID)
+	if !ok {
+		return
+	}
+
+	report, err := h.repo.GetSecurityReportByCrawlResultID(crawlResult.ID)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No security report for this crawl result", "crawl_result_id": crawlResult.ID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security report", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListExpiringCertificates handles GET /api/certificates/expiring
+// @Summary List URLs with expiring TLS certificates
+// @Description List the URLs whose latest crawl found a TLS certificate that expires within the given number of days, or has already expired, soonest first.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param days query int false "Days until expiry" default(30) minimum(0) maximum(365)
+// @Success 200 {object} map[string]interface{} "Expiring certificates"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /certificates/expiring [get]
+func (h *URLHandler) ListExpiringCertificates(c *gin.Context) {
+	var filter models.ExpiringCertificateFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	certificates, err := h.repo.ListExpiringCertificates(now.AddDate(0, 0, filter.Days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expiring certificates", "details": err.Error()})
+		return
+	}
+
+	for i := range certificates {
+		if left := certificates[i].CertExpiresAt.Sub(now); left > 0 {
+			certificates[i].DaysLeft = int(left.Hours() / 24)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":         filter.Days,
+		"certificates": certificates,
+		"count":        len(certificates),
+	})
+}
+
+// GetURLFindings handles GET /api/urls/:id/findings
+// @Summary Get the findings of a crawl of a URL
+// @Description Get the findings the analyzers reported about the crawled page, most severe first: SEO, accessibility, structured data, broken resource, security, mixed content and redirect findings, and those of registered custom analyzers. Accessibility and mixed content findings carry the CSS path of the element they are about. Without result_id the latest crawl is used.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param result_id query int false "ID of the crawl result, e.g. a page of a site crawl"
+// @Param category query string false "Filter by category, the name of a registered analyzer such as seo, accessibility, structured_data, resources, security, mixed_content or redirects"
+// @Param severity query string false "Filter by severity" Enums(info, warning, error)
+// @Success 200 {object} map[string]interface{} "Findings of the crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/findings [get]
+func (h *URLHandler) GetURLFindings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.FindingFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+	
+	// Findings are filed under the name of the analyzer that reported them
+	if filter.Category != "" && !h.isAnalyzer(filter.Category) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": fmt.Sprintf("unknown finding category %q", filter.Category)})
+		return
+	}
+
+	crawlResult, ok := h.crawlResultOrLatest(c, id, filter.ResultID)
+	if !ok {
+		return
+	}
+
+	findings, err := h.repo.GetFindingsByCrawlResultID(crawlResult.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch findings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": crawlResult.ID,
+		"findings":        findings,
+		"count":           len(findings),
+	})
+}
+
+// GetURLRuleResults handles GET /api/urls/:id/rules
+// @Summary Get the outcome of the custom rules on a crawl of a URL
+// @Description Get whether the crawled page passed each custom rule evaluated against it, failures first. Results keep the name of their rule after it is deleted. Without result_id the latest crawl is used.
+// @Tags URLs
+// @Accept json
+// @Produce json
+// @Param id path int true "URL ID"
+// @Param result_id query int false "ID of the crawl result, e.g. a page of a site crawl"
+// @Param passed query bool false "Only passed or only failed rules"
+// @Success 200 {object} map[string]interface{} "Rule results of the crawl"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Crawl result not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/rules [get]
+func (h *URLHandler) GetURLRuleResults(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	var filter models.RuleResultFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	crawlResult, ok := h.crawlResultOrLatest(c, id, filter.ResultID)
+	if !ok {
+		return
+	}
+
+	results, err := h.repo.GetRuleResultsByCrawlResultID(crawlResult.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rule results", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": crawlResult.ID,
+		"rule_results":    results,
+		"passed":          crawlResult.RulesPassed,
+		"failed":          crawlResult.RulesFailed,
+		"count":           len(results),
+	})
+}
+
+// reports whether an analyzer with the given name is registered
+func (h *URLHandler) isAnalyzer(name string) bool {
+	for _, analyzer := range h.crawlerService.GetAnalyzers() {
+		if analyzer.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fetches the given crawl result of the URL or, when resultID is 0, its latest one, writing the error response otherwise
+func (h *URLHandler) crawlResultOrLatest(c *gin.Context, urlID int, resultID int) (*models.CrawlResult, bool) {
+	if resultID != 0 {
+		return h.crawlResultForURL(c, urlID, resultID)
+	}
+
+	crawlResult, err := h.repo.GetCrawlResultByURLID(urlID)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No crawl result found for this URL"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crawl result", "details": err.Error()})
+		return nil, false
+	}
+	return crawlResult, true
+}
+
+// fetches a crawl result and makes sure it belongs to the URL, writing the error response otherwise
+func (h *URLHandler) crawlResultForURL(c *gin.Context, urlID int, resultID int) (*models.CrawlResult, bool) {
+	result, err := h.repo.GetCrawlResultByID(resultID)
+	if err != nil && !database.IsNotFoundError(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch crawl result", "details": err.Error()})
+		return nil, false
+	}
+	if err != nil || result.URLID != urlID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Crawl result not found for this URL", "crawl_result_id": resultID})
+		return nil, false
+	}
+	return result, true
+}
+
 // StopCrawl handles PUT /api/urls/:id/stop
 // @Summary Stop crawling a URL
 // @Description Stop the crawling process for a specific URL
@@ -258,11 +927,11 @@ func (h *URLHandler) StopCrawl(c *gin.Context) {
 
 	err = h.crawlerService.StopCrawl(id)
 	if err != nil {
-		if strings.Contains(err.Error(), "no active job") {
+		if errors.Is(err, services.ErrNoActiveJob) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "No active crawl job found for this URL"})
 			return
 		}
-		if strings.Contains(err.Error(), "already finished") {
+		if errors.Is(err, services.ErrJobFinished) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Crawl job already finished"})
 			return
 		}
@@ -302,6 +971,47 @@ func (h *URLHandler) GetCrawlStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"job_status": jobStatus})
 }
 
+// StreamURLEvents handles GET /api/urls/:id/events
+// @Summary Stream the progress of a URL's crawls
+// @Description Server-sent "progress" events for every status, message or progress change of the URL's crawl jobs on this server. The current state is sent first. Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed, or the current state again when the server restarted meanwhile. EventSource clients may pass the API key as the api_key query parameter.
+// @Tags Crawl Control
+// @Produce text/event-stream
+// @Param id path int true "URL ID"
+// @Param last_event_id query string false "Resume after this event ID"
+// @Success 200 {object} models.JobEvent "Stream of progress events"
+// @Failure 400 {object} map[string]interface{} "Invalid URL ID"
+// @Failure 404 {object} map[string]interface{} "URL not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/{id}/events [get]
+func (h *URLHandler) StreamURLEvents(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	url, err := h.repo.GetURLByID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch URL", "details": err.Error()})
+		return
+	}
+
+	// Without a job on this server the URL's stored status is the current state
+	fallback := &models.JobEvent{
+		URLID:     url.ID,
+		URL:       url.URL,
+		Status:    models.CrawlStatus(url.Status),
+		Timestamp: time.Now(),
+	}
+
+	streamJobEvents(c, h.crawlerService, id, lastEventID(c), fallback)
+}
+
 // DeleteURL handles DELETE /api/urls/:id
 // @Summary Delete a URL
 // @Description Delete a specific URL and all its associated data
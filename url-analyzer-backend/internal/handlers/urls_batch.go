@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+	"url-analyzer/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// how many URLs a batch operation takes at once
+	maxBatchURLs = 10000
+	// the largest batch of URLs to add that is read
+	maxBatchBodySize = 10 << 20
+)
+
+var errUnsupportedBatchFormat = errors.New("unsupported content type, use application/json, text/csv or text/plain")
+
+// CreateURLsBatch handles POST /api/urls/batch
+// @Summary Add many URLs at once
+// @Description Add up to 10000 URLs given as a JSON array, a JSON object with urls and options, CSV (the first column, after an optional "url" header) or newline-delimited text (blank lines and lines starting with # are skipped). Every entry is reported as created, duplicate or invalid, in the order given. The URLs are added in a single transaction, and URLs added before are reported as duplicates, so sending the same batch again changes nothing. With start_crawl a crawl of every added URL is queued.
+// @Tags URLs
+// @Accept json,plain,text/csv
+// @Produce json
+// @Param request body models.CreateURLsBatchRequest true "URLs to add"
+// @Param start_crawl query bool false "Queue a crawl of every added URL"
+// @Success 200 {object} models.CreateURLsBatchResult "Nothing new to add"
+// @Success 201 {object} models.CreateURLsBatchResult "URLs added"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 413 {object} map[string]interface{} "Request body too large"
+// @Failure 415 {object} map[string]interface{} "Unsupported content type"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/batch [post]
+func (h *URLHandler) CreateURLsBatch(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodySize)
+	req, err := readURLBatch(body, c.ContentType())
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large", "details": fmt.Sprintf("at most %d bytes", maxBatchBodySize)})
+		case errors.Is(err, errUnsupportedBatchFormat):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported content type", "details": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		}
+		return
+	}
+
+	if value := c.Query("start_crawl"); value != "" {
+		req.StartCrawl, err = strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_crawl parameter"})
+			return
+		}
+	}
+
+	if len(req.URLs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No URLs given"})
+		return
+	}
+	if len(req.URLs) > maxBatchURLs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d URLs can be added at once", maxBatchURLs)})
+		return
+	}
+
+	summary := &models.CreateURLsBatchResult{Total: len(req.URLs), Results: make([]models.BatchURLResult, len(req.URLs))}
+
+	// Entries to add, and the entries that repeat one of them
+	pending := []string{}
+	pendingEntries := []int{}
+	duplicateOf := map[int]int{}
+	seen := map[string]int{}
+	for i, input := range req.URLs {
+		entry := &summary.Results[i]
+		entry.Input = input
+
+		target := strings.TrimSpace(input)
+		if !importableURL(target) {
+			entry.Status = models.BatchURLInvalid
+			entry.Error = fmt.Sprintf("not an absolute http or https URL of at most %d characters", maxURLLength)
+			continue
+		}
+		// URLs are added as given, equivalent ones are told apart by their key like in the database
+		key, err := database.URLKey(target)
+		if err == nil && len(key) > maxURLLength {
+			err = fmt.Errorf("longer than %d characters once normalized", maxURLLength)
+		}
+		if err != nil {
+			entry.Status = models.BatchURLInvalid
+			entry.Error = err.Error()
+			continue
+		}
+
+		entry.URL = target
+		if first, exists := seen[key]; exists {
+			entry.Status = models.BatchURLDuplicate
+			entry.Error = fmt.Sprintf("same URL as entry %d", first+1)
+			duplicateOf[i] = first
+			continue
+		}
+		seen[key] = i
+		pending = append(pending, target)
+		pendingEntries = append(pendingEntries, i)
+	}
+
+	// Nothing is added unless everything is
+	if len(pending) > 0 {
+		created, err := h.repo.CreateURLs(c.GetInt("user_id"), pending, req.Options)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add URLs", "details": err.Error()})
+			return
+		}
+		for n, result := range created {
+			entry := &summary.Results[pendingEntries[n]]
+			entry.Status = result.Status
+			entry.URLID = result.URLID
+		}
+	}
+	for i, first := range duplicateOf {
+		summary.Results[i].URLID = summary.Results[first].URLID
+	}
+
+	for i := range summary.Results {
+		entry := &summary.Results[i]
+		switch entry.Status {
+		case models.BatchURLCreated:
+			summary.Created++
+			if !req.StartCrawl {
+				continue
+			}
+			if err := h.crawlerService.StartCrawl(entry.URLID); err != nil {
+				entry.Error = fmt.Sprintf("failed to start crawl: %v", err)
+				continue
+			}
+			summary.Queued++
+		case models.BatchURLDuplicate:
+			summary.Duplicates++
+		case models.BatchURLInvalid:
+			summary.Invalid++
+		}
+	}
+
+	status := http.StatusOK
+	if summary.Created > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, summary)
+}
+
+// reads a batch of URLs to add in the format of its content type
+func readURLBatch(body io.Reader, contentType string) (*models.CreateURLsBatchRequest, error) {
+	req := &models.CreateURLsBatchRequest{}
+
+	switch contentType {
+	case "application/json", "":
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 && data[0] == '[' {
+			err = json.Unmarshal(data, &req.URLs)
+		} else {
+			err = json.Unmarshal(data, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The crawl options are checked like those of a single URL
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			return nil, err
+		}
+
+	case "text/csv":
+		reader := csv.NewReader(body)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		for row := 0; ; row++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			value := strings.TrimSpace(record[0])
+			if value == "" || (row == 0 && strings.EqualFold(value, "url")) {
+				continue
+			}
+			req.URLs = append(req.URLs, value)
+		}
+
+	case "text/plain":
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			req.URLs = append(req.URLs, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, errUnsupportedBatchFormat
+	}
+
+	return req, nil
+}
+
+// StartURLsBatch handles PUT /api/urls/batch/start
+// @Summary Start crawls of many URLs
+// @Description Start a crawl of every URL given by its ID or matched by a filter, at most 10000 of them, and report the outcome URL by URL
+// @Tags Crawl Control
+// @Accept json
+// @Produce json
+// @Param request body models.BatchCrawlRequest true "IDs or filter of the URLs"
+// @Success 200 {object} models.BatchCrawlResult "Outcome per URL: started, already_running, not_found or failed"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/batch/start [put]
+func (h *URLHandler) StartURLsBatch(c *gin.Context) {
+	h.runBatchCrawl(c, models.BatchCrawlStarted, func(id int) models.BatchCrawlItem {
+		if item, exists := h.batchURLExists(id); !exists {
+			return item
+		}
+
+		err := h.crawlerService.StartCrawl(id)
+		if err != nil {
+			if errors.Is(err, services.ErrCrawlInProgress) {
+				return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlAlreadyRunning}
+			}
+			return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlFailed, Error: err.Error()}
+		}
+		return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlStarted}
+	})
+}
+
+// StopURLsBatch handles PUT /api/urls/batch/stop
+// @Summary Stop crawls of many URLs
+// @Description Stop the crawl of every URL given by its ID or matched by a filter, at most 10000 of them, and report the outcome URL by URL
+// @Tags Crawl Control
+// @Accept json
+// @Produce json
+// @Param request body models.BatchCrawlRequest true "IDs or filter of the URLs"
+// @Success 200 {object} models.BatchCrawlResult "Outcome per URL: stopped, not_running, not_found or failed"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/batch/stop [put]
+func (h *URLHandler) StopURLsBatch(c *gin.Context) {
+	h.runBatchCrawl(c, models.BatchCrawlStopped, func(id int) models.BatchCrawlItem {
+		if item, exists := h.batchURLExists(id); !exists {
+			return item
+		}
+
+		err := h.crawlerService.StopCrawl(id)
+		if err != nil {
+			if errors.Is(err, services.ErrNoActiveJob) || errors.Is(err, services.ErrJobFinished) {
+				return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlNotRunning}
+			}
+			return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlFailed, Error: err.Error()}
+		}
+		return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlStopped}
+	})
+}
+
+// RestartURLsBatch handles PUT /api/urls/batch/restart
+// @Summary Restart crawls of many URLs
+// @Description Stop any running crawl and start a new one for every URL given by its ID or matched by a filter, at most 10000 of them, and report the outcome URL by URL
+// @Tags Crawl Control
+// @Accept json
+// @Produce json
+// @Param request body models.BatchCrawlRequest true "IDs or filter of the URLs"
+// @Success 200 {object} models.BatchCrawlResult "Outcome per URL: restarted, not_found or failed"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /urls/batch/restart [put]
+func (h *URLHandler) RestartURLsBatch(c *gin.Context) {
+	h.runBatchCrawl(c, models.BatchCrawlRestarted, func(id int) models.BatchCrawlItem {
+		if item, exists := h.batchURLExists(id); !exists {
+			return item
+		}
+
+		h.crawlerService.StopCrawl(id) // Ignore error
+
+		err := h.repo.UpdateURLStatus(id, models.StatusQueued, nil)
+		if err != nil {
+			return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlFailed, Error: err.Error()}
+		}
+		err = h.crawlerService.StartCrawl(id)
+		if err != nil {
+			return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlFailed, Error: err.Error()}
+		}
+		return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlRestarted}
+	})
+}
+
+// applies a crawl operation to the URLs a batch request selects. Outcomes other than
+// succeeded are reported per URL and do not fail the request.
+func (h *URLHandler) runBatchCrawl(c *gin.Context, succeeded models.BatchCrawlStatus, apply func(id int) models.BatchCrawlItem) {
+	ids, ok := h.batchCrawlIDs(c)
+	if !ok {
+		return
+	}
+
+	result := &models.BatchCrawlResult{Total: len(ids), Results: make([]models.BatchCrawlItem, 0, len(ids))}
+	for _, id := range ids {
+		item := apply(id)
+		if item.Status == succeeded {
+			result.Succeeded++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// returns the IDs of the URLs a batch crawl request selects, without duplicates. Writes the
+// error response and returns false when the request is invalid.
+func (h *URLHandler) batchCrawlIDs(c *gin.Context) ([]int, bool) {
+	var req models.BatchCrawlRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return nil, false
+	}
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give either ids or a filter"})
+		return nil, false
+	}
+
+	if req.Filter != nil {
+		ids, err := h.repo.ListURLIDs(*req.Filter, maxBatchURLs+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list URLs", "details": err.Error()})
+			return nil, false
+		}
+		if len(ids) > maxBatchURLs {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The filter matches more than %d URLs", maxBatchURLs)})
+			return nil, false
+		}
+		return ids, true
+	}
+
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, id := range req.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > maxBatchURLs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d URLs can be given at once", maxBatchURLs)})
+		return nil, false
+	}
+	return ids, true
+}
+
+// looks up a URL of a batch crawl operation, returning the item to report when it cannot be used
+func (h *URLHandler) batchURLExists(id int) (models.BatchCrawlItem, bool) {
+	_, err := h.repo.GetURLByID(id)
+	if err == nil {
+		return models.BatchCrawlItem{}, true
+	}
+	if database.IsNotFoundError(err) {
+		return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlNotFound}, false
+	}
+	return models.BatchCrawlItem{URLID: id, Status: models.BatchCrawlFailed, Error: err.Error()}, false
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+	"url-analyzer/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func sendBatch(router *gin.Engine, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateURLsBatch_JSON(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	timeout := 45
+	options := &models.CrawlOptionsOverride{TimeoutSeconds: &timeout}
+	mockRepo.On("CreateURLs", 1, []string{"https://example.com/", "https://Example.com:443/about/"}, options).Return([]models.BatchURLResult{
+		{Input: "https://example.com/", URL: "https://example.com/", Status: models.BatchURLDuplicate, URLID: 3},
+		{Input: "https://Example.com:443/about/", URL: "https://Example.com:443/about/", Status: models.BatchURLCreated, URLID: 9},
+	}, nil)
+	mockCrawler.On("StartCrawl", 9).Return(nil)
+
+	body, _ := json.Marshal(models.CreateURLsBatchRequest{
+		URLs:       []string{" https://example.com/ ", "https://Example.com:443/about/", "not a url", "HTTPS://EXAMPLE.COM/#top"},
+		StartCrawl: true,
+		Options:    options,
+	})
+	w := sendBatch(router, "POST", "/api/urls/batch", "application/json", string(body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var result models.CreateURLsBatchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 2, result.Duplicates)
+	assert.Equal(t, 1, result.Invalid)
+	assert.Equal(t, 1, result.Queued)
+
+	require.Len(t, result.Results, 4)
+	assert.Equal(t, models.BatchURLResult{Input: " https://example.com/ ", URL: "https://example.com/", Status: models.BatchURLDuplicate, URLID: 3}, result.Results[0])
+	assert.Equal(t, models.BatchURLResult{Input: "https://Example.com:443/about/", URL: "https://Example.com:443/about/", Status: models.BatchURLCreated, URLID: 9}, result.Results[1], "URLs are added as given")
+	assert.Equal(t, models.BatchURLInvalid, result.Results[2].Status)
+	assert.Contains(t, result.Results[2].Error, "not an absolute http or https URL")
+	assert.Equal(t, models.BatchURLDuplicate, result.Results[3].Status)
+	assert.Equal(t, 3, result.Results[3].URLID, "an entry repeated in the batch refers to the URL of its first entry")
+	assert.Equal(t, "same URL as entry 1", result.Results[3].Error)
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestCreateURLsBatch_Formats(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json array", "application/json", `["https://a.test/", "https://b.test/"]`},
+		{"csv", "text/csv", "url,title\nhttps://a.test/,Home\n\n\"https://b.test/\",Blog\n"},
+		{"csv without header", "text/csv; charset=utf-8", "https://a.test/\nhttps://b.test/"},
+		{"plain text", "text/plain", "# exported list\nhttps://a.test/\n\n  https://b.test/  \n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			router := setupTestRouter(mockRepo, new(MockCrawlerService))
+
+			mockRepo.On("CreateURLs", 1, []string{"https://a.test/", "https://b.test/"}, (*models.CrawlOptionsOverride)(nil)).Return([]models.BatchURLResult{
+				{Status: models.BatchURLCreated, URLID: 1},
+				{Status: models.BatchURLCreated, URLID: 2},
+			}, nil)
+
+			w := sendBatch(router, "POST", "/api/urls/batch", tc.contentType, tc.body)
+			assert.Equal(t, http.StatusCreated, w.Code)
+
+			var result models.CreateURLsBatchResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.Equal(t, 2, result.Created)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateURLsBatch_StartCrawlQuery(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("CreateURLs", 1, []string{"https://a.test/"}, (*models.CrawlOptionsOverride)(nil)).Return([]models.BatchURLResult{
+		{Status: models.BatchURLCreated, URLID: 5},
+	}, nil)
+	mockCrawler.On("StartCrawl", 5).Return(errors.New("queue is full"))
+
+	w := sendBatch(router, "POST", "/api/urls/batch?start_crawl=true", "text/plain", "https://a.test/\n")
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var result models.CreateURLsBatchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 0, result.Queued)
+	assert.Equal(t, "failed to start crawl: queue is full", result.Results[0].Error)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestCreateURLsBatch_NothingToAdd(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(mockRepo, new(MockCrawlerService))
+
+	w := sendBatch(router, "POST", "/api/urls/batch", "text/plain", "ftp://files.test/\nmailto:me@example.com\n")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.CreateURLsBatchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 2, result.Invalid)
+	mockRepo.AssertNotCalled(t, "CreateURLs", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateURLsBatch_InvalidRequests(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(mockRepo, new(MockCrawlerService))
+
+	tooMany := strings.Repeat("https://a.test/\n", maxBatchURLs+1)
+	tooLarge := strings.Repeat("#\n", maxBatchBodySize/2+1)
+
+	testCases := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+		error       string
+	}{
+		{"empty", "/api/urls/batch", "application/json", `[]`, http.StatusBadRequest, "No URLs given"},
+		{"broken json", "/api/urls/batch", "application/json", `["https://a.test/"`, http.StatusBadRequest, "Invalid request format"},
+		{"invalid options", "/api/urls/batch", "application/json", `{"urls": ["https://a.test/"], "options": {"timeout_seconds": 500}}`, http.StatusBadRequest, "Invalid request format"},
+		{"broken csv", "/api/urls/batch", "text/csv", "\"https://a.test/\n", http.StatusBadRequest, "Invalid request format"},
+		{"unsupported type", "/api/urls/batch", "application/xml", `<urls/>`, http.StatusUnsupportedMediaType, "Unsupported content type"},
+		{"invalid start_crawl", "/api/urls/batch?start_crawl=maybe", "text/plain", "https://a.test/", http.StatusBadRequest, "Invalid start_crawl parameter"},
+		{"too many", "/api/urls/batch", "text/plain", tooMany, http.StatusBadRequest, fmt.Sprintf("At most %d URLs can be added at once", maxBatchURLs)},
+		{"too large", "/api/urls/batch", "text/plain", tooLarge, http.StatusRequestEntityTooLarge, "Request body too large"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := sendBatch(router, "POST", tc.path, tc.contentType, tc.body)
+			assert.Equal(t, tc.status, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tc.error, response["error"])
+		})
+	}
+
+	mockRepo.AssertNotCalled(t, "CreateURLs", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateURLsBatch_DatabaseError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(mockRepo, new(MockCrawlerService))
+
+	mockRepo.On("CreateURLs", 1, []string{"https://a.test/"}, (*models.CrawlOptionsOverride)(nil)).Return(nil, errors.New("deadlock found"))
+
+	w := sendBatch(router, "POST", "/api/urls/batch", "text/plain", "https://a.test/")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "deadlock found")
+}
+
+func TestStartURLsBatch_IDs(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("GetURLByID", 1).Return(&models.URL{ID: 1}, nil)
+	mockRepo.On("GetURLByID", 2).Return(&models.URL{ID: 2}, nil)
+	mockRepo.On("GetURLByID", 3).Return(nil, errors.New("URL not found"))
+	mockRepo.On("GetURLByID", 4).Return(&models.URL{ID: 4}, nil)
+	mockCrawler.On("StartCrawl", 1).Return(nil)
+	mockCrawler.On("StartCrawl", 2).Return(fmt.Errorf("%w for URL ID 2", services.ErrCrawlInProgress))
+	mockCrawler.On("StartCrawl", 4).Return(errors.New("worker pool closed"))
+
+	w := sendBatch(router, "PUT", "/api/urls/batch/start", "application/json", `{"ids": [1, 2, 3, 1, 4]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.BatchCrawlResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, []models.BatchCrawlItem{
+		{URLID: 1, Status: models.BatchCrawlStarted},
+		{URLID: 2, Status: models.BatchCrawlAlreadyRunning},
+		{URLID: 3, Status: models.BatchCrawlNotFound},
+		{URLID: 4, Status: models.BatchCrawlFailed, Error: "worker pool closed"},
+	}, result.Results)
+
+	mockCrawler.AssertNumberOfCalls(t, "StartCrawl", 3)
+}
+
+func TestStopURLsBatch_Filter(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	running := models.StatusRunning
+	mockRepo.On("ListURLIDs", models.URLFilter{Status: &running, Search: "shop"}, maxBatchURLs+1).Return([]int{5, 6, 7}, nil)
+	mockRepo.On("GetURLByID", 5).Return(&models.URL{ID: 5}, nil)
+	mockRepo.On("GetURLByID", 6).Return(&models.URL{ID: 6}, nil)
+	mockRepo.On("GetURLByID", 7).Return(nil, errors.New("URL not found"))
+	mockCrawler.On("StopCrawl", 5).Return(nil)
+	mockCrawler.On("StopCrawl", 6).Return(services.ErrJobFinished)
+
+	w := sendBatch(router, "PUT", "/api/urls/batch/stop", "application/json", `{"filter": {"status": "running", "search": "shop"}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.BatchCrawlResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, []models.BatchCrawlItem{
+		{URLID: 5, Status: models.BatchCrawlStopped},
+		{URLID: 6, Status: models.BatchCrawlNotRunning},
+		{URLID: 7, Status: models.BatchCrawlNotFound},
+	}, result.Results)
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertNotCalled(t, "StopCrawl", 7)
+}
+
+func TestRestartURLsBatch(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("ListURLIDs", models.URLFilter{Rules: "failing"}, maxBatchURLs+1).Return([]int{7, 8}, nil)
+	mockRepo.On("GetURLByID", 7).Return(&models.URL{ID: 7}, nil)
+	mockRepo.On("GetURLByID", 8).Return(&models.URL{ID: 8}, nil)
+	mockCrawler.On("StopCrawl", mock.Anything).Return(fmt.Errorf("%w for URL ID 7", services.ErrNoActiveJob))
+	mockRepo.On("UpdateURLStatus", 7, models.StatusQueued, (*string)(nil)).Return(nil)
+	mockRepo.On("UpdateURLStatus", 8, models.StatusQueued, (*string)(nil)).Return(errors.New("connection reset"))
+	mockCrawler.On("StartCrawl", 7).Return(nil)
+
+	w := sendBatch(router, "PUT", "/api/urls/batch/restart", "application/json", `{"filter": {"rules": "failing"}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.BatchCrawlResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, []models.BatchCrawlItem{
+		{URLID: 7, Status: models.BatchCrawlRestarted},
+		{URLID: 8, Status: models.BatchCrawlFailed, Error: "connection reset"},
+	}, result.Results)
+	mockCrawler.AssertNotCalled(t, "StartCrawl", 8)
+}
+
+func TestBatchCrawl_InvalidRequests(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(mockRepo, new(MockCrawlerService))
+
+	mockRepo.On("ListURLIDs", models.URLFilter{}, maxBatchURLs+1).Return(make([]int, maxBatchURLs+1), nil)
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+		error  string
+	}{
+		{"nothing selected", `{}`, http.StatusBadRequest, "Give either ids or a filter"},
+		{"ids and filter", `{"ids": [1], "filter": {}}`, http.StatusBadRequest, "Give either ids or a filter"},
+		{"invalid id", `{"ids": [0]}`, http.StatusBadRequest, "Invalid request format"},
+		{"invalid filter", `{"filter": {"rules": "sometimes"}}`, http.StatusBadRequest, "Invalid request format"},
+		{"rule ID without rules", `{"filter": {"rule_id": 5}}`, http.StatusBadRequest, "Invalid request format"},
+		{"filter matches too many", `{"filter": {}}`, http.StatusBadRequest, fmt.Sprintf("The filter matches more than %d URLs", maxBatchURLs)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := sendBatch(router, "PUT", "/api/urls/batch/start", "application/json", tc.body)
+			assert.Equal(t, tc.status, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tc.error, response["error"])
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+	"url-analyzer/internal/database"
 	"url-analyzer/internal/models"
 	"url-analyzer/internal/services"
 
@@ -21,8 +27,8 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) CreateURL(url string) (*models.URL, error) {
-	args := m.Called(url)
+func (m *MockRepository) CreateURL(userID int, url string, crawlOptions *models.CrawlOptionsOverride) (*models.URL, error) {
+	args := m.Called(userID, url, crawlOptions)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -55,6 +61,11 @@ func (m *MockRepository) UpdateURLStatus(id int, status models.URLStatus, errorM
 	return args.Error(0)
 }
 
+func (m *MockRepository) UpdateURLCrawlOptions(id int, crawlOptions *models.CrawlOptionsOverride) error {
+	args := m.Called(id, crawlOptions)
+	return args.Error(0)
+}
+
 func (m *MockRepository) DeleteURL(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -78,11 +89,144 @@ func (m *MockRepository) GetCrawlResultByURLID(urlID int) (*models.CrawlResult,
 	return args.Get(0).(*models.CrawlResult), args.Error(1)
 }
 
+func (m *MockRepository) GetCrawlResultByID(id int) (*models.CrawlResult, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlResult), args.Error(1)
+}
+
+func (m *MockRepository) ListCrawlResultsByURLID(urlID int, filter models.HistoryFilter) ([]models.CrawlResult, int, error) {
+	args := m.Called(urlID, filter)
+	return args.Get(0).([]models.CrawlResult), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) GetBrokenLinksByCrawlResultID(crawlResultID int) ([]models.BrokenLink, error) {
+	args := m.Called(crawlResultID)
+	return args.Get(0).([]models.BrokenLink), args.Error(1)
+}
+
+func (m *MockRepository) GetBrokenLinksByCrawlResultIDs(crawlResultIDs []int) (map[int][]models.BrokenLink, error) {
+	args := m.Called(crawlResultIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int][]models.BrokenLink), args.Error(1)
+}
+
+func (m *MockRepository) GetSitePages(rootResultID int) ([]models.CrawlResult, error) {
+	args := m.Called(rootResultID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CrawlResult), args.Error(1)
+}
+
 func (m *MockRepository) CreateBrokenLinks(crawlResultID int, brokenLinks []models.BrokenLink) error {
 	args := m.Called(crawlResultID, brokenLinks)
 	return args.Error(0)
 }
 
+func (m *MockRepository) CreateLinks(crawlResultID int, links []models.Link) error {
+	args := m.Called(crawlResultID, links)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLinksByCrawlResultID(crawlResultID int, filter models.LinkFilter) ([]models.Link, int, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.Link), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) GetLinksByURLs(crawlResultID int, urls []string) ([]models.Link, error) {
+	args := m.Called(crawlResultID, urls)
+	return args.Get(0).([]models.Link), args.Error(1)
+}
+
+func (m *MockRepository) CreateResources(crawlResultID int, resources []models.PageResource) error {
+	args := m.Called(crawlResultID, resources)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetResourcesByCrawlResultID(crawlResultID int, filter models.ResourceFilter) ([]models.PageResource, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.PageResource), args.Error(1)
+}
+
+func (m *MockRepository) CreateSecurityReport(report *models.SecurityReport) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetSecurityReportByCrawlResultID(crawlResultID int) (*models.SecurityReport, error) {
+	args := m.Called(crawlResultID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityReport), args.Error(1)
+}
+
+func (m *MockRepository) ListExpiringCertificates(before time.Time) ([]models.ExpiringCertificate, error) {
+	args := m.Called(before)
+	return args.Get(0).([]models.ExpiringCertificate), args.Error(1)
+}
+
+func (m *MockRepository) ListRulesForURL(urlID int) ([]models.Rule, error) {
+	args := m.Called(urlID)
+	return args.Get(0).([]models.Rule), args.Error(1)
+}
+
+func (m *MockRepository) CreateRuleResults(crawlResultID int, results []models.RuleResult) error {
+	args := m.Called(crawlResultID, results)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRuleResultsByCrawlResultID(crawlResultID int, filter models.RuleResultFilter) ([]models.RuleResult, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.RuleResult), args.Error(1)
+}
+
+func (m *MockRepository) CreateURLs(userID int, urls []string, crawlOptions *models.CrawlOptionsOverride) ([]models.BatchURLResult, error) {
+	args := m.Called(userID, urls, crawlOptions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BatchURLResult), args.Error(1)
+}
+
+func (m *MockRepository) ListURLIDs(filter models.URLFilter, limit int) ([]int, error) {
+	args := m.Called(filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockRepository) CreateForms(crawlResultID int, forms []models.Form) error {
+	args := m.Called(crawlResultID, forms)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFormsByCrawlResultID(crawlResultID int) ([]models.Form, error) {
+	args := m.Called(crawlResultID)
+	return args.Get(0).([]models.Form), args.Error(1)
+}
+
+func (m *MockRepository) CreateFindings(crawlResultID int, findings []models.Finding) error {
+	args := m.Called(crawlResultID, findings)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFindingsByCrawlResultID(crawlResultID int, filter models.FindingFilter) ([]models.Finding, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.Finding), args.Error(1)
+}
+
+func (m *MockRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetBrokenLinksByURLID(urlID int) ([]models.BrokenLink, error) {
 	args := m.Called(urlID)
 	if args.Get(0) == nil {
@@ -91,6 +235,54 @@ func (m *MockRepository) GetBrokenLinksByURLID(urlID int) ([]models.BrokenLink,
 	return args.Get(0).([]models.BrokenLink), args.Error(1)
 }
 
+func (m *MockRepository) EnqueueCrawlJob(urlID int, options models.CrawlJobOptions) (*models.CrawlJobRecord, error) {
+	args := m.Called(urlID, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlJobRecord), args.Error(1)
+}
+
+func (m *MockRepository) ClaimNextCrawlJob(workerID string, lease time.Duration) (*models.CrawlJobRecord, error) {
+	args := m.Called(workerID, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlJobRecord), args.Error(1)
+}
+
+func (m *MockRepository) HeartbeatCrawlJob(id int, workerID string, lease time.Duration) error {
+	args := m.Called(id, workerID, lease)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FinishCrawlJob(id int, workerID string, outcome models.CrawlJobOutcome, save func(database.CrawlResultWriter) error) error {
+	args := m.Called(id, workerID, outcome)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	// The results are written through the mock's own Create methods
+	if save != nil {
+		return save(m)
+	}
+	return nil
+}
+
+func (m *MockRepository) CancelCrawlJobs(urlID int) (int, error) {
+	args := m.Called(urlID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ReleaseCrawlJob(id int, workerID string) error {
+	args := m.Called(id, workerID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecoverCrawlJobs(maxAttempts int) (int, error) {
+	args := m.Called(maxAttempts)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockRepository) GetUserByAPIKey(apiKey string) (*models.User, error) {
 	args := m.Called(apiKey)
 	if args.Get(0) == nil {
@@ -117,6 +309,16 @@ func (m *MockCrawlerService) StartCrawl(urlID int) error {
 	return args.Error(0)
 }
 
+func (m *MockCrawlerService) StartCrawlWithOptions(urlID int, overrides *models.CrawlOptionsOverride) error {
+	args := m.Called(urlID, overrides)
+	return args.Error(0)
+}
+
+func (m *MockCrawlerService) StartSiteCrawl(urlID int, req models.SiteCrawlRequest) error {
+	args := m.Called(urlID, req)
+	return args.Error(0)
+}
+
 func (m *MockCrawlerService) StopCrawl(urlID int) error {
 	args := m.Called(urlID)
 	return args.Error(0)
@@ -140,10 +342,20 @@ func (m *MockCrawlerService) GetCrawlerStats() map[string]interface{} {
 	return args.Get(0).(map[string]interface{})
 }
 
+func (m *MockCrawlerService) GetAnalyzers() []models.AnalyzerInfo {
+	args := m.Called()
+	return args.Get(0).([]models.AnalyzerInfo)
+}
+
 func (m *MockCrawlerService) CleanupCompletedJobs() {
 	m.Called()
 }
 
+func (m *MockCrawlerService) SubscribeJobEvents(urlID int, lastEventID string) (*services.JobEventSubscription, []models.JobEvent, bool) {
+	args := m.Called(urlID, lastEventID)
+	return args.Get(0).(*services.JobEventSubscription), args.Get(1).([]models.JobEvent), args.Bool(2)
+}
+
 func setupTestRouter(repo *MockRepository, crawlerService services.CrawlerServiceInterface) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -151,6 +363,11 @@ func setupTestRouter(repo *MockRepository, crawlerService services.CrawlerServic
 	handler := NewURLHandler(repo, crawlerService)
 	
 	// Add routes without auth middleware for testing
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	})
+	
 	api := router.Group("/api")
 	{
 		api.POST("/urls", handler.CreateURL)
@@ -158,10 +375,26 @@ func setupTestRouter(repo *MockRepository, crawlerService services.CrawlerServic
 		api.GET("/urls/:id", handler.GetURL)
 		api.DELETE("/urls/:id", handler.DeleteURL)
 		api.DELETE("/urls", handler.DeleteURLs)
+		api.POST("/urls/batch", handler.CreateURLsBatch)
+		api.PUT("/urls/batch/start", handler.StartURLsBatch)
+		api.PUT("/urls/batch/stop", handler.StopURLsBatch)
+		api.PUT("/urls/batch/restart", handler.RestartURLsBatch)
 		api.PUT("/urls/:id/start", handler.StartCrawl)
 		api.PUT("/urls/:id/stop", handler.StopCrawl)
 		api.PUT("/urls/:id/restart", handler.RestartCrawl)
 		api.GET("/urls/:id/status", handler.GetCrawlStatus)
+		api.GET("/urls/:id/events", handler.StreamURLEvents)
+		api.PUT("/urls/:id/crawl-site", handler.StartSiteCrawl)
+		api.GET("/urls/:id/pages", handler.GetSitePages)
+		api.GET("/urls/:id/history", handler.GetURLHistory)
+		api.GET("/urls/:id/diff", handler.GetURLDiff)
+		api.GET("/urls/:id/links", handler.GetURLLinks)
+		api.GET("/urls/:id/forms", handler.GetURLForms)
+		api.GET("/urls/:id/resources", handler.GetURLResources)
+		api.GET("/urls/:id/findings", handler.GetURLFindings)
+		api.GET("/urls/:id/rules", handler.GetURLRuleResults)
+		api.GET("/urls/:id/security", handler.GetURLSecurity)
+		api.GET("/certificates/expiring", handler.ListExpiringCertificates)
 	}
 	
 	return router
@@ -180,7 +413,7 @@ func TestCreateURL_Success(t *testing.T) {
 
 	// Mock expectations
 	mockRepo.On("GetURLByURL", "https://example.com").Return((*models.URL)(nil), sql.ErrNoRows)
-	mockRepo.On("CreateURL", "https://example.com").Return(testURL, nil)
+	mockRepo.On("CreateURL", 1, "https://example.com", (*models.CrawlOptionsOverride)(nil)).Return(testURL, nil)
 
 	// Create request
 	reqBody := models.CreateURLRequest{URL: "https://example.com"}
@@ -205,6 +438,45 @@ func TestCreateURL_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateURL_WithCrawlOptions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	timeout := 60
+	testURL := &models.URL{
+		ID:           1,
+		URL:          "https://example.com",
+		Status:       models.StatusQueued,
+		CrawlOptions: &models.CrawlOptionsOverride{TimeoutSeconds: &timeout},
+	}
+
+	mockRepo.On("GetURLByURL", "https://example.com").Return((*models.URL)(nil), sql.ErrNoRows)
+	mockRepo.On("CreateURL", 1, "https://example.com", mock.MatchedBy(func(options *models.CrawlOptionsOverride) bool {
+		return options != nil && *options.TimeoutSeconds == 60 && options.UserAgent == nil
+	})).Return(testURL, nil)
+
+	jsonBody := []byte(`{"url": "https://example.com", "options": {"timeout_seconds": 60}}`)
+	req, _ := http.NewRequest("POST", "/api/urls", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockRepo.AssertExpectations(t)
+
+	// Overrides outside the allowed ranges are rejected
+	jsonBody = []byte(`{"url": "https://example.com", "options": {"concurrent_checks": 500}}`)
+	req, _ = http.NewRequest("POST", "/api/urls", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestCreateURL_AlreadyExists(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCrawler := new(MockCrawlerService)
@@ -302,6 +574,36 @@ func TestListURLs_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestListURLs_FailingRules(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("ListURLs", mock.MatchedBy(func(filter models.URLFilter) bool {
+		return filter.Rules == "failing" && filter.RuleID == 3
+	})).Return([]models.URLWithResult{}, 0, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls?rules=failing&rule_id=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/urls?rules=broken", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A rule ID alone would not filter at all
+	req, _ = http.NewRequest("GET", "/api/urls?rule_id=3", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetURL_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCrawler := new(MockCrawlerService)
@@ -336,6 +638,60 @@ func TestGetURL_Success(t *testing.T) {
 	mockCrawler.AssertExpectations(t)
 }
 
+func TestGetURL_WithSEOAndFindings(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	crawlResult := &models.CrawlResult{
+		ID:    5,
+		URLID: 1,
+		SEO:   &models.SEOMetadata{Canonical: "https://example.com/", Lang: "en", OpenGraph: map[string]string{"og:title": "Example"}},
+		StructuredData: &models.StructuredData{
+			Types:  []string{"Offer", "Product"},
+			JSONLD: []models.JSONLDBlock{{Valid: true, Types: []string{"Offer", "Product"}}},
+		},
+	}
+	findings := []models.Finding{
+		{ID: 1, CrawlResultID: 5, Category: models.FindingCategorySEO, Code: "missing_description", Severity: models.SeverityWarning, Message: "Page has no meta description"},
+	}
+
+	mockRepo.On("GetURLByID", 1).Return(&models.URL{ID: 1, URL: "https://example.com", Status: models.StatusCompleted}, nil)
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(crawlResult, nil)
+	// Only the broken links of the latest result are shown, not those of earlier crawls
+	mockRepo.On("GetBrokenLinksByCrawlResultID", 5).Return([]models.BrokenLink{
+		{ID: 7, CrawlResultID: 5, URL: "https://example.com/missing", StatusCode: 404},
+	}, nil)
+	mockRepo.On("GetFindingsByCrawlResultID", 5, models.FindingFilter{}).Return(findings, nil)
+	mockCrawler.On("GetJobStatus", 1).Return((*models.CrawlJob)(nil), assert.AnError)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CrawlResult models.CrawlResult  `json:"crawl_result"`
+		BrokenLinks []models.BrokenLink `json:"broken_links"`
+		Findings    []models.Finding    `json:"findings"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	require.NotNil(t, response.CrawlResult.SEO)
+	assert.Equal(t, "en", response.CrawlResult.SEO.Lang)
+	assert.Equal(t, "Example", response.CrawlResult.SEO.OpenGraph["og:title"])
+	require.NotNil(t, response.CrawlResult.StructuredData)
+	assert.Equal(t, []string{"Offer", "Product"}, response.CrawlResult.StructuredData.Types)
+	require.Len(t, response.BrokenLinks, 1)
+	assert.Equal(t, "https://example.com/missing", response.BrokenLinks[0].URL)
+	require.Len(t, response.Findings, 1)
+	assert.Equal(t, "missing_description", response.Findings[0].Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestStartCrawl_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCrawler := new(MockCrawlerService)
@@ -368,6 +724,39 @@ func TestStartCrawl_Success(t *testing.T) {
 	mockCrawler.AssertExpectations(t)
 }
 
+func TestStartCrawl_WithCrawlOptions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	timeout := 60
+	testURL := &models.URL{
+		ID:           1,
+		URL:          "https://example.com",
+		Status:       models.StatusQueued,
+		CrawlOptions: &models.CrawlOptionsOverride{TimeoutSeconds: &timeout},
+	}
+
+	// The crawler service merges the overrides into the ones already saved on the URL
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	mockCrawler.On("StartCrawlWithOptions", 1, mock.MatchedBy(func(options *models.CrawlOptionsOverride) bool {
+		return options != nil && options.TimeoutSeconds == nil && *options.UserAgent == "CustomBot/2.0"
+	})).Return(nil)
+
+	jsonBody := []byte(`{"options": {"user_agent": "CustomBot/2.0"}}`)
+	req, _ := http.NewRequest("PUT", "/api/urls/1/start", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateURLCrawlOptions", mock.Anything, mock.Anything)
+}
+
 func TestDeleteURL_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockCrawler := new(MockCrawlerService)
@@ -392,4 +781,752 @@ func TestDeleteURL_Success(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 	mockCrawler.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestStartSiteCrawl_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	testURL := &models.URL{
+		ID:     1,
+		URL:    "https://example.com",
+		Status: models.StatusQueued,
+	}
+
+	// Mock expectations
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	mockCrawler.On("StartSiteCrawl", 1, models.SiteCrawlRequest{MaxDepth: 3, MaxPages: 20}).Return(nil)
+
+	jsonBody, _ := json.Marshal(models.SiteCrawlRequest{MaxDepth: 3, MaxPages: 20})
+	req, _ := http.NewRequest("PUT", "/api/urls/1/crawl-site", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestGetSitePages_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	rootID := 10
+	pages := []models.CrawlResult{
+		{ID: 10, URLID: 1},
+		{ID: 11, URLID: 1, Depth: 1, ParentID: &rootID, RootID: &rootID},
+		{ID: 12, URLID: 1, Depth: 1, ParentID: &rootID, RootID: &rootID},
+	}
+
+	// Mock expectations
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&pages[0], nil)
+	mockRepo.On("GetSitePages", 10).Return(pages, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/pages", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Root  models.CrawlResultNode `json:"root"`
+		Count int                    `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, response.Count)
+	assert.Equal(t, 10, response.Root.ID)
+	assert.Len(t, response.Root.Children, 2)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLHistory_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	results := []models.CrawlResult{
+		{ID: 12, URLID: 1, BrokenLinksCount: 1},
+		{ID: 11, URLID: 1},
+	}
+
+	mockRepo.On("GetURLByID", 1).Return(&models.URL{ID: 1, URL: "https://example.com"}, nil)
+	mockRepo.On("ListCrawlResultsByURLID", 1, models.HistoryFilter{Page: 2, PageSize: 2}).Return(results, 5, nil)
+	mockRepo.On("GetBrokenLinksByCrawlResultIDs", []int{12, 11}).Return(map[int][]models.BrokenLink{
+		12: {{CrawlResultID: 12, URL: "https://example.com/gone", StatusCode: 404}},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/history?page=2&page_size=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data       []models.CrawlHistoryEntry `json:"data"`
+		Total      int                        `json:"total"`
+		TotalPages int                        `json:"total_pages"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, 12, response.Data[0].ID)
+	assert.Len(t, response.Data[0].BrokenLinks, 1)
+	assert.NotNil(t, response.Data[1].BrokenLinks)
+	assert.Empty(t, response.Data[1].BrokenLinks)
+	assert.Equal(t, 5, response.Total)
+	assert.Equal(t, 3, response.TotalPages)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLDiff_LatestRuns(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	oldTitle, newTitle := "Old", "New"
+	older := &models.CrawlResult{ID: 11, URLID: 1, Title: &oldTitle, H1Count: 1, InternalLinks: 4}
+	newer := &models.CrawlResult{ID: 12, URLID: 1, Title: &newTitle, H1Count: 1, InternalLinks: 6}
+
+	mockRepo.On("ListCrawlResultsByURLID", 1, models.HistoryFilter{Page: 1, PageSize: 2}).Return([]models.CrawlResult{*newer, *older}, 2, nil)
+	mockRepo.On("GetCrawlResultByID", 11).Return(older, nil)
+	mockRepo.On("GetCrawlResultByID", 12).Return(newer, nil)
+	// A site crawl can list the same broken link more than once
+	mockRepo.On("GetBrokenLinksByCrawlResultID", 11).Return([]models.BrokenLink{
+		{URL: "https://example.com/fixed", StatusCode: 500},
+		{URL: "https://example.com/fixed", StatusCode: 500},
+		{URL: "https://example.com/still-broken", StatusCode: 404},
+		{URL: "https://example.com/removed", StatusCode: 404},
+		{URL: "https://example.com/unchecked", StatusCode: 404},
+	}, nil)
+	mockRepo.On("GetBrokenLinksByCrawlResultID", 12).Return([]models.BrokenLink{
+		{URL: "https://example.com/still-broken", StatusCode: 404},
+		{URL: "https://example.com/new", StatusCode: 404},
+		{URL: "https://example.com/new", StatusCode: 404},
+	}, nil)
+	// Only a link checked and found working is fixed, one past the check limit is not
+	mockRepo.On("GetLinksByURLs", 12, []string{
+		"https://example.com/fixed", "https://example.com/still-broken", "https://example.com/removed", "https://example.com/unchecked",
+	}).Return([]models.Link{
+		{URL: "https://example.com/fixed", Status: models.LinkStatusOK},
+		{URL: "https://example.com/still-broken", Status: models.LinkStatusBroken},
+		{URL: "https://example.com/unchecked", Status: models.LinkStatusUnchecked},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/diff", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var diff models.CrawlDiff
+	err := json.Unmarshal(w.Body.Bytes(), &diff)
+	require.NoError(t, err)
+
+	assert.Equal(t, 11, diff.FromResultID)
+	assert.Equal(t, 12, diff.ToResultID)
+	assert.Equal(t, models.ValueChange{From: "Old", To: "New"}, diff.Changes["title"])
+	assert.Contains(t, diff.Changes, "internal_links")
+	assert.NotContains(t, diff.Changes, "h1_count")
+	require.Len(t, diff.NewlyBroken, 1)
+	assert.Equal(t, "https://example.com/new", diff.NewlyBroken[0].URL)
+	require.Len(t, diff.NewlyFixed, 1)
+	assert.Equal(t, "https://example.com/fixed", diff.NewlyFixed[0].URL)
+	require.Len(t, diff.NoLongerLinked, 1)
+	assert.Equal(t, "https://example.com/removed", diff.NoLongerLinked[0].URL)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLDiff_ResultOfAnotherURL(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("GetCrawlResultByID", 11).Return(&models.CrawlResult{ID: 11, URLID: 2}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/diff?from=11&to=12", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// from and to go together
+	req, _ = http.NewRequest("GET", "/api/urls/1/diff?from=11", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLLinks_FiltersLatestCrawl(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	statusCode := 404
+	broken := models.LinkStatusBroken
+	links := []models.Link{
+		{ID: 3, CrawlResultID: 12, URL: "https://example.com/gone", AnchorText: "Gone", IsInternal: true, Status: models.LinkStatusBroken, StatusCode: &statusCode},
+	}
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 12, URLID: 1}, nil)
+	mockRepo.On("ListLinksByCrawlResultID", 12, models.LinkFilter{Type: "internal", Status: &broken, Page: 1, PageSize: 50}).Return(links, 1, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/links?type=internal&status=broken", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data  []models.Link `json:"data"`
+		Total int           `json:"total"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, response.Total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "https://example.com/gone", response.Data[0].URL)
+	assert.Equal(t, models.LinkStatusBroken, response.Data[0].Status)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLLinks_Redirected(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	statusCode := 200
+	redirected := true
+	links := []models.Link{
+		{ID: 4, CrawlResultID: 12, URL: "http://example.com/old", Status: models.LinkStatusOK, StatusCode: &statusCode, Redirects: models.RedirectChain{
+			{URL: "http://example.com/old", StatusCode: 302, Location: "https://example.com/new"},
+		}},
+	}
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 12, URLID: 1}, nil)
+	mockRepo.On("ListLinksByCrawlResultID", 12, models.LinkFilter{Redirected: &redirected, Page: 1, PageSize: 50}).Return(links, 1, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/links?redirected=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.Link `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, links[0].Redirects, response.Data[0].Redirects)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLLinks_Category(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	statusCode := 429
+	status := models.LinkStatusUnverified
+	category := models.LinkCheckRateLimited
+	links := []models.Link{
+		{ID: 5, CrawlResultID: 12, URL: "https://example.com/busy", Status: models.LinkStatusUnverified, Category: &category, StatusCode: &statusCode},
+	}
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 12, URLID: 1}, nil)
+	mockRepo.On("ListLinksByCrawlResultID", 12, models.LinkFilter{Status: &status, Category: &category, Page: 1, PageSize: 50}).Return(links, 1, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/links?status=unverified&category=rate_limited", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"category":"rate_limited"`)
+
+	req, _ = http.NewRequest("GET", "/api/urls/1/links?category=slow", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLLinks_InvalidFilterAndForeignResult(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/links?type=sideways", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/urls/1/links?status=fine", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Results of other URLs are not found
+	mockRepo.On("GetCrawlResultByID", 7).Return(&models.CrawlResult{ID: 7, URLID: 2}, nil)
+
+	req, _ = http.NewRequest("GET", "/api/urls/1/links?result_id=7", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "ListLinksByCrawlResultID", mock.Anything, mock.Anything)
+}
+
+func TestGetURLForms_ForResult(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	forms := []models.Form{
+		{ID: 1, CrawlResultID: 7, Type: models.FormTypeLogin, Score: 6, Action: "http://example.com/login", Method: "POST", PostsOverHTTP: true, PasswordFields: 1, Signals: models.StringList{"single password field"}},
+	}
+
+	mockRepo.On("GetCrawlResultByID", 7).Return(&models.CrawlResult{ID: 7, URLID: 1}, nil)
+	mockRepo.On("GetFormsByCrawlResultID", 7).Return(forms, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/forms?result_id=7", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CrawlResultID int           `json:"crawl_result_id"`
+		Forms         []models.Form `json:"forms"`
+		Count         int           `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, response.CrawlResultID)
+	assert.Equal(t, 1, response.Count)
+	require.Len(t, response.Forms, 1)
+	assert.Equal(t, models.FormTypeLogin, response.Forms[0].Type)
+	assert.True(t, response.Forms[0].PostsOverHTTP)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLForms_NoCrawlYet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(nil, errors.New("crawl result not found"))
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/forms", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLResources_BrokenImages(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	summary := &models.ResourceSummary{
+		Counts:          map[models.ResourceType]int{models.ResourceTypeImage: 3, models.ResourceTypeScript: 1},
+		Total:           4,
+		TotalBytes:      20480,
+		ThirdParty:      1,
+		ThirdPartyHosts: []string{"cdn.example.com"},
+		Broken:          1,
+	}
+	statusCode := 404
+	resources := []models.PageResource{
+		{ID: 2, CrawlResultID: 5, URL: "https://example.com/missing.png", Type: models.ResourceTypeImage, Status: models.LinkStatusBroken, StatusCode: &statusCode},
+	}
+	resourceType := models.ResourceTypeImage
+	status := models.LinkStatusBroken
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 5, URLID: 1, Resources: summary}, nil)
+	mockRepo.On("GetResourcesByCrawlResultID", 5, models.ResourceFilter{Type: &resourceType, Status: &status}).Return(resources, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/resources?type=image&status=broken", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CrawlResultID int                    `json:"crawl_result_id"`
+		Summary       models.ResourceSummary `json:"summary"`
+		Resources     []models.PageResource  `json:"resources"`
+		Count         int                    `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, response.CrawlResultID)
+	assert.Equal(t, *summary, response.Summary)
+	assert.Equal(t, 1, response.Count)
+	require.Len(t, response.Resources, 1)
+	assert.Equal(t, "https://example.com/missing.png", response.Resources[0].URL)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLResources_InvalidType(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/resources?type=video", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetCrawlResultByURLID", mock.Anything)
+}
+
+// the analyzers the mock crawler service reports
+var testAnalyzers = []models.AnalyzerInfo{
+	{Name: "seo", Enabled: true},
+	{Name: "accessibility", Enabled: true},
+	{Name: "security", Enabled: false},
+}
+
+func TestGetURLFindings_FilteredByCategory(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	findings := []models.Finding{
+		{ID: 3, CrawlResultID: 5, Category: models.FindingCategoryAccessibility, Code: "image_missing_alt", Severity: models.SeverityError, Message: `Image "logo.png" has no alt text`, Selector: "main#content > img"},
+	}
+	severity := models.SeverityError
+
+	mockCrawler.On("GetAnalyzers").Return(testAnalyzers)
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 5, URLID: 1}, nil)
+	mockRepo.On("GetFindingsByCrawlResultID", 5, models.FindingFilter{Category: "accessibility", Severity: &severity}).Return(findings, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/findings?category=accessibility&severity=error", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CrawlResultID int              `json:"crawl_result_id"`
+		Findings      []models.Finding `json:"findings"`
+		Count         int              `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, response.CrawlResultID)
+	assert.Equal(t, 1, response.Count)
+	require.Len(t, response.Findings, 1)
+	assert.Equal(t, "main#content > img", response.Findings[0].Selector)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLFindings_InvalidCategory(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockCrawler.On("GetAnalyzers").Return(testAnalyzers)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/findings?category=performance", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetCrawlResultByURLID", mock.Anything)
+}
+
+func TestGetURLFindings_CustomAnalyzerCategory(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	findings := []models.Finding{
+		{ID: 8, CrawlResultID: 5, Category: "performance", Code: "large_page", Severity: models.SeverityWarning, Message: "Page weighs 4 MB"},
+	}
+
+	mockCrawler.On("GetAnalyzers").Return(append(testAnalyzers, models.AnalyzerInfo{Name: "performance", Enabled: true}))
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 5, URLID: 1}, nil)
+	mockRepo.On("GetFindingsByCrawlResultID", 5, models.FindingFilter{Category: "performance"}).Return(findings, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/findings?category=performance", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"large_page"`)
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestGetURLRuleResults_Failed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	ruleID := 3
+	passed := false
+	results := []models.RuleResult{
+		{ID: 9, CrawlResultID: 5, RuleID: &ruleID, RuleName: "One h1", Passed: false, Severity: models.SeverityError, Message: "h1 matches 2 elements, expected 1"},
+	}
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 5, URLID: 1, RulesPassed: 2, RulesFailed: 1}, nil)
+	mockRepo.On("GetRuleResultsByCrawlResultID", 5, models.RuleResultFilter{Passed: &passed}).Return(results, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/rules?passed=false", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CrawlResultID int                 `json:"crawl_result_id"`
+		RuleResults   []models.RuleResult `json:"rule_results"`
+		Passed        int                 `json:"passed"`
+		Failed        int                 `json:"failed"`
+		Count         int                 `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, response.CrawlResultID)
+	assert.Equal(t, 2, response.Passed)
+	assert.Equal(t, 1, response.Failed)
+	require.Len(t, response.RuleResults, 1)
+	assert.Equal(t, "h1 matches 2 elements, expected 1", response.RuleResults[0].Message)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLSecurity_ForCrawlResult(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	version := "TLS 1.3"
+	expiresAt := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	report := &models.SecurityReport{
+		ID:            4,
+		CrawlResultID: 7,
+		Score:         85,
+		Grade:         "B",
+		Checks: models.SecurityChecks{
+			{Name: "hsts", Status: models.SecurityCheckPass, Weight: 15, Value: "max-age=31536000", Message: "HSTS is enabled"},
+			{Name: "csp", Status: models.SecurityCheckFail, Weight: 15, Message: "Content-Security-Policy header is missing"},
+		},
+		Cookies:       models.CookieReports{{Name: "session", Secure: true, HttpOnly: true, SameSite: "Lax"}},
+		TLSVersion:    &version,
+		CertExpiresAt: &expiresAt,
+	}
+
+	mockRepo.On("GetCrawlResultByID", 7).Return(&models.CrawlResult{ID: 7, URLID: 1}, nil)
+	mockRepo.On("GetSecurityReportByCrawlResultID", 7).Return(report, nil)
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/security?result_id=7", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.SecurityReport
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, *report, response)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetURLSecurity_NoReport(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	mockRepo.On("GetCrawlResultByURLID", 1).Return(&models.CrawlResult{ID: 5, URLID: 1}, nil)
+	mockRepo.On("GetSecurityReportByCrawlResultID", 5).Return(nil, errors.New("security report not found"))
+
+	req, _ := http.NewRequest("GET", "/api/urls/1/security", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListExpiringCertificates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	issuer := "R3"
+	certificates := []models.ExpiringCertificate{
+		{URLID: 2, URL: "https://old.example.com", CrawlResultID: 9, CertIssuer: &issuer, CertExpiresAt: time.Now().Add(-24 * time.Hour)},
+		{URLID: 1, URL: "https://example.com", CrawlResultID: 5, CertIssuer: &issuer, CertExpiresAt: time.Now().Add(10*24*time.Hour + time.Hour)},
+	}
+
+	// Only URLs expiring within the requested window are asked for
+	mockRepo.On("ListExpiringCertificates", mock.MatchedBy(func(before time.Time) bool {
+		return before.After(time.Now().AddDate(0, 0, 14).Add(-time.Minute)) && before.Before(time.Now().AddDate(0, 0, 14))
+	})).Return(certificates, nil)
+
+	req, _ := http.NewRequest("GET", "/api/certificates/expiring?days=14", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Days         int                          `json:"days"`
+		Certificates []models.ExpiringCertificate `json:"certificates"`
+		Count        int                          `json:"count"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 14, response.Days)
+	assert.Equal(t, 2, response.Count)
+	require.Len(t, response.Certificates, 2)
+	assert.Equal(t, 0, response.Certificates[0].DaysLeft, "expired certificates have no days left")
+	assert.Equal(t, 10, response.Certificates[1].DaysLeft)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListExpiringCertificates_InvalidDays(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	req, _ := http.NewRequest("GET", "/api/certificates/expiring?days=400", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "ListExpiringCertificates", mock.Anything)
+}
+
+// reads the server-sent events of a stream one at a time, returning the ID and data of the next one
+func readEvents(t *testing.T, body io.Reader) func() (string, string) {
+	reader := bufio.NewReader(body)
+	return func() (string, string) {
+		id, data := "", ""
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "id:"):
+				id = strings.TrimPrefix(line, "id:")
+			case strings.HasPrefix(line, "data:"):
+				data = strings.TrimPrefix(line, "data:")
+			case line == "" && data != "":
+				return id, data
+			}
+		}
+	}
+}
+
+func TestStreamURLEvents_ResumesAndPushesLiveEvents(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	broker := services.NewJobEventBroker()
+	first := broker.Publish(models.JobEvent{URLID: 1, Status: models.CrawlStatusQueued})
+	second := broker.Publish(models.JobEvent{URLID: 1, Status: models.CrawlStatusStarted})
+	sub, replay, resumed := broker.Subscribe(1, first.ID)
+	require.True(t, resumed)
+
+	mockRepo.On("GetURLByID", 1).Return(&models.URL{ID: 1, URL: "https://example.com", Status: models.StatusRunning}, nil)
+	mockCrawler.On("SubscribeJobEvents", 1, first.ID).Return(sub, replay, true)
+
+	// Streaming needs a real connection
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/urls/1/events", nil)
+	req.Header.Set("Last-Event-ID", first.ID)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	// The missed event is replayed without a snapshot, then live events follow
+	nextEvent := readEvents(t, resp.Body)
+	id, _ := nextEvent()
+	assert.Equal(t, second.ID, id)
+	broker.Publish(models.JobEvent{URLID: 2, Status: models.CrawlStatusStarted})
+	live := broker.Publish(models.JobEvent{URLID: 1, Status: models.CrawlStatusCompleted})
+	id, _ = nextEvent()
+	assert.Equal(t, live.ID, id)
+
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestStreamURLEvents_SnapshotAfterRestart(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockCrawler := new(MockCrawlerService)
+	router := setupTestRouter(mockRepo, mockCrawler)
+
+	// The client's last event is from before a restart and no job of the URL runs here
+	broker := services.NewJobEventBroker()
+	sub, _, resumed := broker.Subscribe(1, "oldboot-7")
+	require.False(t, resumed)
+
+	mockRepo.On("GetURLByID", 1).Return(&models.URL{ID: 1, URL: "https://example.com", Status: models.StatusCompleted}, nil)
+	mockCrawler.On("SubscribeJobEvents", 1, "oldboot-7").Return(sub, []models.JobEvent{}, false)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/urls/1/events?last_event_id=oldboot-7")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The stored status is sent as the current state, without an ID to resume from
+	id, data := readEvents(t, resp.Body)()
+	assert.Empty(t, id)
+	var event models.JobEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, models.CrawlStatusCompleted, event.Status)
+	assert.Equal(t, "https://example.com", event.URL)
+
+	mockCrawler.AssertExpectations(t)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handles webhook HTTP requests. Webhooks belong to the user whose API key registered them.
+type WebhookHandler struct {
+	repo     database.RepositoryInterface
+	webhooks database.WebhookRepositoryInterface
+}
+
+// creates a new webhook handler
+func NewWebhookHandler(repo database.RepositoryInterface, webhooks database.WebhookRepositoryInterface) *WebhookHandler {
+	return &WebhookHandler{
+		repo:     repo,
+		webhooks: webhooks,
+	}
+}
+
+// CreateWebhook handles POST /api/webhooks
+// @Summary Register a webhook
+// @Description Have crawl.completed, crawl.failed and crawl.blocked events POSTed to a URL, optionally only for one analyzed URL. Each request carries X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers; the signature is "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret. The secret is only returned in this response.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWebhookRequest true "Webhook to register"
+// @Success 201 {object} map[string]interface{} "Webhook created with its secret"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 404 {object} map[string]interface{} "URL not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	if req.URLID != nil {
+		_, err := h.repo.GetURLByID(*req.URLID)
+		if err != nil {
+			if database.IsNotFoundError(err) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch URL", "details": err.Error()})
+			return
+		}
+	}
+
+	events := models.WebhookEvents(req.Events)
+	if len(events) == 0 {
+		events = models.WebhookEvents{models.WebhookEventCrawlCompleted, models.WebhookEventCrawlFailed, models.WebhookEventCrawlBlocked}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		secret, err = generateWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret", "details": err.Error()})
+			return
+		}
+	}
+
+	webhook := &models.Webhook{
+		UserID:    c.GetInt("user_id"),
+		URLID:     req.URLID,
+		TargetURL: req.TargetURL,
+		Secret:    secret,
+		Events:    events,
+	}
+
+	if err := h.webhooks.CreateWebhook(webhook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+		"secret":  webhook.Secret,
+	})
+}
+
+// ListWebhooks handles GET /api/webhooks
+// @Summary List webhooks
+// @Description List the webhooks registered by the calling user
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Webhooks"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhooks.ListWebhooksByUserID(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+		"count":    len(webhooks),
+	})
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/:id
+// @Summary Delete a webhook
+// @Description Delete a webhook of the calling user together with its delivery log
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]interface{} "Webhook deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook ID"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhook, ok := h.webhookFromPath(c)
+	if !ok {
+		return
+	}
+
+	err := h.webhooks.DeleteWebhook(webhook.ID)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// ListWebhookDeliveries handles GET /api/webhooks/:id/deliveries
+// @Summary Get the delivery log of a webhook
+// @Description List the events sent or still to be sent to a webhook, newest first, with their attempts, response codes and errors. Failed attempts are retried with exponential backoff.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "Filter by delivery status" Enums(pending, succeeded, failed)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} models.PaginatedResponse "Deliveries"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook ID or query parameters"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	webhook, ok := h.webhookFromPath(c)
+	if !ok {
+		return
+	}
+
+	var filter models.DeliveryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+
+	// Set defaults
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 100 {
+		filter.PageSize = 20
+	}
+
+	deliveries, total, err := h.webhooks.ListWebhookDeliveries(webhook.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries", "details": err.Error()})
+		return
+	}
+
+	totalPages := (total + filter.PageSize - 1) / filter.PageSize
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:       deliveries,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		Total:      total,
+		TotalPages: totalPages,
+	})
+}
+
+// fetches the calling user's webhook named by the :id path parameter, writing the error response otherwise.
+// Webhooks of other users are reported as not found.
+func (h *WebhookHandler) webhookFromPath(c *gin.Context) (*models.Webhook, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	webhook, err := h.webhooks.GetWebhookByID(id)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook", "details": err.Error()})
+		return nil, false
+	}
+
+	if webhook.UserID != c.GetInt("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// returns a random 64 character hex secret
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWebhookRepository for testing
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetWebhookByID(id int) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListWebhooksByUserID(userID int) ([]models.Webhook, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) DeleteWebhook(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListWebhooksForEvent(event string, urlID int) ([]models.Webhook, error) {
+	args := m.Called(event, urlID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimWebhookDelivery(id int, dueAt time.Time, leaseUntil time.Time) (bool, error) {
+	args := m.Called(id, dueAt, leaseUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListWebhookDeliveries(webhookID int, filter models.DeliveryFilter) ([]models.WebhookDelivery, int, error) {
+	args := m.Called(webhookID, filter)
+	return args.Get(0).([]models.WebhookDelivery), args.Int(1), args.Error(2)
+}
+
+func setupWebhookTestRouter(repo *MockRepository, webhooks *MockWebhookRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	handler := NewWebhookHandler(repo, webhooks)
+
+	// Stand in for the auth middleware
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	})
+
+	api := router.Group("/api")
+	{
+		api.POST("/webhooks", handler.CreateWebhook)
+		api.GET("/webhooks", handler.ListWebhooks)
+		api.DELETE("/webhooks/:id", handler.DeleteWebhook)
+		api.GET("/webhooks/:id/deliveries", handler.ListWebhookDeliveries)
+	}
+
+	return router
+}
+
+func TestCreateWebhook_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	router := setupWebhookTestRouter(mockRepo, mockWebhooks)
+
+	mockWebhooks.On("CreateWebhook", mock.MatchedBy(func(webhook *models.Webhook) bool {
+		// Events default to all of them and a secret is generated
+		return webhook.UserID == 1 && webhook.TargetURL == "https://ci.example.com/hook" &&
+			webhook.Events.Contains(models.WebhookEventCrawlBlocked) && len(webhook.Events) == 3 && len(webhook.Secret) == 64
+	})).Return(nil)
+
+	jsonBody := []byte(`{"target_url": "https://ci.example.com/hook"}`)
+	req, _ := http.NewRequest("POST", "/api/webhooks", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Len(t, response["secret"], 64)
+	assert.NotContains(t, response["webhook"], "secret")
+
+	mockWebhooks.AssertExpectations(t)
+}
+
+func TestCreateWebhook_InvalidEvent(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	router := setupWebhookTestRouter(mockRepo, mockWebhooks)
+
+	jsonBody := []byte(`{"target_url": "https://ci.example.com/hook", "events": ["crawl.started"]}`)
+	req, _ := http.NewRequest("POST", "/api/webhooks", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockWebhooks.AssertNotCalled(t, "CreateWebhook", mock.Anything)
+}
+
+func TestListWebhookDeliveries_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	router := setupWebhookTestRouter(mockRepo, mockWebhooks)
+
+	status := models.DeliveryStatusFailed
+	deliveries := []models.WebhookDelivery{
+		{ID: 8, WebhookID: 3, Event: models.WebhookEventCrawlCompleted, Payload: []byte(`{"url_id":1}`), Status: status, Attempts: 6},
+	}
+
+	mockWebhooks.On("GetWebhookByID", 3).Return(&models.Webhook{ID: 3, UserID: 1}, nil)
+	mockWebhooks.On("ListWebhookDeliveries", 3, models.DeliveryFilter{Status: &status, Page: 1, PageSize: 20}).Return(deliveries, 1, nil)
+
+	req, _ := http.NewRequest("GET", "/api/webhooks/3/deliveries?status=failed", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data  []models.WebhookDelivery `json:"data"`
+		Total int                      `json:"total"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Total)
+	require.Len(t, response.Data, 1)
+	assert.JSONEq(t, `{"url_id":1}`, string(response.Data[0].Payload))
+
+	mockWebhooks.AssertExpectations(t)
+}
+
+func TestDeleteWebhook_OtherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	router := setupWebhookTestRouter(mockRepo, mockWebhooks)
+
+	mockWebhooks.On("GetWebhookByID", 3).Return(&models.Webhook{ID: 3, UserID: 2}, nil)
+
+	req, _ := http.NewRequest("DELETE", "/api/webhooks/3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockWebhooks.AssertNotCalled(t, "DeleteWebhook", mock.Anything)
+}
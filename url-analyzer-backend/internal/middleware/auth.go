@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"url-analyzer/internal/database"
 
@@ -19,6 +20,12 @@ func AuthMiddleware(repo database.RepositoryInterface) gin.HandlerFunc {
 
 		// Get API key from header
 		authHeader := c.GetHeader("Authorization")
+		
+		// Browsers cannot set headers on EventSource requests, so event streams may pass the key in the query
+		if authHeader == "" && strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			authHeader = c.Query("api_key")
+		}
+		
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
 			c.Abort()
@@ -104,7 +111,7 @@ func LoggingMiddleware() gin.HandlerFunc {
 			param.ClientIP,
 			param.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
 			param.Method,
-			param.Path,
+			redactAPIKey(param.Path),
 			param.Request.Proto,
 			param.StatusCode,
 			param.Latency,
@@ -114,6 +121,24 @@ func LoggingMiddleware() gin.HandlerFunc {
 	})
 }
 
+// hides the API key event streams may pass in the query, so that access logs do not hold it
+func redactAPIKey(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Drop a query that cannot be parsed rather than risk logging a key
+		return base + "?[unparsable query]"
+	}
+	if _, ok := query["api_key"]; !ok {
+		return path
+	}
+	query.Set("api_key", "REDACTED")
+	return base + "?" + query.Encode()
+}
+
 // handles errors
 func ErrorHandlingMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactAPIKey(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/urls", "/api/urls"},
+		{"/api/urls?page=2", "/api/urls?page=2"},
+		{"/api/jobs/events?api_key=secret-key", "/api/jobs/events?api_key=REDACTED"},
+		{"/api/urls/1/events?last_event_id=7&api_key=secret-key", "/api/urls/1/events?api_key=REDACTED&last_event_id=7"},
+		{"/api/jobs/events?api_key=secret%zz", "/api/jobs/events?[unparsable query]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, redactAPIKey(tc.path))
+			assert.NotContains(t, redactAPIKey(tc.path), "secret")
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package models
 
 import (
+	"context"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
+	"url-analyzer/pkg/cron"
+
+	"github.com/andybalholm/cascadia"
 )
 
 // URLStatus represents the status of a URL crawl
@@ -14,178 +21,1606 @@ const (
 	StatusRunning   URLStatus = "running"
 	StatusCompleted URLStatus = "completed"
 	StatusError     URLStatus = "error"
+	StatusBlocked   URLStatus = "blocked"
+)
+
+// Scan implements the sql.Scanner interface
+func (s *URLStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = StatusQueued
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*s = URLStatus(v)
+	case []byte:
+		*s = URLStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T into URLStatus", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (s URLStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// CrawlStatus represents the current status of a crawl operation
+type CrawlStatus string
+
+const (
+	CrawlStatusQueued    CrawlStatus = "queued"
+	CrawlStatusStarted   CrawlStatus = "started"
+	CrawlStatusFetching  CrawlStatus = "fetching"
+	CrawlStatusParsing   CrawlStatus = "parsing"
+	CrawlStatusAnalyzing CrawlStatus = "analyzing"
+	CrawlStatusChecking  CrawlStatus = "checking_links"
+	CrawlStatusCompleted CrawlStatus = "completed"
+	CrawlStatusFailed    CrawlStatus = "failed"
+	CrawlStatusBlocked   CrawlStatus = "blocked"
+)
+
+// IsFinished reports whether a crawl in this status has stopped running
+func (s CrawlStatus) IsFinished() bool {
+	return s == CrawlStatusCompleted || s == CrawlStatusFailed || s == CrawlStatusBlocked
+}
+
+// JobStatus represents the state of a crawl job in the persistent queue
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// Scan implements the sql.Scanner interface
+func (s *JobStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = JobStatusQueued
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*s = JobStatus(v)
+	case []byte:
+		*s = JobStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JobStatus", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (s JobStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// DeliveryStatus represents the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// Scan implements the sql.Scanner interface
+func (s *DeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = DeliveryStatusPending
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*s = DeliveryStatus(v)
+	case []byte:
+		*s = DeliveryStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T into DeliveryStatus", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (s DeliveryStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// LinkStatus represents the outcome of checking a link found on a page
+type LinkStatus string
+
+const (
+	LinkStatusOK         LinkStatus = "ok"
+	LinkStatusBroken     LinkStatus = "broken"
+	LinkStatusBlocked    LinkStatus = "blocked" // disallowed by robots.txt
+	LinkStatusUnchecked  LinkStatus = "unchecked"
+	LinkStatusUnverified LinkStatus = "unverified" // the check failed in a way the link check policy does not count as broken
+)
+
+// Scan implements the sql.Scanner interface
+func (s *LinkStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = LinkStatusUnchecked
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*s = LinkStatus(v)
+	case []byte:
+		*s = LinkStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T into LinkStatus", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (s LinkStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// LinkCheckCategory classifies the outcome of checking a link or resource
+type LinkCheckCategory string
+
+const (
+	LinkCheckOK              LinkCheckCategory = "ok"
+	LinkCheckBroken          LinkCheckCategory = "broken"       // 404, 410, 5xx and other error statuses, too many redirects
+	LinkCheckForbidden       LinkCheckCategory = "forbidden"    // 401, 403 or 999, the server turns the crawler away
+	LinkCheckRateLimited     LinkCheckCategory = "rate_limited" // 429, still after retrying
+	LinkCheckTimeout         LinkCheckCategory = "timeout"
+	LinkCheckDNSFailure      LinkCheckCategory = "dns_failure"
+	LinkCheckTLSError        LinkCheckCategory = "tls_error"
+	LinkCheckConnectionError LinkCheckCategory = "connection_error" // refused, reset or another transport error
+	LinkCheckIgnored         LinkCheckCategory = "ignored"          // not requested because of a host rule
+)
+
+// LinkHostAction says whether the links to a host are checked
+type LinkHostAction string
+
+const (
+	LinkHostIgnore LinkHostAction = "ignore"
+	LinkHostAllow  LinkHostAction = "allow"
+)
+
+// LinkHostRule ignores or allows checking the links to a host and its subdomains.
+// The rule for the longest matching host applies, so an allowed subdomain of an ignored host is checked.
+type LinkHostRule struct {
+	Host   string         `json:"host" binding:"required,hostname"`
+	Action LinkHostAction `json:"action" binding:"required,oneof=ignore allow"`
+}
+
+// LinkCheckPolicy decides how links and resources are checked and which outcomes count as broken.
+// Failed checks in the other categories are unverified.
+type LinkCheckPolicy struct {
+	BrokenCategories []LinkCheckCategory `json:"broken_categories"`
+	HostRules        []LinkHostRule      `json:"host_rules"`
+	RateLimitRetries int                 `json:"rate_limit_retries"` // retries of a 429, waiting as long as Retry-After says
+	MaxRetryAfter    time.Duration       `json:"max_retry_after"`    // longer Retry-After waits are not retried
+}
+
+// CountsAsBroken reports whether the policy counts a check outcome as a broken link
+func (p LinkCheckPolicy) CountsAsBroken(category LinkCheckCategory) bool {
+	for _, broken := range p.BrokenCategories {
+		if broken == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Ignores reports whether the links to a host are left unchecked by the host rules
+func (p LinkCheckPolicy) Ignores(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	action, matched := LinkHostAllow, ""
+	for _, rule := range p.HostRules {
+		ruleHost := strings.ToLower(strings.TrimSuffix(rule.Host, "."))
+		if (host == ruleHost || strings.HasSuffix(host, "."+ruleHost)) && len(ruleHost) > len(matched) {
+			action, matched = rule.Action, ruleHost
+		}
+	}
+	return action == LinkHostIgnore
+}
+
+// ResourceType represents the kind of asset a page references
+type ResourceType string
+
+const (
+	ResourceTypeScript     ResourceType = "script"
+	ResourceTypeStylesheet ResourceType = "stylesheet"
+	ResourceTypeImage      ResourceType = "image"
+	ResourceTypeFont       ResourceType = "font"
+	ResourceTypeIframe     ResourceType = "iframe"
+)
+
+// ResourceTypes lists every resource type in the order they are reported
+var ResourceTypes = []ResourceType{
+	ResourceTypeScript,
+	ResourceTypeStylesheet,
+	ResourceTypeImage,
+	ResourceTypeFont,
+	ResourceTypeIframe,
+}
+
+// Scan implements the sql.Scanner interface
+func (t *ResourceType) Scan(value interface{}) error {
+	if value == nil {
+		*t = ""
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*t = ResourceType(v)
+	case []byte:
+		*t = ResourceType(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ResourceType", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (t ResourceType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// FormType represents what a form found on a page is for
+type FormType string
+
+const (
+	FormTypeLogin         FormType = "login"
+	FormTypeSignup        FormType = "signup"
+	FormTypePasswordReset FormType = "password_reset"
+	FormTypeSearch        FormType = "search"
+	FormTypeOther         FormType = "other"
+)
+
+// Scan implements the sql.Scanner interface
+func (t *FormType) Scan(value interface{}) error {
+	if value == nil {
+		*t = FormTypeOther
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*t = FormType(v)
+	case []byte:
+		*t = FormType(v)
+	default:
+		return fmt.Errorf("cannot scan %T into FormType", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (t FormType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+// Scan implements the sql.Scanner interface
+func (l *StringList) Scan(value interface{}) error {
+	*l = StringList{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// FindingSeverity represents how serious a finding about a crawled page is
+type FindingSeverity string
+
+const (
+	SeverityInfo    FindingSeverity = "info"
+	SeverityWarning FindingSeverity = "warning"
+	SeverityError   FindingSeverity = "error"
+)
+
+// Scan implements the sql.Scanner interface
+func (s *FindingSeverity) Scan(value interface{}) error {
+	if value == nil {
+		*s = SeverityInfo
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		*s = FindingSeverity(v)
+	case []byte:
+		*s = FindingSeverity(v)
+	default:
+		return fmt.Errorf("cannot scan %T into FindingSeverity", value)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (s FindingSeverity) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Finding categories
+const (
+	FindingCategorySEO            = "seo"
+	FindingCategoryAccessibility  = "accessibility"
+	FindingCategoryStructuredData = "structured_data"
+	FindingCategoryResources      = "resources"
+	FindingCategorySecurity       = "security"
+	FindingCategoryMixedContent   = "mixed_content"
+	FindingCategoryRedirects      = "redirects"
+	FindingCategorySitemap        = "sitemap"
 )
 
+// Webhook events
+const (
+	WebhookEventCrawlCompleted = "crawl.completed"
+	WebhookEventCrawlFailed    = "crawl.failed"
+	WebhookEventCrawlBlocked   = "crawl.blocked" // robots.txt does not allow crawling the URL
+)
+
+// WebhookEvents is the list of events a webhook subscribes to
+type WebhookEvents []string
+
+// Contains reports whether the list includes the event
+func (e WebhookEvents) Contains(event string) bool {
+	for _, subscribed := range e {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan implements the sql.Scanner interface
+func (e *WebhookEvents) Scan(value interface{}) error {
+	*e = WebhookEvents{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	case []byte:
+		return json.Unmarshal(v, e)
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEvents", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (e WebhookEvents) Value() (driver.Value, error) {
+	if e == nil {
+		e = WebhookEvents{}
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// CrawlJobOptions holds what a queued crawl job needs to know beyond its URL
+type CrawlJobOptions struct {
+	SiteCrawl *SiteCrawlRequest     `json:"site_crawl,omitempty"`
+	Overrides *CrawlOptionsOverride `json:"overrides,omitempty"`
+}
+
+// Scan implements the sql.Scanner interface
+func (o *CrawlJobOptions) Scan(value interface{}) error {
+	*o = CrawlJobOptions{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	case []byte:
+		return json.Unmarshal(v, o)
+	default:
+		return fmt.Errorf("cannot scan %T into CrawlJobOptions", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (o CrawlJobOptions) Value() (driver.Value, error) {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// URL represents a URL to be crawled (Database model)
+type URL struct {
+	ID           int                   `json:"id" db:"id"`
+	UserID       *int                  `json:"user_id,omitempty" db:"user_id"`
+	URL          string                `json:"url" db:"url"`
+	Status       URLStatus             `json:"status" db:"status"`
+	ErrorMessage *string               `json:"error_message,omitempty" db:"error_message"`
+	CrawlOptions *CrawlOptionsOverride `json:"crawl_options,omitempty" db:"crawl_options"`
+	CreatedAt    time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// CrawlResult represents the result of crawling a URL (Database model)
+type CrawlResult struct {
+	ID                   int              `json:"id" db:"id"`
+	URLID                int              `json:"url_id" db:"url_id"`
+	Title                *string          `json:"title" db:"title"`
+	HTMLVersion          *string          `json:"html_version" db:"html_version"`
+	Doctype              *string          `json:"doctype" db:"doctype"`
+	DocumentMode         *string          `json:"document_mode" db:"document_mode"`
+	SEO                  *SEOMetadata     `json:"seo" db:"seo_metadata"`
+	StructuredData       *StructuredData  `json:"structured_data" db:"structured_data"`
+	Resources            *ResourceSummary `json:"resources" db:"resource_summary"`
+	RedirectChain        RedirectChain    `json:"redirect_chain" db:"redirect_chain"`
+	Analyzers            AnalyzerRuns     `json:"analyzers" db:"analyzer_runs"`
+	RulesPassed          int              `json:"rules_passed" db:"rules_passed"`
+	RulesFailed          int              `json:"rules_failed" db:"rules_failed"`
+	Fetcher              string           `json:"fetcher" db:"fetcher"`
+	Sitemap              *SitemapCoverage `json:"sitemap" db:"sitemap_coverage"`
+	H1Count              int              `json:"h1_count" db:"h1_count"`
+	H2Count              int              `json:"h2_count" db:"h2_count"`
+	H3Count              int              `json:"h3_count" db:"h3_count"`
+	H4Count              int              `json:"h4_count" db:"h4_count"`
+	H5Count              int              `json:"h5_count" db:"h5_count"`
+	H6Count              int              `json:"h6_count" db:"h6_count"`
+	InternalLinks        int              `json:"internal_links" db:"internal_links"`
+	ExternalLinks        int              `json:"external_links" db:"external_links"`
+	BrokenLinksCount     int              `json:"broken_links_count" db:"broken_links_count"`
+	LinksBlockedByRobots int              `json:"links_blocked_by_robots" db:"links_blocked_by_robots"`
+	HasLoginForm         bool             `json:"has_login_form" db:"has_login_form"`
+	PageURL              *string          `json:"page_url,omitempty" db:"page_url"`
+	Depth                int              `json:"depth" db:"depth"`
+	ParentID             *int             `json:"parent_id,omitempty" db:"parent_id"`
+	RootID               *int             `json:"root_id,omitempty" db:"root_id"`
+	CrawledAt            time.Time        `json:"crawled_at" db:"crawled_at"`
+}
+
+// BrokenLink represents a broken link found during crawling (Database model)
+type BrokenLink struct {
+	ID              int    `json:"id" db:"id"`
+	CrawlResultID   int    `json:"crawl_result_id" db:"crawl_result_id"`
+	URL             string `json:"url" db:"url"`
+	StatusCode      int    `json:"status_code" db:"status_code"`
+	ErrorMessage    string `json:"error_message" db:"error_message"`
+	LinkText        string `json:"link_text" db:"link_text"`
+	IsInternal      bool   `json:"is_internal" db:"is_internal"`
+	Category        *LinkCheckCategory `json:"category,omitempty" db:"category"` // nil for links found before categories were recorded
+}
+
+// Link represents a link found on a crawled page together with the outcome of its check (Database model)
+type Link struct {
+	ID            int                `json:"id" db:"id"`
+	CrawlResultID int                `json:"crawl_result_id" db:"crawl_result_id"`
+	URL           string             `json:"url" db:"url"`
+	AnchorText    string             `json:"anchor_text" db:"anchor_text"`
+	Rel           string             `json:"rel" db:"rel"`
+	IsInternal    bool               `json:"is_internal" db:"is_internal"`
+	Status        LinkStatus         `json:"status" db:"check_status"`
+	Category      *LinkCheckCategory `json:"category" db:"check_category"`
+	StatusCode    *int               `json:"status_code" db:"status_code"`
+	ErrorMessage  *string            `json:"error_message" db:"error_message"`
+	Redirects     RedirectChain      `json:"redirects" db:"redirects"`
+}
+
+// PageResource represents a script, stylesheet, image, font or iframe referenced by a crawled page (Database model)
+type PageResource struct {
+	ID            int                `json:"id" db:"id"`
+	CrawlResultID int                `json:"crawl_result_id" db:"crawl_result_id"`
+	URL           string             `json:"url" db:"url"`
+	Type          ResourceType       `json:"type" db:"type"`
+	IsThirdParty  bool               `json:"is_third_party" db:"is_third_party"`
+	Status        LinkStatus         `json:"status" db:"check_status"`
+	Category      *LinkCheckCategory `json:"category" db:"check_category"`
+	StatusCode    *int               `json:"status_code" db:"status_code"`
+	Size          *int64             `json:"size" db:"size"`
+	ContentType   *string            `json:"content_type" db:"content_type"`
+	ErrorMessage  *string            `json:"error_message" db:"error_message"`
+}
+
+// Form represents a form found on a crawled page (Database model)
+type Form struct {
+	ID             int        `json:"id" db:"id"`
+	CrawlResultID  int        `json:"crawl_result_id" db:"crawl_result_id"`
+	Type           FormType   `json:"type" db:"form_type"`
+	Score          int        `json:"score" db:"score"`
+	Action         string     `json:"action" db:"action"`
+	Method         string     `json:"method" db:"method"`
+	PostsOverHTTP  bool       `json:"posts_over_http" db:"posts_over_http"`
+	PasswordFields int        `json:"password_fields" db:"password_fields"`
+	OAuthProviders StringList `json:"oauth_providers" db:"oauth_providers"`
+	Signals        StringList `json:"signals" db:"signals"`
+}
+
+// Finding represents a problem or notable fact about a crawled page (Database model)
+type Finding struct {
+	ID            int             `json:"id" db:"id"`
+	CrawlResultID int             `json:"crawl_result_id" db:"crawl_result_id"`
+	Category      string          `json:"category" db:"category"`
+	Code          string          `json:"code" db:"code"`
+	Severity      FindingSeverity `json:"severity" db:"severity"`
+	Message       string          `json:"message" db:"message"`
+	Selector      string          `json:"selector,omitempty" db:"selector"`
+}
+
+// CrawlJobRecord represents a crawl job in the persistent queue (Database model)
+type CrawlJobRecord struct {
+	ID             int             `json:"id" db:"id"`
+	URLID          int             `json:"url_id" db:"url_id"`
+	Status         JobStatus       `json:"status" db:"status"`
+	Options        CrawlJobOptions `json:"options" db:"options"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	WorkerID       *string         `json:"worker_id,omitempty" db:"worker_id"`
+	LeaseExpiresAt *time.Time      `json:"lease_expires_at,omitempty" db:"lease_expires_at"`
+	HeartbeatAt    *time.Time      `json:"heartbeat_at,omitempty" db:"heartbeat_at"`
+	ErrorMessage   *string         `json:"error_message,omitempty" db:"error_message"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	StartedAt      *time.Time      `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// CrawlJobOutcome is how a crawl job ended: the final status of the job and of its URL
+type CrawlJobOutcome struct {
+	JobStatus    JobStatus
+	URLStatus    URLStatus
+	ErrorMessage *string
+}
+
+// CrawlSchedule represents a recurring crawl of a URL (Database model).
+// A schedule has either a cron expression or an interval.
+type CrawlSchedule struct {
+	ID              int        `json:"id" db:"id"`
+	URLID           int        `json:"url_id" db:"url_id"`
+	CronExpr        *string    `json:"cron_expr,omitempty" db:"cron_expr"`
+	IntervalSeconds *int       `json:"interval_seconds,omitempty" db:"interval_seconds"`
+	Enabled         bool       `json:"enabled" db:"enabled"`
+	NextRunAt       time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	LastError       *string    `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NextRunAfter returns the first time after t the schedule is due
+func (s *CrawlSchedule) NextRunAfter(t time.Time) (time.Time, error) {
+	if s.CronExpr != nil {
+		schedule, err := cron.Parse(*s.CronExpr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		next := schedule.Next(t)
+		if next.IsZero() {
+			return time.Time{}, fmt.Errorf("cron expression %q never matches", *s.CronExpr)
+		}
+		return next, nil
+	}
+
+	if s.IntervalSeconds != nil && *s.IntervalSeconds > 0 {
+		return t.Add(time.Duration(*s.IntervalSeconds) * time.Second).Truncate(time.Second), nil
+	}
+
+	return time.Time{}, fmt.Errorf("schedule has neither a cron expression nor an interval")
+}
+
+// FollowingRunAfter returns the run that follows the due run at NextRunAt. Interval schedules keep
+// their cadence from NextRunAt rather than drifting with now, skipping the runs missed before now.
+func (s *CrawlSchedule) FollowingRunAfter(now time.Time) (time.Time, error) {
+	if s.CronExpr != nil || s.IntervalSeconds == nil || *s.IntervalSeconds <= 0 || s.NextRunAt.IsZero() {
+		return s.NextRunAfter(now)
+	}
+
+	interval := time.Duration(*s.IntervalSeconds) * time.Second
+	next := s.NextRunAt.Add(interval)
+	if !next.After(now) {
+		missed := now.Sub(next)/interval + 1
+		next = next.Add(missed * interval)
+	}
+	return next, nil
+}
+
+// Webhook represents a user's subscription to crawl events (Database model).
+// The secret is only returned when the webhook is created.
+type Webhook struct {
+	ID        int           `json:"id" db:"id"`
+	UserID    int           `json:"user_id" db:"user_id"`
+	URLID     *int          `json:"url_id,omitempty" db:"url_id"`
+	TargetURL string        `json:"target_url" db:"target_url"`
+	Secret    string        `json:"-" db:"secret"`
+	Events    WebhookEvents `json:"events" db:"events"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// RuleType represents what a custom rule checks on a crawled page
+type RuleType string
+
+const (
+	RuleSelectorExists RuleType = "selector_exists" // the selector matches at least one element
+	RuleSelectorAbsent RuleType = "selector_absent" // the selector matches no element
+	RuleElementCount   RuleType = "element_count"   // the number of elements the selector matches compares to the count
+	RuleTitleMatches   RuleType = "title_matches"   // the title matches the regular expression of the pattern
+	RuleTextContains   RuleType = "text_contains"   // the visible text contains the pattern, ignoring case
+	RuleTextAbsent     RuleType = "text_absent"     // the visible text does not contain the pattern, ignoring case
+)
+
+// Comparison operators of element count rules
+const (
+	RuleOperatorEqual          = "eq"
+	RuleOperatorNotEqual       = "ne"
+	RuleOperatorLess           = "lt"
+	RuleOperatorLessOrEqual    = "lte"
+	RuleOperatorGreater        = "gt"
+	RuleOperatorGreaterOrEqual = "gte"
+)
+
+// Rule is a check a user defined to be evaluated against every crawled page of a URL,
+// or of every URL the user added when it has no URL ID (Database model)
+type Rule struct {
+	ID        int             `json:"id" db:"id"`
+	UserID    int             `json:"user_id" db:"user_id"`
+	URLID     *int            `json:"url_id,omitempty" db:"url_id"`
+	Name      string          `json:"name" db:"name"`
+	Type      RuleType        `json:"type" db:"type"`
+	Selector  string          `json:"selector,omitempty" db:"selector"`
+	Pattern   string          `json:"pattern,omitempty" db:"pattern"`
+	Operator  string          `json:"operator,omitempty" db:"operator"`
+	Count     int             `json:"count" db:"expected_count"`
+	Severity  FindingSeverity `json:"severity" db:"severity"`
+	Enabled   bool            `json:"enabled" db:"enabled"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// RuleResult represents whether a crawled page passed a rule (Database model)
+type RuleResult struct {
+	ID            int             `json:"id" db:"id"`
+	CrawlResultID int             `json:"crawl_result_id" db:"crawl_result_id"`
+	RuleID        *int            `json:"rule_id" db:"rule_id"` // nil once the rule is deleted
+	RuleName      string          `json:"rule_name" db:"rule_name"`
+	Passed        bool            `json:"passed" db:"passed"`
+	Severity      FindingSeverity `json:"severity" db:"severity"`
+	Message       string          `json:"message" db:"message"`
+}
+
+// WebhookDelivery represents one event sent, or still to be sent, to a webhook (Database model)
+type WebhookDelivery struct {
+	ID            int             `json:"id" db:"id"`
+	WebhookID     int             `json:"webhook_id" db:"webhook_id"`
+	Event         string          `json:"event" db:"event"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        DeliveryStatus  `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	ResponseCode  *int            `json:"response_code,omitempty" db:"response_code"`
+	LastError     *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// User represents an API user
+type User struct {
+	ID        int       `json:"id" db:"id"`
+	Username  string    `json:"username" db:"username"`
+	APIKey    string    `json:"api_key" db:"api_key"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// URLWithResult combines URL with its crawl result
+type URLWithResult struct {
+	URL
+	CrawlResult *CrawlResult `json:"crawl_result,omitempty"`
+}
+
+// ============================================================================
+// Business Logic Types (used by crawler and services)
+// ============================================================================
+
+// CrawlJobResult represents the complete result of crawling a webpage
+type CrawlJobResult struct {
+	URL                  string            `json:"url"`
+	Title                string            `json:"title"`
+	HTMLVersion          string            `json:"html_version"`
+	Doctype              string            `json:"doctype"`
+	DocumentMode         string            `json:"document_mode"`
+	HeadingCounts        map[string]int    `json:"heading_counts"`
+	InternalLinks        int               `json:"internal_links"`
+	ExternalLinks        int               `json:"external_links"`
+	BrokenLinks          []CrawlBrokenLink `json:"broken_links"`
+	Links                []CrawlLink       `json:"links"`
+	BlockedByRobots      bool              `json:"blocked_by_robots"`
+	LinksBlockedByRobots int               `json:"links_blocked_by_robots"`
+	HasLoginForm         bool              `json:"has_login_form"`
+	Forms                []CrawlForm       `json:"forms"`
+	SEO                  *SEOMetadata      `json:"seo"`
+	StructuredData       *StructuredData   `json:"structured_data"`
+	Resources            []CrawlResource   `json:"resources"`
+	ResourceSummary      *ResourceSummary  `json:"resource_summary"`
+	Security             *SecurityReport   `json:"security"`
+	RedirectChain        RedirectChain     `json:"redirect_chain"`
+	Analyzers            AnalyzerRuns      `json:"analyzers"`
+	RuleResults          []CrawlRuleResult `json:"rule_results"`
+	Findings             []CrawlFinding    `json:"findings"`
+	Fetcher              string            `json:"fetcher"` // how the page was loaded
+	Sitemap              *SitemapCoverage  `json:"sitemap"`
+	CrawlDuration        time.Duration     `json:"crawl_duration"`
+	Error                error             `json:"error,omitempty"`
+	StatusCode           int               `json:"status_code"`
+	ContentLength        int64             `json:"content_length"`
+	ResponseHeaders      map[string]string `json:"response_headers"`
+}
+
+// CrawlBrokenLink represents a broken link found during crawling
+type CrawlBrokenLink struct {
+	URL          string            `json:"url"`
+	StatusCode   int               `json:"status_code"`
+	ErrorMessage string            `json:"error_message"`
+	LinkText     string            `json:"link_text"`
+	IsInternal   bool              `json:"is_internal"`
+	Category     LinkCheckCategory `json:"category"`
+}
+
+// CrawlLink represents a link found during crawling and the outcome of its check.
+// StatusCode is 0 when the link was not requested or the request failed.
+type CrawlLink struct {
+	URL          string            `json:"url"`
+	Text         string            `json:"text"`
+	Rel          string            `json:"rel"`
+	IsInternal   bool              `json:"is_internal"`
+	Status       LinkStatus        `json:"status"`
+	Category     LinkCheckCategory `json:"category,omitempty"` // empty when the link was not requested
+	StatusCode   int               `json:"status_code"`
+	ErrorMessage string            `json:"error_message"`
+	Redirects    RedirectChain     `json:"redirects"`
+}
+
+// RedirectHop is one redirect followed while fetching a URL: the URL requested,
+// the redirect status it answered with and the Location it pointed to
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// RedirectChain represents the redirects followed for a URL stored as a JSON array, empty when it did not redirect
+type RedirectChain []RedirectHop
+
+// Scan implements the sql.Scanner interface
+func (r *RedirectChain) Scan(value interface{}) error {
+	*r = RedirectChain{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	case []byte:
+		return json.Unmarshal(v, r)
+	default:
+		return fmt.Errorf("cannot scan %T into RedirectChain", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (r RedirectChain) Value() (driver.Value, error) {
+	if r == nil {
+		r = RedirectChain{}
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// AnalyzerInfo describes an analyzer of the crawler pipeline
+type AnalyzerInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"` // whether crawls run it unless their options say otherwise
+}
+
+// AnalyzerRun records how one analyzer fared on a crawled page
+type AnalyzerRun struct {
+	Name       string             `json:"name"`
+	DurationMS float64            `json:"duration_ms"`
+	Findings   int                `json:"findings"`
+	Metrics    map[string]float64 `json:"metrics,omitempty"`
+	Error      string             `json:"error,omitempty"` // the analyzer failed and its findings and metrics are missing
+}
+
+// AnalyzerRuns represents the analyzers run on a crawled page stored as a JSON array, in the order they ran
+type AnalyzerRuns []AnalyzerRun
+
+// Scan implements the sql.Scanner interface
+func (r *AnalyzerRuns) Scan(value interface{}) error {
+	*r = AnalyzerRuns{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	case []byte:
+		return json.Unmarshal(v, r)
+	default:
+		return fmt.Errorf("cannot scan %T into AnalyzerRuns", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (r AnalyzerRuns) Value() (driver.Value, error) {
+	if r == nil {
+		r = AnalyzerRuns{}
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// SecurityCheckStatus represents the outcome of one check of a security report
+type SecurityCheckStatus string
+
+const (
+	SecurityCheckPass SecurityCheckStatus = "pass"
+	SecurityCheckWarn SecurityCheckStatus = "warn"
+	SecurityCheckFail SecurityCheckStatus = "fail"
+)
+
+// SecurityReport grades the security headers, cookies and TLS connection of a crawled page (Database model).
+// The score is out of 100, passed checks earn their full weight and warnings half of it.
+type SecurityReport struct {
+	ID            int            `json:"id,omitempty" db:"id"`
+	CrawlResultID int            `json:"crawl_result_id,omitempty" db:"crawl_result_id"`
+	Score         int            `json:"score" db:"score"`
+	Grade         string         `json:"grade" db:"grade"`
+	Checks        SecurityChecks `json:"checks" db:"checks"`
+	Cookies       CookieReports  `json:"cookies" db:"cookies"`
+	TLSVersion    *string        `json:"tls_version" db:"tls_version"`
+	CertIssuer    *string        `json:"cert_issuer" db:"cert_issuer"`
+	CertSubject   *string        `json:"cert_subject" db:"cert_subject"`
+	CertExpiresAt *time.Time     `json:"cert_expires_at" db:"cert_expires_at"`
+}
+
+// SecurityCheck is one graded aspect of a security report, e.g. "hsts"
+type SecurityCheck struct {
+	Name    string              `json:"name"`
+	Status  SecurityCheckStatus `json:"status"`
+	Weight  int                 `json:"weight"`
+	Value   string              `json:"value"` // the header value or setting the check looked at
+	Message string              `json:"message"`
+}
+
+// CookieReport shows the security attributes of a cookie set by a crawled page
+type CookieReport struct {
+	Name     string `json:"name"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"http_only"`
+	SameSite string `json:"same_site"` // "Strict", "Lax", "None" or empty when not set
+}
+
+// SecurityChecks represents the checks of a security report stored as a JSON array
+type SecurityChecks []SecurityCheck
+
+// Scan implements the sql.Scanner interface
+func (c *SecurityChecks) Scan(value interface{}) error {
+	*c = SecurityChecks{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return fmt.Errorf("cannot scan %T into SecurityChecks", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (c SecurityChecks) Value() (driver.Value, error) {
+	if c == nil {
+		c = SecurityChecks{}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// CookieReports represents the cookies of a security report stored as a JSON array
+type CookieReports []CookieReport
+
+// Scan implements the sql.Scanner interface
+func (c *CookieReports) Scan(value interface{}) error {
+	*c = CookieReports{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return fmt.Errorf("cannot scan %T into CookieReports", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (c CookieReports) Value() (driver.Value, error) {
+	if c == nil {
+		c = CookieReports{}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// ExpiringCertificate represents a URL whose latest crawl found a TLS certificate close to expiry
+type ExpiringCertificate struct {
+	URLID         int       `json:"url_id" db:"url_id"`
+	URL           string    `json:"url" db:"url"`
+	CrawlResultID int       `json:"crawl_result_id" db:"crawl_result_id"`
+	CertIssuer    *string   `json:"cert_issuer" db:"cert_issuer"`
+	CertSubject   *string   `json:"cert_subject" db:"cert_subject"`
+	CertExpiresAt time.Time `json:"cert_expires_at" db:"cert_expires_at"`
+	DaysLeft      int       `json:"days_left" db:"-"`
+}
+
+// CrawlResource represents an asset referenced by a crawled page. Size is the Content-Length
+// the server reported, 0 when the resource was not checked or the size is unknown.
+type CrawlResource struct {
+	URL          string            `json:"url"`
+	Type         ResourceType      `json:"type"`
+	IsThirdParty bool              `json:"is_third_party"`
+	Status       LinkStatus        `json:"status"`
+	Category     LinkCheckCategory `json:"category,omitempty"` // empty when the resource was not requested
+	StatusCode   int               `json:"status_code"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type"`
+	ErrorMessage string            `json:"error_message"`
+}
+
+// ResourceSummary sums up the resources of a page. Sizes are only known for checked resources.
+type ResourceSummary struct {
+	Counts          map[ResourceType]int `json:"counts"`
+	Total           int                  `json:"total"`
+	TotalBytes      int64                `json:"total_bytes"`
+	UnknownSizes    int                  `json:"unknown_sizes"` // checked resources whose size the server did not report
+	ThirdParty      int                  `json:"third_party"`
+	ThirdPartyHosts []string             `json:"third_party_hosts"`
+	Broken          int                  `json:"broken"`
+}
+
+// Scan implements the sql.Scanner interface
+func (s *ResourceSummary) Scan(value interface{}) error {
+	*s = ResourceSummary{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case []byte:
+		return json.Unmarshal(v, s)
+	default:
+		return fmt.Errorf("cannot scan %T into ResourceSummary", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (s ResourceSummary) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// CrawlForm represents a form found during crawling, classified by the type that scored highest.
+// Action is absolute and empty for password fields outside of any form, whose target is up to scripts.
+type CrawlForm struct {
+	Type           FormType `json:"type"`
+	Score          int      `json:"score"`
+	Action         string   `json:"action"`
+	Method         string   `json:"method"`
+	PostsOverHTTP  bool     `json:"posts_over_http"`
+	PasswordFields int      `json:"password_fields"`
+	OAuthProviders []string `json:"oauth_providers"`
+	Signals        []string `json:"signals"`
+}
+
+// CrawlRuleResult represents the outcome of a custom rule on a crawled page
+type CrawlRuleResult struct {
+	RuleID   int             `json:"rule_id"`
+	Name     string          `json:"name"`
+	Passed   bool            `json:"passed"`
+	Severity FindingSeverity `json:"severity"`
+	Message  string          `json:"message"` // what the page had, e.g. "h1 matches 2 elements, expected 1"
+}
+
+// CrawlFinding represents a problem or notable fact found during crawling. Codes are
+// stable identifiers within a category, e.g. "missing_description" for "seo".
+type CrawlFinding struct {
+	Category string          `json:"category"`
+	Code     string          `json:"code"`
+	Severity FindingSeverity `json:"severity"`
+	Message  string          `json:"message"`
+	Selector string          `json:"selector,omitempty"` // CSS path of the element the finding is about, if any
+}
+
+// SEOMetadata holds what a page tells search engines and social networks about itself.
+// URLs are absolute and Open Graph and Twitter card tags are keyed by their full name, e.g. "og:title".
+type SEOMetadata struct {
+	Description string              `json:"description"`
+	Robots      []string            `json:"robots"`
+	Canonical   string              `json:"canonical"`
+	Hreflang    []HreflangAlternate `json:"hreflang"`
+	OpenGraph   map[string]string   `json:"open_graph"`
+	TwitterCard map[string]string   `json:"twitter_card"`
+	Lang        string              `json:"lang"`
+}
+
+// HreflangAlternate is a version of the page in another language or region
+type HreflangAlternate struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// Scan implements the sql.Scanner interface
+func (m *SEOMetadata) Scan(value interface{}) error {
+	*m = SEOMetadata{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case []byte:
+		return json.Unmarshal(v, m)
+	default:
+		return fmt.Errorf("cannot scan %T into SEOMetadata", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (m SEOMetadata) Value() (driver.Value, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// StructuredData holds the machine-readable descriptions embedded in a page as JSON-LD,
+// microdata and RDFa. schema.org types are given by their short name, e.g. "Product".
+type StructuredData struct {
+	Types     []string         `json:"types"` // every type found in any syntax, without duplicates
+	JSONLD    []JSONLDBlock    `json:"json_ld"`
+	Microdata []StructuredItem `json:"microdata"`
+	RDFa      []StructuredItem `json:"rdfa"`
+}
+
+// JSONLDBlock is one <script type="application/ld+json"> block of a page
+type JSONLDBlock struct {
+	Valid bool            `json:"valid"`
+	Error string          `json:"error,omitempty"`
+	Types []string        `json:"types"`
+	Data  json.RawMessage `json:"data,omitempty"` // the parsed block, left out when it is invalid or too large
+}
+
+// StructuredItem is a microdata or RDFa item. Property values are strings or nested items.
+type StructuredItem struct {
+	Types      []string                 `json:"types"`
+	ID         string                   `json:"id,omitempty"`
+	Properties map[string][]interface{} `json:"properties"`
+}
+
+// Scan implements the sql.Scanner interface
+func (d *StructuredData) Scan(value interface{}) error {
+	*d = StructuredData{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	case []byte:
+		return json.Unmarshal(v, d)
+	default:
+		return fmt.Errorf("cannot scan %T into StructuredData", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (d StructuredData) Value() (driver.Value, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// SitemapCoverage compares the pages a crawl found linked with the pages the site's sitemaps list.
+// The sitemaps are those robots.txt names, or /sitemap.xml when it names none. URL lists hold at
+// most a few hundred entries, the counts are complete.
+type SitemapCoverage struct {
+	Sitemaps          []string `json:"sitemaps"`     // the sitemap files read, empty when the site has none
+	SitemapURLs       int      `json:"sitemap_urls"` // pages the sitemaps list
+	InSitemap         bool     `json:"in_sitemap"`   // whether the crawled page, or its canonical URL, is listed
+	LinkedURLs        int      `json:"linked_urls"`  // internal pages linked from the crawled pages
+	NotInSitemap      []string `json:"not_in_sitemap"`
+	NotInSitemapCount int      `json:"not_in_sitemap_count"`
+	NotLinked         []string `json:"not_linked"` // listed pages no crawled page links to, only known for site crawls
+	NotLinkedCount    int      `json:"not_linked_count"`
+	Truncated         bool     `json:"truncated"` // the sitemaps were too large to read completely
+	Errors            []string `json:"errors"`    // sitemaps that could not be read
+}
+
+// Scan implements the sql.Scanner interface
+func (s *SitemapCoverage) Scan(value interface{}) error {
+	*s = SitemapCoverage{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case []byte:
+		return json.Unmarshal(v, s)
+	default:
+		return fmt.Errorf("cannot scan %T into SitemapCoverage", value)
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (s SitemapCoverage) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// SitePageResult represents a single page visited during a site crawl
+type SitePageResult struct {
+	URL       string          `json:"url"`
+	ParentURL string          `json:"parent_url,omitempty"`
+	Depth     int             `json:"depth"`
+	Result    *CrawlJobResult `json:"result"`
+}
+
+// SiteCrawlResult represents the result of crawling a site breadth-first from a root URL
+type SiteCrawlResult struct {
+	RootURL         string           `json:"root_url"`
+	Pages           []SitePageResult `json:"pages"`
+	PagesDiscovered int              `json:"pages_discovered"`
+	BlockedByRobots bool             `json:"blocked_by_robots"`
+	Sitemap         *SitemapCoverage `json:"sitemap"` // the pages linked from all crawled pages compared with the sitemap
+	CrawlDuration   time.Duration    `json:"crawl_duration"`
+	Error           error            `json:"error,omitempty"`
+}
+
+// Fetchers the crawler can load pages with
+const (
+	FetcherHTTP    = "http"    // a plain GET request, the page as served
+	FetcherBrowser = "browser" // a headless Chrome or Chromium, the page after its scripts ran
+)
+
+// CrawlOptions contains configuration for the crawler
+type CrawlOptions struct {
+	Timeout          time.Duration   `json:"timeout"`
+	MaxRedirects     int             `json:"max_redirects"`
+	RedirectHopLimit int             `json:"redirect_hop_limit"` // redirect chains with more hops are reported
+	UserAgent        string          `json:"user_agent"`
+	FollowRobotsTxt  bool            `json:"follow_robots_txt"`
+	CheckBrokenLinks bool            `json:"check_broken_links"`
+	CheckResources   bool            `json:"check_resources"` // request scripts, stylesheets, images, fonts and iframes for status and size
+	LinkPolicy       LinkCheckPolicy `json:"link_policy"`
+	MaxLinksToCheck  int             `json:"max_links_to_check"`
+	ConcurrentChecks int             `json:"concurrent_checks"`
+	RespectRateLimit bool            `json:"respect_rate_limit"`
+	RateLimitDelay   time.Duration   `json:"rate_limit_delay"`
+	MaxDepth         int             `json:"max_depth"`
+	MaxPages         int             `json:"max_pages"`
+	Rules            []Rule          `json:"-"`            // the custom rules evaluated against every page
+	Analyzers        map[string]bool `json:"analyzers"`    // turns analyzers on or off by name, those not listed run
+	Fetcher          string          `json:"fetcher"`      // how pages are loaded, http or browser
+	BrowserPath      string          `json:"browser_path"` // the Chrome or Chromium binary of the browser fetcher, looked up on the PATH when empty
+	RenderWait       time.Duration   `json:"render_wait"`  // how long the browser fetcher waits for the network to go idle after the load event
+}
+
+// AnalyzerEnabled reports whether the analyzer with the given name runs
+func (o CrawlOptions) AnalyzerEnabled(name string) bool {
+	enabled, ok := o.Analyzers[name]
+	return !ok || enabled
+}
+
+// CrawlOptionsOverride holds per-URL changes to the default crawl options. Unset fields keep the default.
+type CrawlOptionsOverride struct {
+	TimeoutSeconds    *int                `json:"timeout_seconds,omitempty" binding:"omitempty,min=1,max=120"`
+	UserAgent         *string             `json:"user_agent,omitempty" binding:"omitempty,min=1,max=255"`
+	CheckBrokenLinks  *bool               `json:"check_broken_links,omitempty"`
+	CheckResources    *bool               `json:"check_resources,omitempty"`
+	RedirectHopLimit  *int                `json:"redirect_hop_limit,omitempty" binding:"omitempty,min=1,max=20"`
+	BrokenCategories  []LinkCheckCategory `json:"broken_categories,omitempty" binding:"omitempty,dive,oneof=broken forbidden rate_limited timeout dns_failure tls_error connection_error"`
+	LinkHostRules     []LinkHostRule      `json:"link_host_rules,omitempty" binding:"omitempty,max=100,dive"`
+	RateLimitRetries  *int                `json:"rate_limit_retries,omitempty" binding:"omitempty,min=0,max=5"`
+	MaxLinksToCheck   *int                `json:"max_links_to_check,omitempty" binding:"omitempty,min=0,max=1000"`
+	ConcurrentChecks  *int                `json:"concurrent_checks,omitempty" binding:"omitempty,min=1,max=20"`
+	Analyzers         map[string]bool     `json:"analyzers,omitempty" binding:"omitempty,max=50,dive,keys,min=1,max=50,endkeys"`
+	Fetcher           *string             `json:"fetcher,omitempty" binding:"omitempty,oneof=http browser"`
+	RenderWaitSeconds *int                `json:"render_wait_seconds,omitempty" binding:"omitempty,min=0,max=30"`
+}
+
+// Apply returns the options with the overridden fields replaced
+func (o *CrawlOptionsOverride) Apply(options CrawlOptions) CrawlOptions {
+	if o == nil {
+		return options
+	}
+	if o.TimeoutSeconds != nil {
+		options.Timeout = time.Duration(*o.TimeoutSeconds) * time.Second
+	}
+	if o.UserAgent != nil {
+		options.UserAgent = *o.UserAgent
+	}
+	if o.CheckBrokenLinks != nil {
+		options.CheckBrokenLinks = *o.CheckBrokenLinks
+	}
+	if o.CheckResources != nil {
+		options.CheckResources = *o.CheckResources
+	}
+	if o.RedirectHopLimit != nil {
+		options.RedirectHopLimit = *o.RedirectHopLimit
+	}
+	if o.BrokenCategories != nil {
+		options.LinkPolicy.BrokenCategories = o.BrokenCategories
+	}
+	if o.LinkHostRules != nil {
+		options.LinkPolicy.HostRules = o.LinkHostRules
+	}
+	if o.RateLimitRetries != nil {
+		options.LinkPolicy.RateLimitRetries = *o.RateLimitRetries
+	}
+	if o.MaxLinksToCheck != nil {
+		options.MaxLinksToCheck = *o.MaxLinksToCheck
+	}
+	if o.ConcurrentChecks != nil {
+		options.ConcurrentChecks = *o.ConcurrentChecks
+	}
+	if o.Analyzers != nil {
+		options.Analyzers = mergeAnalyzerSwitches(options.Analyzers, o.Analyzers)
+	}
+	if o.Fetcher != nil {
+		options.Fetcher = *o.Fetcher
+	}
+	if o.RenderWaitSeconds != nil {
+		options.RenderWait = time.Duration(*o.RenderWaitSeconds) * time.Second
+	}
+	return options
+}
+
+// Merge returns the overrides of o with the fields set in other taking precedence
+func (o *CrawlOptionsOverride) Merge(other *CrawlOptionsOverride) *CrawlOptionsOverride {
+	if o == nil {
+		return other
+	}
+	if other == nil {
+		return o
+	}
+
+	merged := *o
+	if other.TimeoutSeconds != nil {
+		merged.TimeoutSeconds = other.TimeoutSeconds
+	}
+	if other.UserAgent != nil {
+		merged.UserAgent = other.UserAgent
+	}
+	if other.CheckBrokenLinks != nil {
+		merged.CheckBrokenLinks = other.CheckBrokenLinks
+	}
+	if other.CheckResources != nil {
+		merged.CheckResources = other.CheckResources
+	}
+	if other.RedirectHopLimit != nil {
+		merged.RedirectHopLimit = other.RedirectHopLimit
+	}
+	if other.BrokenCategories != nil {
+		merged.BrokenCategories = other.BrokenCategories
+	}
+	if other.LinkHostRules != nil {
+		merged.LinkHostRules = other.LinkHostRules
+	}
+	if other.RateLimitRetries != nil {
+		merged.RateLimitRetries = other.RateLimitRetries
+	}
+	if other.MaxLinksToCheck != nil {
+		merged.MaxLinksToCheck = other.MaxLinksToCheck
+	}
+	if other.ConcurrentChecks != nil {
+		merged.ConcurrentChecks = other.ConcurrentChecks
+	}
+	if other.Analyzers != nil {
+		merged.Analyzers = mergeAnalyzerSwitches(o.Analyzers, other.Analyzers)
+	}
+	if other.Fetcher != nil {
+		merged.Fetcher = other.Fetcher
+	}
+	if other.RenderWaitSeconds != nil {
+		merged.RenderWaitSeconds = other.RenderWaitSeconds
+	}
+	return &merged
+}
+
+// returns a new map of analyzer switches with those of other taking precedence
+func mergeAnalyzerSwitches(switches map[string]bool, other map[string]bool) map[string]bool {
+	merged := make(map[string]bool, len(switches)+len(other))
+	for name, enabled := range switches {
+		merged[name] = enabled
+	}
+	for name, enabled := range other {
+		merged[name] = enabled
+	}
+	return merged
+}
+
 // Scan implements the sql.Scanner interface
-func (s *URLStatus) Scan(value interface{}) error {
-	if value == nil {
-		*s = StatusQueued
-		return nil
-	}
+func (o *CrawlOptionsOverride) Scan(value interface{}) error {
+	*o = CrawlOptionsOverride{}
 	switch v := value.(type) {
+	case nil:
+		return nil
 	case string:
-		*s = URLStatus(v)
+		return json.Unmarshal([]byte(v), o)
 	case []byte:
-		*s = URLStatus(v)
+		return json.Unmarshal(v, o)
 	default:
-		return fmt.Errorf("cannot scan %T into URLStatus", value)
+		return fmt.Errorf("cannot scan %T into CrawlOptionsOverride", value)
 	}
-	return nil
 }
 
 // Value implements the driver.Valuer interface
-func (s URLStatus) Value() (driver.Value, error) {
-	return string(s), nil
+func (o CrawlOptionsOverride) Value() (driver.Value, error) {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
 }
 
-// CrawlStatus represents the current status of a crawl operation
-type CrawlStatus string
+// LinkInfo represents information about a link found on the page
+type LinkInfo struct {
+	URL        string `json:"url"`
+	Text       string `json:"text"`
+	Rel        string `json:"rel"`
+	IsInternal bool   `json:"is_internal"`
+	IsExternal bool   `json:"is_external"`
+}
 
-const (
-	CrawlStatusStarted   CrawlStatus = "started"
-	CrawlStatusFetching  CrawlStatus = "fetching"
-	CrawlStatusParsing   CrawlStatus = "parsing"
-	CrawlStatusAnalyzing CrawlStatus = "analyzing"
-	CrawlStatusChecking  CrawlStatus = "checking_links"
-	CrawlStatusCompleted CrawlStatus = "completed"
-	CrawlStatusFailed    CrawlStatus = "failed"
-)
+// HTMLInfo represents parsed HTML structure information
+type HTMLInfo struct {
+	Title          string            `json:"title"`
+	Headings       map[string]int    `json:"headings"`
+	Links          []LinkInfo        `json:"links"`
+	HasLoginForm   bool              `json:"has_login_form"`
+	Forms          []CrawlForm       `json:"forms"`
+	SEO            SEOMetadata       `json:"seo"`
+	StructuredData StructuredData    `json:"structured_data"`
+	Resources      []CrawlResource   `json:"resources"`
+	MetaTags       map[string]string `json:"meta_tags"`
+}
 
-// URL represents a URL to be crawled (Database model)
-type URL struct {
-	ID           int       `json:"id" db:"id"`
-	URL          string    `json:"url" db:"url"`
-	Status       URLStatus `json:"status" db:"status"`
-	ErrorMessage *string   `json:"error_message,omitempty" db:"error_message"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+// JobEvent is a status, message or progress change of a crawl job, as pushed to event stream clients.
+// IDs are "<boot>-<seq>", the sequence number increasing with every event published since this
+// server instance started. Snapshots of the current state carry no ID.
+type JobEvent struct {
+	ID        string      `json:"id,omitempty"`
+	URLID     int         `json:"url_id"`
+	URL       string      `json:"url"`
+	Status    CrawlStatus `json:"status"`
+	Message   string      `json:"message"`
+	Progress  float64     `json:"progress"`
+	Timestamp time.Time   `json:"timestamp"`
 }
 
-// CrawlResult represents the result of crawling a URL (Database model)
-type CrawlResult struct {
-	ID               int       `json:"id" db:"id"`
-	URLID            int       `json:"url_id" db:"url_id"`
-	Title            *string   `json:"title" db:"title"`
-	HTMLVersion      *string   `json:"html_version" db:"html_version"`
-	H1Count          int       `json:"h1_count" db:"h1_count"`
-	H2Count          int       `json:"h2_count" db:"h2_count"`
-	H3Count          int       `json:"h3_count" db:"h3_count"`
-	H4Count          int       `json:"h4_count" db:"h4_count"`
-	H5Count          int       `json:"h5_count" db:"h5_count"`
-	H6Count          int       `json:"h6_count" db:"h6_count"`
-	InternalLinks    int       `json:"internal_links" db:"internal_links"`
-	ExternalLinks    int       `json:"external_links" db:"external_links"`
-	BrokenLinksCount int       `json:"broken_links_count" db:"broken_links_count"`
-	HasLoginForm     bool      `json:"has_login_form" db:"has_login_form"`
-	CrawledAt        time.Time `json:"crawled_at" db:"crawled_at"`
+// CrawlEvent describes how a crawl of a URL ended
+type CrawlEvent struct {
+	URLID        int
+	URL          string
+	Status       URLStatus
+	ErrorMessage *string
+
+	// what a completed crawl saved, for site crawls that of the root page
+	Result      *CrawlResult
+	BrokenLinks []BrokenLink
+	Links       []Link
 }
 
-// BrokenLink represents a broken link found during crawling (Database model)
-type BrokenLink struct {
-	ID              int    `json:"id" db:"id"`
-	CrawlResultID   int    `json:"crawl_result_id" db:"crawl_result_id"`
-	URL             string `json:"url" db:"url"`
-	StatusCode      int    `json:"status_code" db:"status_code"`
-	ErrorMessage    string `json:"error_message" db:"error_message"`
-	LinkText        string `json:"link_text" db:"link_text"`
-	IsInternal      bool   `json:"is_internal" db:"is_internal"`
+// WebhookPayload is the JSON body POSTed to webhooks
+type WebhookPayload struct {
+	Event         string               `json:"event"`
+	OccurredAt    time.Time            `json:"occurred_at"`
+	URLID         int                  `json:"url_id"`
+	URL           string               `json:"url"`
+	Status        URLStatus            `json:"status"`
+	ErrorMessage  *string              `json:"error_message,omitempty"`
+	CrawlResultID *int                 `json:"crawl_result_id,omitempty"`
+	Summary       *WebhookCrawlSummary `json:"summary,omitempty"`
+	BrokenLinks   *BrokenLinkDelta     `json:"broken_links,omitempty"`
 }
 
-// User represents an API user
-type User struct {
-	ID        int       `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	APIKey    string    `json:"api_key" db:"api_key"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+// WebhookCrawlSummary holds the counts of a completed crawl
+type WebhookCrawlSummary struct {
+	Title            *string `json:"title"`
+	InternalLinks    int     `json:"internal_links"`
+	ExternalLinks    int     `json:"external_links"`
+	BrokenLinksCount int     `json:"broken_links_count"`
+	HasLoginForm     bool    `json:"has_login_form"`
 }
 
-// URLWithResult combines URL with its crawl result
-type URLWithResult struct {
-	URL
-	CrawlResult *CrawlResult `json:"crawl_result,omitempty"`
+// BrokenLinkDelta lists the broken links that appeared or disappeared since the previous crawl
+type BrokenLinkDelta struct {
+	PreviousResultID *int         `json:"previous_result_id,omitempty"`
+	NewlyBroken      []BrokenLink `json:"newly_broken"`
+	NewlyFixed       []BrokenLink `json:"newly_fixed"`
+	NoLongerLinked   []BrokenLink `json:"no_longer_linked"`
+}
+
+// CrawlJob represents an active crawl job
+type CrawlJob struct {
+	ID        int                   `json:"id"`
+	QueueID   int                   `json:"queue_id,omitempty"`
+	WorkerID  string                `json:"worker_id,omitempty"` // the worker holding the job's lease while it runs
+	URL       string                `json:"url"`
+	Status    CrawlStatus           `json:"status"`
+	Progress  float64               `json:"progress"`
+	Message   string                `json:"message"`
+	StartTime time.Time             `json:"start_time"`
+	EndTime   *time.Time            `json:"end_time,omitempty"`
+	Result    *CrawlJobResult       `json:"result,omitempty"`
+	SiteCrawl *SiteCrawlRequest     `json:"site_crawl,omitempty"`
+	Options   *CrawlOptionsOverride `json:"options,omitempty"`
+	Context   context.Context       `json:"-"` // Cancelled when the job is stopped
+	Cancel    context.CancelFunc    `json:"-"`
 }
 
 // ============================================================================
-// Business Logic Types (used by crawler and services)
+// API Request/Response Types
 // ============================================================================
 
-// CrawlJobResult represents the complete result of crawling a webpage
-type CrawlJobResult struct {
-	URL              string                 `json:"url"`
-	Title            string                 `json:"title"`
-	HTMLVersion      string                 `json:"html_version"`
-	HeadingCounts    map[string]int         `json:"heading_counts"`
-	InternalLinks    int                    `json:"internal_links"`
-	ExternalLinks    int                    `json:"external_links"`
-	BrokenLinks      []CrawlBrokenLink      `json:"broken_links"`
-	HasLoginForm     bool                   `json:"has_login_form"`
-	CrawlDuration    time.Duration          `json:"crawl_duration"`
-	Error            error                  `json:"error,omitempty"`
-	StatusCode       int                    `json:"status_code"`
-	ContentLength    int64                  `json:"content_length"`
-	ResponseHeaders  map[string]string      `json:"response_headers"`
+// CreateURLRequest represents the request to create a new URL
+type CreateURLRequest struct {
+	URL     string                `json:"url" binding:"required,url"`
+	Options *CrawlOptionsOverride `json:"options,omitempty"`
 }
 
-// CrawlBrokenLink represents a broken link found during crawling
-type CrawlBrokenLink struct {
-	URL          string `json:"url"`
-	StatusCode   int    `json:"status_code"`
-	ErrorMessage string `json:"error_message"`
-	LinkText     string `json:"link_text"`
-	IsInternal   bool   `json:"is_internal"`
+// SitemapImportRequest represents the request to add the pages a sitemap or sitemap index lists.
+// The sitemap is given by its URL or uploaded as the multipart file "file", XML or gzip compressed.
+type SitemapImportRequest struct {
+	URL        string                `json:"url" form:"url" binding:"omitempty,url"`
+	MaxURLs    int                   `json:"max_urls" form:"max_urls" binding:"min=0,max=50000"` // default 1000
+	StartCrawl bool                  `json:"start_crawl" form:"start_crawl"`                     // queue a crawl of every URL the import adds
+	Options    *CrawlOptionsOverride `json:"options,omitempty" form:"-"`                         // crawl options of the URLs the import adds
 }
 
-// CrawlOptions contains configuration for the crawler
-type CrawlOptions struct {
-	Timeout           time.Duration `json:"timeout"`
-	MaxRedirects      int           `json:"max_redirects"`
-	UserAgent         string        `json:"user_agent"`
-	FollowRobotsTxt   bool          `json:"follow_robots_txt"`
-	CheckBrokenLinks  bool          `json:"check_broken_links"`
-	MaxLinksToCheck   int           `json:"max_links_to_check"`
-	ConcurrentChecks  int           `json:"concurrent_checks"`
-	RespectRateLimit  bool          `json:"respect_rate_limit"`
-	RateLimitDelay    time.Duration `json:"rate_limit_delay"`
+// SitemapImportResult reports what a sitemap import added
+type SitemapImportResult struct {
+	Sitemaps   []string `json:"sitemaps"`   // the sitemap files read
+	Found      int      `json:"found"`      // URLs listed in them
+	Created    int      `json:"created"`    // URLs added
+	Existing   int      `json:"existing"`   // URLs that had been added before
+	Duplicates int      `json:"duplicates"` // URLs listed more than once
+	Invalid    int      `json:"invalid"`    // entries that are not absolute http or https URLs of at most 768 characters
+	Queued     int      `json:"queued"`     // crawls started for the added URLs
+	URLIDs     []int    `json:"url_ids"`    // IDs of the added URLs
+	Truncated  bool     `json:"truncated"`  // max_urls, or the limit on sitemap files, cut the import short
+	Errors     []string `json:"errors"`     // sitemaps that could not be read and URLs that could not be added or crawled
 }
 
-// LinkInfo represents information about a link found on the page
-type LinkInfo struct {
-	URL        string `json:"url"`
-	Text       string `json:"text"`
-	IsInternal bool   `json:"is_internal"`
-	IsExternal bool   `json:"is_external"`
+// CreateURLsBatchRequest represents the JSON form of a batch of URLs to add. A bare JSON array
+// of URLs is accepted too, as are CSV (the first column, after an optional "url" header) and
+// newline-delimited text.
+type CreateURLsBatchRequest struct {
+	URLs       []string              `json:"urls"`
+	StartCrawl bool                  `json:"start_crawl"`       // queue a crawl of every URL the batch adds
+	Options    *CrawlOptionsOverride `json:"options,omitempty"` // crawl options of the URLs the batch adds
 }
 
-// HTMLInfo represents parsed HTML structure information
-type HTMLInfo struct {
-	Title        string            `json:"title"`
-	HTMLVersion  string            `json:"html_version"`
-	Headings     map[string]int    `json:"headings"`
-	Links        []LinkInfo        `json:"links"`
-	HasLoginForm bool              `json:"has_login_form"`
-	MetaTags     map[string]string `json:"meta_tags"`
+// BatchURLStatus is what became of one entry of a batch of URLs to add
+type BatchURLStatus string
+
+const (
+	BatchURLCreated   BatchURLStatus = "created"
+	BatchURLDuplicate BatchURLStatus = "duplicate" // added before, or listed earlier in the batch
+	BatchURLInvalid   BatchURLStatus = "invalid"
+)
+
+// BatchURLResult reports what became of one entry of a batch of URLs to add
+type BatchURLResult struct {
+	Input  string         `json:"input"`         // the entry as given
+	URL    string         `json:"url,omitempty"` // the URL added, the entry without surrounding whitespace
+	Status BatchURLStatus `json:"status"`
+	URLID  int            `json:"url_id,omitempty"` // the added URL, or the one it duplicates
+	Error  string         `json:"error,omitempty"`  // why the entry is invalid or its crawl did not start
 }
 
-// CrawlJob represents an active crawl job
-type CrawlJob struct {
-	ID        int               `json:"id"`
-	URL       string            `json:"url"`
-	Status    CrawlStatus       `json:"status"`
-	Progress  float64           `json:"progress"`
-	Message   string            `json:"message"`
-	StartTime time.Time         `json:"start_time"`
-	EndTime   *time.Time        `json:"end_time,omitempty"`
-	Result    *CrawlJobResult   `json:"result,omitempty"`
-	Cancel    chan struct{}     `json:"-"` // Don't serialize channel
+// CreateURLsBatchResult reports what a batch of URLs added, entry by entry in the order given
+type CreateURLsBatchResult struct {
+	Total      int              `json:"total"`
+	Created    int              `json:"created"`
+	Duplicates int              `json:"duplicates"`
+	Invalid    int              `json:"invalid"`
+	Queued     int              `json:"queued"` // crawls started for the added URLs
+	Results    []BatchURLResult `json:"results"`
 }
 
-// ============================================================================
-// API Request/Response Types
-// ============================================================================
+// BatchCrawlRequest selects the URLs a batch crawl operation applies to.
+// Exactly one of ids and filter must be given, an empty filter matches every URL.
+type BatchCrawlRequest struct {
+	IDs    []int      `json:"ids,omitempty" binding:"omitempty,dive,min=1"`
+	Filter *URLFilter `json:"filter,omitempty"`
+}
 
-// CreateURLRequest represents the request to create a new URL
-type CreateURLRequest struct {
-	URL string `json:"url" binding:"required,url"`
+// BatchCrawlStatus is the outcome of a batch crawl operation for one URL
+type BatchCrawlStatus string
+
+const (
+	BatchCrawlStarted        BatchCrawlStatus = "started"
+	BatchCrawlStopped        BatchCrawlStatus = "stopped"
+	BatchCrawlRestarted      BatchCrawlStatus = "restarted"
+	BatchCrawlAlreadyRunning BatchCrawlStatus = "already_running"
+	BatchCrawlNotRunning     BatchCrawlStatus = "not_running"
+	BatchCrawlNotFound       BatchCrawlStatus = "not_found"
+	BatchCrawlFailed         BatchCrawlStatus = "failed"
+)
+
+// BatchCrawlItem reports the outcome of a batch crawl operation for one URL
+type BatchCrawlItem struct {
+	URLID  int              `json:"url_id"`
+	Status BatchCrawlStatus `json:"status"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// BatchCrawlResult reports the outcome of a batch crawl operation, URL by URL
+type BatchCrawlResult struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"` // URLs started, stopped or restarted
+	Results   []BatchCrawlItem `json:"results"`
+}
+
+// StartCrawlRequest represents the optional request body when starting a crawl
+type StartCrawlRequest struct {
+	Options *CrawlOptionsOverride `json:"options,omitempty"`
+}
+
+// SiteCrawlRequest represents the request to crawl a site starting from a URL
+type SiteCrawlRequest struct {
+	MaxDepth int `json:"max_depth" binding:"min=0,max=10"`
+	MaxPages int `json:"max_pages" binding:"min=0,max=1000"`
+}
+
+// CrawlResultNode represents a page of a site crawl together with the pages discovered from it
+type CrawlResultNode struct {
+	CrawlResult
+	Children []*CrawlResultNode `json:"children"`
 }
 
 // PaginatedResponse represents a paginated API response
@@ -198,13 +1633,227 @@ type PaginatedResponse struct {
 }
 
 // URLFilter represents filters for URL listing
+// The JSON form selects the URLs of batch operations, which neither page nor sort.
 type URLFilter struct {
-	Status    *URLStatus `form:"status"`
-	Search    string     `form:"search"`
-	Page      int        `form:"page,default=1"`
-	PageSize  int        `form:"page_size,default=10"`
-	SortBy    string     `form:"sort_by,default=created_at"`
-	SortOrder string     `form:"sort_order,default=desc"`
+	Status    *URLStatus `form:"status" json:"status,omitempty"`
+	Search    string     `form:"search" json:"search,omitempty"`
+	Page      int        `form:"page,default=1" json:"-"`
+	PageSize  int        `form:"page_size,default=10" json:"-"`
+	SortBy    string     `form:"sort_by,default=created_at" json:"-"`
+	SortOrder string     `form:"sort_order,default=desc" json:"-"`
+	Rules     string     `form:"rules" json:"rules,omitempty" binding:"omitempty,oneof=passing failing"`            // the outcome of the custom rules on the latest crawl
+	RuleID    int        `form:"rule_id" json:"rule_id,omitempty" binding:"omitempty,min=1,excluded_without=Rules"` // narrows rules to the outcome of one rule, so it needs rules
+}
+
+// ScheduleRequest represents the request to change a crawl schedule.
+// Exactly one of cron_expr (five fields, UTC) and interval_seconds must be given.
+type ScheduleRequest struct {
+	CronExpr        *string `json:"cron_expr,omitempty" binding:"omitempty,max=100"`
+	IntervalSeconds *int    `json:"interval_seconds,omitempty" binding:"omitempty,min=60"`
+	Enabled         *bool   `json:"enabled,omitempty"`
+}
+
+// Validate checks that the request describes exactly one valid schedule
+func (r ScheduleRequest) Validate() error {
+	if (r.CronExpr == nil) == (r.IntervalSeconds == nil) {
+		return fmt.Errorf("exactly one of cron_expr and interval_seconds is required")
+	}
+	if r.CronExpr != nil {
+		if _, err := cron.Parse(*r.CronExpr); err != nil {
+			return fmt.Errorf("invalid cron_expr: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateScheduleRequest represents the request to schedule recurring crawls of a URL
+type CreateScheduleRequest struct {
+	URLID int `json:"url_id" binding:"required,min=1"`
+	ScheduleRequest
+}
+
+// ScheduleFilter represents filters for schedule listing
+type ScheduleFilter struct {
+	URLID int `form:"url_id"`
+}
+
+// CreateWebhookRequest represents the request to register a webhook.
+// Events default to every crawl event and a secret is generated when none is given.
+type CreateWebhookRequest struct {
+	TargetURL string   `json:"target_url" binding:"required,url,max=2048"`
+	Events    []string `json:"events" binding:"omitempty,dive,oneof=crawl.completed crawl.failed crawl.blocked"`
+	URLID     *int     `json:"url_id" binding:"omitempty,min=1"`
+	Secret    string   `json:"secret" binding:"omitempty,min=16,max=255"`
+}
+
+// RuleRequest represents the request to create or replace a custom rule. Selector rules need a
+// selector, title and text rules a pattern and element count rules a count, compared with operator
+// (default eq). Severity defaults to warning.
+type RuleRequest struct {
+	Name     string          `json:"name" binding:"required,max=255"`
+	Type     RuleType        `json:"type" binding:"required,oneof=selector_exists selector_absent element_count title_matches text_contains text_absent"`
+	Selector string          `json:"selector" binding:"max=1000"`
+	Pattern  string          `json:"pattern" binding:"max=1000"`
+	Operator string          `json:"operator" binding:"omitempty,oneof=eq ne lt lte gt gte"`
+	Count    *int            `json:"count" binding:"omitempty,min=0"`
+	URLID    *int            `json:"url_id" binding:"omitempty,min=1"`
+	Severity FindingSeverity `json:"severity" binding:"omitempty,oneof=info warning error"`
+	Enabled  *bool           `json:"enabled"`
+}
+
+// Validate checks that the request has what its type needs and that its selector and pattern compile
+func (r RuleRequest) Validate() error {
+	switch r.Type {
+	case RuleSelectorExists, RuleSelectorAbsent, RuleElementCount:
+		if strings.TrimSpace(r.Selector) == "" {
+			return fmt.Errorf("selector is required for %s rules", r.Type)
+		}
+		if _, err := cascadia.Compile(r.Selector); err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+		if r.Type == RuleElementCount && r.Count == nil {
+			return fmt.Errorf("count is required for element_count rules")
+		}
+	case RuleTitleMatches:
+		if r.Pattern == "" {
+			return fmt.Errorf("pattern is required for title_matches rules")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case RuleTextContains, RuleTextAbsent:
+		if strings.TrimSpace(r.Pattern) == "" {
+			return fmt.Errorf("pattern is required for %s rules", r.Type)
+		}
+	}
+	return nil
+}
+
+// Apply sets the fields of a rule from the request, filling in the defaults
+func (r RuleRequest) Apply(rule *Rule) {
+	rule.Name = r.Name
+	rule.Type = r.Type
+	rule.URLID = r.URLID
+	rule.Selector = ""
+	rule.Pattern = ""
+	rule.Operator = ""
+	rule.Count = 0
+
+	switch r.Type {
+	case RuleSelectorExists, RuleSelectorAbsent:
+		rule.Selector = r.Selector
+	case RuleElementCount:
+		rule.Selector = r.Selector
+		rule.Operator = r.Operator
+		if rule.Operator == "" {
+			rule.Operator = RuleOperatorEqual
+		}
+		if r.Count != nil {
+			rule.Count = *r.Count
+		}
+	default:
+		rule.Pattern = r.Pattern
+	}
+
+	rule.Severity = r.Severity
+	if rule.Severity == "" {
+		rule.Severity = SeverityWarning
+	}
+	rule.Enabled = true
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+}
+
+// RuleFilter represents filters for rule listing
+type RuleFilter struct {
+	URLID int `form:"url_id"`
+}
+
+// RuleResultFilter selects the rule results of a crawl result of a URL. Without a result ID the latest crawl is used.
+type RuleResultFilter struct {
+	ResultID int   `form:"result_id"`
+	Passed   *bool `form:"passed"`
+}
+
+// DeliveryFilter represents filters and pagination for the delivery log of a webhook
+type DeliveryFilter struct {
+	Status   *DeliveryStatus `form:"status"`
+	Page     int             `form:"page,default=1"`
+	PageSize int             `form:"page_size,default=20"`
+}
+
+// CrawlResultFilter selects a crawl result of a URL. Without a result ID the latest crawl is used.
+type CrawlResultFilter struct {
+	ResultID int `form:"result_id"`
+}
+
+// FindingFilter selects the findings of a crawl result of a URL. Without a result ID the latest crawl is used.
+type FindingFilter struct {
+	ResultID int              `form:"result_id"`
+	Category string           `form:"category" binding:"omitempty,max=50"` // the name of the analyzer that reported the finding
+	Severity *FindingSeverity `form:"severity" binding:"omitempty,oneof=info warning error"`
+}
+
+// ResourceFilter selects the resources of a crawl result of a URL. Without a result ID the latest crawl is used.
+type ResourceFilter struct {
+	ResultID int                `form:"result_id"`
+	Type     *ResourceType      `form:"type" binding:"omitempty,oneof=script stylesheet image font iframe"`
+	Status   *LinkStatus        `form:"status" binding:"omitempty,oneof=ok broken blocked unchecked unverified"`
+	Category *LinkCheckCategory `form:"category" binding:"omitempty,oneof=ok broken forbidden rate_limited timeout dns_failure tls_error connection_error ignored"`
+}
+
+// ExpiringCertificateFilter selects URLs whose certificate expires within the given number of days
+type ExpiringCertificateFilter struct {
+	Days int `form:"days,default=30" binding:"min=0,max=365"`
+}
+
+// LinkFilter represents filters and pagination for the link inventory of a URL.
+// Without a result ID the links of the latest crawl are listed.
+type LinkFilter struct {
+	ResultID   int                `form:"result_id"`
+	Type       string             `form:"type" binding:"omitempty,oneof=internal external"`
+	Status     *LinkStatus        `form:"status" binding:"omitempty,oneof=ok broken blocked unchecked unverified"`
+	Category   *LinkCheckCategory `form:"category" binding:"omitempty,oneof=ok broken forbidden rate_limited timeout dns_failure tls_error connection_error ignored"`
+	Redirected *bool              `form:"redirected"`
+	Page       int                `form:"page,default=1"`
+	PageSize   int                `form:"page_size,default=50"`
+}
+
+// HistoryFilter represents pagination for the crawl history of a URL
+type HistoryFilter struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=10"`
+}
+
+// DiffFilter selects the two crawl results to compare. Omitted IDs default to the latest run and the one before it.
+type DiffFilter struct {
+	From int `form:"from"`
+	To   int `form:"to"`
+}
+
+// CrawlHistoryEntry represents a past crawl of a URL together with the broken links it found
+type CrawlHistoryEntry struct {
+	CrawlResult
+	BrokenLinks []BrokenLink `json:"broken_links"`
+}
+
+// ValueChange represents a value that differs between two crawls
+type ValueChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// CrawlDiff represents what changed between two crawls of a URL
+type CrawlDiff struct {
+	FromResultID   int                    `json:"from_result_id"`
+	ToResultID     int                    `json:"to_result_id"`
+	FromCrawledAt  time.Time              `json:"from_crawled_at"`
+	ToCrawledAt    time.Time              `json:"to_crawled_at"`
+	Changes        map[string]ValueChange `json:"changes"`
+	NewlyBroken    []BrokenLink           `json:"newly_broken"`
+	NewlyFixed     []BrokenLink           `json:"newly_fixed"`
+	NoLongerLinked []BrokenLink           `json:"no_longer_linked"` // broken before, no longer on the page
 }
 
 // ============================================================================
@@ -228,14 +1877,164 @@ func DefaultCrawlOptions() CrawlOptions {
 	return CrawlOptions{
 		Timeout:          30 * time.Second,
 		MaxRedirects:     5,
+		RedirectHopLimit: 2,
 		UserAgent:        "URL-Analyzer-Bot/1.0 (Educational Purpose)",
 		FollowRobotsTxt:  true,
 		CheckBrokenLinks: true,
+		LinkPolicy: LinkCheckPolicy{
+			BrokenCategories: []LinkCheckCategory{LinkCheckBroken, LinkCheckTimeout, LinkCheckDNSFailure, LinkCheckTLSError, LinkCheckConnectionError},
+			HostRules:        []LinkHostRule{},
+			RateLimitRetries: 1,
+			MaxRetryAfter:    10 * time.Second,
+		},
 		MaxLinksToCheck:  100,
 		ConcurrentChecks: 5,
 		RespectRateLimit: true,
 		RateLimitDelay:   1 * time.Second,
+		MaxDepth:         2,
+		MaxPages:         50,
+		Fetcher:          FetcherHTTP,
+		RenderWait:       3 * time.Second,
+	}
+}
+
+// DiffCrawlResults compares two crawls of a URL. Only the fields that changed are listed,
+// broken links are matched by their URL. A link broken before counts as fixed only when
+// toLinks, the links the newer crawl found, has it checked and working; one the newer crawl
+// no longer links to is listed as no longer linked instead.
+func DiffCrawlResults(from, to *CrawlResult, fromBroken, toBroken []BrokenLink, toLinks []Link) *CrawlDiff {
+	diff := &CrawlDiff{
+		FromResultID:   from.ID,
+		ToResultID:     to.ID,
+		FromCrawledAt:  from.CrawledAt,
+		ToCrawledAt:    to.CrawledAt,
+		Changes:        make(map[string]ValueChange),
+		NewlyBroken:    []BrokenLink{},
+		NewlyFixed:     []BrokenLink{},
+		NoLongerLinked: []BrokenLink{},
+	}
+
+	compare := func(field string, fromValue, toValue interface{}) {
+		if fromValue != toValue {
+			diff.Changes[field] = ValueChange{From: fromValue, To: toValue}
+		}
+	}
+
+	stringValue := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+
+	compare("title", stringValue(from.Title), stringValue(to.Title))
+	compare("html_version", stringValue(from.HTMLVersion), stringValue(to.HTMLVersion))
+	compare("doctype", stringValue(from.Doctype), stringValue(to.Doctype))
+	compare("h1_count", from.H1Count, to.H1Count)
+	compare("h2_count", from.H2Count, to.H2Count)
+	compare("h3_count", from.H3Count, to.H3Count)
+	compare("h4_count", from.H4Count, to.H4Count)
+	compare("h5_count", from.H5Count, to.H5Count)
+	compare("h6_count", from.H6Count, to.H6Count)
+	compare("internal_links", from.InternalLinks, to.InternalLinks)
+	compare("external_links", from.ExternalLinks, to.ExternalLinks)
+	compare("broken_links_count", from.BrokenLinksCount, to.BrokenLinksCount)
+	compare("has_login_form", from.HasLoginForm, to.HasLoginForm)
+	compare("broken_resources", brokenResources(from.Resources), brokenResources(to.Resources))
+	compare("structured_data_types", structuredDataTypes(from.StructuredData), structuredDataTypes(to.StructuredData))
+
+	wasBroken := make(map[string]bool, len(fromBroken))
+	for _, link := range fromBroken {
+		wasBroken[link.URL] = true
+	}
+	isBroken := make(map[string]bool, len(toBroken))
+	for _, link := range toBroken {
+		if isBroken[link.URL] {
+			continue
+		}
+		isBroken[link.URL] = true
+		if !wasBroken[link.URL] {
+			diff.NewlyBroken = append(diff.NewlyBroken, link)
+		}
+	}
+
+	// A link the newer crawl found but did not get to check, or could not tell about, is neither
+	linked := make(map[string]bool, len(toLinks))
+	working := make(map[string]bool, len(toLinks))
+	for _, link := range toLinks {
+		linked[link.URL] = true
+		if link.Status == LinkStatusOK {
+			working[link.URL] = true
+		}
+	}
+	listed := make(map[string]bool, len(fromBroken))
+	for _, link := range fromBroken {
+		if listed[link.URL] || isBroken[link.URL] {
+			continue
+		}
+		listed[link.URL] = true
+		switch {
+		case working[link.URL]:
+			diff.NewlyFixed = append(diff.NewlyFixed, link)
+		case !linked[link.URL]:
+			diff.NoLongerLinked = append(diff.NoLongerLinked, link)
+		}
+	}
+
+	return diff
+}
+
+// BrokenLinkURLs returns the URLs of broken links, each once
+func BrokenLinkURLs(links []BrokenLink) []string {
+	seen := make(map[string]bool, len(links))
+	urls := []string{}
+	for _, link := range links {
+		if !seen[link.URL] {
+			seen[link.URL] = true
+			urls = append(urls, link.URL)
+		}
+	}
+	return urls
+}
+
+// returns the number of broken resources of a crawl result, 0 when they were not recorded
+func brokenResources(summary *ResourceSummary) int {
+	if summary == nil {
+		return 0
+	}
+	return summary.Broken
+}
+
+// lists the structured data types of a crawl result for comparison, e.g. "Offer, Product"
+func structuredDataTypes(data *StructuredData) string {
+	if data == nil {
+		return ""
+	}
+	return strings.Join(data.Types, ", ")
+}
+
+// BuildCrawlResultTree arranges the pages of a site crawl into a tree rooted at the page without a parent
+func BuildCrawlResultTree(results []CrawlResult) *CrawlResultNode {
+	nodes := make(map[int]*CrawlResultNode, len(results))
+	for i := range results {
+		nodes[results[i].ID] = &CrawlResultNode{CrawlResult: results[i], Children: []*CrawlResultNode{}}
+	}
+
+	var root *CrawlResultNode
+	for i := range results {
+		node := nodes[results[i].ID]
+		if results[i].ParentID == nil {
+			if root == nil {
+				root = node
+			}
+			continue
+		}
+		if parent, exists := nodes[*results[i].ParentID]; exists {
+			parent.Children = append(parent.Children, node)
+		}
 	}
+
+	return root
 }
 
 // ProgressCallback is called to report crawl progress
@@ -248,26 +2047,51 @@ type ProgressCallback func(status CrawlStatus, message string, progress float64)
 // ToCrawlResult converts CrawlJobResult to database CrawlResult
 func (cjr *CrawlJobResult) ToCrawlResult(urlID int) *CrawlResult {
 	result := &CrawlResult{
-		URLID:            urlID,
-		H1Count:          cjr.HeadingCounts["h1"],
-		H2Count:          cjr.HeadingCounts["h2"],
-		H3Count:          cjr.HeadingCounts["h3"],
-		H4Count:          cjr.HeadingCounts["h4"],
-		H5Count:          cjr.HeadingCounts["h5"],
-		H6Count:          cjr.HeadingCounts["h6"],
-		InternalLinks:    cjr.InternalLinks,
-		ExternalLinks:    cjr.ExternalLinks,
-		BrokenLinksCount: len(cjr.BrokenLinks),
-		HasLoginForm:     cjr.HasLoginForm,
-	}
-	
+		URLID:                urlID,
+		H1Count:              cjr.HeadingCounts["h1"],
+		H2Count:              cjr.HeadingCounts["h2"],
+		H3Count:              cjr.HeadingCounts["h3"],
+		H4Count:              cjr.HeadingCounts["h4"],
+		H5Count:              cjr.HeadingCounts["h5"],
+		H6Count:              cjr.HeadingCounts["h6"],
+		InternalLinks:        cjr.InternalLinks,
+		ExternalLinks:        cjr.ExternalLinks,
+		BrokenLinksCount:     len(cjr.BrokenLinks),
+		LinksBlockedByRobots: cjr.LinksBlockedByRobots,
+		HasLoginForm:         cjr.HasLoginForm,
+		SEO:                  cjr.SEO,
+		StructuredData:       cjr.StructuredData,
+		Resources:            cjr.ResourceSummary,
+		RedirectChain:        cjr.RedirectChain,
+		Analyzers:            cjr.Analyzers,
+		Fetcher:              cjr.Fetcher,
+		Sitemap:              cjr.Sitemap,
+	}
+
+	for _, ruleResult := range cjr.RuleResults {
+		if ruleResult.Passed {
+			result.RulesPassed++
+		} else {
+			result.RulesFailed++
+		}
+	}
+
 	if cjr.Title != "" {
 		result.Title = &cjr.Title
 	}
 	if cjr.HTMLVersion != "" {
 		result.HTMLVersion = &cjr.HTMLVersion
 	}
-	
+	if cjr.Doctype != "" {
+		result.Doctype = &cjr.Doctype
+	}
+	if cjr.DocumentMode != "" {
+		result.DocumentMode = &cjr.DocumentMode
+	}
+	if cjr.URL != "" {
+		result.PageURL = &cjr.URL
+	}
+
 	return result
 }
 
@@ -283,6 +2107,136 @@ func (cjr *CrawlJobResult) ToBrokenLinks(crawlResultID int) []BrokenLink {
 			LinkText:      bl.LinkText,
 			IsInternal:    bl.IsInternal,
 		}
+		if bl.Category != "" {
+			category := bl.Category
+			brokenLinks[i].Category = &category
+		}
 	}
 	return brokenLinks
-}
\ No newline at end of file
+}
+
+// ToLinks converts the CrawlLink slice to a database Link slice
+func (cjr *CrawlJobResult) ToLinks(crawlResultID int) []Link {
+	links := make([]Link, len(cjr.Links))
+	for i, cl := range cjr.Links {
+		links[i] = Link{
+			CrawlResultID: crawlResultID,
+			URL:           cl.URL,
+			AnchorText:    cl.Text,
+			Rel:           cl.Rel,
+			IsInternal:    cl.IsInternal,
+			Status:        cl.Status,
+			Redirects:     cl.Redirects,
+		}
+		if cl.Category != "" {
+			category := cl.Category
+			links[i].Category = &category
+		}
+		if cl.StatusCode != 0 {
+			statusCode := cl.StatusCode
+			links[i].StatusCode = &statusCode
+		}
+		if cl.ErrorMessage != "" {
+			errorMessage := cl.ErrorMessage
+			links[i].ErrorMessage = &errorMessage
+		}
+	}
+	return links
+}
+
+// ToResources converts the CrawlResource slice to a database PageResource slice
+func (cjr *CrawlJobResult) ToResources(crawlResultID int) []PageResource {
+	resources := make([]PageResource, len(cjr.Resources))
+	for i, cr := range cjr.Resources {
+		resources[i] = PageResource{
+			CrawlResultID: crawlResultID,
+			URL:           cr.URL,
+			Type:          cr.Type,
+			IsThirdParty:  cr.IsThirdParty,
+			Status:        cr.Status,
+		}
+		if cr.Category != "" {
+			category := cr.Category
+			resources[i].Category = &category
+		}
+		if cr.StatusCode != 0 {
+			statusCode := cr.StatusCode
+			resources[i].StatusCode = &statusCode
+		}
+		if cr.Size != 0 {
+			size := cr.Size
+			resources[i].Size = &size
+		}
+		if cr.ContentType != "" {
+			contentType := cr.ContentType
+			resources[i].ContentType = &contentType
+		}
+		if cr.ErrorMessage != "" {
+			errorMessage := cr.ErrorMessage
+			resources[i].ErrorMessage = &errorMessage
+		}
+	}
+	return resources
+}
+
+// ToSecurityReport returns the security report of the crawl for storage, nil when there is none
+func (cjr *CrawlJobResult) ToSecurityReport(crawlResultID int) *SecurityReport {
+	if cjr.Security == nil {
+		return nil
+	}
+	report := *cjr.Security
+	report.CrawlResultID = crawlResultID
+	return &report
+}
+
+// ToForms converts the CrawlForm slice to a database Form slice
+func (cjr *CrawlJobResult) ToForms(crawlResultID int) []Form {
+	forms := make([]Form, len(cjr.Forms))
+	for i, cf := range cjr.Forms {
+		forms[i] = Form{
+			CrawlResultID:  crawlResultID,
+			Type:           cf.Type,
+			Score:          cf.Score,
+			Action:         cf.Action,
+			Method:         cf.Method,
+			PostsOverHTTP:  cf.PostsOverHTTP,
+			PasswordFields: cf.PasswordFields,
+			OAuthProviders: StringList(cf.OAuthProviders),
+			Signals:        StringList(cf.Signals),
+		}
+	}
+	return forms
+}
+
+// ToRuleResults converts the CrawlRuleResult slice to a database RuleResult slice
+func (cjr *CrawlJobResult) ToRuleResults(crawlResultID int) []RuleResult {
+	results := make([]RuleResult, len(cjr.RuleResults))
+	for i, crr := range cjr.RuleResults {
+		ruleID := crr.RuleID
+		results[i] = RuleResult{
+			CrawlResultID: crawlResultID,
+			RuleID:        &ruleID,
+			RuleName:      crr.Name,
+			Passed:        crr.Passed,
+			Severity:      crr.Severity,
+			Message:       crr.Message,
+		}
+	}
+	return results
+}
+
+// ToFindings converts the CrawlFinding slice to a database Finding slice
+func (cjr *CrawlJobResult) ToFindings(crawlResultID int) []Finding {
+	findings := make([]Finding, len(cjr.Findings))
+	for i, cf := range cjr.Findings {
+		findings[i] = Finding{
+			CrawlResultID: crawlResultID,
+			Category:      cf.Category,
+			Code:          cf.Code,
+			Severity:      cf.Severity,
+			Message:       cf.Message,
+			Selector:      cf.Selector,
+		}
+	}
+	return findings
+}
@@ -1,8 +1,12 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 	"sync"
 	"time"
 	"url-analyzer/internal/database"
@@ -10,27 +14,166 @@ import (
 	"url-analyzer/pkg/crawler"
 )
 
+const (
+	// how long a claimed job stays with its worker without a heartbeat
+	jobLeaseDuration = 2 * time.Minute
+	// how often a worker extends the lease of the job it is running
+	jobHeartbeatInterval = 30 * time.Second
+	// how often idle workers look for queued jobs they were not woken up for
+	jobPollInterval = 2 * time.Second
+	// how often expired leases are looked for while the server is running
+	jobRecoveryInterval = 1 * time.Minute
+	// how many times a job is claimed before it is given up on
+	jobMaxAttempts = 3
+	// how long the sitemaps of a site are reused by later crawls of its pages
+	sitemapCacheTTL = 1 * time.Hour
+	// how long the robots.txt of a host is reused by later crawls
+	robotsCacheTTL = 1 * time.Hour
+)
+
+// Errors of starting and stopping crawls, returned wrapped with the URL ID
+var (
+	ErrCrawlInProgress = errors.New("crawl already in progress")
+	ErrNoActiveJob     = errors.New("no active job found")
+	ErrJobFinished     = errors.New("job already finished")
+)
+
 // handles crawling operations and database interactions
 type CrawlerService struct {
 	repo     database.RepositoryInterface
-	crawler  *crawler.Crawler
+	options  models.CrawlOptions
 	jobs     map[int]*models.CrawlJob
 	jobsMu   sync.RWMutex
+	
+	// worker pool consuming the persistent crawl job queue
+	workerID          string
+	workers           int
+	heartbeatInterval time.Duration
+	wake              chan struct{}
+	stop              chan struct{}
+	wg                sync.WaitGroup
+	// set once Stop was called; running jobs are then handed back to the queue. Guarded by jobsMu.
+	stopping bool
+	
+	// told how every crawl ended, nil when nobody listens
+	notifier CrawlNotifier
+	
+	// pushes job status changes to event stream clients
+	events *JobEventBroker
+	
+	// robots.txt files read by earlier jobs, so jobs on the same host read it once
+	// and keep to its Crawl-delay together
+	robots *crawler.RobotsCache
+	
+	// sitemaps read by earlier jobs, so crawling many pages of a site reads them once
+	sitemaps *crawler.SitemapCache
 }
 
 // creates a new crawler service
 func NewCrawlerService(repo database.RepositoryInterface) *CrawlerService {
 	options := models.DefaultCrawlOptions()
 	
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	
 	return &CrawlerService{
-		repo:    repo,
-		crawler: crawler.NewCrawler(options),
-		jobs:    make(map[int]*models.CrawlJob),
+		repo:              repo,
+		options:           options,
+		jobs:              make(map[int]*models.CrawlJob),
+		workerID:          fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		heartbeatInterval: jobHeartbeatInterval,
+		wake:              make(chan struct{}, 1),
+		stop:              make(chan struct{}),
+		events:            NewJobEventBroker(),
+		robots:            crawler.NewRobotsCache(robotsCacheTTL),
+		sitemaps:          crawler.NewSitemapCache(sitemapCacheTTL),
+	}
+}
+
+// sets who is told how every crawl ended. Must be called before Start.
+func (cs *CrawlerService) SetNotifier(notifier CrawlNotifier) {
+	cs.notifier = notifier
+}
+
+// sets the Chrome or Chromium binary crawls with the browser fetcher use, instead of the
+// first one found on the PATH. Must be called before Start.
+func (cs *CrawlerService) SetBrowserPath(path string) {
+	cs.options.BrowserPath = path
+}
+
+// recovers jobs orphaned by a previous run and starts the given number of
+// workers consuming the crawl job queue
+func (cs *CrawlerService) Start(workers int) error {
+	if workers <= 0 {
+		workers = 1
+	}
+	
+	requeued, err := cs.repo.RecoverCrawlJobs(jobMaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to recover crawl jobs: %w", err)
+	}
+	if requeued > 0 {
+		log.Printf("Requeued %d crawl jobs interrupted by a previous shutdown", requeued)
+	}
+	
+	cs.workers = workers
+	for i := 0; i < workers; i++ {
+		cs.wg.Add(1)
+		// Each worker holds its jobs under its own ID, so a job requeued to a sibling is never taken for its own
+		go cs.runWorker(fmt.Sprintf("%s-%d", cs.workerID, i+1))
+	}
+	
+	cs.wg.Add(1)
+	go cs.runRecovery()
+	
+	log.Printf("Started %d crawl workers (%s)", workers, cs.workerID)
+	return nil
+}
+
+// stops the workers, cancelling the jobs they are running and handing them back to the queue
+// so that another instance, or the next boot, runs them right away. Returns once every worker did.
+func (cs *CrawlerService) Stop() {
+	close(cs.stop)
+	
+	cs.jobsMu.Lock()
+	cs.stopping = true
+	for _, job := range cs.jobs {
+		if !job.Status.IsFinished() {
+			job.Cancel()
+		}
 	}
+	cs.jobsMu.Unlock()
+	
+	cs.wg.Wait()
 }
 
 // starts crawling a URL asynchronously
 func (cs *CrawlerService) StartCrawl(urlID int) error {
+	return cs.startJob(urlID, nil, nil)
+}
+
+// starts crawling a URL asynchronously with overrides merged into the URL's crawl options.
+// The merged options are saved on the URL for later crawls once this one is queued.
+func (cs *CrawlerService) StartCrawlWithOptions(urlID int, overrides *models.CrawlOptionsOverride) error {
+	return cs.startJob(urlID, nil, overrides)
+}
+
+// starts crawling a whole site from a URL asynchronously, following internal links
+func (cs *CrawlerService) StartSiteCrawl(urlID int, req models.SiteCrawlRequest) error {
+	if req.MaxDepth <= 0 {
+		req.MaxDepth = cs.options.MaxDepth
+	}
+	if req.MaxPages <= 0 {
+		req.MaxPages = cs.options.MaxPages
+	}
+	
+	return cs.startJob(urlID, &req, nil)
+}
+
+// adds a crawl job for a URL to the queue
+func (cs *CrawlerService) startJob(urlID int, siteCrawl *models.SiteCrawlRequest, overrides *models.CrawlOptionsOverride) error {
 	// Get URL from database
 	urlRecord, err := cs.repo.GetURLByID(urlID)
 	if err != nil {
@@ -39,39 +182,311 @@ func (cs *CrawlerService) StartCrawl(urlID int) error {
 	
 	// Check if already running
 	cs.jobsMu.RLock()
-	if job, exists := cs.jobs[urlID]; exists && job.Status != models.CrawlStatusCompleted && job.Status != models.CrawlStatusFailed {
+	if job, exists := cs.jobs[urlID]; exists && !job.Status.IsFinished() {
 		cs.jobsMu.RUnlock()
-		return fmt.Errorf("crawl already in progress for URL ID %d", urlID)
+		return fmt.Errorf("%w for URL ID %d", ErrCrawlInProgress, urlID)
 	}
 	cs.jobsMu.RUnlock()
 	
-	// Update status to running
-	err = cs.repo.UpdateURLStatus(urlID, models.StatusRunning, nil)
+	crawlOptions := urlRecord.CrawlOptions
+	if overrides != nil {
+		crawlOptions = urlRecord.CrawlOptions.Merge(overrides)
+	}
+	
+	// The job keeps the URL's crawl options as they were when it was queued
+	jobOptions := models.CrawlJobOptions{
+		SiteCrawl: siteCrawl,
+		Overrides: crawlOptions,
+	}
+	
+	// Fails when another server instance, or a concurrent start, queued a job for the URL
+	record, err := cs.repo.EnqueueCrawlJob(urlID, jobOptions)
+	if err != nil {
+		if errors.Is(err, database.ErrActiveCrawlJob) {
+			return fmt.Errorf("%w for URL ID %d", ErrCrawlInProgress, urlID)
+		}
+		return fmt.Errorf("failed to enqueue crawl job: %w", err)
+	}
+	
+	err = cs.repo.UpdateURLStatus(urlID, models.StatusQueued, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update URL status: %w", err)
 	}
 	
-	// Create job
-	job := &models.CrawlJob{
-		ID:        urlID,
-		URL:       urlRecord.URL,
-		Status:    models.CrawlStatusStarted,
-		Progress:  0.0,
-		Message:   "Starting crawl",
-		StartTime: time.Now(),
-		Cancel:    make(chan struct{}),
+	// Remember the overrides for later crawls of the URL only once this crawl is queued with them.
+	// The job already has them, so failing to save them does not fail the start.
+	if overrides != nil {
+		if err := cs.repo.UpdateURLCrawlOptions(urlID, crawlOptions); err != nil {
+			log.Printf("Failed to save crawl options of URL %d: %v", urlID, err)
+		}
 	}
 	
 	cs.jobsMu.Lock()
+	job := cs.newJob(record, urlRecord.URL)
 	cs.jobs[urlID] = job
+	cs.publishJob(job)
 	cs.jobsMu.Unlock()
 	
-	// Start crawling in goroutine
-	go cs.performCrawl(job)
+	cs.wakeWorker()
 	
 	return nil
 }
 
+// creates the in-memory view of a queued crawl job
+func (cs *CrawlerService) newJob(record *models.CrawlJobRecord, url string) *models.CrawlJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	
+	return &models.CrawlJob{
+		ID:        record.URLID,
+		QueueID:   record.ID,
+		URL:       url,
+		Status:    models.CrawlStatusQueued,
+		Progress:  0.0,
+		Message:   "Waiting for a crawl worker",
+		StartTime: time.Now(),
+		SiteCrawl: record.Options.SiteCrawl,
+		Options:   record.Options.Overrides,
+		Context:   ctx,
+		Cancel:    cancel,
+	}
+}
+
+// lets an idle worker know a job was queued
+func (cs *CrawlerService) wakeWorker() {
+	select {
+	case cs.wake <- struct{}{}:
+	default:
+	}
+}
+
+// claims and runs queued jobs under the given worker ID until the service is stopped
+func (cs *CrawlerService) runWorker(workerID string) {
+	defer cs.wg.Done()
+	
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-cs.stop:
+			return
+		default:
+		}
+		
+		record, err := cs.repo.ClaimNextCrawlJob(workerID, jobLeaseDuration)
+		if err != nil {
+			log.Printf("Failed to claim crawl job: %v", err)
+		}
+		
+		if err != nil || record == nil {
+			select {
+			case <-cs.stop:
+				return
+			case <-cs.wake:
+			case <-ticker.C:
+			}
+			continue
+		}
+		
+		cs.runJob(record, workerID)
+	}
+}
+
+// periodically requeues jobs whose worker stopped sending heartbeats
+func (cs *CrawlerService) runRecovery() {
+	defer cs.wg.Done()
+	
+	ticker := time.NewTicker(jobRecoveryInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-cs.stop:
+			return
+		case <-ticker.C:
+			requeued, err := cs.repo.RecoverCrawlJobs(jobMaxAttempts)
+			if err != nil {
+				log.Printf("Failed to recover crawl jobs: %v", err)
+				continue
+			}
+			if requeued > 0 {
+				log.Printf("Requeued %d crawl jobs with expired leases", requeued)
+				cs.wakeWorker()
+			}
+		}
+	}
+}
+
+// runs a job claimed by a worker, keeping its lease alive until the crawl records how it ended
+func (cs *CrawlerService) runJob(record *models.CrawlJobRecord, workerID string) {
+	urlRecord, err := cs.repo.GetURLByID(record.URLID)
+	if err != nil {
+		errorMessage := fmt.Sprintf("failed to get URL: %v", err)
+		outcome := models.CrawlJobOutcome{JobStatus: models.JobStatusFailed, URLStatus: models.StatusError, ErrorMessage: &errorMessage}
+		if err := cs.repo.FinishCrawlJob(record.ID, workerID, outcome, nil); err != nil {
+			log.Printf("Failed to finish crawl job %d: %v", record.ID, err)
+		}
+		return
+	}
+	
+	// Jobs queued by another instance or recovered after a restart have no in-memory job yet
+	cs.jobsMu.Lock()
+	job, exists := cs.jobs[record.URLID]
+	if !exists || job.QueueID != record.ID {
+		job = cs.newJob(record, urlRecord.URL)
+		cs.jobs[record.URLID] = job
+	}
+	// Jobs requeued for orphaned URLs carry no options of their own
+	if job.Options == nil {
+		job.Options = urlRecord.CrawlOptions
+	}
+	job.WorkerID = workerID
+	job.Status = models.CrawlStatusStarted
+	job.Message = "Starting crawl"
+	job.StartTime = time.Now()
+	cs.publishJob(job)
+	// Claimed while the service was stopping: the job goes straight back to the queue
+	if cs.stopping {
+		job.Cancel()
+	}
+	cs.jobsMu.Unlock()
+	
+	err = cs.repo.UpdateURLStatus(record.URLID, models.StatusRunning, nil)
+	if err != nil {
+		log.Printf("Failed to update URL status for ID %d: %v", record.URLID, err)
+	}
+	
+	heartbeatDone := make(chan struct{})
+	go cs.heartbeat(job, heartbeatDone)
+	
+	cs.performCrawl(job)
+	close(heartbeatDone)
+	
+	// Stopped jobs leave no results behind. Jobs stopped by a shutdown are handed back to the queue.
+	if job.Context.Err() != nil && cs.stoppedByShutdown(job) {
+		cs.releaseJob(job)
+		return
+	}
+	
+	// StopCrawl has normally cancelled the job in the queue already, and jobs lost to another
+	// worker are not this one's to finish, so the queue usually turns this down.
+	if job.Context.Err() != nil {
+		errorMessage := "Cancelled by user"
+		outcome := models.CrawlJobOutcome{JobStatus: models.JobStatusCancelled, URLStatus: models.StatusError, ErrorMessage: &errorMessage}
+		err := cs.repo.FinishCrawlJob(record.ID, workerID, outcome, nil)
+		if err != nil && !errors.Is(err, database.ErrCrawlJobLeaseLost) {
+			log.Printf("Failed to finish crawl job %d: %v", record.ID, err)
+		}
+	}
+}
+
+// reports whether a cancelled job was stopped by Stop. Jobs stopped by a user, or lost to
+// another worker, got their final status before they were cancelled.
+func (cs *CrawlerService) stoppedByShutdown(job *models.CrawlJob) bool {
+	cs.jobsMu.RLock()
+	defer cs.jobsMu.RUnlock()
+	
+	return cs.stopping && !job.Status.IsFinished()
+}
+
+// hands a job cancelled by a shutdown back to the queue. Jobs the worker no longer holds were
+// cancelled or taken over elsewhere meanwhile and are left alone.
+func (cs *CrawlerService) releaseJob(job *models.CrawlJob) {
+	err := cs.repo.ReleaseCrawlJob(job.QueueID, job.WorkerID)
+	if errors.Is(err, database.ErrCrawlJobLeaseLost) {
+		return
+	}
+	if err != nil {
+		// The lease expires and the job is recovered then
+		log.Printf("Failed to release crawl job %d: %v", job.QueueID, err)
+		return
+	}
+	
+	cs.jobsMu.Lock()
+	defer cs.jobsMu.Unlock()
+	
+	log.Printf("Requeued crawl job %d of URL ID %d on shutdown", job.QueueID, job.ID)
+	job.Status = models.CrawlStatusQueued
+	job.Message = "Requeued: the server is shutting down"
+	job.Progress = 0.0
+	cs.publishJob(job)
+}
+
+// extends the lease of a running job until done is closed. A job whose lease is lost,
+// because it was cancelled or requeued elsewhere, is stopped.
+func (cs *CrawlerService) heartbeat(job *models.CrawlJob, done <-chan struct{}) {
+	ticker := time.NewTicker(cs.heartbeatInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := cs.repo.HeartbeatCrawlJob(job.QueueID, job.WorkerID, jobLeaseDuration)
+			if errors.Is(err, database.ErrCrawlJobLeaseLost) {
+				cs.abandonJob(job)
+				return
+			}
+			if err != nil {
+				log.Printf("Failed to extend lease of crawl job %d: %v", job.QueueID, err)
+			}
+		}
+	}
+}
+
+// stops a job the worker no longer holds, discarding whatever it found.
+// Whoever cancelled or took over the job records how it ends.
+func (cs *CrawlerService) abandonJob(job *models.CrawlJob) {
+	cs.jobsMu.Lock()
+	defer cs.jobsMu.Unlock()
+	
+	log.Printf("Crawl job %d of URL ID %d was cancelled or requeued elsewhere, discarding its results", job.QueueID, job.ID)
+	job.Cancel()
+	
+	// StopCrawl already gave jobs cancelled on this instance their status
+	if job.Status.IsFinished() {
+		return
+	}
+	job.Status = models.CrawlStatusFailed
+	job.Message = "Stopped: the crawl job was cancelled or requeued elsewhere"
+	job.Progress = 100.0
+	endTime := time.Now()
+	job.EndTime = &endTime
+	cs.publishJob(job)
+}
+
+// records how a job ended, after save wrote its results when given, and tells the notifier in the
+// same transaction. When the worker no longer holds the job the job is abandoned instead and
+// ErrCrawlJobLeaseLost is returned.
+func (cs *CrawlerService) finishJob(job *models.CrawlJob, outcome models.CrawlJobOutcome, save func(database.CrawlResultWriter, *models.CrawlEvent) error) error {
+	event := models.CrawlEvent{
+		URLID:        job.ID,
+		URL:          job.URL,
+		Status:       outcome.URLStatus,
+		ErrorMessage: outcome.ErrorMessage,
+	}
+	
+	err := cs.repo.FinishCrawlJob(job.QueueID, job.WorkerID, outcome, func(w database.CrawlResultWriter) error {
+		if save != nil {
+			if err := save(w, &event); err != nil {
+				return err
+			}
+		}
+		if cs.notifier == nil {
+			return nil
+		}
+		return cs.notifier.NotifyCrawl(w, event)
+	})
+	if errors.Is(err, database.ErrCrawlJobLeaseLost) {
+		cs.abandonJob(job)
+	}
+	if err == nil && cs.notifier != nil {
+		cs.notifier.Flush()
+	}
+	return err
+}
+
 // performs the actual crawling
 func (cs *CrawlerService) performCrawl(job *models.CrawlJob) {
 	defer func() {
@@ -81,13 +496,26 @@ func (cs *CrawlerService) performCrawl(job *models.CrawlJob) {
 		}
 	}()
 	
-	// Set up progress callback
-	cs.crawler.SetProgressCallback(func(status models.CrawlStatus, message string, progress float64) {
-		cs.updateJobProgress(job.ID, status, message, progress)
-	})
+	if job.SiteCrawl != nil {
+		cs.performSiteCrawl(job)
+		return
+	}
+	
+	// Workers run concurrently, so every job reports progress through its own crawler
+	jobCrawler := crawler.NewCrawler(cs.jobOptions(job))
+	defer jobCrawler.Close()
+	jobCrawler.SetRobotsCache(cs.robots)
+	jobCrawler.SetSitemapCache(cs.sitemaps)
+	jobCrawler.SetProgressCallback(cs.crawlProgress(job))
 	
 	// Perform crawl
-	result := cs.crawler.CrawlURL(job.URL)
+	result := jobCrawler.CrawlURL(job.Context, job.URL)
+	
+	// Whoever cancelled the job records its outcome
+	if job.Context.Err() != nil {
+		log.Printf("Crawl job %d cancelled, discarding results", job.ID)
+		return
+	}
 	
 	// Update job with result
 	cs.jobsMu.Lock()
@@ -96,26 +524,103 @@ func (cs *CrawlerService) performCrawl(job *models.CrawlJob) {
 	job.EndTime = &endTime
 	cs.jobsMu.Unlock()
 	
+	if result.BlockedByRobots {
+		cs.handleCrawlBlocked(job)
+		return
+	}
+	
 	if result.Error != nil {
 		cs.handleCrawlError(job, result.Error)
 		return
 	}
 	
-	// Save results to database
-	err := cs.saveCrawlResults(job.ID, result)
+	// Save results to database, together with the completed status, unless the job was lost meanwhile
+	err := cs.finishJob(job, models.CrawlJobOutcome{JobStatus: models.JobStatusCompleted, URLStatus: models.StatusCompleted}, func(w database.CrawlResultWriter, event *models.CrawlEvent) error {
+		crawlResult := result.ToCrawlResult(job.ID)
+		if err := cs.saveCrawlResults(w, crawlResult, result); err != nil {
+			return err
+		}
+		describeResult(event, crawlResult, result)
+		return nil
+	})
+	if errors.Is(err, database.ErrCrawlJobLeaseLost) {
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to save crawl results for URL ID %d: %v", job.ID, err)
 		cs.handleCrawlError(job, fmt.Errorf("failed to save results: %w", err))
 		return
 	}
 	
-	// Update status to completed
-	err = cs.repo.UpdateURLStatus(job.ID, models.StatusCompleted, nil)
+	cs.updateJobProgress(job.ID, models.CrawlStatusCompleted, "Crawl completed successfully", 100.0)
+}
+
+// returns the crawl options of a job together with the custom rules that apply to its URL.
+// The crawl goes ahead without rules when they cannot be loaded.
+func (cs *CrawlerService) jobOptions(job *models.CrawlJob) models.CrawlOptions {
+	options := job.Options.Apply(cs.options)
+	
+	rules, err := cs.repo.ListRulesForURL(job.ID)
 	if err != nil {
-		log.Printf("Failed to update URL status for ID %d: %v", job.ID, err)
+		log.Printf("Failed to load rules for URL ID %d: %v", job.ID, err)
+		return options
 	}
+	options.Rules = rules
 	
-	cs.updateJobProgress(job.ID, models.CrawlStatusCompleted, "Crawl completed successfully", 100.0)
+	return options
+}
+
+// performs a breadth-first crawl of the site behind the job's URL
+func (cs *CrawlerService) performSiteCrawl(job *models.CrawlJob) {
+	options := cs.jobOptions(job)
+	options.MaxDepth = job.SiteCrawl.MaxDepth
+	options.MaxPages = job.SiteCrawl.MaxPages
+	
+	siteCrawler := crawler.NewCrawler(options)
+	defer siteCrawler.Close()
+	siteCrawler.SetRobotsCache(cs.robots)
+	siteCrawler.SetSitemapCache(cs.sitemaps)
+	siteCrawler.SetProgressCallback(cs.crawlProgress(job))
+	
+	site := siteCrawler.CrawlSite(job.Context, job.URL)
+	
+	// Whoever cancelled the job records its outcome
+	if job.Context.Err() != nil {
+		log.Printf("Site crawl job %d cancelled, discarding results", job.ID)
+		return
+	}
+	
+	cs.jobsMu.Lock()
+	if len(site.Pages) > 0 {
+		job.Result = site.Pages[0].Result
+	}
+	endTime := time.Now()
+	job.EndTime = &endTime
+	cs.jobsMu.Unlock()
+	
+	if site.BlockedByRobots {
+		cs.handleCrawlBlocked(job)
+		return
+	}
+	
+	if site.Error != nil {
+		cs.handleCrawlError(job, site.Error)
+		return
+	}
+	
+	err := cs.finishJob(job, models.CrawlJobOutcome{JobStatus: models.JobStatusCompleted, URLStatus: models.StatusCompleted}, func(w database.CrawlResultWriter, event *models.CrawlEvent) error {
+		return cs.saveSiteCrawlResults(w, event, site)
+	})
+	if errors.Is(err, database.ErrCrawlJobLeaseLost) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to save site crawl results for URL ID %d: %v", job.ID, err)
+		cs.handleCrawlError(job, fmt.Errorf("failed to save results: %w", err))
+		return
+	}
+	
+	cs.updateJobProgress(job.ID, models.CrawlStatusCompleted, fmt.Sprintf("Site crawl completed: %d pages", len(site.Pages)), 100.0)
 }
 
 // handles errors during crawling
@@ -123,22 +628,46 @@ func (cs *CrawlerService) handleCrawlError(job *models.CrawlJob, err error) {
 	errorMessage := err.Error()
 	
 	// Update database status
-	dbErr := cs.repo.UpdateURLStatus(job.ID, models.StatusError, &errorMessage)
+	outcome := models.CrawlJobOutcome{JobStatus: models.JobStatusFailed, URLStatus: models.StatusError, ErrorMessage: &errorMessage}
+	dbErr := cs.finishJob(job, outcome, nil)
+	if errors.Is(dbErr, database.ErrCrawlJobLeaseLost) {
+		return
+	}
 	if dbErr != nil {
-		log.Printf("Failed to update URL status for ID %d: %v", job.ID, dbErr)
+		log.Printf("Failed to finish crawl job %d: %v", job.QueueID, dbErr)
 	}
 	
 	// Update job status
 	cs.updateJobProgress(job.ID, models.CrawlStatusFailed, errorMessage, 100.0)
 }
 
-// saves crawl results to the database
-func (cs *CrawlerService) saveCrawlResults(urlID int, result *models.CrawlJobResult) error {
-	// Convert to database model
-	crawlResult := result.ToCrawlResult(urlID)
+// records that robots.txt does not allow crawling the job's URL
+func (cs *CrawlerService) handleCrawlBlocked(job *models.CrawlJob) {
+	message := "Blocked by robots.txt"
+	
+	outcome := models.CrawlJobOutcome{JobStatus: models.JobStatusCompleted, URLStatus: models.StatusBlocked, ErrorMessage: &message}
+	err := cs.finishJob(job, outcome, nil)
+	if errors.Is(err, database.ErrCrawlJobLeaseLost) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to finish crawl job %d: %v", job.QueueID, err)
+	}
 	
+	cs.updateJobProgress(job.ID, models.CrawlStatusBlocked, message, 100.0)
+}
+
+// adds what a completed crawl saved to the event telling how it ended
+func describeResult(event *models.CrawlEvent, crawlResult *models.CrawlResult, result *models.CrawlJobResult) {
+	event.Result = crawlResult
+	event.BrokenLinks = result.ToBrokenLinks(crawlResult.ID)
+	event.Links = result.ToLinks(crawlResult.ID)
+}
+
+// saves crawl results through the writer of the transaction finishing the job
+func (cs *CrawlerService) saveCrawlResults(w database.CrawlResultWriter, crawlResult *models.CrawlResult, result *models.CrawlJobResult) error {
 	// Save crawl result
-	err := cs.repo.CreateCrawlResult(crawlResult)
+	err := w.CreateCrawlResult(crawlResult)
 	if err != nil {
 		return fmt.Errorf("failed to create crawl result: %w", err)
 	}
@@ -147,27 +676,176 @@ func (cs *CrawlerService) saveCrawlResults(urlID int, result *models.CrawlJobRes
 	if len(result.BrokenLinks) > 0 {
 		brokenLinks := result.ToBrokenLinks(crawlResult.ID)
 		
-		err = cs.repo.CreateBrokenLinks(crawlResult.ID, brokenLinks)
+		err = w.CreateBrokenLinks(crawlResult.ID, brokenLinks)
 		if err != nil {
 			log.Printf("Failed to save broken links: %v", err)
 		}
 	}
 	
+	// Save the link inventory
+	if len(result.Links) > 0 {
+		err = w.CreateLinks(crawlResult.ID, result.ToLinks(crawlResult.ID))
+		if err != nil {
+			log.Printf("Failed to save links: %v", err)
+		}
+	}
+	
+	// Save the resources
+	if len(result.Resources) > 0 {
+		err = w.CreateResources(crawlResult.ID, result.ToResources(crawlResult.ID))
+		if err != nil {
+			log.Printf("Failed to save resources: %v", err)
+		}
+	}
+	
+	// Save the security report
+	if report := result.ToSecurityReport(crawlResult.ID); report != nil {
+		err = w.CreateSecurityReport(report)
+		if err != nil {
+			log.Printf("Failed to save security report: %v", err)
+		}
+	}
+	
+	// Save the forms
+	if len(result.Forms) > 0 {
+		err = w.CreateForms(crawlResult.ID, result.ToForms(crawlResult.ID))
+		if err != nil {
+			log.Printf("Failed to save forms: %v", err)
+		}
+	}
+	
+	// Save the findings
+	if len(result.Findings) > 0 {
+		err = w.CreateFindings(crawlResult.ID, result.ToFindings(crawlResult.ID))
+		if err != nil {
+			log.Printf("Failed to save findings: %v", err)
+		}
+	}
+	
+	// Save the outcomes of the custom rules
+	if len(result.RuleResults) > 0 {
+		err = w.CreateRuleResults(crawlResult.ID, result.ToRuleResults(crawlResult.ID))
+		if err != nil {
+			log.Printf("Failed to save rule results: %v", err)
+		}
+	}
+	
 	return nil
 }
 
+// saves every successfully crawled page of a site crawl, linking each page to its root and parent.
+// The root page's result is added to the event.
+func (cs *CrawlerService) saveSiteCrawlResults(w database.CrawlResultWriter, event *models.CrawlEvent, site *models.SiteCrawlResult) error {
+	urlID := event.URLID
+	resultIDs := make(map[string]int, len(site.Pages))
+	var rootID *int
+	
+	for _, page := range site.Pages {
+		if page.Result == nil || page.Result.Error != nil || page.Result.BlockedByRobots {
+			continue
+		}
+		
+		crawlResult := page.Result.ToCrawlResult(urlID)
+		crawlResult.Depth = page.Depth
+		crawlResult.RootID = rootID
+		if rootID == nil && site.Sitemap != nil {
+			// The root result holds the coverage of the whole site
+			crawlResult.Sitemap = site.Sitemap
+		}
+		if parentID, exists := resultIDs[page.ParentURL]; exists {
+			crawlResult.ParentID = &parentID
+		} else {
+			crawlResult.ParentID = rootID
+		}
+		
+		err := cs.saveCrawlResults(w, crawlResult, page.Result)
+		if err != nil {
+			return err
+		}
+		
+		resultIDs[page.URL] = crawlResult.ID
+		if rootID == nil {
+			id := crawlResult.ID
+			rootID = &id
+			describeResult(event, crawlResult, page.Result)
+		}
+	}
+	
+	return nil
+}
+
+// reports a crawler's progress on a job; the crawler being done is not the job being
+// done, so its final statuses wait for the job's outcome to be recorded
+func (cs *CrawlerService) crawlProgress(job *models.CrawlJob) func(models.CrawlStatus, string, float64) {
+	return func(status models.CrawlStatus, message string, progress float64) {
+		if status.IsFinished() {
+			return
+		}
+		cs.updateJobProgress(job.ID, status, message, progress)
+	}
+}
+
 // updates the progress of a crawl job
 func (cs *CrawlerService) updateJobProgress(jobID int, status models.CrawlStatus, message string, progress float64) {
 	cs.jobsMu.Lock()
 	defer cs.jobsMu.Unlock()
 	
 	if job, exists := cs.jobs[jobID]; exists {
+		// Cancelled jobs keep the status StopCrawl gave them
+		if job.Context != nil && job.Context.Err() != nil {
+			return
+		}
 		job.Status = status
 		job.Message = message
 		job.Progress = progress
+		cs.publishJob(job)
 	}
 }
 
+// pushes the current state of a job to event stream subscribers; callers hold jobsMu
+func (cs *CrawlerService) publishJob(job *models.CrawlJob) {
+	cs.events.Publish(models.JobEvent{
+		URLID:    job.ID,
+		URL:      job.URL,
+		Status:   job.Status,
+		Message:  job.Message,
+		Progress: job.Progress,
+	})
+}
+
+// subscribes to the status changes of the crawl jobs of a URL, or of every URL when urlID is 0.
+// When the client can resume after lastEventID the kept events published after it are returned.
+// Otherwise, e.g. when lastEventID is from before a restart, a snapshot of the jobs on this
+// server is returned instead and resumed is false.
+func (cs *CrawlerService) SubscribeJobEvents(urlID int, lastEventID string) (*JobEventSubscription, []models.JobEvent, bool) {
+	// Jobs change only while jobsMu is held, so no event falls between the snapshot and the subscription
+	cs.jobsMu.RLock()
+	defer cs.jobsMu.RUnlock()
+	
+	sub, replay, resumed := cs.events.Subscribe(urlID, lastEventID)
+	if resumed {
+		return sub, replay, true
+	}
+	
+	snapshot := []models.JobEvent{}
+	for id, job := range cs.jobs {
+		if urlID != 0 && id != urlID {
+			continue
+		}
+		snapshot = append(snapshot, models.JobEvent{
+			URLID:     job.ID,
+			URL:       job.URL,
+			Status:    job.Status,
+			Message:   job.Message,
+			Progress:  job.Progress,
+			Timestamp: time.Now(),
+		})
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].URLID < snapshot[j].URLID })
+	
+	return sub, snapshot, false
+}
+
 // returns the status of a crawl job
 func (cs *CrawlerService) GetJobStatus(urlID int) (*models.CrawlJob, error) {
 	cs.jobsMu.RLock()
@@ -175,7 +853,7 @@ func (cs *CrawlerService) GetJobStatus(urlID int) (*models.CrawlJob, error) {
 	
 	job, exists := cs.jobs[urlID]
 	if !exists {
-		return nil, fmt.Errorf("no active job found for URL ID %d", urlID)
+		return nil, fmt.Errorf("%w for URL ID %d", ErrNoActiveJob, urlID)
 	}
 	
 	// Return a copy to avoid race conditions
@@ -188,17 +866,31 @@ func (cs *CrawlerService) StopCrawl(urlID int) error {
 	cs.jobsMu.Lock()
 	defer cs.jobsMu.Unlock()
 	
+	cancelled, err := cs.repo.CancelCrawlJobs(urlID)
+	if err != nil {
+		log.Printf("Failed to cancel queued crawl jobs for URL ID %d: %v", urlID, err)
+	}
+	
 	job, exists := cs.jobs[urlID]
 	if !exists {
-		return fmt.Errorf("no active job found for URL ID %d", urlID)
+		// The job may be queued or running on another server instance
+		if cancelled > 0 {
+			errorMessage := "Cancelled by user"
+			if err := cs.repo.UpdateURLStatus(urlID, models.StatusError, &errorMessage); err != nil {
+				log.Printf("Failed to update URL status for cancelled job %d: %v", urlID, err)
+			}
+			return nil
+		}
+		return fmt.Errorf("%w for URL ID %d", ErrNoActiveJob, urlID)
 	}
 	
-	if job.Status == models.CrawlStatusCompleted || job.Status == models.CrawlStatusFailed {
-		return fmt.Errorf("job already finished")
+	// A job the queue had nothing left to cancel of finished just now, its outcome stands
+	if job.Status.IsFinished() || (err == nil && cancelled == 0) {
+		return ErrJobFinished
 	}
 	
-	// Signal cancellation
-	close(job.Cancel)
+	// Signal cancellation, aborting the job's outstanding requests
+	job.Cancel()
 	
 	// Update status
 	job.Status = models.CrawlStatusFailed
@@ -206,10 +898,11 @@ func (cs *CrawlerService) StopCrawl(urlID int) error {
 	job.Progress = 100.0
 	endTime := time.Now()
 	job.EndTime = &endTime
+	cs.publishJob(job)
 	
 	// Update database
 	errorMessage := "Cancelled by user"
-	err := cs.repo.UpdateURLStatus(urlID, models.StatusError, &errorMessage)
+	err = cs.repo.UpdateURLStatus(urlID, models.StatusError, &errorMessage)
 	if err != nil {
 		log.Printf("Failed to update URL status for cancelled job %d: %v", urlID, err)
 	}
@@ -223,7 +916,7 @@ func (cs *CrawlerService) CleanupCompletedJobs() {
 	defer cs.jobsMu.Unlock()
 	
 	for id, job := range cs.jobs {
-		if job.Status == models.CrawlStatusCompleted || job.Status == models.CrawlStatusFailed {
+		if job.Status.IsFinished() {
 			if job.EndTime != nil && time.Since(*job.EndTime) > 1*time.Hour {
 				delete(cs.jobs, id)
 			}
@@ -238,7 +931,7 @@ func (cs *CrawlerService) GetActiveJobs() map[int]*models.CrawlJob {
 	
 	activeJobs := make(map[int]*models.CrawlJob)
 	for id, job := range cs.jobs {
-		if job.Status != models.CrawlStatusCompleted && job.Status != models.CrawlStatusFailed {
+		if !job.Status.IsFinished() {
 			jobCopy := *job
 			activeJobs[id] = &jobCopy
 		}
@@ -247,13 +940,21 @@ func (cs *CrawlerService) GetActiveJobs() map[int]*models.CrawlJob {
 	return activeJobs
 }
 
+// returns the registered analyzers and whether crawls run them by default
+func (cs *CrawlerService) GetAnalyzers() []models.AnalyzerInfo {
+	return crawler.AnalyzerInfos(cs.options)
+}
+
 // returns crawler statistics
 func (cs *CrawlerService) GetCrawlerStats() map[string]interface{} {
 	cs.jobsMu.RLock()
 	defer cs.jobsMu.RUnlock()
 	
-	stats := cs.crawler.GetStats()
+	// Jobs get their own crawler, so report the defaults they start from
+	stats := crawler.OptionStats(cs.options)
 	stats["active_jobs"] = len(cs.jobs)
+	stats["workers"] = cs.workers
+	stats["worker_id"] = cs.workerID
 	
 	statusCounts := make(map[string]int)
 	for _, job := range cs.jobs {
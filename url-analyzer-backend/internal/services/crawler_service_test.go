@@ -3,8 +3,10 @@ package services
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+	"url-analyzer/internal/database"
 	"url-analyzer/internal/models"
 
 	"github.com/stretchr/testify/assert"
@@ -17,8 +19,8 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) CreateURL(url string) (*models.URL, error) {
-	args := m.Called(url)
+func (m *MockRepository) CreateURL(userID int, url string, crawlOptions *models.CrawlOptionsOverride) (*models.URL, error) {
+	args := m.Called(userID, url, crawlOptions)
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
@@ -45,6 +47,11 @@ func (m *MockRepository) UpdateURLStatus(id int, status models.URLStatus, errorM
 	return args.Error(0)
 }
 
+func (m *MockRepository) UpdateURLCrawlOptions(id int, crawlOptions *models.CrawlOptionsOverride) error {
+	args := m.Called(id, crawlOptions)
+	return args.Error(0)
+}
+
 func (m *MockRepository) DeleteURL(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -70,16 +77,197 @@ func (m *MockRepository) GetCrawlResultByURLID(urlID int) (*models.CrawlResult,
 	return args.Get(0).(*models.CrawlResult), args.Error(1)
 }
 
+func (m *MockRepository) GetCrawlResultByID(id int) (*models.CrawlResult, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlResult), args.Error(1)
+}
+
+func (m *MockRepository) ListCrawlResultsByURLID(urlID int, filter models.HistoryFilter) ([]models.CrawlResult, int, error) {
+	args := m.Called(urlID, filter)
+	return args.Get(0).([]models.CrawlResult), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) GetBrokenLinksByCrawlResultID(crawlResultID int) ([]models.BrokenLink, error) {
+	args := m.Called(crawlResultID)
+	return args.Get(0).([]models.BrokenLink), args.Error(1)
+}
+
+func (m *MockRepository) GetBrokenLinksByCrawlResultIDs(crawlResultIDs []int) (map[int][]models.BrokenLink, error) {
+	args := m.Called(crawlResultIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int][]models.BrokenLink), args.Error(1)
+}
+
+func (m *MockRepository) GetSitePages(rootResultID int) ([]models.CrawlResult, error) {
+	args := m.Called(rootResultID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CrawlResult), args.Error(1)
+}
+
 func (m *MockRepository) CreateBrokenLinks(crawlResultID int, brokenLinks []models.BrokenLink) error {
 	args := m.Called(crawlResultID, brokenLinks)
 	return args.Error(0)
 }
 
+func (m *MockRepository) CreateLinks(crawlResultID int, links []models.Link) error {
+	args := m.Called(crawlResultID, links)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLinksByCrawlResultID(crawlResultID int, filter models.LinkFilter) ([]models.Link, int, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.Link), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) GetLinksByURLs(crawlResultID int, urls []string) ([]models.Link, error) {
+	args := m.Called(crawlResultID, urls)
+	return args.Get(0).([]models.Link), args.Error(1)
+}
+
+func (m *MockRepository) CreateResources(crawlResultID int, resources []models.PageResource) error {
+	args := m.Called(crawlResultID, resources)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetResourcesByCrawlResultID(crawlResultID int, filter models.ResourceFilter) ([]models.PageResource, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.PageResource), args.Error(1)
+}
+
+func (m *MockRepository) CreateSecurityReport(report *models.SecurityReport) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetSecurityReportByCrawlResultID(crawlResultID int) (*models.SecurityReport, error) {
+	args := m.Called(crawlResultID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityReport), args.Error(1)
+}
+
+func (m *MockRepository) ListExpiringCertificates(before time.Time) ([]models.ExpiringCertificate, error) {
+	args := m.Called(before)
+	return args.Get(0).([]models.ExpiringCertificate), args.Error(1)
+}
+
+func (m *MockRepository) ListRulesForURL(urlID int) ([]models.Rule, error) {
+	args := m.Called(urlID)
+	return args.Get(0).([]models.Rule), args.Error(1)
+}
+
+func (m *MockRepository) CreateRuleResults(crawlResultID int, results []models.RuleResult) error {
+	args := m.Called(crawlResultID, results)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRuleResultsByCrawlResultID(crawlResultID int, filter models.RuleResultFilter) ([]models.RuleResult, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.RuleResult), args.Error(1)
+}
+
+func (m *MockRepository) CreateURLs(userID int, urls []string, crawlOptions *models.CrawlOptionsOverride) ([]models.BatchURLResult, error) {
+	args := m.Called(userID, urls, crawlOptions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BatchURLResult), args.Error(1)
+}
+
+func (m *MockRepository) ListURLIDs(filter models.URLFilter, limit int) ([]int, error) {
+	args := m.Called(filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockRepository) CreateForms(crawlResultID int, forms []models.Form) error {
+	args := m.Called(crawlResultID, forms)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFormsByCrawlResultID(crawlResultID int) ([]models.Form, error) {
+	args := m.Called(crawlResultID)
+	return args.Get(0).([]models.Form), args.Error(1)
+}
+
+func (m *MockRepository) CreateFindings(crawlResultID int, findings []models.Finding) error {
+	args := m.Called(crawlResultID, findings)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFindingsByCrawlResultID(crawlResultID int, filter models.FindingFilter) ([]models.Finding, error) {
+	args := m.Called(crawlResultID, filter)
+	return args.Get(0).([]models.Finding), args.Error(1)
+}
+
+func (m *MockRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetBrokenLinksByURLID(urlID int) ([]models.BrokenLink, error) {
 	args := m.Called(urlID)
 	return args.Get(0).([]models.BrokenLink), args.Error(1)
 }
 
+func (m *MockRepository) EnqueueCrawlJob(urlID int, options models.CrawlJobOptions) (*models.CrawlJobRecord, error) {
+	args := m.Called(urlID, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlJobRecord), args.Error(1)
+}
+
+func (m *MockRepository) ClaimNextCrawlJob(workerID string, lease time.Duration) (*models.CrawlJobRecord, error) {
+	args := m.Called(workerID, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlJobRecord), args.Error(1)
+}
+
+func (m *MockRepository) HeartbeatCrawlJob(id int, workerID string, lease time.Duration) error {
+	args := m.Called(id, workerID, lease)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FinishCrawlJob(id int, workerID string, outcome models.CrawlJobOutcome, save func(database.CrawlResultWriter) error) error {
+	args := m.Called(id, workerID, outcome)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	// The results are written through the mock's own Create methods
+	if save != nil {
+		return save(m)
+	}
+	return nil
+}
+
+func (m *MockRepository) CancelCrawlJobs(urlID int) (int, error) {
+	args := m.Called(urlID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ReleaseCrawlJob(id int, workerID string) error {
+	args := m.Called(id, workerID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecoverCrawlJobs(maxAttempts int) (int, error) {
+	args := m.Called(maxAttempts)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockRepository) GetUserByAPIKey(apiKey string) (*models.User, error) {
 	args := m.Called(apiKey)
 	return args.Get(0).(*models.User), args.Error(1)
@@ -90,6 +278,20 @@ func (m *MockRepository) Ping() error {
 	return args.Error(0)
 }
 
+// MockNotifier implements CrawlNotifier for testing
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) NotifyCrawl(w database.CrawlResultWriter, event models.CrawlEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockNotifier) Flush() {
+	m.Called()
+}
+
 func createTestHTTPServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		html := `
@@ -101,16 +303,53 @@ func createTestHTTPServer() *httptest.Server {
 <body>
     <h1>Test Heading</h1>
     <a href="/internal">Internal Link</a>
-    <a href="https://example.com">External Link</a>
+    <a href="https://example.com" rel="nofollow">External Link</a>
 </body>
 </html>`
 		w.Write([]byte(html))
 	}))
 }
 
+// mocks the queue calls made when a crawl for the URL is queued
+func expectEnqueue(mockRepo *MockRepository, urlID int, queueID int) *models.CrawlJobRecord {
+	record := &models.CrawlJobRecord{ID: queueID, URLID: urlID, Status: models.JobStatusQueued}
+	
+	mockRepo.On("EnqueueCrawlJob", urlID, mock.AnythingOfType("models.CrawlJobOptions")).Return(record, nil).Once()
+	mockRepo.On("UpdateURLStatus", urlID, models.StatusQueued, (*string)(nil)).Return(nil).Once()
+	
+	return record
+}
+
+// mocks a queue that hands out the given jobs once and is empty afterwards
+func expectClaims(mockRepo *MockRepository, records ...*models.CrawlJobRecord) {
+	for _, record := range records {
+		mockRepo.On("ClaimNextCrawlJob", mock.Anything, mock.Anything).Return(record, nil).Once()
+	}
+	mockRepo.On("ClaimNextCrawlJob", mock.Anything, mock.Anything).Return(nil, nil)
+}
+
+// waits until the crawl job of a URL has finished
+func waitForJob(t *testing.T, service *CrawlerService, urlID int) *models.CrawlJob {
+	maxWait := 5 * time.Second
+	start := time.Now()
+	
+	for time.Since(start) < maxWait {
+		job, err := service.GetJobStatus(urlID)
+		if err == nil && job.Status.IsFinished() {
+			return job
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	
+	t.Fatalf("crawl job for URL ID %d did not finish in time", urlID)
+	return nil
+}
+
 func TestCrawlerService_StartCrawl_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
+	mockNotifier := new(MockNotifier)
 	service := NewCrawlerService(mockRepo)
+	service.SetNotifier(mockNotifier)
 	
 	server := createTestHTTPServer()
 	defer server.Close()
@@ -123,31 +362,162 @@ func TestCrawlerService_StartCrawl_Success(t *testing.T) {
 	}
 	
 	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	record := expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, record)
 	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
-	mockRepo.On("CreateCrawlResult", mock.AnythingOfType("*models.CrawlResult")).Return(nil)
+	// The rules of the URL are evaluated and their outcome saved with the result
+	mockRepo.On("ListRulesForURL", 1).Return([]models.Rule{
+		{ID: 3, Name: "One h1", Type: models.RuleElementCount, Selector: "h1", Operator: models.RuleOperatorEqual, Count: 1, Severity: models.SeverityError, Enabled: true},
+		{ID: 4, Name: "Has a footer", Type: models.RuleSelectorExists, Selector: "footer", Severity: models.SeverityWarning, Enabled: true},
+	}, nil)
+	mockRepo.On("CreateCrawlResult", mock.MatchedBy(func(result *models.CrawlResult) bool {
+		return result.RulesPassed == 1 && result.RulesFailed == 1 && result.Fetcher == models.FetcherHTTP &&
+			result.Sitemap != nil && len(result.Sitemap.Sitemaps) == 0 && result.Sitemap.LinkedURLs == 1
+	})).Return(nil)
+	mockRepo.On("CreateRuleResults", mock.AnythingOfType("int"), mock.MatchedBy(func(results []models.RuleResult) bool {
+		return len(results) == 2 &&
+			results[0].RuleID != nil && *results[0].RuleID == 3 && results[0].Passed &&
+			results[1].RuleName == "Has a footer" && !results[1].Passed && results[1].Message == "footer matches 0 elements"
+	})).Return(nil).Once()
 	// Make CreateBrokenLinks optional since the test server might not have broken links
 	mockRepo.On("CreateBrokenLinks", mock.AnythingOfType("int"), mock.AnythingOfType("[]models.BrokenLink")).Return(nil).Maybe()
-	mockRepo.On("UpdateURLStatus", 1, models.StatusCompleted, (*string)(nil)).Return(nil)
+	// Every link is saved, checked or not. Whether the external one is reachable depends on the network.
+	mockRepo.On("CreateLinks", mock.AnythingOfType("int"), mock.MatchedBy(func(links []models.Link) bool {
+		return len(links) == 2 &&
+			links[0].URL == server.URL+"/internal" && links[0].AnchorText == "Internal Link" && links[0].IsInternal &&
+			links[0].Status == models.LinkStatusOK && links[0].StatusCode != nil && *links[0].StatusCode == http.StatusOK &&
+			links[1].URL == "https://example.com" && links[1].Rel == "nofollow" && !links[1].IsInternal &&
+			links[1].Status != models.LinkStatusUnchecked
+	})).Return(nil).Once()
+	// The plain HTTP test server sends no security headers
+	mockRepo.On("CreateSecurityReport", mock.MatchedBy(func(report *models.SecurityReport) bool {
+		return report.Grade == "F" && report.TLSVersion == nil && len(report.Checks) == 9
+	})).Return(nil).Once()
+	// The test page has neither a description nor a lang attribute, is served over HTTP and the site has no sitemap
+	mockRepo.On("CreateFindings", mock.AnythingOfType("int"), mock.MatchedBy(func(findings []models.Finding) bool {
+		codes := []string{}
+		for _, finding := range findings {
+			codes = append(codes, finding.Category+"/"+finding.Code)
+		}
+		return assert.ObjectsAreEqual([]string{"seo/missing_description", "seo/missing_lang", "security/not_https", "sitemap/missing_sitemap"}, codes)
+	})).Return(nil).Once()
+	// The results are saved in the transaction that completes the job and its URL
+	mockRepo.On("FinishCrawlJob", 10, mock.AnythingOfType("string"), models.CrawlJobOutcome{JobStatus: models.JobStatusCompleted, URLStatus: models.StatusCompleted}).Return(nil)
+	// Webhooks are told what the crawl saved, and sent once it is recorded
+	mockNotifier.On("NotifyCrawl", mock.MatchedBy(func(event models.CrawlEvent) bool {
+		return event.URLID == 1 && event.URL == server.URL && event.Status == models.StatusCompleted &&
+			event.Result != nil && event.Result.RulesPassed == 1 && len(event.Links) == 2
+	})).Return(nil).Once()
+	mockNotifier.On("Flush").Once()
 	
-	// Start crawl
+	events, _, _ := service.SubscribeJobEvents(1, "")
+	defer events.Close()
+	
+	// Queue the crawl, then let a worker pick it up
 	err := service.StartCrawl(1)
 	require.NoError(t, err)
 	
-	// Wait for crawl to complete (longer timeout for safety)
-	maxWait := 5 * time.Second
-	start := time.Now()
+	job, err := service.GetJobStatus(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.CrawlStatusQueued, job.Status)
+	assert.Equal(t, 10, job.QueueID)
 	
-	for time.Since(start) < maxWait {
-		job, err := service.GetJobStatus(1)
-		if err == nil && (job.Status == models.CrawlStatusCompleted || job.Status == models.CrawlStatusFailed) {
-			// Wait a bit more to ensure all database operations complete
-			time.Sleep(100 * time.Millisecond)
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
+	require.NoError(t, service.Start(1))
+	
+	job = waitForJob(t, service, 1)
+	assert.Equal(t, models.CrawlStatusCompleted, job.Status)
+	
+	// Stopping waits for the worker to record the job as finished
+	service.Stop()
+	
+	// Every transition was pushed to subscribers, in order
+	var statuses []models.CrawlStatus
+	for len(events.Events) > 0 {
+		statuses = append(statuses, (<-events.Events).Status)
+	}
+	require.NotEmpty(t, statuses)
+	assert.Equal(t, models.CrawlStatusQueued, statuses[0])
+	assert.Equal(t, models.CrawlStatusStarted, statuses[1])
+	assert.Equal(t, models.CrawlStatusCompleted, statuses[len(statuses)-1])
+	
+	mockRepo.AssertExpectations(t)
+	mockNotifier.AssertExpectations(t)
+}
+
+func TestCrawlerService_StartCrawl_URLCrawlOptions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	checkLinks := false
+	userAgent := "CustomBot/2.0"
+	testURL := &models.URL{
+		ID:     1,
+		URL:    "http://example.com",
+		Status: models.StatusQueued,
+		CrawlOptions: &models.CrawlOptionsOverride{
+			CheckBrokenLinks: &checkLinks,
+			UserAgent:        &userAgent,
+		},
 	}
 	
-	// Verify expectations were met (be more lenient)
+	// The job is queued with the URL's options so a recovered job crawls the same way
+	record := &models.CrawlJobRecord{ID: 10, URLID: 1, Options: models.CrawlJobOptions{Overrides: testURL.CrawlOptions}}
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	mockRepo.On("EnqueueCrawlJob", 1, models.CrawlJobOptions{Overrides: testURL.CrawlOptions}).Return(record, nil)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusQueued, (*string)(nil)).Return(nil)
+	
+	require.NoError(t, service.StartCrawl(1))
+	
+	job, err := service.GetJobStatus(1)
+	require.NoError(t, err)
+	
+	options := job.Options.Apply(service.options)
+	assert.False(t, options.CheckBrokenLinks)
+	assert.Equal(t, "CustomBot/2.0", options.UserAgent)
+	assert.Equal(t, service.options.Timeout, options.Timeout)
+	
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCrawlerService_StartCrawlWithOptions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	timeout := 60
+	userAgent := "CustomBot/2.0"
+	testURL := &models.URL{
+		ID:           1,
+		URL:          "http://example.com",
+		Status:       models.StatusCompleted,
+		CrawlOptions: &models.CrawlOptionsOverride{TimeoutSeconds: &timeout},
+	}
+	overrides := &models.CrawlOptionsOverride{UserAgent: &userAgent}
+	merged := testURL.CrawlOptions.Merge(overrides)
+	
+	// Options rejected with the start are not saved on the URL
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	mockRepo.On("EnqueueCrawlJob", 1, models.CrawlJobOptions{Overrides: merged}).Return(nil, database.ErrActiveCrawlJob).Once()
+	
+	err := service.StartCrawlWithOptions(1, overrides)
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "UpdateURLCrawlOptions", mock.Anything, mock.Anything)
+	
+	// The job is queued with the merged options, which are kept for later crawls
+	record := &models.CrawlJobRecord{ID: 10, URLID: 1, Options: models.CrawlJobOptions{Overrides: merged}}
+	mockRepo.On("EnqueueCrawlJob", 1, models.CrawlJobOptions{Overrides: merged}).Return(record, nil).Once()
+	mockRepo.On("UpdateURLStatus", 1, models.StatusQueued, (*string)(nil)).Return(nil)
+	mockRepo.On("UpdateURLCrawlOptions", 1, merged).Return(nil)
+	
+	require.NoError(t, service.StartCrawlWithOptions(1, overrides))
+	
+	job, err := service.GetJobStatus(1)
+	require.NoError(t, err)
+	
+	options := job.Options.Apply(service.options)
+	assert.Equal(t, 60*time.Second, options.Timeout)
+	assert.Equal(t, "CustomBot/2.0", options.UserAgent)
+	
 	mockRepo.AssertExpectations(t)
 }
 
@@ -179,7 +549,7 @@ func TestCrawlerService_StartCrawl_AlreadyRunning(t *testing.T) {
 	
 	// Mock expectations for first crawl
 	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
-	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	expectEnqueue(mockRepo, 1, 10)
 	
 	// Start first crawl
 	err := service.StartCrawl(1)
@@ -188,39 +558,56 @@ func TestCrawlerService_StartCrawl_AlreadyRunning(t *testing.T) {
 	// Try to start second crawl immediately (should fail)
 	err = service.StartCrawl(1)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "crawl already in progress")
+	assert.ErrorIs(t, err, ErrCrawlInProgress)
 	
 	mockRepo.AssertExpectations(t)
 }
 
-func TestCrawlerService_StopCrawl(t *testing.T) {
+func TestCrawlerService_StartCrawl_QueuedElsewhere(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewCrawlerService(mockRepo)
 	
-	server := createTestHTTPServer()
-	defer server.Close()
+	testURL := &models.URL{
+		ID:     1,
+		URL:    "http://example.com",
+		Status: models.StatusRunning,
+	}
+	
+	// Another server instance holds an active job for the URL
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	mockRepo.On("EnqueueCrawlJob", 1, mock.AnythingOfType("models.CrawlJobOptions")).Return(nil, database.ErrActiveCrawlJob)
+	
+	err := service.StartCrawl(1)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrCrawlInProgress)
+	
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateURLStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCrawlerService_StopCrawl(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
 	
 	testURL := &models.URL{
 		ID:     1,
-		URL:    server.URL,
+		URL:    "http://example.com",
 		Status: models.StatusQueued,
 	}
 	
 	// Mock expectations - be more specific about the error status update
 	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
-	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("CancelCrawlJobs", 1).Return(1, nil)
 	mockRepo.On("UpdateURLStatus", 1, models.StatusError, mock.MatchedBy(func(msg *string) bool {
 		return msg != nil && *msg == "Cancelled by user"
 	})).Return(nil)
 	
-	// Start crawl
+	// Queue crawl
 	err := service.StartCrawl(1)
 	require.NoError(t, err)
 	
-	// Give it a moment to start
-	time.Sleep(200 * time.Millisecond)
-	
-	// Stop crawl
+	// Stop crawl before a worker picked it up
 	err = service.StopCrawl(1)
 	assert.NoError(t, err)
 	
@@ -230,10 +617,284 @@ func TestCrawlerService_StopCrawl(t *testing.T) {
 	assert.Equal(t, models.CrawlStatusFailed, job.Status)
 	assert.Equal(t, "Cancelled by user", job.Message)
 	
-	// Wait a bit to ensure all operations complete
-	time.Sleep(100 * time.Millisecond)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCrawlerService_StopCrawl_Running(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	// The page never answers, only cancelling the job ends the request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	
+	testURL := &models.URL{
+		ID:     1,
+		URL:    server.URL,
+		Status: models.StatusQueued,
+	}
+	
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	record := expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, record)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	mockRepo.On("ListRulesForURL", 1).Return([]models.Rule{}, nil)
+	mockRepo.On("CancelCrawlJobs", 1).Return(1, nil)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusError, mock.MatchedBy(func(msg *string) bool {
+		return msg != nil && *msg == "Cancelled by user"
+	})).Return(nil).Once()
+	// StopCrawl already cancelled the job in the queue, so the worker cannot finish it
+	mockRepo.On("FinishCrawlJob", 10, mock.AnythingOfType("string"), mock.MatchedBy(func(outcome models.CrawlJobOutcome) bool {
+		return outcome.JobStatus == models.JobStatusCancelled
+	})).Return(database.ErrCrawlJobLeaseLost)
+	
+	require.NoError(t, service.StartCrawl(1))
+	require.NoError(t, service.Start(1))
+	
+	// Wait for the worker to be fetching the page
+	require.Eventually(t, func() bool {
+		job, err := service.GetJobStatus(1)
+		return err == nil && job.Status == models.CrawlStatusFetching
+	}, 5*time.Second, 20*time.Millisecond)
+	
+	require.NoError(t, service.StopCrawl(1))
+	
+	// The worker returns as soon as the request is aborted
+	service.Stop()
+	
+	job, err := service.GetJobStatus(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.CrawlStatusFailed, job.Status)
+	assert.Equal(t, "Cancelled by user", job.Message)
 	
 	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateCrawlResult", mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateURLStatus", 1, models.StatusCompleted, mock.Anything)
+}
+
+func TestCrawlerService_LeaseLost_StopsCrawl(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockNotifier := new(MockNotifier)
+	service := NewCrawlerService(mockRepo)
+	service.SetNotifier(mockNotifier)
+	service.heartbeatInterval = 20 * time.Millisecond
+	
+	// The page never answers, only stopping the job ends the request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	
+	testURL := &models.URL{
+		ID:     1,
+		URL:    server.URL,
+		Status: models.StatusQueued,
+	}
+	
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	record := expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, record)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	mockRepo.On("ListRulesForURL", 1).Return([]models.Rule{}, nil)
+	// Another instance cancelled the job, or requeued it after its lease expired
+	mockRepo.On("HeartbeatCrawlJob", 10, mock.AnythingOfType("string"), jobLeaseDuration).Return(database.ErrCrawlJobLeaseLost)
+	mockRepo.On("FinishCrawlJob", 10, mock.AnythingOfType("string"), mock.Anything).Return(database.ErrCrawlJobLeaseLost)
+	
+	require.NoError(t, service.StartCrawl(1))
+	require.NoError(t, service.Start(1))
+	
+	job := waitForJob(t, service, 1)
+	assert.Equal(t, models.CrawlStatusFailed, job.Status)
+	assert.Contains(t, job.Message, "cancelled or requeued")
+	
+	service.Stop()
+	
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateCrawlResult", mock.Anything)
+	mockNotifier.AssertNotCalled(t, "NotifyCrawl", mock.Anything)
+}
+
+func TestCrawlerService_Stop_ReleasesRunningJobs(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockNotifier := new(MockNotifier)
+	service := NewCrawlerService(mockRepo)
+	service.SetNotifier(mockNotifier)
+	
+	// The page never answers, only stopping the job ends the request
+	requested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	
+	testURL := &models.URL{
+		ID:     1,
+		URL:    server.URL,
+		Status: models.StatusQueued,
+	}
+	
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	record := expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, record)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	mockRepo.On("ListRulesForURL", 1).Return([]models.Rule{}, nil)
+	// The job goes back to the queue instead of waiting for its lease to expire
+	mockRepo.On("ReleaseCrawlJob", 10, mock.AnythingOfType("string")).Return(nil).Once()
+	
+	require.NoError(t, service.StartCrawl(1))
+	require.NoError(t, service.Start(1))
+	
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the crawl did not start")
+	}
+	
+	stopped := make(chan struct{})
+	go func() {
+		service.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not cancel the running crawl")
+	}
+	
+	job, err := service.GetJobStatus(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.CrawlStatusQueued, job.Status)
+	
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "FinishCrawlJob", mock.Anything, mock.Anything, mock.Anything)
+	mockNotifier.AssertNotCalled(t, "NotifyCrawl", mock.Anything)
+}
+
+func TestCrawlerService_FinishLeaseLost_DiscardsResults(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockNotifier := new(MockNotifier)
+	service := NewCrawlerService(mockRepo)
+	service.SetNotifier(mockNotifier)
+	
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(404)
+			return
+		}
+		w.Write([]byte(`<html lang="en"><head><title>Done</title></head><body><h1>Done</h1></body></html>`))
+	}))
+	defer server.Close()
+	
+	testURL := &models.URL{
+		ID:     1,
+		URL:    server.URL,
+		Status: models.StatusQueued,
+	}
+	
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	record := expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, record)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	mockRepo.On("ListRulesForURL", 1).Return([]models.Rule{}, nil)
+	// The crawl finished, but by then the job had been cancelled in the queue
+	mockRepo.On("FinishCrawlJob", 10, mock.AnythingOfType("string"), mock.Anything).Return(database.ErrCrawlJobLeaseLost)
+	
+	require.NoError(t, service.StartCrawl(1))
+	require.NoError(t, service.Start(1))
+	
+	job := waitForJob(t, service, 1)
+	assert.Equal(t, models.CrawlStatusFailed, job.Status)
+	
+	service.Stop()
+	
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateCrawlResult", mock.Anything)
+	mockNotifier.AssertNotCalled(t, "NotifyCrawl", mock.Anything)
+}
+
+func TestCrawlerService_StopCrawl_QueuedElsewhere(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	// Jobs unknown to this instance are still cancelled in the queue
+	mockRepo.On("CancelCrawlJobs", 1).Return(1, nil)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusError, mock.MatchedBy(func(msg *string) bool {
+		return msg != nil && *msg == "Cancelled by user"
+	})).Return(nil)
+	
+	err := service.StopCrawl(1)
+	assert.NoError(t, err)
+	
+	// Without any job there is nothing to stop
+	mockRepo.On("CancelCrawlJobs", 2).Return(0, nil)
+	
+	err = service.StopCrawl(2)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrNoActiveJob)
+	
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCrawlerService_Start_RunsRecoveredJob(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		w.WriteHeader(500)
+	}))
+	defer server.Close()
+	
+	testURL := &models.URL{
+		ID:     2,
+		URL:    server.URL,
+		Status: models.StatusQueued,
+	}
+	
+	// A job requeued on boot has no in-memory job until a worker claims it
+	record := &models.CrawlJobRecord{ID: 20, URLID: 2, Status: models.JobStatusRunning, Attempts: 2}
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(1, nil)
+	expectClaims(mockRepo, record)
+	mockRepo.On("GetURLByID", 2).Return(testURL, nil)
+	mockRepo.On("UpdateURLStatus", 2, models.StatusRunning, (*string)(nil)).Return(nil)
+	mockRepo.On("ListRulesForURL", 2).Return([]models.Rule{}, nil)
+	mockRepo.On("FinishCrawlJob", 20, mock.AnythingOfType("string"), mock.MatchedBy(func(outcome models.CrawlJobOutcome) bool {
+		return outcome.JobStatus == models.JobStatusFailed && outcome.URLStatus == models.StatusError &&
+			outcome.ErrorMessage != nil && *outcome.ErrorMessage != ""
+	})).Return(nil)
+	
+	require.NoError(t, service.Start(2))
+	
+	job := waitForJob(t, service, 2)
+	assert.Equal(t, models.CrawlStatusFailed, job.Status)
+	assert.Equal(t, 20, job.QueueID)
+	
+	service.Stop()
+	
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateCrawlResult", mock.Anything)
 }
 
 func TestCrawlerService_GetActiveJobs(t *testing.T) {
@@ -264,6 +925,32 @@ func TestCrawlerService_GetActiveJobs(t *testing.T) {
 	assert.NotContains(t, activeJobs, 2)
 }
 
+func TestCrawlerService_SubscribeJobEvents_SnapshotWhenNotResumed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	service.jobsMu.Lock()
+	service.jobs[2] = &models.CrawlJob{ID: 2, URL: "http://test.com", Status: models.CrawlStatusCompleted, Progress: 100}
+	service.jobs[1] = &models.CrawlJob{ID: 1, URL: "http://example.com", Status: models.CrawlStatusChecking, Progress: 40}
+	service.publishJob(service.jobs[1])
+	service.jobsMu.Unlock()
+	
+	// A client whose last event is from before a restart gets the state of every job, without IDs
+	sub, events, resumed := service.SubscribeJobEvents(0, "oldboot-1")
+	defer sub.Close()
+	assert.False(t, resumed)
+	require.Len(t, events, 2)
+	assert.Equal(t, 1, events[0].URLID)
+	assert.Equal(t, 40.0, events[0].Progress)
+	assert.Empty(t, events[0].ID)
+	assert.Equal(t, models.CrawlStatusCompleted, events[1].Status)
+	
+	// A client of this boot resumes from its last event
+	_, events, resumed = service.SubscribeJobEvents(1, service.events.boot+"-1")
+	assert.True(t, resumed)
+	assert.Empty(t, events)
+}
+
 func TestCrawlerService_CleanupCompletedJobs(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewCrawlerService(mockRepo)
@@ -304,8 +991,106 @@ func TestCrawlerService_GetCrawlerStats(t *testing.T) {
 	assert.Contains(t, stats, "timeout")
 	assert.Contains(t, stats, "max_redirects")
 	assert.Contains(t, stats, "user_agent")
+	assert.Equal(t, models.FetcherHTTP, stats["fetcher"])
 	assert.Contains(t, stats, "active_jobs")
 	assert.Contains(t, stats, "job_status_counts")
 	
 	assert.Equal(t, 0, stats["active_jobs"])
-}
\ No newline at end of file
+	assert.Contains(t, stats, "workers")
+	assert.Contains(t, stats, "worker_id")
+}
+
+func TestCrawlerService_StartCrawl_BlockedByRobots(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockNotifier := new(MockNotifier)
+	service := NewCrawlerService(mockRepo)
+	service.SetNotifier(mockNotifier)
+	
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /\n"))
+			return
+		}
+		w.Write([]byte("<html><body>Secret</body></html>"))
+	}))
+	defer server.Close()
+	
+	testURL := &models.URL{
+		ID:     1,
+		URL:    server.URL,
+		Status: models.StatusQueued,
+	}
+	
+	// Blocked crawls are recorded as blocked, never as errors or results
+	mockRepo.On("GetURLByID", 1).Return(testURL, nil)
+	record := expectEnqueue(mockRepo, 1, 10)
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, record)
+	mockRepo.On("UpdateURLStatus", 1, models.StatusRunning, (*string)(nil)).Return(nil)
+	mockRepo.On("ListRulesForURL", 1).Return([]models.Rule{}, nil)
+	mockRepo.On("FinishCrawlJob", 10, mock.AnythingOfType("string"), mock.MatchedBy(func(outcome models.CrawlJobOutcome) bool {
+		return outcome.JobStatus == models.JobStatusCompleted && outcome.URLStatus == models.StatusBlocked &&
+			outcome.ErrorMessage != nil && *outcome.ErrorMessage == "Blocked by robots.txt"
+	})).Return(nil)
+	// Webhooks hear about blocked crawls as failures
+	mockNotifier.On("NotifyCrawl", mock.MatchedBy(func(event models.CrawlEvent) bool {
+		return event.URLID == 1 && event.Status == models.StatusBlocked && *event.ErrorMessage == "Blocked by robots.txt"
+	})).Return(nil).Once()
+	mockNotifier.On("Flush").Once()
+	
+	err := service.StartCrawl(1)
+	require.NoError(t, err)
+	require.NoError(t, service.Start(1))
+	
+	job := waitForJob(t, service, 1)
+	assert.Equal(t, models.CrawlStatusBlocked, job.Status)
+	
+	service.Stop()
+	
+	mockRepo.AssertExpectations(t)
+	mockNotifier.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateCrawlResult", mock.Anything)
+}
+
+func TestCrawlerService_SharesRobotsBetweenJobs(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewCrawlerService(mockRepo)
+	
+	var robotsRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&robotsRequests, 1)
+			w.Write([]byte("User-agent: *\nDisallow: /\n"))
+			return
+		}
+		w.Write([]byte("<html><body>Secret</body></html>"))
+	}))
+	defer server.Close()
+	
+	// Two pages of one host, crawled by jobs with crawlers of their own
+	var records []*models.CrawlJobRecord
+	for i, path := range []string{"/", "/other"} {
+		urlID := i + 1
+		mockRepo.On("GetURLByID", urlID).Return(&models.URL{ID: urlID, URL: server.URL + path, Status: models.StatusQueued}, nil)
+		records = append(records, expectEnqueue(mockRepo, urlID, 10+urlID))
+		mockRepo.On("UpdateURLStatus", urlID, models.StatusRunning, (*string)(nil)).Return(nil)
+		mockRepo.On("ListRulesForURL", urlID).Return([]models.Rule{}, nil)
+		mockRepo.On("FinishCrawlJob", 10+urlID, mock.AnythingOfType("string"), mock.MatchedBy(func(outcome models.CrawlJobOutcome) bool {
+			return outcome.URLStatus == models.StatusBlocked
+		})).Return(nil)
+	}
+	mockRepo.On("RecoverCrawlJobs", jobMaxAttempts).Return(0, nil)
+	expectClaims(mockRepo, records...)
+	
+	require.NoError(t, service.StartCrawl(1))
+	require.NoError(t, service.StartCrawl(2))
+	require.NoError(t, service.Start(1))
+	
+	assert.Equal(t, models.CrawlStatusBlocked, waitForJob(t, service, 1).Status)
+	assert.Equal(t, models.CrawlStatusBlocked, waitForJob(t, service, 2).Status)
+	
+	service.Stop()
+	
+	assert.Equal(t, int32(1), atomic.LoadInt32(&robotsRequests))
+	mockRepo.AssertExpectations(t)
+}
@@ -1,15 +1,30 @@
 package services
 
-import "url-analyzer/internal/models"
+import (
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+)
 
 // defines the contract for crawler service operations
 type CrawlerServiceInterface interface {
 	StartCrawl(urlID int) error
+	StartCrawlWithOptions(urlID int, overrides *models.CrawlOptionsOverride) error
+	StartSiteCrawl(urlID int, req models.SiteCrawlRequest) error
 	StopCrawl(urlID int) error
 	GetJobStatus(urlID int) (*models.CrawlJob, error)
 	GetActiveJobs() map[int]*models.CrawlJob
 	GetCrawlerStats() map[string]interface{}
+	GetAnalyzers() []models.AnalyzerInfo
 	CleanupCompletedJobs()
+	SubscribeJobEvents(urlID int, lastEventID string) (*JobEventSubscription, []models.JobEvent, bool)
+}
+
+// is told how every crawl ended, e.g. to send webhooks. What it has to send is written through the
+// writer of the transaction recording the outcome, so it is kept exactly when the outcome is;
+// Flush is called once that transaction committed.
+type CrawlNotifier interface {
+	NotifyCrawl(w database.CrawlResultWriter, event models.CrawlEvent) error
+	Flush()
 }
 
 // Ensure CrawlerService implements CrawlerServiceInterface
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"url-analyzer/internal/models"
+)
+
+const (
+	// how many recent events are kept for clients resuming with Last-Event-ID
+	jobEventHistorySize = 1000
+	// how many events a subscriber may fall behind before it is dropped
+	jobEventSubscriberBuffer = 256
+)
+
+// fans crawl job events out to event stream subscribers and keeps the most
+// recent ones so that reconnecting clients can catch up on what they missed.
+// Event IDs are "<boot>-<seq>": seq restarts with every broker, so IDs of
+// another boot are never mistaken for ones of this boot.
+type JobEventBroker struct {
+	mu          sync.Mutex
+	boot        string
+	lastSeq     int64
+	history     []models.JobEvent
+	next        int
+	subscribers map[*JobEventSubscription]struct{}
+}
+
+// JobEventSubscription receives the events of one URL, or of every URL when its URL ID is 0.
+// Events is closed when the subscriber fell too far behind or was closed.
+type JobEventSubscription struct {
+	Events <-chan models.JobEvent
+
+	urlID  int
+	events chan models.JobEvent
+	broker *JobEventBroker
+}
+
+// creates a new job event broker
+func NewJobEventBroker() *JobEventBroker {
+	return &JobEventBroker{
+		boot:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:     make([]models.JobEvent, 0, jobEventHistorySize),
+		subscribers: make(map[*JobEventSubscription]struct{}),
+	}
+}
+
+// assigns the event its ID and timestamp and sends it to every matching subscriber without blocking.
+// Subscribers that cannot keep up are dropped; they resume from their last event ID.
+func (b *JobEventBroker) Publish(event models.JobEvent) models.JobEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastSeq++
+	event.ID = b.boot + "-" + strconv.FormatInt(b.lastSeq, 10)
+	event.Timestamp = time.Now()
+
+	if len(b.history) < jobEventHistorySize {
+		b.history = append(b.history, event)
+	} else {
+		b.history[b.next] = event
+		b.next = (b.next + 1) % jobEventHistorySize
+	}
+
+	for sub := range b.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+
+	return event
+}
+
+// subscribes to the events of a URL, or of every URL when urlID is 0. When lastEventID is one of
+// this boot's and the events after it are still kept, they are returned and resumed is true.
+// Otherwise the client may have missed events and needs the current state instead.
+func (b *JobEventBroker) Subscribe(urlID int, lastEventID string) (sub *JobEventSubscription, replay []models.JobEvent, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan models.JobEvent, jobEventSubscriberBuffer)
+	sub = &JobEventSubscription{
+		Events: events,
+		urlID:  urlID,
+		events: events,
+		broker: b,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	replay = []models.JobEvent{}
+	lastSeq, ok := b.parseEventID(lastEventID)
+	// The history is a ring; the oldest event sits at next once it is full
+	firstSeq := b.lastSeq - int64(len(b.history)) + 1
+	if !ok || lastSeq < firstSeq-1 {
+		return sub, replay, false
+	}
+
+	for i := lastSeq - firstSeq + 1; i < int64(len(b.history)); i++ {
+		event := b.history[(b.next+int(i))%len(b.history)]
+		if sub.matches(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	return sub, replay, true
+}
+
+// returns the sequence number of an event ID published by this broker
+func (b *JobEventBroker) parseEventID(id string) (int64, bool) {
+	boot, seq, found := strings.Cut(id, "-")
+	if !found || boot != b.boot {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil || n < 0 || n > b.lastSeq {
+		return 0, false
+	}
+	return n, true
+}
+
+// stops the subscription and closes its Events channel
+func (s *JobEventSubscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+// reports whether the subscription wants the event
+func (s *JobEventSubscription) matches(event models.JobEvent) bool {
+	return s.urlID == 0 || s.urlID == event.URLID
+}
+
+// unregisters a subscriber; callers hold b.mu
+func (b *JobEventBroker) remove(sub *JobEventSubscription) {
+	if _, exists := b.subscribers[sub]; !exists {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
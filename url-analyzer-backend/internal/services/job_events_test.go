@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobEventBroker_PublishFiltersByURL(t *testing.T) {
+	broker := NewJobEventBroker()
+
+	all, _, _ := broker.Subscribe(0, "")
+	defer all.Close()
+	one, _, _ := broker.Subscribe(2, "")
+	defer one.Close()
+
+	broker.Publish(models.JobEvent{URLID: 1, Status: models.CrawlStatusStarted})
+	broker.Publish(models.JobEvent{URLID: 2, Status: models.CrawlStatusStarted})
+
+	event := <-all.Events
+	assert.Equal(t, broker.boot+"-1", event.ID)
+	assert.Equal(t, 1, event.URLID)
+	event = <-all.Events
+	assert.Equal(t, broker.boot+"-2", event.ID)
+
+	event = <-one.Events
+	assert.Equal(t, broker.boot+"-2", event.ID)
+	assert.Equal(t, 2, event.URLID)
+	assert.Empty(t, one.Events)
+}
+
+func TestJobEventBroker_SubscribeReplaysMissedEvents(t *testing.T) {
+	broker := NewJobEventBroker()
+
+	for i := 0; i < 5; i++ {
+		broker.Publish(models.JobEvent{URLID: 1 + i%2})
+	}
+
+	sub, replay, resumed := broker.Subscribe(1, broker.boot+"-2")
+	defer sub.Close()
+
+	// Events 3 and 5 are URL 1's after event 2
+	assert.True(t, resumed)
+	require.Len(t, replay, 2)
+	assert.Equal(t, broker.boot+"-3", replay[0].ID)
+	assert.Equal(t, broker.boot+"-5", replay[1].ID)
+
+	// Up to date: resumed with nothing to replay
+	_, replay, resumed = broker.Subscribe(0, broker.boot+"-5")
+	assert.True(t, resumed)
+	assert.Empty(t, replay)
+}
+
+func TestJobEventBroker_SubscribeFromAnotherBoot(t *testing.T) {
+	broker := NewJobEventBroker()
+
+	for i := 0; i < 5; i++ {
+		broker.Publish(models.JobEvent{URLID: 1})
+	}
+
+	// Sequence numbers restart with every boot, so an ID of another one cannot be resumed from
+	// even when its number was published here too
+	for _, id := range []string{"", "2", "0123abc-2", broker.boot + "-99", broker.boot + "-x"} {
+		sub, replay, resumed := broker.Subscribe(0, id)
+		assert.False(t, resumed, id)
+		assert.Empty(t, replay, id)
+		sub.Close()
+	}
+
+	// The next boot numbers its events apart from this one's
+	assert.NotEqual(t, broker.boot, NewJobEventBroker().boot)
+}
+
+func TestJobEventBroker_ReplayKeepsOnlyRecentEvents(t *testing.T) {
+	broker := NewJobEventBroker()
+
+	for i := 0; i < jobEventHistorySize+10; i++ {
+		broker.Publish(models.JobEvent{URLID: 1})
+	}
+
+	// Events after 10 are all kept
+	sub, replay, resumed := broker.Subscribe(0, broker.boot+"-10")
+	defer sub.Close()
+
+	assert.True(t, resumed)
+	require.Len(t, replay, jobEventHistorySize)
+	assert.Equal(t, broker.boot+"-11", replay[0].ID)
+	assert.Equal(t, fmt.Sprintf("%s-%d", broker.boot, jobEventHistorySize+10), replay[len(replay)-1].ID)
+
+	// Event 10 was dropped from the history, so a client that last saw 9 missed it
+	_, replay, resumed = broker.Subscribe(0, broker.boot+"-9")
+	assert.False(t, resumed)
+	assert.Empty(t, replay)
+}
+
+func TestJobEventBroker_DropsSlowSubscribers(t *testing.T) {
+	broker := NewJobEventBroker()
+
+	sub, _, _ := broker.Subscribe(0, "")
+
+	for i := 0; i < jobEventSubscriberBuffer+1; i++ {
+		broker.Publish(models.JobEvent{URLID: 1})
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	assert.Equal(t, jobEventSubscriberBuffer, received)
+
+	// Closing a dropped subscription is harmless
+	sub.Close()
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+)
+
+const (
+	// how often due schedules are looked for
+	schedulerTickInterval = 30 * time.Second
+	// how many due schedules are handled per tick
+	schedulerBatchSize = 50
+)
+
+// starts crawls for schedules when they are due
+type SchedulerService struct {
+	repo           database.ScheduleRepositoryInterface
+	crawlerService CrawlerServiceInterface
+	now            func() time.Time
+	stop           chan struct{}
+	wg             sync.WaitGroup
+}
+
+// creates a new scheduler service
+func NewSchedulerService(repo database.ScheduleRepositoryInterface, crawlerService CrawlerServiceInterface) *SchedulerService {
+	return &SchedulerService{
+		repo:           repo,
+		crawlerService: crawlerService,
+		now:            func() time.Time { return time.Now().UTC() },
+		stop:           make(chan struct{}),
+	}
+}
+
+// starts checking for due schedules in the background
+func (ss *SchedulerService) Start() {
+	ss.wg.Add(1)
+	go func() {
+		defer ss.wg.Done()
+
+		ticker := time.NewTicker(schedulerTickInterval)
+		defer ticker.Stop()
+
+		for {
+			ss.RunDue()
+
+			select {
+			case <-ss.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stops checking for due schedules
+func (ss *SchedulerService) Stop() {
+	close(ss.stop)
+	ss.wg.Wait()
+}
+
+// starts a crawl for every schedule that is due and moves each schedule to its next run.
+// Returns the number of crawls started.
+func (ss *SchedulerService) RunDue() int {
+	now := ss.now()
+
+	schedules, err := ss.repo.ListDueSchedules(now, schedulerBatchSize)
+	if err != nil {
+		log.Printf("Failed to list due schedules: %v", err)
+		return 0
+	}
+
+	started := 0
+	for i := range schedules {
+		if ss.runSchedule(&schedules[i], now) {
+			started++
+		}
+	}
+
+	return started
+}
+
+// claims a due schedule and starts its crawl, reporting whether a crawl was started
+func (ss *SchedulerService) runSchedule(schedule *models.CrawlSchedule, now time.Time) bool {
+	nextRunAt, err := schedule.FollowingRunAfter(now)
+	if err != nil {
+		// Keep the schedule from being picked up every tick
+		log.Printf("Schedule %d has no next run: %v", schedule.ID, err)
+		schedule.Enabled = false
+		if err := ss.repo.UpdateSchedule(schedule); err != nil {
+			log.Printf("Failed to disable schedule %d: %v", schedule.ID, err)
+		}
+		ss.recordRun(schedule.ID, fmt.Errorf("schedule disabled: %w", err))
+		return false
+	}
+
+	claimed, err := ss.repo.AdvanceSchedule(schedule.ID, schedule.NextRunAt, nextRunAt)
+	if err != nil {
+		log.Printf("Failed to advance schedule %d: %v", schedule.ID, err)
+		return false
+	}
+	if !claimed {
+		// Another server instance got to it first
+		return false
+	}
+
+	err = ss.crawlerService.StartCrawl(schedule.URLID)
+	if err != nil {
+		if errors.Is(err, ErrCrawlInProgress) {
+			// Runs of the same URL never overlap, the next one will catch up
+			err = fmt.Errorf("skipped: crawl already in progress")
+		}
+		ss.recordRun(schedule.ID, err)
+		return false
+	}
+
+	ss.recordRun(schedule.ID, nil)
+	return true
+}
+
+// records the outcome of a scheduled run
+func (ss *SchedulerService) recordRun(scheduleID int, runErr error) {
+	var lastError *string
+	if runErr != nil {
+		message := runErr.Error()
+		lastError = &message
+	}
+
+	if err := ss.repo.RecordScheduleRun(scheduleID, lastError); err != nil {
+		log.Printf("Failed to record run of schedule %d: %v", scheduleID, err)
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockScheduleRepository for testing
+type MockScheduleRepository struct {
+	mock.Mock
+}
+
+var _ database.ScheduleRepositoryInterface = (*MockScheduleRepository)(nil)
+
+func (m *MockScheduleRepository) CreateSchedule(schedule *models.CrawlSchedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) GetScheduleByID(id int) (*models.CrawlSchedule, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlSchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) ListSchedules(filter models.ScheduleFilter) ([]models.CrawlSchedule, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.CrawlSchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) UpdateSchedule(schedule *models.CrawlSchedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) DeleteSchedule(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) ListDueSchedules(now time.Time, limit int) ([]models.CrawlSchedule, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.CrawlSchedule), args.Error(1)
+}
+
+func (m *MockScheduleRepository) AdvanceSchedule(id int, dueAt time.Time, nextRunAt time.Time) (bool, error) {
+	args := m.Called(id, dueAt, nextRunAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockScheduleRepository) RecordScheduleRun(id int, lastError *string) error {
+	args := m.Called(id, lastError)
+	return args.Error(0)
+}
+
+// MockCrawlerService for testing
+type MockCrawlerService struct {
+	mock.Mock
+}
+
+var _ CrawlerServiceInterface = (*MockCrawlerService)(nil)
+
+func (m *MockCrawlerService) StartCrawl(urlID int) error {
+	args := m.Called(urlID)
+	return args.Error(0)
+}
+
+func (m *MockCrawlerService) StartCrawlWithOptions(urlID int, overrides *models.CrawlOptionsOverride) error {
+	args := m.Called(urlID, overrides)
+	return args.Error(0)
+}
+
+func (m *MockCrawlerService) StartSiteCrawl(urlID int, req models.SiteCrawlRequest) error {
+	args := m.Called(urlID, req)
+	return args.Error(0)
+}
+
+func (m *MockCrawlerService) StopCrawl(urlID int) error {
+	args := m.Called(urlID)
+	return args.Error(0)
+}
+
+func (m *MockCrawlerService) GetJobStatus(urlID int) (*models.CrawlJob, error) {
+	args := m.Called(urlID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CrawlJob), args.Error(1)
+}
+
+func (m *MockCrawlerService) GetActiveJobs() map[int]*models.CrawlJob {
+	args := m.Called()
+	return args.Get(0).(map[int]*models.CrawlJob)
+}
+
+func (m *MockCrawlerService) GetCrawlerStats() map[string]interface{} {
+	args := m.Called()
+	return args.Get(0).(map[string]interface{})
+}
+
+func (m *MockCrawlerService) GetAnalyzers() []models.AnalyzerInfo {
+	args := m.Called()
+	return args.Get(0).([]models.AnalyzerInfo)
+}
+
+func (m *MockCrawlerService) CleanupCompletedJobs() {
+	m.Called()
+}
+
+func (m *MockCrawlerService) SubscribeJobEvents(urlID int, lastEventID string) (*JobEventSubscription, []models.JobEvent, bool) {
+	args := m.Called(urlID, lastEventID)
+	return args.Get(0).(*JobEventSubscription), args.Get(1).([]models.JobEvent), args.Bool(2)
+}
+
+// creates a scheduler whose clock is fixed at now
+func newTestScheduler(repo *MockScheduleRepository, crawlerService *MockCrawlerService, now time.Time) *SchedulerService {
+	scheduler := NewSchedulerService(repo, crawlerService)
+	scheduler.now = func() time.Time { return now }
+	return scheduler
+}
+
+func TestSchedulerService_RunDue_StartsCrawl(t *testing.T) {
+	mockRepo := new(MockScheduleRepository)
+	mockCrawler := new(MockCrawlerService)
+	now := time.Date(2024, time.May, 15, 10, 0, 10, 0, time.UTC)
+	scheduler := newTestScheduler(mockRepo, mockCrawler, now)
+
+	cronExpr := "*/15 * * * *"
+	interval := 3600
+	dueAt := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	schedules := []models.CrawlSchedule{
+		{ID: 1, URLID: 10, CronExpr: &cronExpr, Enabled: true, NextRunAt: dueAt},
+		{ID: 2, URLID: 20, IntervalSeconds: &interval, Enabled: true, NextRunAt: dueAt},
+	}
+
+	mockRepo.On("ListDueSchedules", now, schedulerBatchSize).Return(schedules, nil)
+	mockRepo.On("AdvanceSchedule", 1, dueAt, time.Date(2024, time.May, 15, 10, 15, 0, 0, time.UTC)).Return(true, nil)
+	// The interval is counted from the due time, not from when the tick ran
+	mockRepo.On("AdvanceSchedule", 2, dueAt, time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)).Return(true, nil)
+	mockCrawler.On("StartCrawl", 10).Return(nil)
+	mockCrawler.On("StartCrawl", 20).Return(nil)
+	mockRepo.On("RecordScheduleRun", 1, (*string)(nil)).Return(nil)
+	mockRepo.On("RecordScheduleRun", 2, (*string)(nil)).Return(nil)
+
+	assert.Equal(t, 2, scheduler.RunDue())
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestSchedulerService_RunDue_SkipsRunningCrawl(t *testing.T) {
+	mockRepo := new(MockScheduleRepository)
+	mockCrawler := new(MockCrawlerService)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	scheduler := newTestScheduler(mockRepo, mockCrawler, now)
+
+	interval := 600
+	schedules := []models.CrawlSchedule{
+		{ID: 1, URLID: 10, IntervalSeconds: &interval, Enabled: true, NextRunAt: now},
+	}
+
+	mockRepo.On("ListDueSchedules", now, schedulerBatchSize).Return(schedules, nil)
+	mockRepo.On("AdvanceSchedule", 1, now, now.Add(10*time.Minute)).Return(true, nil)
+	mockCrawler.On("StartCrawl", 10).Return(fmt.Errorf("%w for URL ID 10", ErrCrawlInProgress))
+	mockRepo.On("RecordScheduleRun", 1, mock.MatchedBy(func(lastError *string) bool {
+		return lastError != nil && *lastError == "skipped: crawl already in progress"
+	})).Return(nil)
+
+	assert.Equal(t, 0, scheduler.RunDue())
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestSchedulerService_RunDue_SkipsMissedIntervalRuns(t *testing.T) {
+	mockRepo := new(MockScheduleRepository)
+	mockCrawler := new(MockCrawlerService)
+	now := time.Date(2024, time.May, 15, 10, 25, 0, 0, time.UTC)
+	scheduler := newTestScheduler(mockRepo, mockCrawler, now)
+
+	// Due at 10:00 every 10 minutes, but the server was down until 10:25
+	interval := 600
+	dueAt := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	schedules := []models.CrawlSchedule{
+		{ID: 1, URLID: 10, IntervalSeconds: &interval, Enabled: true, NextRunAt: dueAt},
+	}
+
+	mockRepo.On("ListDueSchedules", now, schedulerBatchSize).Return(schedules, nil)
+	mockRepo.On("AdvanceSchedule", 1, dueAt, time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)).Return(true, nil)
+	mockCrawler.On("StartCrawl", 10).Return(nil)
+	mockRepo.On("RecordScheduleRun", 1, (*string)(nil)).Return(nil)
+
+	assert.Equal(t, 1, scheduler.RunDue())
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertExpectations(t)
+}
+
+func TestSchedulerService_RunDue_ClaimedElsewhere(t *testing.T) {
+	mockRepo := new(MockScheduleRepository)
+	mockCrawler := new(MockCrawlerService)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	scheduler := newTestScheduler(mockRepo, mockCrawler, now)
+
+	interval := 600
+	schedules := []models.CrawlSchedule{
+		{ID: 1, URLID: 10, IntervalSeconds: &interval, Enabled: true, NextRunAt: now},
+	}
+
+	mockRepo.On("ListDueSchedules", now, schedulerBatchSize).Return(schedules, nil)
+	mockRepo.On("AdvanceSchedule", 1, now, now.Add(10*time.Minute)).Return(false, nil)
+
+	assert.Equal(t, 0, scheduler.RunDue())
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertNotCalled(t, "StartCrawl", mock.Anything)
+	mockRepo.AssertNotCalled(t, "RecordScheduleRun", mock.Anything, mock.Anything)
+}
+
+func TestSchedulerService_RunDue_DisablesScheduleWithoutNextRun(t *testing.T) {
+	mockRepo := new(MockScheduleRepository)
+	mockCrawler := new(MockCrawlerService)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	scheduler := newTestScheduler(mockRepo, mockCrawler, now)
+
+	// February 30th never comes
+	cronExpr := "0 0 30 2 *"
+	schedules := []models.CrawlSchedule{
+		{ID: 1, URLID: 10, CronExpr: &cronExpr, Enabled: true, NextRunAt: now},
+	}
+
+	mockRepo.On("ListDueSchedules", now, schedulerBatchSize).Return(schedules, nil)
+	mockRepo.On("UpdateSchedule", mock.MatchedBy(func(schedule *models.CrawlSchedule) bool {
+		return schedule.ID == 1 && !schedule.Enabled
+	})).Return(nil)
+	mockRepo.On("RecordScheduleRun", 1, mock.AnythingOfType("*string")).Return(nil)
+
+	assert.Equal(t, 0, scheduler.RunDue())
+
+	mockRepo.AssertExpectations(t)
+	mockCrawler.AssertNotCalled(t, "StartCrawl", mock.Anything)
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// how often due deliveries are looked for when nothing new was queued
+	webhookTickInterval = 15 * time.Second
+	// how many due deliveries are sent per tick
+	webhookBatchSize = 50
+	// how long a webhook endpoint has to answer
+	webhookTimeout = 10 * time.Second
+	// how long a delivery being sent is hidden from other senders
+	webhookSendLease = 1 * time.Minute
+	// how many times a delivery is attempted before it is given up on
+	webhookMaxAttempts = 6
+	// the wait before the first retry, doubled for every further one
+	webhookRetryBaseDelay = 30 * time.Second
+)
+
+// queues webhook deliveries for finished crawls and sends them, retrying failures with exponential backoff
+type WebhookService struct {
+	repo     database.RepositoryInterface
+	webhooks database.WebhookRepositoryInterface
+	client   *resty.Client
+	now      func() time.Time
+	wake     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Ensure WebhookService implements CrawlNotifier
+var _ CrawlNotifier = (*WebhookService)(nil)
+
+// creates a new webhook service
+func NewWebhookService(repo database.RepositoryInterface, webhooks database.WebhookRepositoryInterface) *WebhookService {
+	client := resty.New()
+	client.SetTimeout(webhookTimeout)
+	client.SetHeader("User-Agent", "URL-Analyzer-Webhook/1.0")
+
+	return &WebhookService{
+		repo:     repo,
+		webhooks: webhooks,
+		client:   client,
+		now:      func() time.Time { return time.Now().UTC() },
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// starts sending due deliveries in the background
+func (ws *WebhookService) Start() {
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+
+		ticker := time.NewTicker(webhookTickInterval)
+		defer ticker.Stop()
+
+		for {
+			ws.DeliverDue()
+
+			select {
+			case <-ws.stop:
+				return
+			case <-ticker.C:
+			case <-ws.wake:
+			}
+		}
+	}()
+}
+
+// stops sending deliveries. Pending ones are sent after the next start.
+func (ws *WebhookService) Stop() {
+	close(ws.stop)
+	ws.wg.Wait()
+}
+
+// queues a delivery to every webhook subscribed to how the crawl ended, through the writer of the
+// transaction recording it. A crawl is only recorded together with its deliveries.
+func (ws *WebhookService) NotifyCrawl(w database.CrawlResultWriter, event models.CrawlEvent) error {
+	eventName := webhookEventFor(event.Status)
+
+	webhooks, err := ws.webhooks.ListWebhooksForEvent(eventName, event.URLID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks for %s: %w", eventName, err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := ws.buildPayload(eventName, event)
+	if err != nil {
+		return fmt.Errorf("failed to build %s webhook payload: %w", eventName, err)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook payload: %w", eventName, err)
+	}
+
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         eventName,
+			Payload:       body,
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: payload.OccurredAt,
+		}
+		if err := w.CreateWebhookDelivery(delivery); err != nil {
+			return fmt.Errorf("failed to queue delivery to webhook %d: %w", webhook.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// sends the deliveries of crawls recorded since the last tick without waiting for the next one
+func (ws *WebhookService) Flush() {
+	select {
+	case ws.wake <- struct{}{}:
+	default:
+	}
+}
+
+// returns the webhook event telling how a crawl ended
+func webhookEventFor(status models.URLStatus) string {
+	switch status {
+	case models.StatusCompleted:
+		return models.WebhookEventCrawlCompleted
+	case models.StatusBlocked:
+		return models.WebhookEventCrawlBlocked
+	default:
+		return models.WebhookEventCrawlFailed
+	}
+}
+
+// describes the crawl, and for completed crawls its result and the broken links
+// that appeared or disappeared since the crawl before it
+func (ws *WebhookService) buildPayload(eventName string, event models.CrawlEvent) (*models.WebhookPayload, error) {
+	payload := &models.WebhookPayload{
+		Event:        eventName,
+		OccurredAt:   ws.now().Truncate(time.Second),
+		URLID:        event.URLID,
+		URL:          event.URL,
+		Status:       event.Status,
+		ErrorMessage: event.ErrorMessage,
+	}
+
+	if event.Status != models.StatusCompleted || event.Result == nil {
+		return payload, nil
+	}
+
+	latest := event.Result
+	payload.CrawlResultID = &latest.ID
+	payload.Summary = &models.WebhookCrawlSummary{
+		Title:            latest.Title,
+		InternalLinks:    latest.InternalLinks,
+		ExternalLinks:    latest.ExternalLinks,
+		BrokenLinksCount: latest.BrokenLinksCount,
+		HasLoginForm:     latest.HasLoginForm,
+	}
+
+	// The crawl is not committed yet, so the latest crawl stored is the one before it
+	results, _, err := ws.repo.ListCrawlResultsByURLID(event.URLID, models.HistoryFilter{Page: 1, PageSize: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an earlier crawl every broken link is new
+	first := models.DiffCrawlResults(&models.CrawlResult{}, latest, nil, event.BrokenLinks, nil)
+	delta := &models.BrokenLinkDelta{
+		NewlyBroken:    first.NewlyBroken,
+		NewlyFixed:     first.NewlyFixed,
+		NoLongerLinked: first.NoLongerLinked,
+	}
+	if len(results) > 0 && results[0].ID != latest.ID {
+		previous := results[0]
+		previousLinks, err := ws.repo.GetBrokenLinksByCrawlResultID(previous.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := models.DiffCrawlResults(&previous, latest, previousLinks, event.BrokenLinks, event.Links)
+		delta.PreviousResultID = &previous.ID
+		delta.NewlyBroken = diff.NewlyBroken
+		delta.NewlyFixed = diff.NewlyFixed
+		delta.NoLongerLinked = diff.NoLongerLinked
+	}
+	payload.BrokenLinks = delta
+
+	return payload, nil
+}
+
+// sends every delivery that is due. Returns the number delivered successfully.
+func (ws *WebhookService) DeliverDue() int {
+	now := ws.now()
+
+	deliveries, err := ws.webhooks.ListDueWebhookDeliveries(now, webhookBatchSize)
+	if err != nil {
+		log.Printf("Failed to list due webhook deliveries: %v", err)
+		return 0
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		claimed, err := ws.webhooks.ClaimWebhookDelivery(delivery.ID, delivery.NextAttemptAt, now.Add(webhookSendLease))
+		if err != nil {
+			log.Printf("Failed to claim webhook delivery %d: %v", delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			// Another server instance is sending it
+			continue
+		}
+
+		if ws.deliver(delivery) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+// makes one attempt at a delivery and records its outcome, reporting whether it succeeded
+func (ws *WebhookService) deliver(delivery *models.WebhookDelivery) bool {
+	webhook, err := ws.webhooks.GetWebhookByID(delivery.WebhookID)
+	if err != nil {
+		// Deleting a webhook deletes its deliveries; anything else is retried once the lease expires
+		log.Printf("Failed to fetch webhook %d for delivery %d: %v", delivery.WebhookID, delivery.ID, err)
+		return false
+	}
+
+	statusCode, sendErr := ws.send(webhook, delivery)
+
+	delivery.Attempts++
+	if statusCode > 0 {
+		delivery.ResponseCode = &statusCode
+	}
+
+	if sendErr == nil {
+		deliveredAt := ws.now()
+		delivery.Status = models.DeliveryStatusSucceeded
+		delivery.DeliveredAt = &deliveredAt
+		delivery.LastError = nil
+	} else {
+		message := sendErr.Error()
+		delivery.LastError = &message
+		if delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = models.DeliveryStatusFailed
+		} else {
+			delivery.NextAttemptAt = ws.now().Add(webhookRetryDelay(delivery.Attempts))
+		}
+	}
+
+	if err := ws.webhooks.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("Failed to record outcome of webhook delivery %d: %v", delivery.ID, err)
+	}
+
+	return sendErr == nil
+}
+
+// POSTs the signed payload to the webhook, returning the response status code if there was a response
+func (ws *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := ws.now().Unix()
+
+	resp, err := ws.client.R().
+		SetHeaders(map[string]string{
+			"Content-Type":        "application/json",
+			"X-Webhook-Event":     delivery.Event,
+			"X-Webhook-Delivery":  strconv.Itoa(delivery.ID),
+			"X-Webhook-Timestamp": strconv.FormatInt(timestamp, 10),
+			"X-Webhook-Signature": SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload),
+		}).
+		SetBody([]byte(delivery.Payload)).
+		Post(webhook.TargetURL)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return resp.StatusCode(), fmt.Errorf("unexpected response status %d", resp.StatusCode())
+	}
+
+	return resp.StatusCode(), nil
+}
+
+// returns how long to wait after the given number of failed attempts
+func webhookRetryDelay(attempts int) time.Duration {
+	return webhookRetryBaseDelay << (attempts - 1)
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature header value for a payload:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"url-analyzer/internal/database"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWebhookRepository for testing
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+var _ database.WebhookRepositoryInterface = (*MockWebhookRepository)(nil)
+
+func (m *MockWebhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetWebhookByID(id int) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListWebhooksByUserID(userID int) ([]models.Webhook, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) DeleteWebhook(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListWebhooksForEvent(event string, urlID int) ([]models.Webhook, error) {
+	args := m.Called(event, urlID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimWebhookDelivery(id int, dueAt time.Time, leaseUntil time.Time) (bool, error) {
+	args := m.Called(id, dueAt, leaseUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListWebhookDeliveries(webhookID int, filter models.DeliveryFilter) ([]models.WebhookDelivery, int, error) {
+	args := m.Called(webhookID, filter)
+	return args.Get(0).([]models.WebhookDelivery), args.Int(1), args.Error(2)
+}
+
+// creates a webhook service whose clock is fixed at now
+func newTestWebhookService(repo *MockRepository, webhooks *MockWebhookRepository, now time.Time) *WebhookService {
+	service := NewWebhookService(repo, webhooks)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestWebhookService_NotifyCrawl_Completed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	writer := new(MockRepository)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	service := newTestWebhookService(mockRepo, mockWebhooks, now)
+
+	title := "Example"
+	latest := &models.CrawlResult{ID: 12, URLID: 1, Title: &title, InternalLinks: 5, ExternalLinks: 2, BrokenLinksCount: 1}
+	previous := models.CrawlResult{ID: 11, URLID: 1, Title: &title, InternalLinks: 5, ExternalLinks: 2, BrokenLinksCount: 2}
+
+	mockWebhooks.On("ListWebhooksForEvent", models.WebhookEventCrawlCompleted, 1).Return([]models.Webhook{{ID: 3}, {ID: 4}}, nil)
+	// The crawl being recorded is not committed yet, so the latest stored result is the one before it
+	mockRepo.On("ListCrawlResultsByURLID", 1, models.HistoryFilter{Page: 1, PageSize: 1}).Return([]models.CrawlResult{previous}, 1, nil)
+	mockRepo.On("GetBrokenLinksByCrawlResultID", 11).Return([]models.BrokenLink{
+		{URL: "https://example.com/old", StatusCode: 500},
+		{URL: "https://example.com/removed", StatusCode: 404},
+	}, nil)
+
+	var payloads []models.WebhookPayload
+	writer.On("CreateWebhookDelivery", mock.AnythingOfType("*models.WebhookDelivery")).Run(func(args mock.Arguments) {
+		delivery := args.Get(0).(*models.WebhookDelivery)
+		assert.Equal(t, models.DeliveryStatusPending, delivery.Status)
+		assert.Equal(t, now, delivery.NextAttemptAt)
+
+		var payload models.WebhookPayload
+		require.NoError(t, json.Unmarshal(delivery.Payload, &payload))
+		payloads = append(payloads, payload)
+	}).Return(nil).Twice()
+
+	err := service.NotifyCrawl(writer, models.CrawlEvent{
+		URLID:       1,
+		URL:         "https://example.com",
+		Status:      models.StatusCompleted,
+		Result:      latest,
+		BrokenLinks: []models.BrokenLink{{URL: "https://example.com/new", StatusCode: 404}},
+		Links: []models.Link{
+			{URL: "https://example.com/new", Status: models.LinkStatusBroken},
+			{URL: "https://example.com/old", Status: models.LinkStatusOK},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 2)
+	payload := payloads[0]
+	assert.Equal(t, models.WebhookEventCrawlCompleted, payload.Event)
+	assert.Equal(t, "https://example.com", payload.URL)
+	assert.Equal(t, 12, *payload.CrawlResultID)
+	assert.Equal(t, 5, payload.Summary.InternalLinks)
+	assert.Equal(t, 11, *payload.BrokenLinks.PreviousResultID)
+	require.Len(t, payload.BrokenLinks.NewlyBroken, 1)
+	assert.Equal(t, "https://example.com/new", payload.BrokenLinks.NewlyBroken[0].URL)
+	require.Len(t, payload.BrokenLinks.NewlyFixed, 1)
+	assert.Equal(t, "https://example.com/old", payload.BrokenLinks.NewlyFixed[0].URL)
+	require.Len(t, payload.BrokenLinks.NoLongerLinked, 1)
+	assert.Equal(t, "https://example.com/removed", payload.BrokenLinks.NoLongerLinked[0].URL)
+
+	mockRepo.AssertExpectations(t)
+	mockWebhooks.AssertExpectations(t)
+	writer.AssertExpectations(t)
+	mockWebhooks.AssertNotCalled(t, "CreateWebhookDelivery", mock.Anything)
+}
+
+func TestWebhookService_NotifyCrawl_FirstCrawl(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	writer := new(MockRepository)
+	service := newTestWebhookService(mockRepo, mockWebhooks, time.Now())
+
+	latest := &models.CrawlResult{ID: 12, URLID: 1, BrokenLinksCount: 1}
+
+	mockWebhooks.On("ListWebhooksForEvent", models.WebhookEventCrawlCompleted, 1).Return([]models.Webhook{{ID: 3}}, nil)
+	mockRepo.On("ListCrawlResultsByURLID", 1, models.HistoryFilter{Page: 1, PageSize: 1}).Return([]models.CrawlResult{}, 0, nil)
+	writer.On("CreateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		var payload models.WebhookPayload
+		return json.Unmarshal(delivery.Payload, &payload) == nil &&
+			payload.BrokenLinks.PreviousResultID == nil && len(payload.BrokenLinks.NewlyBroken) == 1
+	})).Return(nil).Once()
+
+	err := service.NotifyCrawl(writer, models.CrawlEvent{
+		URLID:       1,
+		URL:         "https://example.com",
+		Status:      models.StatusCompleted,
+		Result:      latest,
+		BrokenLinks: []models.BrokenLink{{URL: "https://example.com/new", StatusCode: 404}},
+	})
+	require.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	writer.AssertExpectations(t)
+}
+
+func TestWebhookService_NotifyCrawl_NoWebhooks(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	writer := new(MockRepository)
+	service := newTestWebhookService(mockRepo, mockWebhooks, time.Now())
+
+	mockWebhooks.On("ListWebhooksForEvent", models.WebhookEventCrawlFailed, 1).Return([]models.Webhook{}, nil)
+
+	message := "connection refused"
+	err := service.NotifyCrawl(writer, models.CrawlEvent{URLID: 1, Status: models.StatusError, ErrorMessage: &message})
+	require.NoError(t, err)
+
+	mockWebhooks.AssertExpectations(t)
+	writer.AssertNotCalled(t, "CreateWebhookDelivery", mock.Anything)
+}
+
+func TestWebhookService_NotifyCrawl_Blocked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	writer := new(MockRepository)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	service := newTestWebhookService(mockRepo, mockWebhooks, now)
+
+	mockWebhooks.On("ListWebhooksForEvent", models.WebhookEventCrawlBlocked, 1).Return([]models.Webhook{{ID: 3}}, nil)
+	writer.On("CreateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		var payload models.WebhookPayload
+		return delivery.Event == models.WebhookEventCrawlBlocked &&
+			json.Unmarshal(delivery.Payload, &payload) == nil &&
+			payload.Status == models.StatusBlocked && payload.URL == "https://example.com"
+	})).Return(nil)
+
+	message := "Blocked by robots.txt"
+	err := service.NotifyCrawl(writer, models.CrawlEvent{URLID: 1, URL: "https://example.com", Status: models.StatusBlocked, ErrorMessage: &message})
+	require.NoError(t, err)
+
+	mockWebhooks.AssertExpectations(t)
+	writer.AssertExpectations(t)
+}
+
+func TestWebhookService_NotifyCrawl_DeliveryNotSaved(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	writer := new(MockRepository)
+	service := newTestWebhookService(mockRepo, mockWebhooks, time.Now())
+
+	mockWebhooks.On("ListWebhooksForEvent", models.WebhookEventCrawlFailed, 1).Return([]models.Webhook{{ID: 3}}, nil)
+	writer.On("CreateWebhookDelivery", mock.Anything).Return(assert.AnError)
+
+	// The error rolls back the transaction recording the crawl, so it is never recorded without its deliveries
+	message := "connection refused"
+	err := service.NotifyCrawl(writer, models.CrawlEvent{URLID: 1, Status: models.StatusError, ErrorMessage: &message})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestWebhookService_Flush_DeliversWithoutWaiting(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	service := newTestWebhookService(mockRepo, mockWebhooks, time.Now())
+
+	checked := make(chan struct{}, 2)
+	mockWebhooks.On("ListDueWebhookDeliveries", mock.Anything, webhookBatchSize).Run(func(args mock.Arguments) {
+		checked <- struct{}{}
+	}).Return([]models.WebhookDelivery{}, nil)
+
+	service.Start()
+	defer service.Stop()
+
+	// Due deliveries are sent once at start, then again right after a crawl is recorded
+	for i := 0; i < 2; i++ {
+		select {
+		case <-checked:
+		case <-time.After(time.Second):
+			t.Fatal("due deliveries were not sent")
+		}
+		service.Flush()
+	}
+}
+
+func TestWebhookService_DeliverDue_SignedRequest(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	service := newTestWebhookService(mockRepo, mockWebhooks, now)
+
+	body := []byte(`{"event":"crawl.failed","url_id":1}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	deliveries := []models.WebhookDelivery{
+		{ID: 8, WebhookID: 3, Event: models.WebhookEventCrawlFailed, Payload: body, Status: models.DeliveryStatusPending, NextAttemptAt: now},
+	}
+
+	mockWebhooks.On("ListDueWebhookDeliveries", now, webhookBatchSize).Return(deliveries, nil)
+	mockWebhooks.On("ClaimWebhookDelivery", 8, now, now.Add(webhookSendLease)).Return(true, nil)
+	mockWebhooks.On("GetWebhookByID", 3).Return(&models.Webhook{ID: 3, TargetURL: server.URL, Secret: "0123456789abcdef"}, nil)
+	mockWebhooks.On("UpdateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.Status == models.DeliveryStatusSucceeded && delivery.Attempts == 1 &&
+			*delivery.ResponseCode == http.StatusNoContent && delivery.DeliveredAt != nil
+	})).Return(nil)
+
+	assert.Equal(t, 1, service.DeliverDue())
+
+	require.NotNil(t, received)
+	assert.Equal(t, body, receivedBody)
+	assert.Equal(t, models.WebhookEventCrawlFailed, received.Header.Get("X-Webhook-Event"))
+	assert.Equal(t, "8", received.Header.Get("X-Webhook-Delivery"))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), received.Header.Get("X-Webhook-Timestamp"))
+	assert.Equal(t, SignWebhookPayload("0123456789abcdef", now.Unix(), body), received.Header.Get("X-Webhook-Signature"))
+
+	mockWebhooks.AssertExpectations(t)
+}
+
+func TestWebhookService_DeliverDue_RetriesWithBackoff(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	service := newTestWebhookService(mockRepo, mockWebhooks, now)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	deliveries := []models.WebhookDelivery{
+		// Failed twice before: the next retry waits four times the base delay
+		{ID: 8, WebhookID: 3, Payload: []byte(`{}`), Status: models.DeliveryStatusPending, Attempts: 2, NextAttemptAt: now},
+		// Last attempt
+		{ID: 9, WebhookID: 3, Payload: []byte(`{}`), Status: models.DeliveryStatusPending, Attempts: webhookMaxAttempts - 1, NextAttemptAt: now},
+	}
+
+	mockWebhooks.On("ListDueWebhookDeliveries", now, webhookBatchSize).Return(deliveries, nil)
+	mockWebhooks.On("ClaimWebhookDelivery", mock.Anything, now, now.Add(webhookSendLease)).Return(true, nil)
+	mockWebhooks.On("GetWebhookByID", 3).Return(&models.Webhook{ID: 3, TargetURL: server.URL, Secret: "0123456789abcdef"}, nil)
+	mockWebhooks.On("UpdateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.ID == 8 && delivery.Status == models.DeliveryStatusPending && delivery.Attempts == 3 &&
+			delivery.NextAttemptAt.Equal(now.Add(4*webhookRetryBaseDelay)) &&
+			*delivery.ResponseCode == http.StatusServiceUnavailable && delivery.LastError != nil
+	})).Return(nil).Once()
+	mockWebhooks.On("UpdateWebhookDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+		return delivery.ID == 9 && delivery.Status == models.DeliveryStatusFailed && delivery.Attempts == webhookMaxAttempts
+	})).Return(nil).Once()
+
+	assert.Equal(t, 0, service.DeliverDue())
+
+	mockWebhooks.AssertExpectations(t)
+}
+
+func TestWebhookService_DeliverDue_ClaimedElsewhere(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockWebhooks := new(MockWebhookRepository)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	service := newTestWebhookService(mockRepo, mockWebhooks, now)
+
+	deliveries := []models.WebhookDelivery{
+		{ID: 8, WebhookID: 3, Payload: []byte(`{}`), Status: models.DeliveryStatusPending, NextAttemptAt: now},
+	}
+
+	mockWebhooks.On("ListDueWebhookDeliveries", now, webhookBatchSize).Return(deliveries, nil)
+	mockWebhooks.On("ClaimWebhookDelivery", 8, now, now.Add(webhookSendLease)).Return(false, nil)
+
+	assert.Equal(t, 0, service.DeliverDue())
+
+	mockWebhooks.AssertExpectations(t)
+	mockWebhooks.AssertNotCalled(t, "GetWebhookByID", mock.Anything)
+}
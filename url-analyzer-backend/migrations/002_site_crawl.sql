@@ -0,0 +1,8 @@
+ALTER TABLE crawl_results
+    ADD COLUMN page_url VARCHAR(768) NULL AFTER url_id,
+    ADD COLUMN depth INT DEFAULT 0 AFTER has_login_form,
+    ADD COLUMN parent_id INT NULL AFTER depth,  -- page the link was discovered on
+    ADD COLUMN root_id INT NULL AFTER parent_id,  -- root page of the site crawl, NULL for the root itself
+    ADD FOREIGN KEY (parent_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (root_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    ADD INDEX idx_root_id (root_id);
@@ -0,0 +1,5 @@
+ALTER TABLE urls
+    MODIFY COLUMN status ENUM('queued', 'running', 'completed', 'error', 'blocked') DEFAULT 'queued';
+
+ALTER TABLE crawl_results
+    ADD COLUMN links_blocked_by_robots INT DEFAULT 0 AFTER broken_links_count;
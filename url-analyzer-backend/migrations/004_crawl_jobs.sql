@@ -0,0 +1,17 @@
+CREATE TABLE crawl_jobs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    status ENUM('queued', 'running', 'completed', 'failed', 'cancelled') DEFAULT 'queued',
+    options JSON,
+    attempts INT DEFAULT 0,
+    worker_id VARCHAR(255),
+    lease_expires_at TIMESTAMP NULL,  -- a running job whose lease expired is considered orphaned
+    heartbeat_at TIMESTAMP NULL,
+    error_message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_status (status, id),
+    INDEX idx_url_id_status (url_id, status)
+);
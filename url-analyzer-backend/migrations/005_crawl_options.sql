@@ -0,0 +1,2 @@
+ALTER TABLE urls
+    ADD COLUMN crawl_options JSON NULL AFTER error_message;  -- per-URL overrides of the default crawl options
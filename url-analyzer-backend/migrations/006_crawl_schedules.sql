@@ -0,0 +1,15 @@
+CREATE TABLE crawl_schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    cron_expr VARCHAR(100),  -- five-field cron expression evaluated in UTC
+    interval_seconds INT,    -- used when cron_expr is not set
+    enabled BOOLEAN DEFAULT TRUE,
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP NULL,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_due (enabled, next_run_at),
+    INDEX idx_url_id (url_id)
+);
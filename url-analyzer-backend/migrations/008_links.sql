@@ -0,0 +1,14 @@
+CREATE TABLE links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    crawl_result_id INT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    anchor_text VARCHAR(1024),
+    rel VARCHAR(255),         -- raw rel attribute, e.g. "nofollow noopener"
+    is_internal BOOLEAN DEFAULT FALSE,
+    check_status ENUM('ok', 'broken', 'blocked', 'unchecked') DEFAULT 'unchecked',
+    status_code INT NULL,     -- NULL when the link was not requested or the request failed
+    error_message TEXT,
+    FOREIGN KEY (crawl_result_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    INDEX idx_crawl_result_type (crawl_result_id, is_internal),
+    INDEX idx_crawl_result_status (crawl_result_id, check_status)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE crawl_results
+    ADD COLUMN doctype VARCHAR(512) NULL AFTER html_version,  -- the DOCTYPE as written, NULL when the page has none
+    ADD COLUMN document_mode ENUM('no-quirks', 'limited-quirks', 'quirks') NULL AFTER doctype;
@@ -0,0 +1,14 @@
+CREATE TABLE forms (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    crawl_result_id INT NOT NULL,
+    form_type ENUM('login', 'signup', 'password_reset', 'search', 'other') DEFAULT 'other',
+    score INT DEFAULT 0,
+    action VARCHAR(2048),     -- absolute URL the form submits to, empty for password fields outside a form
+    method VARCHAR(10),
+    posts_over_http BOOLEAN DEFAULT FALSE,
+    password_fields INT DEFAULT 0,
+    oauth_providers JSON NOT NULL,  -- e.g. ["github", "google"]
+    signals JSON NOT NULL,    -- reasons for the form type
+    FOREIGN KEY (crawl_result_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    INDEX idx_crawl_result_id (crawl_result_id)
+);
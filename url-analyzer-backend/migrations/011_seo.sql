@@ -0,0 +1,13 @@
+ALTER TABLE crawl_results
+    ADD COLUMN seo_metadata JSON NULL AFTER has_login_form;  -- description, robots, canonical, hreflang, Open Graph, Twitter card and lang
+
+CREATE TABLE findings (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    crawl_result_id INT NOT NULL,
+    category VARCHAR(50) NOT NULL,   -- e.g. "seo"
+    code VARCHAR(100) NOT NULL,      -- e.g. "missing_description"
+    severity ENUM('info', 'warning', 'error') DEFAULT 'info',
+    message TEXT,
+    FOREIGN KEY (crawl_result_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    INDEX idx_crawl_result_id (crawl_result_id, category)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE findings
+    ADD COLUMN selector VARCHAR(1024) NOT NULL DEFAULT '' AFTER message;  -- CSS path of the element, e.g. "body > main > img:nth-of-type(2)"
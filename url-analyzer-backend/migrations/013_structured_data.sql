@@ -0,0 +1,2 @@
+ALTER TABLE crawl_results
+    ADD COLUMN structured_data JSON NULL AFTER seo_metadata;  -- JSON-LD blocks, microdata and RDFa items and the types found
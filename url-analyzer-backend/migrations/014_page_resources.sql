@@ -0,0 +1,18 @@
+ALTER TABLE crawl_results
+    ADD COLUMN resource_summary JSON NULL AFTER structured_data;  -- counts by type, total bytes, third-party hosts and broken resources
+
+CREATE TABLE page_resources (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    crawl_result_id INT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    type ENUM('script', 'stylesheet', 'image', 'font', 'iframe') NOT NULL,
+    is_third_party BOOLEAN DEFAULT FALSE,
+    check_status ENUM('ok', 'broken', 'blocked', 'unchecked') DEFAULT 'unchecked',
+    status_code INT NULL,     -- NULL when the resource was not requested or the request failed
+    size BIGINT NULL,         -- Content-Length in bytes, NULL when unknown
+    content_type VARCHAR(255) NULL,
+    error_message TEXT,
+    FOREIGN KEY (crawl_result_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    INDEX idx_crawl_result_type (crawl_result_id, type),
+    INDEX idx_crawl_result_status (crawl_result_id, check_status)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE security_reports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    crawl_result_id INT NOT NULL UNIQUE,
+    score INT NOT NULL,                -- 0-100, weighted over the checks
+    grade CHAR(1) NOT NULL,            -- A-F
+    checks JSON NOT NULL,              -- header, cookie and TLS checks with their status and weight
+    cookies JSON NOT NULL,             -- flags of the cookies set by the page
+    tls_version VARCHAR(16) NULL,      -- NULL for plain HTTP
+    cert_issuer VARCHAR(512) NULL,
+    cert_subject VARCHAR(512) NULL,
+    cert_expires_at DATETIME NULL,
+    FOREIGN KEY (crawl_result_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    INDEX idx_cert_expires_at (cert_expires_at)
+);
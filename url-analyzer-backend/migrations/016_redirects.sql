@@ -0,0 +1,5 @@
+ALTER TABLE crawl_results
+    ADD COLUMN redirect_chain JSON NULL AFTER resource_summary;  -- hops followed to fetch the page: url, status_code, location
+
+ALTER TABLE links
+    ADD COLUMN redirects JSON NULL AFTER error_message;  -- hops followed when the link was checked, empty when it did not redirect
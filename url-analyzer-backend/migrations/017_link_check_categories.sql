@@ -0,0 +1,9 @@
+ALTER TABLE links
+    MODIFY COLUMN check_status ENUM('ok', 'broken', 'blocked', 'unchecked', 'unverified') DEFAULT 'unchecked',
+    ADD COLUMN check_category VARCHAR(32) NULL AFTER check_status,  -- ok, broken, blocked, rate_limited, timeout, dns_failure, tls_error, connection_error or ignored; NULL when not requested
+    ADD INDEX idx_crawl_result_category (crawl_result_id, check_category);
+
+ALTER TABLE page_resources
+    MODIFY COLUMN check_status ENUM('ok', 'broken', 'blocked', 'unchecked', 'unverified') DEFAULT 'unchecked',
+    ADD COLUMN check_category VARCHAR(32) NULL AFTER check_status,
+    ADD INDEX idx_crawl_result_category (crawl_result_id, check_category);
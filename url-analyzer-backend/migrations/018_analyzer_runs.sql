@@ -0,0 +1,2 @@
+ALTER TABLE crawl_results
+    ADD COLUMN analyzer_runs JSON NULL AFTER redirect_chain;  -- name, duration_ms, findings, metrics and error of each analyzer run on the page
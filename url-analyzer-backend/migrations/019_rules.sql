@@ -0,0 +1,37 @@
+CREATE TABLE rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    url_id INT NULL,                  -- only evaluated against this URL when set
+    name VARCHAR(255) NOT NULL,
+    type ENUM('selector_exists', 'selector_absent', 'element_count', 'title_matches', 'text_contains', 'text_absent') NOT NULL,
+    selector VARCHAR(1000) NOT NULL DEFAULT '',
+    pattern VARCHAR(1000) NOT NULL DEFAULT '',   -- regular expression of title_matches, text of text_contains and text_absent
+    operator ENUM('', 'eq', 'ne', 'lt', 'lte', 'gt', 'gte') NOT NULL DEFAULT '',
+    expected_count INT NOT NULL DEFAULT 0,
+    severity ENUM('info', 'warning', 'error') DEFAULT 'warning',
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_user_id (user_id),
+    INDEX idx_url_id (url_id)
+);
+
+CREATE TABLE rule_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    crawl_result_id INT NOT NULL,
+    rule_id INT NULL,
+    rule_name VARCHAR(255) NOT NULL,  -- kept when the rule is deleted
+    passed BOOLEAN NOT NULL,
+    severity ENUM('info', 'warning', 'error') DEFAULT 'warning',
+    message TEXT,
+    FOREIGN KEY (crawl_result_id) REFERENCES crawl_results(id) ON DELETE CASCADE,
+    FOREIGN KEY (rule_id) REFERENCES rules(id) ON DELETE SET NULL,
+    INDEX idx_crawl_result_id (crawl_result_id, passed),
+    INDEX idx_rule_id (rule_id, passed)
+);
+
+ALTER TABLE crawl_results
+    ADD COLUMN rules_passed INT DEFAULT 0 AFTER analyzer_runs,
+    ADD COLUMN rules_failed INT DEFAULT 0 AFTER rules_passed;
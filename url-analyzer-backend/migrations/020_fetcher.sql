@@ -0,0 +1,2 @@
+ALTER TABLE crawl_results
+    ADD COLUMN fetcher VARCHAR(16) NOT NULL DEFAULT 'http' AFTER rules_failed;  -- how the page was loaded: http or browser
@@ -0,0 +1,2 @@
+ALTER TABLE crawl_results
+    ADD COLUMN sitemap_coverage JSON NULL AFTER fetcher;  -- linked pages missing from the sitemap and listed pages nothing links to
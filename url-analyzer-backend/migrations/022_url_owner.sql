@@ -0,0 +1,7 @@
+ALTER TABLE urls
+    ADD COLUMN user_id INT NULL AFTER id,  -- user who added the URL; only their rules are evaluated against it
+    ADD CONSTRAINT fk_urls_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    ADD INDEX idx_user_id (user_id);
+
+-- URLs added before URLs had owners belong to the first user
+UPDATE urls SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
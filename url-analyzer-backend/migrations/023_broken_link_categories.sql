@@ -0,0 +1,10 @@
+-- The category of a link turned away with 401, 403 or 999 is forbidden, blocked means disallowed by robots.txt
+UPDATE links SET check_category = 'forbidden' WHERE check_category = 'blocked';
+UPDATE page_resources SET check_category = 'forbidden' WHERE check_category = 'blocked';
+UPDATE urls SET crawl_options = CAST(REPLACE(crawl_options, '"blocked"', '"forbidden"') AS JSON)
+    WHERE JSON_CONTAINS(crawl_options->'$.broken_categories', '"blocked"');
+UPDATE crawl_jobs SET options = CAST(REPLACE(options, '"blocked"', '"forbidden"') AS JSON)
+    WHERE status = 'queued' AND JSON_CONTAINS(options->'$.overrides.broken_categories', '"blocked"');
+
+ALTER TABLE broken_links
+    ADD COLUMN category VARCHAR(32) NULL AFTER status_code;  -- check category of the link, NULL for links found before categories were recorded
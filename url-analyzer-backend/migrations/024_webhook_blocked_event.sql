@@ -0,0 +1,3 @@
+-- Crawls blocked by robots.txt used to be sent as crawl.failed; keep telling the webhooks that heard about them
+UPDATE webhooks SET events = JSON_ARRAY_APPEND(events, '$', 'crawl.blocked')
+    WHERE JSON_CONTAINS(events, '"crawl.failed"') AND NOT JSON_CONTAINS(events, '"crawl.blocked"');
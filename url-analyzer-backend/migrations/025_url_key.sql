@@ -0,0 +1,10 @@
+-- URLs are kept and crawled as they were given; equivalent addresses share the normalized url_key instead
+ALTER TABLE urls
+    ADD COLUMN url_key VARCHAR(768) NULL AFTER url;
+
+-- Batch and sitemap imports stored URLs normalized already, so an existing URL is its own key
+UPDATE urls SET url_key = url WHERE url_key IS NULL;
+
+ALTER TABLE urls
+    MODIFY url_key VARCHAR(768) NOT NULL,
+    ADD UNIQUE INDEX idx_url_key (url_key);
@@ -0,0 +1,271 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// the most findings kept per code, the rest are summed up in one more finding
+const maxAccessibilityFindingsPerCode = 25
+
+// link texts that say nothing about where a link goes, compared lower-cased and without punctuation
+var vagueLinkTexts = map[string]bool{
+	"click here": true,
+	"click":      true,
+	"here":       true,
+	"more":       true,
+	"read more":  true,
+	"learn more": true,
+	"more info":  true,
+	"details":    true,
+	"link":       true,
+	"this link":  true,
+	"this":       true,
+	"go":         true,
+}
+
+// ids that can be written as #id in a CSS selector without escaping
+var plainIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// input types that need a label, the others are hidden or named by their value
+var unlabelledInputTypes = map[string]bool{
+	"hidden": true,
+	"submit": true,
+	"reset":  true,
+	"button": true,
+	"image":  true,
+}
+
+// audits a page for common accessibility problems. headingCounts are the h1-h6 counts of the page.
+func accessibilityFindings(doc *goquery.Document, headingCounts map[string]int) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	perCode := map[string]int{}
+	add := func(code string, severity models.FindingSeverity, s *goquery.Selection, format string, args ...interface{}) {
+		perCode[code]++
+		if perCode[code] > maxAccessibilityFindingsPerCode {
+			return
+		}
+		finding := models.CrawlFinding{
+			Category: models.FindingCategoryAccessibility,
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		}
+		if s != nil {
+			finding.Selector = cssPath(s)
+		}
+		findings = append(findings, finding)
+	}
+
+	// Images
+	doc.Find("img, input[type='image' i], area[href]").Each(func(i int, s *goquery.Selection) {
+		if isHiddenFromAssistiveTech(s) || ariaName(doc, s) != "" {
+			return
+		}
+		// An empty alt marks a decorative image
+		if _, exists := s.Attr("alt"); !exists {
+			add("image_missing_alt", models.SeverityError, s, "%s has no alt text", describeElement(s))
+		}
+	})
+
+	// Form fields
+	labelled := map[string]bool{}
+	doc.Find("label[for]").Each(func(i int, s *goquery.Selection) {
+		labelled[strings.TrimSpace(s.AttrOr("for", ""))] = true
+	})
+	doc.Find("input, select, textarea").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "input" && unlabelledInputTypes[strings.ToLower(s.AttrOr("type", ""))] {
+			return
+		}
+		if isHiddenFromAssistiveTech(s) || s.Closest("label").Length() > 0 || ariaName(doc, s) != "" {
+			return
+		}
+		if id := strings.TrimSpace(s.AttrOr("id", "")); id != "" && labelled[id] {
+			return
+		}
+		add("input_missing_label", models.SeverityError, s, "%s has no label", describeElement(s))
+	})
+
+	// Heading order, only meaningful with more than one heading
+	total := 0
+	for _, count := range headingCounts {
+		total += count
+	}
+	if total > 1 {
+		previous := 0
+		doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+			level := int(goquery.NodeName(s)[1] - '0')
+			if previous > 0 && level > previous+1 {
+				add("skipped_heading_level", models.SeverityWarning, s, "Heading h%d follows h%d, skipping h%d", level, previous, previous+1)
+			}
+			previous = level
+		})
+	}
+
+	// Links and buttons
+	doc.Find("a[href], button, [role='button' i], [role='link' i]").Each(func(i int, s *goquery.Selection) {
+		if isHiddenFromAssistiveTech(s) {
+			return
+		}
+
+		isLink := goquery.NodeName(s) == "a" || strings.EqualFold(s.AttrOr("role", ""), "link")
+		name := accessibleName(doc, s)
+		if name == "" {
+			if isLink {
+				add("empty_link", models.SeverityError, s, "Link to %q has no text", s.AttrOr("href", ""))
+			} else {
+				add("empty_button", models.SeverityError, s, "Button has no text")
+			}
+			return
+		}
+
+		if isLink {
+			text := strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+				if strings.ContainsRune(".,:;!?…»›→", r) {
+					return -1
+				}
+				return r
+			}, strings.ToLower(name))), " ")
+			if vagueLinkTexts[text] {
+				add("vague_link_text", models.SeverityWarning, s, "Link text %q does not say where the link goes", name)
+			}
+		}
+	})
+
+	// Sum up what was cut, in the order the codes first appeared
+	summarized := map[string]bool{}
+	for _, finding := range findings {
+		code := finding.Code
+		if perCode[code] > maxAccessibilityFindingsPerCode && !summarized[code] {
+			summarized[code] = true
+			findings = append(findings, models.CrawlFinding{
+				Category: models.FindingCategoryAccessibility,
+				Code:     code,
+				Severity: finding.Severity,
+				Message:  fmt.Sprintf("%d more elements have the same problem", perCode[code]-maxAccessibilityFindingsPerCode),
+			})
+		}
+	}
+
+	return findings
+}
+
+// returns the name assistive technology announces for a link or button: its ARIA name,
+// or its text and the alt text of the images in it
+func accessibleName(doc *goquery.Document, s *goquery.Selection) string {
+	if name := ariaName(doc, s); name != "" {
+		return name
+	}
+
+	if goquery.NodeName(s) == "input" {
+		return strings.TrimSpace(s.AttrOr("value", ""))
+	}
+
+	// Content hidden from assistive technology is not announced
+	visible := s.Clone()
+	visible.Find("[aria-hidden='true' i]").Remove()
+
+	parts := []string{visible.Text()}
+	visible.Find("img[alt], [aria-label]").Each(func(i int, child *goquery.Selection) {
+		parts = append(parts, child.AttrOr("alt", ""), child.AttrOr("aria-label", ""))
+	})
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// returns the name an element is given by aria-labelledby, aria-label or title
+func ariaName(doc *goquery.Document, s *goquery.Selection) string {
+	if ids := strings.Fields(s.AttrOr("aria-labelledby", "")); len(ids) > 0 {
+		texts := []string{}
+		for _, id := range ids {
+			doc.Find("[id]").EachWithBreak(func(i int, target *goquery.Selection) bool {
+				if target.AttrOr("id", "") != id {
+					return true
+				}
+				texts = append(texts, target.Text())
+				return false
+			})
+		}
+		if name := strings.Join(strings.Fields(strings.Join(texts, " ")), " "); name != "" {
+			return name
+		}
+	}
+
+	for _, attr := range []string{"aria-label", "title"} {
+		if name := strings.TrimSpace(s.AttrOr(attr, "")); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// reports whether an element is left out of the accessibility tree
+func isHiddenFromAssistiveTech(s *goquery.Selection) bool {
+	if s.Closest("[aria-hidden='true' i]").Length() > 0 {
+		return true
+	}
+	role := strings.ToLower(s.AttrOr("role", ""))
+	return role == "presentation" || role == "none"
+}
+
+// names an element for a finding message, e.g. `Image "logo.png"` or `Field "email"`
+func describeElement(s *goquery.Selection) string {
+	switch goquery.NodeName(s) {
+	case "img", "input":
+		if goquery.NodeName(s) == "img" || strings.EqualFold(s.AttrOr("type", ""), "image") {
+			return fmt.Sprintf("Image %q", s.AttrOr("src", ""))
+		}
+	case "area":
+		return fmt.Sprintf("Image map area %q", s.AttrOr("href", ""))
+	}
+
+	for _, attr := range []string{"name", "id", "placeholder"} {
+		if value := strings.TrimSpace(s.AttrOr(attr, "")); value != "" {
+			return fmt.Sprintf("Field %q", value)
+		}
+	}
+	return "Field"
+}
+
+// builds a CSS selector that finds the element, anchored at the closest ancestor with an id
+func cssPath(s *goquery.Selection) string {
+	parts := []string{}
+	for node := s.Get(0); node != nil && node.Type == html.ElementNode; node = node.Parent {
+		id := ""
+		for _, attr := range node.Attr {
+			if attr.Key == "id" && attr.Namespace == "" {
+				id = attr.Val
+			}
+		}
+		if plainIDPattern.MatchString(id) {
+			parts = append(parts, node.Data+"#"+id)
+			break
+		}
+
+		part := node.Data
+		if node.Parent != nil {
+			index, count := 0, 0
+			for sibling := node.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+					count++
+					if sibling == node {
+						index = count
+					}
+				}
+			}
+			if count > 1 {
+				part += fmt.Sprintf(":nth-of-type(%d)", index)
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
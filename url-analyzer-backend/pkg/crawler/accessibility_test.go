@@ -0,0 +1,111 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lists the accessibility findings as code and selector pairs
+func accessibilityIssues(findings []models.CrawlFinding) []string {
+	issues := []string{}
+	for _, finding := range findings {
+		if finding.Category == models.FindingCategoryAccessibility {
+			issues = append(issues, finding.Code+" "+finding.Selector)
+		}
+	}
+	return issues
+}
+
+func TestCrawler_AccessibilityFindings(t *testing.T) {
+	result := crawlHTML(t, `<!DOCTYPE html>
+<html>
+<head><title>Shop</title></head>
+<body>
+    <h1>Shop</h1>
+    <h3>Offers</h3>
+    <main id="content">
+        <img src="logo.png">
+        <img src="spacer.gif" alt="">
+        <img src="chart.png" aria-label="Sales chart">
+        <form>
+            <input type="text" name="q">
+            <label for="email">Email</label><input type="email" id="email">
+            <label>Name <input type="text" name="name"></label>
+            <input type="text" name="zip" aria-label="Zip code">
+            <input type="hidden" name="token">
+            <input type="submit" value="Go">
+            <button></button>
+            <button><span aria-hidden="true">×</span></button>
+        </form>
+        <a href="/a"></a>
+        <a href="/b"><img src="home.png" alt="Home"></a>
+        <a href="/c">Click here</a>
+        <a href="/d">Read more…</a>
+        <a href="/e" aria-label="Read more about offers">Read more</a>
+        <a href="/f" aria-hidden="true"></a>
+    </main>
+</body>
+</html>`, nil)
+
+	assert.Equal(t, []string{
+		"image_missing_alt main#content > img:nth-of-type(1)",
+		"input_missing_label main#content > form > input:nth-of-type(1)",
+		"skipped_heading_level html > body > h3",
+		"empty_button main#content > form > button:nth-of-type(1)",
+		"empty_button main#content > form > button:nth-of-type(2)",
+		"empty_link main#content > a:nth-of-type(1)",
+		"vague_link_text main#content > a:nth-of-type(3)",
+		"vague_link_text main#content > a:nth-of-type(4)",
+	}, accessibilityIssues(result.Findings))
+
+	for _, finding := range result.Findings {
+		assert.NotEmpty(t, finding.Message)
+	}
+}
+
+func TestAccessibilityFindings_HeadingOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		headings string
+		expected []string
+	}{
+		{"descending in steps", "<h1>a</h1><h2>b</h2><h3>c</h3><h2>d</h2><h4>e</h4>", []string{"skipped_heading_level html > body > h4"}},
+		{"going back up is fine", "<h1>a</h1><h2>b</h2><h3>c</h3><h1>d</h1>", []string{}},
+		{"starting below h1 is not a skip", "<h2>a</h2><h3>b</h3>", []string{}},
+		{"single heading", "<h4>a</h4>", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html lang="en"><body>` + tt.headings + `</body></html>`))
+			require.NoError(t, err)
+
+			counts := map[string]int{}
+			doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+				counts[goquery.NodeName(s)]++
+			})
+
+			assert.Equal(t, tt.expected, accessibilityIssues(accessibilityFindings(doc, counts)))
+		})
+	}
+}
+
+func TestAccessibilityFindings_CapsFindingsPerCode(t *testing.T) {
+	images := strings.Repeat(`<img src="x.png">`, maxAccessibilityFindingsPerCode+5)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html lang="en"><body>` + images + `</body></html>`))
+	require.NoError(t, err)
+
+	findings := accessibilityFindings(doc, map[string]int{})
+	require.Len(t, findings, maxAccessibilityFindingsPerCode+1)
+
+	summary := findings[len(findings)-1]
+	assert.Equal(t, "image_missing_alt", summary.Code)
+	assert.Empty(t, summary.Selector)
+	assert.Equal(t, fmt.Sprintf("%d more elements have the same problem", 5), summary.Message)
+}
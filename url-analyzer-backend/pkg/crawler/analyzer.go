@@ -0,0 +1,221 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Analyzer checks a crawled page and reports what it finds. Analyzers run one after another once
+// the page is parsed and its links and resources are checked. The name identifies the analyzer in
+// the crawl options and the results, and is the category of its findings.
+type Analyzer interface {
+	Name() string
+	Description() string
+	Analyze(ctx context.Context, page *Page) (AnalyzerOutput, error)
+}
+
+// Page is what analyzers get to see of a crawled page. It is shared by all analyzers and must not be changed.
+type Page struct {
+	RequestedURL *url.URL               // the URL the crawl asked for
+	URL          *url.URL               // the URL the page was finally served from, after redirects
+	Document     *goquery.Document      // the parsed HTML
+	Body         []byte                 // the HTML as fetched: the response body, or the rendered DOM for the browser fetcher
+	Response     *http.Response         // the final response, its body already read
+	Result       *models.CrawlJobResult // everything extracted and checked so far
+	Options      models.CrawlOptions
+}
+
+// AnalyzerOutput is what an analyzer reports about a page
+type AnalyzerOutput struct {
+	Findings []models.CrawlFinding
+	Metrics  map[string]float64 // measurements worth keeping, keyed by a name of the analyzer's choosing
+}
+
+// an analyzer made of a function
+type analyzerFunc struct {
+	name        string
+	description string
+	analyze     func(ctx context.Context, page *Page) (AnalyzerOutput, error)
+}
+
+func (a analyzerFunc) Name() string        { return a.name }
+func (a analyzerFunc) Description() string { return a.description }
+func (a analyzerFunc) Analyze(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+	return a.analyze(ctx, page)
+}
+
+// NewAnalyzer returns an analyzer that runs the given function
+func NewAnalyzer(name string, description string, analyze func(ctx context.Context, page *Page) (AnalyzerOutput, error)) Analyzer {
+	return analyzerFunc{name: name, description: description, analyze: analyze}
+}
+
+// the analyzers every crawl runs, in the order they run
+var registry = struct {
+	sync.RWMutex
+	analyzers []Analyzer
+}{}
+
+// RegisterAnalyzer adds an analyzer to every crawl started after it. It panics when the name is
+// empty or already taken, so register analyzers once, at startup.
+func RegisterAnalyzer(analyzer Analyzer) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	name := analyzer.Name()
+	if name == "" {
+		panic("crawler: analyzer without a name")
+	}
+	for _, registered := range registry.analyzers {
+		if registered.Name() == name {
+			panic(fmt.Sprintf("crawler: analyzer %q registered twice", name))
+		}
+	}
+	registry.analyzers = append(registry.analyzers, analyzer)
+}
+
+// Analyzers returns the registered analyzers in the order they run
+func Analyzers() []Analyzer {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]Analyzer{}, registry.analyzers...)
+}
+
+// AnalyzerInfos describes the registered analyzers and whether the options run them
+func AnalyzerInfos(options models.CrawlOptions) []models.AnalyzerInfo {
+	infos := []models.AnalyzerInfo{}
+	for _, analyzer := range Analyzers() {
+		infos = append(infos, models.AnalyzerInfo{
+			Name:        analyzer.Name(),
+			Description: analyzer.Description(),
+			Enabled:     options.AnalyzerEnabled(analyzer.Name()),
+		})
+	}
+	return infos
+}
+
+// runs the analyzers the options enable on a page and returns their findings, filed under the
+// analyzer's name, and how each run went. A failing or panicking analyzer loses its output but
+// does not fail the crawl.
+func (c *Crawler) runAnalyzers(ctx context.Context, page *Page) ([]models.CrawlFinding, models.AnalyzerRuns) {
+	findings := []models.CrawlFinding{}
+	runs := models.AnalyzerRuns{}
+
+	for _, analyzer := range Analyzers() {
+		if !c.options.AnalyzerEnabled(analyzer.Name()) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		start := time.Now()
+		output, err := analyzeSafely(ctx, analyzer, page)
+		run := models.AnalyzerRun{
+			Name:       analyzer.Name(),
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			run.Error = err.Error()
+			runs = append(runs, run)
+			continue
+		}
+
+		for _, finding := range output.Findings {
+			finding.Category = analyzer.Name()
+			findings = append(findings, finding)
+		}
+		run.Findings = len(output.Findings)
+		run.Metrics = output.Metrics
+		runs = append(runs, run)
+	}
+
+	return findings, runs
+}
+
+// runs an analyzer, turning a panic into an error
+func analyzeSafely(ctx context.Context, analyzer Analyzer, page *Page) (output AnalyzerOutput, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("analyzer panicked: %v", recovered)
+		}
+	}()
+	return analyzer.Analyze(ctx, page)
+}
+
+func init() {
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategorySEO, "Title, description, canonical, robots and social metadata",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			return AnalyzerOutput{Findings: seoFindings(page.Result, page.URL)}, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategoryAccessibility, "Alternative text, labels, headings and link and button text",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			return AnalyzerOutput{Findings: accessibilityFindings(page.Document, page.Result.HeadingCounts)}, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategoryStructuredData, "Validity of JSON-LD, microdata and RDFa",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			output := AnalyzerOutput{Findings: []models.CrawlFinding{}}
+			if data := page.Result.StructuredData; data != nil {
+				output.Findings = structuredDataFindings(data)
+				output.Metrics = map[string]float64{"types": float64(len(data.Types))}
+			}
+			return output, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategoryResources, "Broken scripts, stylesheets, images, fonts and iframes",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			output := AnalyzerOutput{Findings: resourceFindings(page.Result.Resources)}
+			if summary := page.Result.ResourceSummary; summary != nil {
+				output.Metrics = map[string]float64{
+					"total":       float64(summary.Total),
+					"total_bytes": float64(summary.TotalBytes),
+					"third_party": float64(summary.ThirdParty),
+				}
+			}
+			return output, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategorySecurity, "Plain HTTP, outdated TLS and expiring certificates",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			output := AnalyzerOutput{Findings: []models.CrawlFinding{}}
+			if report := page.Result.Security; report != nil {
+				output.Findings = securityFindings(report, time.Now())
+				output.Metrics = map[string]float64{"score": float64(report.Score)}
+			}
+			return output, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategoryMixedContent, "Subresources an HTTPS page loads over plain HTTP",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			return AnalyzerOutput{Findings: mixedContentFindings(page.Document, page.URL)}, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategoryRedirects, "Redirect loops, long chains, downgrades and temporary redirects",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			findings := redirectFindings(page.Result.RedirectChain, page.Result.Links, page.Options.RedirectHopLimit)
+			return AnalyzerOutput{
+				Findings: findings,
+				Metrics:  map[string]float64{"page_hops": float64(len(page.Result.RedirectChain))},
+			}, nil
+		}))
+
+	RegisterAnalyzer(NewAnalyzer(models.FindingCategorySitemap, "Pages missing from the sitemap and sites without one",
+		func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			output := AnalyzerOutput{Findings: sitemapFindings(page.Result.Sitemap)}
+			if coverage := page.Result.Sitemap; coverage != nil {
+				output.Metrics = map[string]float64{
+					"sitemap_urls":   float64(coverage.SitemapURLs),
+					"not_in_sitemap": float64(coverage.NotInSitemapCount),
+				}
+			}
+			return output, nil
+		}))
+}
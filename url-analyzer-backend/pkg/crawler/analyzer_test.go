@@ -0,0 +1,145 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registers analyzers for the length of a test
+func registerTestAnalyzers(t *testing.T, analyzers ...Analyzer) {
+	registry.Lock()
+	registered := registry.analyzers
+	registry.Unlock()
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.analyzers = registered
+		registry.Unlock()
+	})
+
+	for _, analyzer := range analyzers {
+		RegisterAnalyzer(analyzer)
+	}
+}
+
+func TestCrawler_RunsAnalyzers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Analyzed</title></head><body><img src="logo.png"><p>Hello</p></body></html>`))
+	}))
+	defer server.Close()
+
+	registerTestAnalyzers(t,
+		NewAnalyzer("paragraphs", "Counts paragraphs", func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			paragraphs := page.Document.Find("p").Length()
+			return AnalyzerOutput{
+				Findings: []models.CrawlFinding{{Category: "ignored", Code: "few_paragraphs", Severity: models.SeverityInfo, Message: page.Result.Title + " has one paragraph"}},
+				Metrics:  map[string]float64{"paragraphs": float64(paragraphs)},
+			}, nil
+		}),
+		NewAnalyzer("failing", "Always fails", func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			return AnalyzerOutput{Findings: []models.CrawlFinding{{Code: "lost"}}}, errors.New("out of ideas")
+		}),
+		NewAnalyzer("panicking", "Always panics", func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+			panic("boom")
+		}),
+	)
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.Analyzers = map[string]bool{models.FindingCategoryAccessibility: false}
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	runs := map[string]models.AnalyzerRun{}
+	names := []string{}
+	for _, run := range result.Analyzers {
+		runs[run.Name] = run
+		names = append(names, run.Name)
+		assert.GreaterOrEqual(t, run.DurationMS, 0.0)
+	}
+	assert.Equal(t, []string{"seo", "structured_data", "resources", "security", "mixed_content", "redirects", "sitemap", "paragraphs", "failing", "panicking"}, names)
+
+	assert.Equal(t, 1, runs["paragraphs"].Findings)
+	assert.Equal(t, map[string]float64{"paragraphs": 1}, runs["paragraphs"].Metrics)
+	assert.Equal(t, "out of ideas", runs["failing"].Error)
+	assert.Equal(t, 0, runs["failing"].Findings)
+	assert.Equal(t, "analyzer panicked: boom", runs["panicking"].Error)
+	assert.Contains(t, runs["security"].Metrics, "score")
+
+	codes := map[string][]string{}
+	for _, finding := range result.Findings {
+		codes[finding.Category] = append(codes[finding.Category], finding.Code)
+	}
+	assert.Equal(t, []string{"few_paragraphs"}, codes["paragraphs"])
+	assert.Empty(t, codes["accessibility"], "disabled analyzers do not run")
+	assert.Empty(t, codes["failing"])
+	assert.Empty(t, codes["ignored"], "findings are filed under the analyzer's name")
+	assert.NotEmpty(t, codes["seo"])
+}
+
+func TestCrawler_DisabledAnalyzersSkipExtraction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Plain</title><meta name="description" content="A page">
+<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Organization"}</script>
+<script src="/app.js"></script></head><body><img src="/logo.png" alt="Logo"></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.CheckBrokenLinks = false
+	options.Analyzers = map[string]bool{
+		models.FindingCategorySEO:            false,
+		models.FindingCategoryStructuredData: false,
+		models.FindingCategoryResources:      false,
+		models.FindingCategorySecurity:       false,
+	}
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	// The page data only those analyzers look at is not gathered at all
+	assert.Equal(t, "Plain", result.Title)
+	assert.Nil(t, result.SEO)
+	assert.Nil(t, result.StructuredData)
+	assert.Empty(t, result.Resources)
+	assert.Nil(t, result.ResourceSummary)
+	assert.Nil(t, result.Security)
+}
+
+func TestRegisterAnalyzer_Twice(t *testing.T) {
+	registerTestAnalyzers(t)
+	analyzer := NewAnalyzer("once", "Registered once", func(ctx context.Context, page *Page) (AnalyzerOutput, error) {
+		return AnalyzerOutput{}, nil
+	})
+
+	RegisterAnalyzer(analyzer)
+	assert.Panics(t, func() { RegisterAnalyzer(analyzer) })
+	assert.Panics(t, func() { RegisterAnalyzer(NewAnalyzer("", "", nil)) })
+}
+
+func TestAnalyzerInfos(t *testing.T) {
+	options := models.DefaultCrawlOptions()
+	options.Analyzers = map[string]bool{"security": false, "seo": true}
+
+	enabled := map[string]bool{}
+	for _, info := range AnalyzerInfos(options) {
+		assert.NotEmpty(t, info.Description, info.Name)
+		enabled[info.Name] = info.Enabled
+	}
+	assert.Equal(t, map[string]bool{
+		"seo":             true,
+		"accessibility":   true,
+		"structured_data": true,
+		"resources":       true,
+		"security":        false,
+		"mixed_content":   true,
+		"redirects":       true,
+		"sitemap":         true,
+	}, enabled)
+}
@@ -0,0 +1,674 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-analyzer/internal/models"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// how long a started browser has to report its DevTools endpoint
+	browserStartTimeout = 20 * time.Second
+	// the origin the DevTools connection is made from, which the browser is told to allow
+	devToolsOrigin = "http://127.0.0.1"
+)
+
+// the binaries looked up on the PATH when no browser path is configured
+var browserBinaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "headless-shell"}
+
+// serializes the document after the page's scripts ran, doctype included
+const renderedHTMLScript = `(document.doctype ? new XMLSerializer().serializeToString(document.doctype) + "\n" : "") + document.documentElement.outerHTML`
+
+// loads pages in a headless Chrome or Chromium driven over the Chrome DevTools Protocol, so the
+// crawler sees the DOM single-page applications render instead of their empty shell. The browser
+// is started on the first fetch, each page gets its own tab, and Close stops the browser.
+type browserFetcher struct {
+	path       string
+	userAgent  string
+	timeout    time.Duration
+	renderWait time.Duration
+
+	mu          sync.Mutex
+	debuggerURL string // the DevTools endpoint of a running browser, when set none is started
+	cmd         *exec.Cmd
+	dataDir     string
+	conn        *devToolsConn
+}
+
+// creates a browser fetcher. Nothing is started until the first fetch.
+func newBrowserFetcher(options models.CrawlOptions) *browserFetcher {
+	return &browserFetcher{
+		path:       options.BrowserPath,
+		userAgent:  options.UserAgent,
+		timeout:    options.Timeout,
+		renderWait: options.RenderWait,
+	}
+}
+
+func (f *browserFetcher) Name() string { return models.FetcherBrowser }
+
+// loads the page in a new tab, waits for it to render and returns the serialized DOM with the
+// response the document was served with
+func (f *browserFetcher) Fetch(ctx context.Context, targetURL string) (*FetchedPage, error) {
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	conn, err := f.connection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("browser unavailable: %w", err)
+	}
+
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := conn.call(ctx, "", "Target.createTarget", map[string]interface{}{"url": "about:blank"}, &target); err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn.call(closeCtx, "", "Target.closeTarget", map[string]interface{}{"targetId": target.TargetID}, nil)
+	}()
+
+	var session struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := conn.call(ctx, "", "Target.attachToTarget", map[string]interface{}{"targetId": target.TargetID, "flatten": true}, &session); err != nil {
+		return nil, err
+	}
+
+	// The main frame of a tab has the tab's ID
+	tab := newBrowserTab(target.TargetID)
+	conn.listen(session.SessionID, tab.handle)
+	defer conn.forget(session.SessionID)
+
+	setup := []struct {
+		method string
+		params interface{}
+	}{
+		{"Page.enable", nil},
+		{"Page.setLifecycleEventsEnabled", map[string]interface{}{"enabled": true}},
+		{"Network.enable", nil},
+		{"Network.setUserAgentOverride", map[string]interface{}{"userAgent": f.userAgent}},
+	}
+	for _, command := range setup {
+		if err := conn.call(ctx, session.SessionID, command.method, command.params, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var navigation struct {
+		LoaderID  string `json:"loaderId"`
+		ErrorText string `json:"errorText"`
+	}
+	if err := conn.call(ctx, session.SessionID, "Page.navigate", map[string]interface{}{"url": targetURL}, &navigation); err != nil {
+		return nil, err
+	}
+	if navigation.ErrorText != "" {
+		_, chain := tab.document(navigation.LoaderID)
+		return &FetchedPage{RedirectChain: chain}, fmt.Errorf("navigation failed: %s", navigation.ErrorText)
+	}
+
+	// Wait for the load event, then give the scripts until the network goes idle to render the page
+	if err := tab.waitFor(ctx, navigation.LoaderID, "load"); err != nil {
+		_, chain := tab.document(navigation.LoaderID)
+		return &FetchedPage{RedirectChain: chain}, err
+	}
+	if f.renderWait > 0 {
+		idleCtx, cancel := context.WithTimeout(ctx, f.renderWait)
+		tab.waitFor(idleCtx, navigation.LoaderID, "networkIdle")
+		cancel()
+	}
+
+	var evaluation struct {
+		Result struct {
+			Value string `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	err = conn.call(ctx, session.SessionID, "Runtime.evaluate", map[string]interface{}{"expression": renderedHTMLScript, "returnByValue": true}, &evaluation)
+	if err != nil {
+		return nil, err
+	}
+	if evaluation.ExceptionDetails != nil {
+		return nil, fmt.Errorf("failed to read the rendered page: %s", evaluation.ExceptionDetails.Text)
+	}
+
+	response, chain := tab.document(navigation.LoaderID)
+	page := &FetchedPage{RedirectChain: chain}
+	if response == nil {
+		return page, errors.New("the browser received no response for the page")
+	}
+	page.Body = []byte(evaluation.Result.Value)
+	page.Response, err = response.httpResponse(page.Body)
+	if err != nil {
+		return page, err
+	}
+	return page, nil
+}
+
+// stops the browser if the fetcher started one
+func (f *browserFetcher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn != nil {
+		f.conn.close()
+		f.conn = nil
+	}
+	f.stopBrowser()
+	return nil
+}
+
+// returns the connection to the browser, starting the browser and connecting to it first if needed
+func (f *browserFetcher) connection(ctx context.Context) (*devToolsConn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn != nil {
+		if !f.conn.closed() {
+			return f.conn, nil
+		}
+		// The browser went away, start a new one
+		f.conn = nil
+		f.stopBrowser()
+	}
+
+	debuggerURL := f.debuggerURL
+	if debuggerURL == "" {
+		var err error
+		if debuggerURL, err = f.startBrowser(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := dialDevTools(ctx, debuggerURL)
+	if err != nil {
+		f.stopBrowser()
+		return nil, err
+	}
+	f.conn = conn
+	return conn, nil
+}
+
+// starts a headless browser with a throwaway profile and returns its DevTools endpoint
+func (f *browserFetcher) startBrowser(ctx context.Context) (string, error) {
+	path, err := findBrowser(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	dataDir, err := os.MkdirTemp("", "url-analyzer-browser-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create browser profile: %w", err)
+	}
+
+	args := []string{
+		"--headless=new",
+		"--remote-debugging-port=0",
+		"--remote-allow-origins=" + devToolsOrigin,
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-gpu",
+		"--disable-extensions",
+		"--disable-background-networking",
+		"--disable-sync",
+		"--mute-audio",
+		"--hide-scrollbars",
+	}
+	// Chrome refuses to run as root with its sandbox, as it does in most containers
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, "about:blank")
+
+	cmd := exec.Command(path, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return "", fmt.Errorf("failed to start %s: %w", path, err)
+	}
+	f.cmd = cmd
+	f.dataDir = dataDir
+
+	// The browser prints its DevTools endpoint once it listens. The rest of its output is drained
+	// so it never blocks on a full pipe.
+	endpoint := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		found := false
+		for scanner.Scan() {
+			if rest, ok := strings.CutPrefix(scanner.Text(), "DevTools listening on "); ok && !found {
+				found = true
+				endpoint <- strings.TrimSpace(rest)
+			}
+		}
+		if !found {
+			endpoint <- ""
+		}
+	}()
+
+	select {
+	case debuggerURL := <-endpoint:
+		if debuggerURL == "" {
+			f.stopBrowser()
+			return "", fmt.Errorf("%s exited before listening for DevTools connections", path)
+		}
+		return debuggerURL, nil
+	case <-time.After(browserStartTimeout):
+		f.stopBrowser()
+		return "", fmt.Errorf("%s did not start within %s", path, browserStartTimeout)
+	case <-ctx.Done():
+		f.stopBrowser()
+		return "", ctx.Err()
+	}
+}
+
+// kills the started browser and removes its profile
+func (f *browserFetcher) stopBrowser() {
+	if f.cmd != nil {
+		f.cmd.Process.Kill()
+		f.cmd.Wait()
+		f.cmd = nil
+	}
+	if f.dataDir != "" {
+		os.RemoveAll(f.dataDir)
+		f.dataDir = ""
+	}
+}
+
+// returns the configured browser binary or the first known one on the PATH
+func findBrowser(configured string) (string, error) {
+	if configured != "" {
+		return exec.LookPath(configured)
+	}
+	for _, name := range browserBinaries {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("no Chrome or Chromium binary found on the PATH")
+}
+
+// what the browser knows about the navigation of a tab's main frame
+type browserTab struct {
+	frameID string
+
+	mu        sync.Mutex
+	documents map[string]*browserDocument // document requests of the main frame by request ID
+	lifecycle map[string]map[string]bool  // lifecycle events of the main frame by loader ID
+	changed   chan struct{}
+}
+
+// a document request of the main frame and the redirects it followed
+type browserDocument struct {
+	chain    models.RedirectChain
+	response *devToolsResponse
+}
+
+// a response as the DevTools protocol reports it
+type devToolsResponse struct {
+	URL             string                   `json:"url"`
+	Status          int                      `json:"status"`
+	StatusText      string                   `json:"statusText"`
+	Headers         map[string]string        `json:"headers"`
+	SecurityDetails *devToolsSecurityDetails `json:"securityDetails"`
+}
+
+// the TLS connection of a response as the DevTools protocol reports it
+type devToolsSecurityDetails struct {
+	Protocol    string  `json:"protocol"`
+	SubjectName string  `json:"subjectName"`
+	Issuer      string  `json:"issuer"`
+	ValidTo     float64 `json:"validTo"` // seconds since the epoch
+}
+
+func newBrowserTab(frameID string) *browserTab {
+	return &browserTab{
+		frameID:   frameID,
+		documents: make(map[string]*browserDocument),
+		lifecycle: make(map[string]map[string]bool),
+		changed:   make(chan struct{}, 1),
+	}
+}
+
+// records the events about the tab's main frame
+func (t *browserTab) handle(method string, params json.RawMessage) {
+	switch method {
+	case "Network.requestWillBeSent":
+		var event struct {
+			RequestID        string            `json:"requestId"`
+			FrameID          string            `json:"frameId"`
+			Type             string            `json:"type"`
+			RedirectResponse *devToolsResponse `json:"redirectResponse"`
+		}
+		if json.Unmarshal(params, &event) != nil || event.FrameID != t.frameID || event.Type != "Document" {
+			return
+		}
+		t.mu.Lock()
+		document := t.documentLocked(event.RequestID)
+		if redirect := event.RedirectResponse; redirect != nil {
+			document.chain = append(document.chain, models.RedirectHop{
+				URL:        redirect.URL,
+				StatusCode: redirect.Status,
+				Location:   headerValue(redirect.Headers, "Location"),
+			})
+		}
+		t.mu.Unlock()
+
+	case "Network.responseReceived":
+		var event struct {
+			RequestID string           `json:"requestId"`
+			FrameID   string           `json:"frameId"`
+			Type      string           `json:"type"`
+			Response  devToolsResponse `json:"response"`
+		}
+		if json.Unmarshal(params, &event) != nil || event.FrameID != t.frameID || event.Type != "Document" {
+			return
+		}
+		t.mu.Lock()
+		t.documentLocked(event.RequestID).response = &event.Response
+		t.mu.Unlock()
+
+	case "Page.lifecycleEvent":
+		var event struct {
+			FrameID  string `json:"frameId"`
+			LoaderID string `json:"loaderId"`
+			Name     string `json:"name"`
+		}
+		if json.Unmarshal(params, &event) != nil || event.FrameID != t.frameID {
+			return
+		}
+		t.mu.Lock()
+		if t.lifecycle[event.LoaderID] == nil {
+			t.lifecycle[event.LoaderID] = make(map[string]bool)
+		}
+		t.lifecycle[event.LoaderID][event.Name] = true
+		t.mu.Unlock()
+
+		select {
+		case t.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// returns the document request with the given ID, adding it if needed. t.mu must be held.
+func (t *browserTab) documentLocked(requestID string) *browserDocument {
+	document := t.documents[requestID]
+	if document == nil {
+		document = &browserDocument{chain: models.RedirectChain{}}
+		t.documents[requestID] = document
+	}
+	return document
+}
+
+// returns the final response of a navigation, nil if none arrived, and the redirects it followed.
+// The document request of a navigation has the loader's ID.
+func (t *browserTab) document(loaderID string) (*devToolsResponse, models.RedirectChain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	document := t.documents[loaderID]
+	if document == nil {
+		return nil, models.RedirectChain{}
+	}
+	return document.response, append(models.RedirectChain{}, document.chain...)
+}
+
+// waits until the main frame reached a lifecycle event of a navigation
+func (t *browserTab) waitFor(ctx context.Context, loaderID string, name string) error {
+	for {
+		t.mu.Lock()
+		reached := t.lifecycle[loaderID][name]
+		t.mu.Unlock()
+		if reached {
+			return nil
+		}
+
+		select {
+		case <-t.changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// returns the value of a header, whatever the case of its name
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// turns the response into the one the rest of the crawler works with, carrying the rendered HTML
+func (r *devToolsResponse) httpResponse(body []byte) (*http.Response, error) {
+	finalURL, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL %q: %w", r.URL, err)
+	}
+
+	// Repeated headers such as Set-Cookie come joined by newlines
+	header := http.Header{}
+	for name, value := range r.Headers {
+		for _, line := range strings.Split(value, "\n") {
+			header.Add(name, line)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, r.StatusText),
+		StatusCode:    r.Status,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       &http.Request{Method: http.MethodGet, URL: finalURL, Header: http.Header{}},
+		TLS:           r.SecurityDetails.connectionState(),
+	}, nil
+}
+
+// the TLS versions by the names the DevTools protocol gives them
+var devToolsTLSVersions = map[string]uint16{
+	"TLS 1":   tls.VersionTLS10,
+	"TLS 1.0": tls.VersionTLS10,
+	"TLS 1.1": tls.VersionTLS11,
+	"TLS 1.2": tls.VersionTLS12,
+	"TLS 1.3": tls.VersionTLS13,
+	"QUIC":    tls.VersionTLS13,
+}
+
+// describes the TLS connection with what the browser reports of it: the version and the
+// subject, issuer and expiry of the leaf certificate. Nil for plain HTTP.
+func (d *devToolsSecurityDetails) connectionState() *tls.ConnectionState {
+	if d == nil {
+		return nil
+	}
+	return &tls.ConnectionState{
+		Version:           devToolsTLSVersions[d.Protocol],
+		HandshakeComplete: true,
+		PeerCertificates: []*x509.Certificate{{
+			Subject:  pkix.Name{CommonName: d.SubjectName},
+			Issuer:   pkix.Name{CommonName: d.Issuer},
+			NotAfter: time.Unix(int64(d.ValidTo), 0),
+		}},
+	}
+}
+
+// a connection to the DevTools endpoint of a browser. Commands to a tab go to its session,
+// whose events are passed to the handler listening on it.
+type devToolsConn struct {
+	ws     *websocket.Conn
+	nextID atomic.Int64
+
+	mu       sync.Mutex
+	pending  map[int64]chan devToolsMessage
+	sessions map[string]func(method string, params json.RawMessage)
+	done     chan struct{}
+	err      error
+}
+
+// a command sent to the browser
+type devToolsCommand struct {
+	ID        int64       `json:"id"`
+	SessionID string      `json:"sessionId,omitempty"`
+	Method    string      `json:"method"`
+	Params    interface{} `json:"params,omitempty"`
+}
+
+// a reply to a command, or an event when it has no ID
+type devToolsMessage struct {
+	ID        int64           `json:"id"`
+	SessionID string          `json:"sessionId"`
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params"`
+	Result    json.RawMessage `json:"result"`
+	Error     *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// connects to the DevTools endpoint of a browser
+func dialDevTools(ctx context.Context, debuggerURL string) (*devToolsConn, error) {
+	config, err := websocket.NewConfig(debuggerURL, devToolsOrigin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DevTools endpoint %q: %w", debuggerURL, err)
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the browser: %w", err)
+	}
+
+	conn := &devToolsConn{
+		ws:       ws,
+		pending:  make(map[int64]chan devToolsMessage),
+		sessions: make(map[string]func(method string, params json.RawMessage)),
+		done:     make(chan struct{}),
+	}
+	go conn.read()
+	return conn, nil
+}
+
+// sends a command and decodes its result into result, unless it is nil
+func (c *devToolsConn) call(ctx context.Context, sessionID string, method string, params interface{}, result interface{}) error {
+	id := c.nextID.Add(1)
+	reply := make(chan devToolsMessage, 1)
+
+	c.mu.Lock()
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := websocket.JSON.Send(c.ws, devToolsCommand{ID: id, SessionID: sessionID, Method: method, Params: params}); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case msg := <-reply:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("%s: invalid result: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return fmt.Errorf("%s: %w", method, c.err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// passes the events of a session to the handler. It runs on the connection's reader and must not block.
+func (c *devToolsConn) listen(sessionID string, handler func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[sessionID] = handler
+}
+
+// stops passing on the events of a session
+func (c *devToolsConn) forget(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessions, sessionID)
+}
+
+// dispatches replies and events until the connection breaks
+func (c *devToolsConn) read() {
+	for {
+		var msg devToolsMessage
+		if err := websocket.JSON.Receive(c.ws, &msg); err != nil {
+			c.err = fmt.Errorf("browser connection lost: %w", err)
+			close(c.done)
+			return
+		}
+
+		c.mu.Lock()
+		if msg.ID != 0 {
+			reply := c.pending[msg.ID]
+			c.mu.Unlock()
+			if reply != nil {
+				reply <- msg
+			}
+			continue
+		}
+		handler := c.sessions[msg.SessionID]
+		c.mu.Unlock()
+		if handler != nil {
+			handler(msg.Method, msg.Params)
+		}
+	}
+}
+
+// reports whether the connection broke
+func (c *devToolsConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// closes the connection and waits for its reader to stop
+func (c *devToolsConn) close() {
+	c.ws.Close()
+	<-c.done
+}
@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// the page the fake browser renders, as a single-page application would after its scripts ran
+const renderedSPA = `<!DOCTYPE html><html lang="en"><head><title>Shop</title></head>
+<body><div id="root"><h1>Products</h1><a href="/products/1">Shoe</a><a href="/products/2">Boot</a></div></body></html>`
+
+// a DevTools endpoint that plays a browser loading https://shop.test/, which redirects to
+// https://shop.test/en/ and renders renderedSPA
+type fakeBrowser struct {
+	mu       sync.Mutex
+	methods  []string
+	closed   []string
+	navigate func(ws *websocket.Conn, sessionID string) // sends the events of a navigation
+}
+
+func newFakeBrowser(t *testing.T) (*fakeBrowser, string) {
+	browser := &fakeBrowser{navigate: sendShopNavigation}
+	server := httptest.NewServer(websocket.Handler(browser.serve))
+	t.Cleanup(server.Close)
+	return browser, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func (b *fakeBrowser) serve(ws *websocket.Conn) {
+	for {
+		var command struct {
+			ID        int64                  `json:"id"`
+			SessionID string                 `json:"sessionId"`
+			Method    string                 `json:"method"`
+			Params    map[string]interface{} `json:"params"`
+		}
+		if err := websocket.JSON.Receive(ws, &command); err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.methods = append(b.methods, command.Method)
+		b.mu.Unlock()
+
+		result := map[string]interface{}{}
+		switch command.Method {
+		case "Target.createTarget":
+			result["targetId"] = "TAB1"
+		case "Target.attachToTarget":
+			result["sessionId"] = "SESSION1"
+		case "Target.closeTarget":
+			b.mu.Lock()
+			b.closed = append(b.closed, command.Params["targetId"].(string))
+			b.mu.Unlock()
+		case "Page.navigate":
+			b.navigate(ws, command.SessionID)
+			result["frameId"] = "TAB1"
+			result["loaderId"] = "LOADER1"
+		case "Runtime.evaluate":
+			result["result"] = map[string]interface{}{"type": "string", "value": renderedSPA}
+		}
+		websocket.JSON.Send(ws, map[string]interface{}{"id": command.ID, "result": result})
+	}
+}
+
+// sends the events of a tab loading the shop. Events of other frames and subresources are ignored.
+func sendShopNavigation(ws *websocket.Conn, sessionID string) {
+	event := func(method string, params map[string]interface{}) {
+		websocket.JSON.Send(ws, map[string]interface{}{"sessionId": sessionID, "method": method, "params": params})
+	}
+
+	event("Network.requestWillBeSent", map[string]interface{}{
+		"requestId": "LOADER1", "loaderId": "LOADER1", "frameId": "TAB1", "type": "Document",
+		"request": map[string]interface{}{"url": "https://shop.test/"},
+	})
+	event("Network.requestWillBeSent", map[string]interface{}{
+		"requestId": "LOADER1", "loaderId": "LOADER1", "frameId": "TAB1", "type": "Document",
+		"request": map[string]interface{}{"url": "https://shop.test/en/"},
+		"redirectResponse": map[string]interface{}{
+			"url": "https://shop.test/", "status": 301, "statusText": "Moved Permanently",
+			"headers": map[string]interface{}{"location": "/en/"},
+		},
+	})
+	event("Network.responseReceived", map[string]interface{}{
+		"requestId": "LOADER1", "loaderId": "LOADER1", "frameId": "TAB1", "type": "Document",
+		"response": map[string]interface{}{
+			"url": "https://shop.test/en/", "status": 200, "statusText": "OK",
+			"headers": map[string]interface{}{
+				"content-type":              "text/html",
+				"strict-transport-security": "max-age=31536000",
+				"set-cookie":                "session=1; Secure; HttpOnly\ntheme=dark",
+			},
+			"securityDetails": map[string]interface{}{
+				"protocol": "TLS 1.3", "subjectName": "shop.test", "issuer": "Test CA",
+				"validTo": float64(time.Now().Add(90 * 24 * time.Hour).Unix()),
+			},
+		},
+	})
+	event("Network.responseReceived", map[string]interface{}{
+		"requestId": "XHR1", "loaderId": "LOADER1", "frameId": "TAB1", "type": "XHR",
+		"response": map[string]interface{}{"url": "https://shop.test/api/products", "status": 200},
+	})
+	event("Page.lifecycleEvent", map[string]interface{}{"frameId": "IFRAME", "loaderId": "LOADER1", "name": "load"})
+	event("Page.lifecycleEvent", map[string]interface{}{"frameId": "TAB1", "loaderId": "LOADER1", "name": "load"})
+	event("Page.lifecycleEvent", map[string]interface{}{"frameId": "TAB1", "loaderId": "LOADER1", "name": "networkIdle"})
+}
+
+func TestBrowserFetcher_Fetch(t *testing.T) {
+	browser, debuggerURL := newFakeBrowser(t)
+
+	fetcher := newBrowserFetcher(models.DefaultCrawlOptions())
+	fetcher.debuggerURL = debuggerURL
+	defer fetcher.Close()
+
+	page, err := fetcher.Fetch(context.Background(), "https://shop.test/")
+	require.NoError(t, err)
+
+	assert.Equal(t, renderedSPA, string(page.Body))
+	assert.Equal(t, http.StatusOK, page.Response.StatusCode)
+	assert.Equal(t, "https://shop.test/en/", page.Response.Request.URL.String())
+	assert.Equal(t, "text/html", page.Response.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"session=1; Secure; HttpOnly", "theme=dark"}, page.Response.Header.Values("Set-Cookie"))
+	assert.Equal(t, models.RedirectChain{{URL: "https://shop.test/", StatusCode: 301, Location: "/en/"}}, page.RedirectChain)
+
+	require.NotNil(t, page.Response.TLS)
+	assert.Equal(t, uint16(tls.VersionTLS13), page.Response.TLS.Version)
+	require.Len(t, page.Response.TLS.PeerCertificates, 1)
+	assert.Equal(t, "Test CA", page.Response.TLS.PeerCertificates[0].Issuer.CommonName)
+
+	// The tab is closed once the page is read
+	browser.mu.Lock()
+	defer browser.mu.Unlock()
+	assert.Equal(t, []string{"TAB1"}, browser.closed)
+	assert.Contains(t, browser.methods, "Network.setUserAgentOverride")
+}
+
+func TestBrowserFetcher_WaitsForLoad(t *testing.T) {
+	browser, debuggerURL := newFakeBrowser(t)
+	// The page never finishes loading
+	browser.navigate = func(ws *websocket.Conn, sessionID string) {}
+
+	options := models.DefaultCrawlOptions()
+	options.Timeout = 200 * time.Millisecond
+	fetcher := newBrowserFetcher(options)
+	fetcher.debuggerURL = debuggerURL
+	defer fetcher.Close()
+
+	_, err := fetcher.Fetch(context.Background(), "https://shop.test/")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCrawler_BrowserFetcher(t *testing.T) {
+	_, debuggerURL := newFakeBrowser(t)
+
+	options := models.DefaultCrawlOptions()
+	options.Fetcher = models.FetcherBrowser
+	options.FollowRobotsTxt = false
+	options.CheckBrokenLinks = false
+	crawler := NewCrawler(options)
+	crawler.fetcher.(*browserFetcher).debuggerURL = debuggerURL
+	defer crawler.Close()
+
+	result := crawler.CrawlURL(context.Background(), "https://shop.test/")
+	require.NoError(t, result.Error)
+
+	assert.Equal(t, models.FetcherBrowser, result.Fetcher)
+	assert.Equal(t, "Shop", result.Title)
+	assert.Equal(t, 1, result.HeadingCounts["h1"])
+	assert.Equal(t, 2, result.InternalLinks)
+	assert.Equal(t, "HTML5", result.HTMLVersion)
+	assert.Len(t, result.RedirectChain, 1)
+
+	require.NotNil(t, result.Security)
+	require.NotNil(t, result.Security.TLSVersion)
+	assert.Equal(t, "TLS 1.3", *result.Security.TLSVersion)
+	assert.Equal(t, "Test CA", *result.Security.CertIssuer)
+	assert.Len(t, result.Security.Cookies, 2)
+}
+
+func TestDevToolsConn_ReportsErrors(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		var command struct {
+			ID int64 `json:"id"`
+		}
+		websocket.JSON.Receive(ws, &command)
+		websocket.JSON.Send(ws, map[string]interface{}{"id": command.ID, "error": map[string]interface{}{"code": -32000, "message": "Cannot navigate to invalid URL"}})
+		ws.Close()
+	}))
+	defer server.Close()
+
+	conn, err := dialDevTools(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer conn.close()
+
+	err = conn.call(context.Background(), "", "Page.navigate", nil, nil)
+	require.EqualError(t, err, "Page.navigate: Cannot navigate to invalid URL")
+
+	// The connection is gone once the browser hangs up
+	err = conn.call(context.Background(), "", "Page.navigate", nil, &json.RawMessage{})
+	require.Error(t, err)
+	assert.Eventually(t, conn.closed, time.Second, 10*time.Millisecond)
+}
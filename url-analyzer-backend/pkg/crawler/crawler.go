@@ -1,9 +1,13 @@
 package crawler
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +20,9 @@ import (
 // main crawler struct
 type Crawler struct {
 	client   *resty.Client
+	fetcher  Fetcher
+	robots   *robotsCache
+	sitemaps *sitemapCache
 	options  models.CrawlOptions
 	progress models.ProgressCallback
 	mu       sync.RWMutex
@@ -25,7 +32,7 @@ type Crawler struct {
 func NewCrawler(options models.CrawlOptions) *Crawler {
 	client := resty.New()
 	client.SetTimeout(options.Timeout)
-	client.SetRedirectPolicy(resty.FlexibleRedirectPolicy(options.MaxRedirects))
+	client.SetRedirectPolicy(recordingRedirectPolicy(options.MaxRedirects), resty.FlexibleRedirectPolicy(options.MaxRedirects))
 	
 	// Set headers to appear more like a real browser
 	client.SetHeaders(map[string]string{
@@ -38,12 +45,40 @@ func NewCrawler(options models.CrawlOptions) *Crawler {
 		"Upgrade-Insecure-Requests": "1",
 	})
 
+	robots := newRobotsCache(client, options.UserAgent)
 	return &Crawler{
-		client:  client,
-		options: options,
+		client:   client,
+		fetcher:  newFetcher(options, client),
+		robots:   robots,
+		sitemaps: newSitemapCache(client, robots, options.UserAgent),
+		options:  options,
 	}
 }
 
+// replaces how the crawler loads pages, e.g. with a stub in tests. Must be called before crawling.
+func (c *Crawler) SetFetcher(fetcher Fetcher) {
+	c.fetcher = fetcher
+}
+
+// shares the robots.txt files the crawler reads, and how often it requests each host,
+// with other crawlers through a cache. Must be called before crawling.
+func (c *Crawler) SetRobotsCache(cache *RobotsCache) {
+	c.robots.hosts = cache
+}
+
+// shares the sitemaps the crawler reads with other crawlers through a cache. Must be called before crawling.
+func (c *Crawler) SetSitemapCache(cache *SitemapCache) {
+	c.sitemaps.shared = cache
+}
+
+// releases what the fetcher holds on to, such as its browser. The crawler must not be used afterwards.
+func (c *Crawler) Close() error {
+	if closer, ok := c.fetcher.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // sets a callback function to receive progress updates
 func (c *Crawler) SetProgressCallback(callback models.ProgressCallback) {
 	c.mu.Lock()
@@ -62,69 +97,130 @@ func (c *Crawler) reportProgress(status models.CrawlStatus, message string, prog
 	}
 }
 
-// crawls a single URL and returns detailed information
-func (c *Crawler) CrawlURL(targetURL string) *models.CrawlJobResult {
+// discards progress updates
+func discardProgress(status models.CrawlStatus, message string, progress float64) {}
+
+// crawls a single URL and returns detailed information. Cancelling ctx aborts
+// all outstanding requests and the result carries the context's error.
+func (c *Crawler) CrawlURL(ctx context.Context, targetURL string) *models.CrawlJobResult {
+	result, _ := c.crawlPage(ctx, targetURL, c.reportProgress, nil)
+	return result
+}
+
+// fetches and analyzes a single page, returning the result and the links found on it
+func (c *Crawler) crawlPage(ctx context.Context, targetURL string, report models.ProgressCallback, cache *linkCheckCache) (*models.CrawlJobResult, []models.LinkInfo) {
 	startTime := time.Now()
 	
 	result := &models.CrawlJobResult{
+		Analyzers:       models.AnalyzerRuns{},
+		RuleResults:     []models.CrawlRuleResult{},
+		RedirectChain:   models.RedirectChain{},
 		URL:             targetURL,
 		HeadingCounts:   make(map[string]int),
 		BrokenLinks:     []models.CrawlBrokenLink{},
+		Links:           []models.CrawlLink{},
+		Forms:           []models.CrawlForm{},
+		Resources:       []models.CrawlResource{},
+		Findings:        []models.CrawlFinding{},
 		ResponseHeaders: make(map[string]string),
 	}
 	
-	c.reportProgress(models.CrawlStatusStarted, "Starting crawl", 0.0)
+	report(models.CrawlStatusStarted, "Starting crawl", 0.0)
 	
 	// Validate URL
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
 		result.Error = fmt.Errorf("invalid URL: %w", err)
-		c.reportProgress(models.CrawlStatusFailed, "Invalid URL", 100.0)
-		return result
+		report(models.CrawlStatusFailed, "Invalid URL", 100.0)
+		return result, nil
+	}
+	
+	// Honor robots.txt before touching the page
+	if c.followsRobots(parsedURL) {
+		if !c.robots.allowed(ctx, parsedURL) {
+			if ctx.Err() != nil {
+				return cancelled(ctx, result), nil
+			}
+			result.BlockedByRobots = true
+			result.CrawlDuration = time.Since(startTime)
+			report(models.CrawlStatusBlocked, "Blocked by robots.txt", 100.0)
+			return result, nil
+		}
+		c.robots.waitTurn(ctx, parsedURL)
+	}
+	
+	if ctx.Err() != nil {
+		return cancelled(ctx, result), nil
 	}
 	
 	// Fetch the webpage
-	c.reportProgress(models.CrawlStatusFetching, "Fetching webpage", 10.0)
-	resp, err := c.client.R().Get(targetURL)
+	report(models.CrawlStatusFetching, "Fetching webpage", 10.0)
+	result.Fetcher = c.fetcher.Name()
+	page, err := c.fetcher.Fetch(ctx, targetURL)
+	if page != nil {
+		result.RedirectChain = page.RedirectChain
+	}
 	if err != nil {
+		if ctx.Err() != nil {
+			return cancelled(ctx, result), nil
+		}
 		result.Error = fmt.Errorf("failed to fetch URL: %w", err)
-		c.reportProgress(models.CrawlStatusFailed, "Failed to fetch webpage", 100.0)
-		return result
+		report(models.CrawlStatusFailed, "Failed to fetch webpage", 100.0)
+		return result, nil
 	}
 	
-	result.StatusCode = resp.StatusCode()
-	result.ContentLength = int64(len(resp.Body()))
+	resp := page.Response
+	result.StatusCode = resp.StatusCode
+	result.ContentLength = int64(len(page.Body))
 	
 	// Store response headers
-	for key, values := range resp.Header() {
+	for key, values := range resp.Header {
 		if len(values) > 0 {
 			result.ResponseHeaders[key] = values[0]
 		}
 	}
 	
-	if resp.StatusCode() >= 400 {
-		result.Error = fmt.Errorf("HTTP error: %d %s", resp.StatusCode(), http.StatusText(resp.StatusCode()))
-		c.reportProgress(models.CrawlStatusFailed, fmt.Sprintf("HTTP %d error", resp.StatusCode()), 100.0)
-		return result
+	if resp.StatusCode >= 400 {
+		result.Error = fmt.Errorf("HTTP error: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		report(models.CrawlStatusFailed, fmt.Sprintf("HTTP %d error", resp.StatusCode), 100.0)
+		return result, nil
 	}
 	
 	// Parse HTML
-	c.reportProgress(models.CrawlStatusParsing, "Parsing HTML", 30.0)
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(resp.Body())))
+	report(models.CrawlStatusParsing, "Parsing HTML", 30.0)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.Body))
 	if err != nil {
 		result.Error = fmt.Errorf("failed to parse HTML: %w", err)
-		c.reportProgress(models.CrawlStatusFailed, "Failed to parse HTML", 100.0)
-		return result
+		report(models.CrawlStatusFailed, "Failed to parse HTML", 100.0)
+		return result, nil
 	}
 	
 	// Extract HTML information
-	c.reportProgress(models.CrawlStatusAnalyzing, "Analyzing content", 50.0)
+	report(models.CrawlStatusAnalyzing, "Analyzing content", 50.0)
 	htmlInfo := c.extractHTMLInfo(doc, parsedURL)
 	
 	result.Title = htmlInfo.Title
-	result.HTMLVersion = htmlInfo.HTMLVersion
+	doctype := detectDoctype(page.Body)
+	result.HTMLVersion = doctype.version
+	result.Doctype = doctype.raw
+	result.DocumentMode = doctype.documentMode
 	result.HeadingCounts = htmlInfo.Headings
 	result.HasLoginForm = htmlInfo.HasLoginForm
+	result.Forms = htmlInfo.Forms
+	
+	if c.options.AnalyzerEnabled(models.FindingCategorySEO) {
+		// Robots directives can also come with the response
+		seo := htmlInfo.SEO
+		for _, header := range resp.Header.Values("X-Robots-Tag") {
+			seo.Robots = append(seo.Robots, headerRobotsDirectives(header)...)
+		}
+		result.SEO = &seo
+	}
+	
+	if c.options.AnalyzerEnabled(models.FindingCategoryStructuredData) {
+		structuredData := htmlInfo.StructuredData
+		result.StructuredData = &structuredData
+	}
 	
 	// Count internal vs external links
 	result.InternalLinks = 0
@@ -138,18 +234,108 @@ func (c *Crawler) CrawlURL(targetURL string) *models.CrawlJobResult {
 		}
 	}
 	
-	// Check for broken links if enabled
-	if c.options.CheckBrokenLinks {
-		c.reportProgress(models.CrawlStatusChecking, "Checking links", 70.0)
-		result.BrokenLinks = c.checkBrokenLinks(htmlInfo.Links, parsedURL)
+	// Check the links and resources if enabled, otherwise they are only listed
+	result.Links = newLinkInventory(htmlInfo.Links)
+	result.Resources = htmlInfo.Resources
+	if c.options.CheckBrokenLinks || c.options.CheckResources {
+		message := "Checking links"
+		if !c.options.CheckBrokenLinks {
+			message = "Checking resources"
+		} else if c.options.CheckResources {
+			message = "Checking links and resources"
+		}
+		report(models.CrawlStatusChecking, message, 70.0)
+		c.checkPage(ctx, result.Links, result.Resources, cache)
+		if c.options.CheckBrokenLinks {
+			result.BrokenLinks, result.LinksBlockedByRobots = brokenLinksOf(result.Links)
+		}
+		
+		// Checks interrupted by a cancellation say nothing about the links
+		if ctx.Err() != nil {
+			return cancelled(ctx, result), nil
+		}
+	}
+	
+	if c.options.AnalyzerEnabled(models.FindingCategoryResources) {
+		summary := summarizeResources(result.Resources)
+		result.ResourceSummary = &summary
+	}
+	
+	// Grade the security headers, cookies and TLS connection of the response
+	if c.options.AnalyzerEnabled(models.FindingCategorySecurity) {
+		security := analyzeSecurity(resp, parsedURL, time.Now())
+		result.Security = &security
+	}
+	
+	// Relative references inherit the scheme the page was finally served over
+	pageURL := parsedURL
+	if resp.Request != nil {
+		pageURL = resp.Request.URL
+	}
+	
+	// Compare the pages linked from the page with the site's sitemap
+	if c.options.AnalyzerEnabled(models.FindingCategorySitemap) {
+		report(models.CrawlStatusChecking, "Reading sitemap", 80.0)
+		sitemap := c.sitemaps.site(ctx, parsedURL)
+		result.Sitemap = sitemap.coverage(linkedPages(htmlInfo.Links), nil)
+		canonical := canonicalURL(doc, parsedURL)
+		result.Sitemap.InSitemap = sitemap.lists(pageURL.String()) || (canonical != "" && sitemap.lists(canonical))
+		if ctx.Err() != nil {
+			return cancelled(ctx, result), nil
+		}
+	}
+	
+	// Evaluate the custom rules of the URL
+	result.RuleResults = evaluateRules(doc, result.Title, c.options.Rules)
+	
+	// Let the analyzers look at the page
+	report(models.CrawlStatusAnalyzing, "Running analyzers", 90.0)
+	result.Findings, result.Analyzers = c.runAnalyzers(ctx, &Page{
+		RequestedURL: parsedURL,
+		URL:          pageURL,
+		Document:     doc,
+		Body:         page.Body,
+		Response:     resp,
+		Result:       result,
+		Options:      c.options,
+	})
+	if ctx.Err() != nil {
+		return cancelled(ctx, result), nil
 	}
 	
 	result.CrawlDuration = time.Since(startTime)
-	c.reportProgress(models.CrawlStatusCompleted, "Crawl completed", 100.0)
+	report(models.CrawlStatusCompleted, "Crawl completed", 100.0)
 	
+	return result, htmlInfo.Links
+}
+
+// marks a result as abandoned because its crawl was cancelled. Nothing is
+// reported, the caller that cancelled the crawl already knows about it.
+func cancelled(ctx context.Context, result *models.CrawlJobResult) *models.CrawlJobResult {
+	result.Error = fmt.Errorf("crawl cancelled: %w", ctx.Err())
+	result.BrokenLinks = []models.CrawlBrokenLink{}
+	result.Links = []models.CrawlLink{}
+	result.Resources = []models.CrawlResource{}
 	return result
 }
 
+// waits for the given duration, returning early with the context's error when it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // extracts detailed information from the HTML document
 func (c *Crawler) extractHTMLInfo(doc *goquery.Document, baseURL *url.URL) models.HTMLInfo {
 	info := models.HTMLInfo{
@@ -161,9 +347,6 @@ func (c *Crawler) extractHTMLInfo(doc *goquery.Document, baseURL *url.URL) model
 	// Extract title
 	info.Title = strings.TrimSpace(doc.Find("title").First().Text())
 	
-	// Detect HTML version
-	info.HTMLVersion = c.detectHTMLVersion(doc)
-	
 	// Count headings
 	for i := 1; i <= 6; i++ {
 		tag := fmt.Sprintf("h%d", i)
@@ -197,6 +380,7 @@ func (c *Crawler) extractHTMLInfo(doc *goquery.Document, baseURL *url.URL) model
 		linkInfo := models.LinkInfo{
 			URL:  linkURL.String(),
 			Text: strings.TrimSpace(s.Text()),
+			Rel:  strings.Join(strings.Fields(s.AttrOr("rel", "")), " "),
 		}
 		
 		// Determine if link is internal or external
@@ -209,128 +393,227 @@ func (c *Crawler) extractHTMLInfo(doc *goquery.Document, baseURL *url.URL) model
 		info.Links = append(info.Links, linkInfo)
 	})
 	
-	// Check for login forms
-	info.HasLoginForm = c.detectLoginForm(doc)
+	// Classify forms
+	info.Forms = analyzeForms(doc, baseURL)
+	for _, form := range info.Forms {
+		if form.Type == models.FormTypeLogin {
+			info.HasLoginForm = true
+		}
+	}
 	
-	// Extract meta tags
+	// Extract meta tags, named or Open Graph style
 	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
-		if name, exists := s.Attr("name"); exists {
+		name, exists := s.Attr("name")
+		if !exists {
+			name, exists = s.Attr("property")
+		}
+		if exists {
 			if content, exists := s.Attr("content"); exists {
 				info.MetaTags[name] = content
 			}
 		}
 	})
 	
-	return info
-}
-
-// attempts to detect the HTML version
-func (c *Crawler) detectHTMLVersion(doc *goquery.Document) string {
-	// Check for HTML5 doctype
-	doctype := doc.Find("html").First().AttrOr("", "")
-	
-	// Look for HTML5 indicators
-	if doc.Find("article, aside, footer, header, nav, section, main").Length() > 0 {
-		return "HTML5"
+	// Page data only its analyzer looks at is left out when the analyzer is turned off
+	if c.options.AnalyzerEnabled(models.FindingCategorySEO) {
+		info.SEO = extractSEOMetadata(doc, baseURL)
 	}
-	
-	// Check for specific HTML4 doctypes in the raw content
-	if strings.Contains(strings.ToLower(doctype), "html 4") {
-		return "HTML 4.01"
+	if c.options.AnalyzerEnabled(models.FindingCategoryStructuredData) {
+		info.StructuredData = extractStructuredData(doc, baseURL)
 	}
-	
-	if strings.Contains(strings.ToLower(doctype), "xhtml") {
-		return "XHTML 1.0"
+	info.Resources = []models.CrawlResource{}
+	if c.options.AnalyzerEnabled(models.FindingCategoryResources) {
+		info.Resources = extractResources(doc, baseURL)
 	}
 	
-	return "HTML5"
+	return info
 }
 
-// checks if the page contains a login form
-func (c *Crawler) detectLoginForm(doc *goquery.Document) bool {
-	// Look for password fields first (most reliable indicator)
-	if doc.Find("input[type='password']").Length() > 0 {
-		return true
-	}
-	
-	// Look for common login form patterns with password fields
-	loginIndicators := []string{
-		"input[name*='password']",
-		"input[name*='login']",
-		"input[name*='username']",
-	}
-	
-	for _, selector := range loginIndicators {
-		if doc.Find(selector).Length() > 0 {
-			// Check if there's also a password field in the same form
-			doc.Find(selector).Each(func(i int, s *goquery.Selection) {
-				form := s.Closest("form")
-				if form.Length() > 0 && form.Find("input[type='password']").Length() > 0 {
-					return
-				}
+// lists links as not checked
+func newLinkInventory(links []models.LinkInfo) []models.CrawlLink {
+	inventory := make([]models.CrawlLink, len(links))
+	for i, link := range links {
+		inventory[i] = models.CrawlLink{
+			URL:        link.URL,
+			Text:       link.Text,
+			Rel:        link.Rel,
+			IsInternal: link.IsInternal,
+			Status:     models.LinkStatusUnchecked,
+			Redirects:  models.RedirectChain{},
+		}
+	}
+	return inventory
+}
+
+// checks the links and resources of a page in one pool of ConcurrentChecks requests, recording the
+// outcomes in place. Links are only checked with CheckBrokenLinks and resources with CheckResources,
+// and of each only the first MaxLinksToCheck. Those disallowed by robots.txt are marked blocked.
+func (c *Crawler) checkPage(ctx context.Context, links []models.CrawlLink, resources []models.CrawlResource, cache *linkCheckCache) {
+	urls := []string{}
+	record := []func(outcome checkOutcome){}
+	
+	if c.options.CheckBrokenLinks {
+		for i := 0; i < len(links) && i < c.options.MaxLinksToCheck; i++ {
+			link := &links[i]
+			urls = append(urls, link.URL)
+			record = append(record, func(outcome checkOutcome) {
+				link.Status = outcome.status()
+				link.Category = outcome.category
+				link.StatusCode = outcome.statusCode
+				link.ErrorMessage = outcome.errorMessage
+				link.Redirects = outcome.redirects
 			})
 		}
 	}
 	
-	// Look for forms with login-related actions that also have password fields
-	loginActions := []string{"login", "signin", "auth"}
-	for _, action := range loginActions {
-		selector := fmt.Sprintf("form[action*='%s']", action)
-		doc.Find(selector).Each(func(i int, form *goquery.Selection) {
-			if form.Find("input[type='password']").Length() > 0 {
-				return
-			}
-		})
+	if c.options.CheckResources {
+		for i := 0; i < len(resources) && i < c.options.MaxLinksToCheck; i++ {
+			resource := &resources[i]
+			urls = append(urls, resource.URL)
+			record = append(record, func(outcome checkOutcome) {
+				resource.Status = outcome.status()
+				resource.Category = outcome.category
+				resource.StatusCode = outcome.statusCode
+				resource.ErrorMessage = outcome.errorMessage
+				resource.Size = outcome.size
+				resource.ContentType = outcome.contentType
+			})
+		}
 	}
 	
-	return false
+	for i, outcome := range c.checkURLs(ctx, urls, cache) {
+		if outcome.checked || outcome.blocked || outcome.ignored {
+			record[i](outcome)
+		}
+	}
 }
 
-// checks a list of links for broken ones
-func (c *Crawler) checkBrokenLinks(links []models.LinkInfo, baseURL *url.URL) []models.CrawlBrokenLink {
-	brokenLinks := []models.CrawlBrokenLink{}
-	
-	// Limit the number of links to check
-	linksToCheck := links
-	if len(links) > c.options.MaxLinksToCheck {
-		linksToCheck = links[:c.options.MaxLinksToCheck]
+// the outcome of one URL of a batch of checks
+type checkOutcome struct {
+	linkCheck
+	checked bool // false when the URL was skipped, blocked, ignored or its check was cancelled
+	blocked bool // disallowed by robots.txt
+}
+
+// returns the link status a check outcome amounts to
+func (o checkOutcome) status() models.LinkStatus {
+	switch {
+	case o.blocked:
+		return models.LinkStatusBlocked
+	case o.ignored:
+		return models.LinkStatusUnchecked
+	case o.broken:
+		return models.LinkStatusBroken
+	case o.category != models.LinkCheckOK:
+		return models.LinkStatusUnverified
+	default:
+		return models.LinkStatusOK
 	}
+}
+
+// checks URLs concurrently, reusing outcomes from the cache when one is given, and returns
+// the outcome of each. URLs disallowed by robots.txt are not requested.
+func (c *Crawler) checkURLs(ctx context.Context, urls []string, cache *linkCheckCache) []checkOutcome {
+	outcomes := make([]checkOutcome, len(urls))
 	
 	// Use a channel to limit concurrency
 	semaphore := make(chan struct{}, c.options.ConcurrentChecks)
 	var wg sync.WaitGroup
-	var mu sync.Mutex
 	
-	for _, link := range linksToCheck {
+	for i, target := range urls {
 		// Skip certain types of links
-		if c.shouldSkipLink(link.URL) {
+		if c.shouldSkipLink(target) {
+			continue
+		}
+		
+		// Leave the hosts the link check policy ignores alone
+		if targetURL, err := url.Parse(target); err == nil && c.options.LinkPolicy.Ignores(targetURL.Hostname()) {
+			outcomes[i].linkCheck = linkCheck{category: models.LinkCheckIgnored, ignored: true}
 			continue
 		}
 		
 		wg.Add(1)
-		go func(linkInfo models.LinkInfo) {
+		// Each goroutine only writes its own outcome
+		go func(target string, outcome *checkOutcome) {
 			defer wg.Done()
 			
 			// Acquire semaphore
-			semaphore <- struct{}{}
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-semaphore }()
 			
+			// Honor robots.txt for the URL's host
+			if targetURL, err := url.Parse(target); err == nil && c.followsRobots(targetURL) {
+				if !c.robots.allowed(ctx, targetURL) {
+					if ctx.Err() != nil {
+						return
+					}
+					outcome.blocked = true
+					return
+				}
+				c.robots.waitTurn(ctx, targetURL)
+			}
+			
 			// Rate limiting
 			if c.options.RespectRateLimit {
-				time.Sleep(c.options.RateLimitDelay)
+				if sleepContext(ctx, c.options.RateLimitDelay) != nil {
+					return
+				}
+			}
+			
+			checkFn := func(linkURL string) linkCheck {
+				return c.checkSingleLink(ctx, linkURL)
 			}
 			
-			brokenLink := c.checkSingleLink(linkInfo)
-			if brokenLink != nil {
-				mu.Lock()
-				brokenLinks = append(brokenLinks, *brokenLink)
-				mu.Unlock()
+			var result linkCheck
+			if cache != nil {
+				result = cache.check(target, checkFn)
+			} else {
+				result = checkFn(target)
 			}
-		}(link)
+			if result.cancelled {
+				return
+			}
+			
+			outcome.linkCheck = result
+			outcome.checked = true
+		}(target, &outcomes[i])
 	}
 	
 	wg.Wait()
-	return brokenLinks
+	return outcomes
+}
+
+// picks the broken links out of a checked link inventory and counts the links blocked by robots.txt
+func brokenLinksOf(inventory []models.CrawlLink) ([]models.CrawlBrokenLink, int) {
+	brokenLinks := []models.CrawlBrokenLink{}
+	blockedByRobots := 0
+	
+	for _, link := range inventory {
+		switch link.Status {
+		case models.LinkStatusBroken:
+			brokenLinks = append(brokenLinks, models.CrawlBrokenLink{
+				URL:          link.URL,
+				StatusCode:   link.StatusCode,
+				ErrorMessage: link.ErrorMessage,
+				LinkText:     link.Text,
+				IsInternal:   link.IsInternal,
+				Category:     link.Category,
+			})
+		case models.LinkStatusBlocked:
+			blockedByRobots++
+		}
+	}
+	
+	return brokenLinks, blockedByRobots
+}
+
+// reports whether robots.txt rules apply to a URL
+func (c *Crawler) followsRobots(target *url.URL) bool {
+	return c.options.FollowRobotsTxt && (target.Scheme == "http" || target.Scheme == "https")
 }
 
 // determines if a link should be skipped during broken link checking
@@ -347,49 +630,115 @@ func (c *Crawler) shouldSkipLink(linkURL string) bool {
 	return false
 }
 
-// checks if a single link is broken
-func (c *Crawler) checkSingleLink(linkInfo models.LinkInfo) *models.CrawlBrokenLink {
-	// Use HEAD request first for efficiency
-	resp, err := c.client.R().Head(linkInfo.URL)
+// the outcome of requesting a link
+type linkCheck struct {
+	statusCode   int
+	errorMessage string
+	size         int64 // bytes, 0 when the server did not say
+	contentType  string
+	redirects    models.RedirectChain // the redirects followed to the final response
+	category     models.LinkCheckCategory
+	retryAfter   string // the Retry-After header of a 429
+	broken       bool   // the link check policy counts the category as broken
+	ignored      bool   // not requested because of a host rule
+	cancelled    bool
+}
+
+// checks if a single link is broken under the link check policy. Checks interrupted by a
+// cancellation are marked as such.
+func (c *Crawler) checkSingleLink(ctx context.Context, linkURL string) linkCheck {
+	policy := c.options.LinkPolicy
+	
+	// Use HEAD request first for efficiency, and GET when it fails or the server does not support HEAD
+	method := http.MethodHead
+	check := c.requestLink(ctx, method, linkURL)
+	if !check.cancelled && (check.statusCode == 0 || check.statusCode == http.StatusMethodNotAllowed || check.statusCode == http.StatusNotImplemented) {
+		method = http.MethodGet
+		check = c.requestLink(ctx, method, linkURL)
+	}
+	
+	// Wait out rate limits, as long as the server asks for a reasonable pause
+	for retry := 0; !check.cancelled && check.category == models.LinkCheckRateLimited && retry < policy.RateLimitRetries; retry++ {
+		wait := retryAfter(check.retryAfter, time.Now())
+		if wait > policy.MaxRetryAfter {
+			break
+		}
+		if sleepContext(ctx, wait) != nil {
+			return linkCheck{cancelled: true}
+		}
+		check = c.requestLink(ctx, method, linkURL)
+	}
 	
+	if check.cancelled {
+		return check
+	}
+	check.broken = policy.CountsAsBroken(check.category)
+	return check
+}
+
+// requests a link once and classifies the outcome
+func (c *Crawler) requestLink(ctx context.Context, method string, linkURL string) linkCheck {
+	requestCtx, redirects := recordRedirects(ctx)
+	resp, err := c.client.R().SetContext(requestCtx).Execute(method, linkURL)
 	if err != nil {
-		// If HEAD fails, try GET
-		resp, err = c.client.R().Get(linkInfo.URL)
-		if err != nil {
-			return &models.CrawlBrokenLink{
-				URL:          linkInfo.URL,
-				StatusCode:   0,
-				ErrorMessage: err.Error(),
-				LinkText:     linkInfo.Text,
-				IsInternal:   linkInfo.IsInternal,
-			}
+		if ctx.Err() != nil {
+			return linkCheck{cancelled: true}
 		}
+		return linkCheck{errorMessage: err.Error(), category: classifyError(err), redirects: redirects.chain}
 	}
 	
 	statusCode := resp.StatusCode()
-	
-	// Consider 4xx and 5xx as broken
-	if statusCode >= 400 {
-		return &models.CrawlBrokenLink{
-			URL:          linkInfo.URL,
-			StatusCode:   statusCode,
-			ErrorMessage: http.StatusText(statusCode),
-			LinkText:     linkInfo.Text,
-			IsInternal:   linkInfo.IsInternal,
+	check := linkCheck{
+		statusCode: statusCode,
+		category:   classifyStatus(statusCode),
+		redirects:  redirects.chain,
+	}
+	if check.category != models.LinkCheckOK {
+		check.errorMessage = http.StatusText(statusCode)
+		if check.errorMessage == "" {
+			check.errorMessage = fmt.Sprintf("HTTP %d", statusCode)
 		}
+		check.retryAfter = resp.Header().Get("Retry-After")
+		return check
 	}
 	
-	return nil
+	check.contentType = strings.TrimSpace(strings.Split(resp.Header().Get("Content-Type"), ";")[0])
+	if size, err := strconv.ParseInt(resp.Header().Get("Content-Length"), 10, 64); err == nil && size > 0 {
+		check.size = size
+	} else if method == http.MethodGet {
+		check.size = int64(len(resp.Body()))
+	}
+	return check
 }
 
 // returns crawler statistics
 func (c *Crawler) GetStats() map[string]interface{} {
+	stats := OptionStats(c.options)
+	stats["fetcher"] = c.fetcher.Name()
+	return stats
+}
+
+// returns the statistics of crawlers created with the given options
+func OptionStats(options models.CrawlOptions) map[string]interface{} {
+	fetcher := models.FetcherHTTP
+	if options.Fetcher == models.FetcherBrowser {
+		fetcher = models.FetcherBrowser
+	}
+	
 	return map[string]interface{}{
-		"timeout":            c.options.Timeout.String(),
-		"max_redirects":      c.options.MaxRedirects,
-		"user_agent":         c.options.UserAgent,
-		"check_broken_links": c.options.CheckBrokenLinks,
-		"max_links_to_check": c.options.MaxLinksToCheck,
-		"concurrent_checks":  c.options.ConcurrentChecks,
+		"timeout":            options.Timeout.String(),
+		"max_redirects":      options.MaxRedirects,
+		"redirect_hop_limit": options.RedirectHopLimit,
+		"user_agent":         options.UserAgent,
+		"check_broken_links": options.CheckBrokenLinks,
+		"check_resources":    options.CheckResources,
+		"link_policy":        options.LinkPolicy,
+		"max_links_to_check": options.MaxLinksToCheck,
+		"concurrent_checks":  options.ConcurrentChecks,
+		"follow_robots_txt":  options.FollowRobotsTxt,
+		"max_depth":          options.MaxDepth,
+		"max_pages":          options.MaxPages,
+		"analyzers":          AnalyzerInfos(options),
+		"fetcher":            fetcher,
 	}
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package crawler
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -86,7 +87,7 @@ func TestCrawler_CrawlURL_Success(t *testing.T) {
 	
 	crawler := NewCrawler(options)
 	
-	result := crawler.CrawlURL(server.URL)
+	result := crawler.CrawlURL(context.Background(), server.URL)
 	
 	// Basic assertions
 	require.NoError(t, result.Error)
@@ -97,6 +98,8 @@ func TestCrawler_CrawlURL_Success(t *testing.T) {
 	// Content assertions
 	assert.Equal(t, "Test Page Title", result.Title)
 	assert.Equal(t, "HTML5", result.HTMLVersion)
+	assert.Equal(t, "<!DOCTYPE html>", result.Doctype)
+	assert.Equal(t, ModeNoQuirks, result.DocumentMode)
 	assert.True(t, result.HasLoginForm)
 	
 	// Heading counts
@@ -140,7 +143,7 @@ func TestCrawler_CrawlURL_InvalidURL(t *testing.T) {
 	options := models.DefaultCrawlOptions()
 	crawler := NewCrawler(options)
 	
-	result := crawler.CrawlURL("not-a-valid-url://test")
+	result := crawler.CrawlURL(context.Background(), "not-a-valid-url://test")
 	
 	assert.Error(t, result.Error)
 	assert.True(t, 
@@ -160,7 +163,7 @@ func TestCrawler_CrawlURL_404Error(t *testing.T) {
 	options := models.DefaultCrawlOptions()
 	crawler := NewCrawler(options)
 	
-	result := crawler.CrawlURL(server.URL)
+	result := crawler.CrawlURL(context.Background(), server.URL)
 	
 	assert.Error(t, result.Error)
 	assert.Equal(t, 404, result.StatusCode)
@@ -188,6 +191,16 @@ func TestCrawler_DetectLoginForm(t *testing.T) {
 			html: `<form action="/login"><input type="text"></form>`,
 			expected: false, // No password field, so should be false
 		},
+		{
+			name: "Two-step login without a password field",
+			html: `<form action="/login"><input type="email" name="email" autocomplete="username"><button>Sign in</button></form>`,
+			expected: true,
+		},
+		{
+			name: "Signup form",
+			html: `<form action="/register"><input type="email"><input type="password"><input type="password"><button>Register</button></form>`,
+			expected: false,
+		},
 		{
 			name: "Form with login action and password",
 			html: `<form action="/login"><input type="password"></form>`,
@@ -216,7 +229,7 @@ func TestCrawler_DetectLoginForm(t *testing.T) {
 			}))
 			defer server.Close()
 			
-			result := crawler.CrawlURL(server.URL)
+			result := crawler.CrawlURL(context.Background(), server.URL)
 			require.NoError(t, result.Error)
 			assert.Equal(t, tc.expected, result.HasLoginForm, "Login form detection failed for case: %s", tc.name)
 		})
@@ -239,7 +252,7 @@ func TestCrawler_ProgressCallback(t *testing.T) {
 		progressValues = append(progressValues, progress)
 	})
 	
-	result := crawler.CrawlURL(server.URL)
+	result := crawler.CrawlURL(context.Background(), server.URL)
 	
 	require.NoError(t, result.Error)
 	assert.Greater(t, len(progressUpdates), 0)
@@ -273,7 +286,7 @@ func TestCrawler_LinkClassification(t *testing.T) {
 	options.CheckBrokenLinks = false
 	crawler := NewCrawler(options)
 	
-	result := crawler.CrawlURL(server.URL)
+	result := crawler.CrawlURL(context.Background(), server.URL)
 	
 	require.NoError(t, result.Error)
 	
@@ -286,6 +299,68 @@ func TestCrawler_LinkClassification(t *testing.T) {
 	assert.Greater(t, totalLinks, 0, "Should have some valid links")
 }
 
+func TestCrawler_LinkInventory(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+    <a href="/ok" rel="nofollow  noopener">OK</a>
+    <a href="/gone">Gone</a>
+    <a href="/private">Private</a>
+    <a href="/later">Later</a>
+</body></html>`))
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.MaxLinksToCheck = 3
+	crawler := NewCrawler(options)
+	
+	result := crawler.CrawlURL(context.Background(), server.URL)
+	
+	require.NoError(t, result.Error)
+	require.Len(t, result.Links, 4)
+	
+	// Links keep their page order and every one is listed, checked or not
+	assert.Equal(t, server.URL+"/ok", result.Links[0].URL)
+	assert.Equal(t, "nofollow noopener", result.Links[0].Rel)
+	assert.Equal(t, models.LinkStatusOK, result.Links[0].Status)
+	assert.Equal(t, http.StatusOK, result.Links[0].StatusCode)
+	
+	assert.Equal(t, models.LinkStatusBroken, result.Links[1].Status)
+	assert.Equal(t, http.StatusGone, result.Links[1].StatusCode)
+	
+	assert.Equal(t, models.LinkStatusBlocked, result.Links[2].Status)
+	assert.Equal(t, 0, result.Links[2].StatusCode)
+	
+	// Beyond MaxLinksToCheck
+	assert.Equal(t, models.LinkStatusUnchecked, result.Links[3].Status)
+	
+	// The summary counts come from the inventory
+	require.Len(t, result.BrokenLinks, 1)
+	assert.Equal(t, server.URL+"/gone", result.BrokenLinks[0].URL)
+	assert.Equal(t, "Gone", result.BrokenLinks[0].LinkText)
+	assert.Equal(t, 1, result.LinksBlockedByRobots)
+	
+	// Without link checks links are listed as unchecked
+	options.CheckBrokenLinks = false
+	result = NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	
+	require.NoError(t, result.Error)
+	require.Len(t, result.Links, 4)
+	for _, link := range result.Links {
+		assert.Equal(t, models.LinkStatusUnchecked, link.Status)
+	}
+	assert.Empty(t, result.BrokenLinks)
+}
+
 func TestCrawler_HTMLVersionDetection(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -298,9 +373,14 @@ func TestCrawler_HTMLVersionDetection(t *testing.T) {
 			expected: "HTML5",
 		},
 		{
-			name: "Basic HTML without semantic elements",
+			name: "Basic HTML without a doctype",
 			html: `<html><body><div>Test</div></body></html>`,
-			expected: "HTML5", // Default assumption
+			expected: VersionNoDoctype,
+		},
+		{
+			name: "HTML 4.01 Strict with semantic-looking markup",
+			html: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd"><html><body><div class="header">Test</div></body></html>`,
+			expected: "HTML 4.01 Strict",
 		},
 	}
 	
@@ -315,13 +395,73 @@ func TestCrawler_HTMLVersionDetection(t *testing.T) {
 			}))
 			defer server.Close()
 			
-			result := crawler.CrawlURL(server.URL)
+			result := crawler.CrawlURL(context.Background(), server.URL)
 			require.NoError(t, result.Error)
 			assert.Equal(t, tc.expected, result.HTMLVersion)
 		})
 	}
 }
 
+func TestCrawler_CrawlURL_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		// Hold the page until the test is over
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	
+	options := models.DefaultCrawlOptions()
+	options.CheckBrokenLinks = false
+	crawler := NewCrawler(options)
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	
+	start := time.Now()
+	result := crawler.CrawlURL(ctx, server.URL)
+	
+	require.Error(t, result.Error)
+	assert.ErrorIs(t, result.Error, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCrawler_CheckBrokenLinks_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(404)
+		case "/":
+			w.Write([]byte(`<html><body><a href="/slow">Slow</a><a href="/missing">Missing</a></body></html>`))
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+	
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	crawler := NewCrawler(options)
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	
+	result := crawler.CrawlURL(ctx, server.URL)
+	
+	// Links checked before the cancellation are not reported either
+	assert.ErrorIs(t, result.Error, context.Canceled)
+	assert.Empty(t, result.BrokenLinks)
+}
+
 func TestDefaultCrawlOptions(t *testing.T) {
 	options := models.DefaultCrawlOptions()
 	
@@ -333,6 +473,7 @@ func TestDefaultCrawlOptions(t *testing.T) {
 	assert.Equal(t, 5, options.ConcurrentChecks)
 	assert.True(t, options.RespectRateLimit)
 	assert.Equal(t, 1*time.Second, options.RateLimitDelay)
+	assert.Equal(t, models.FetcherHTTP, options.Fetcher)
 }
 
 // Benchmark test for crawler performance
@@ -347,7 +488,7 @@ func BenchmarkCrawler_CrawlURL(b *testing.B) {
 	b.ResetTimer()
 	
 	for i := 0; i < b.N; i++ {
-		result := crawler.CrawlURL(server.URL)
+		result := crawler.CrawlURL(context.Background(), server.URL)
 		if result.Error != nil {
 			b.Fatalf("Crawl failed: %v", result.Error)
 		}
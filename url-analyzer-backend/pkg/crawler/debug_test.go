@@ -1,6 +1,7 @@
 package crawler
 
 import (
+	"context"
 	"testing"
 	"url-analyzer/internal/models"
 )
@@ -13,7 +14,7 @@ func TestCrawler_DebugLinks(t *testing.T) {
 	options.CheckBrokenLinks = false // Skip for faster debugging
 	
 	crawler := NewCrawler(options)
-	result := crawler.CrawlURL(server.URL)
+	result := crawler.CrawlURL(context.Background(), server.URL)
 	
 	if result.Error != nil {
 		t.Fatalf("Crawl failed: %v", result.Error)
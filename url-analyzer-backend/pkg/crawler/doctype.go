@@ -0,0 +1,277 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTML versions reported for documents without a recognizable doctype
+const (
+	VersionNoDoctype = "No DOCTYPE"
+	VersionUnknown   = "Unknown"
+)
+
+// Document modes browsers render a page in, as chosen by its doctype
+const (
+	ModeNoQuirks      = "no-quirks"
+	ModeLimitedQuirks = "limited-quirks"
+	ModeQuirks        = "quirks"
+)
+
+// the longest raw doctype kept, longer ones are cut
+const maxDoctypeLength = 512
+
+// what the DOCTYPE of a document says about it
+type doctypeInfo struct {
+	raw          string // the doctype token as written, empty when there is none
+	version      string
+	documentMode string
+}
+
+// a standard doctype, recognized by its public identifier or, when it has
+// none, by the end of its system identifier. Identifiers are lower case.
+type knownDoctype struct {
+	publicID string
+	systemID string
+	version  string
+}
+
+var knownDoctypes = []knownDoctype{
+	{"-//w3c//dtd html 4.01//", "/tr/html4/strict.dtd", "HTML 4.01 Strict"},
+	{"-//w3c//dtd html 4.01 transitional//", "/tr/html4/loose.dtd", "HTML 4.01 Transitional"},
+	{"-//w3c//dtd html 4.01 frameset//", "/tr/html4/frameset.dtd", "HTML 4.01 Frameset"},
+	{"-//w3c//dtd html 4.0//", "/tr/rec-html40/strict.dtd", "HTML 4.0 Strict"},
+	{"-//w3c//dtd html 4.0 transitional//", "/tr/rec-html40/loose.dtd", "HTML 4.0 Transitional"},
+	{"-//w3c//dtd html 4.0 frameset//", "/tr/rec-html40/frameset.dtd", "HTML 4.0 Frameset"},
+	{"-//w3c//dtd xhtml 1.0 strict//", "/xhtml1-strict.dtd", "XHTML 1.0 Strict"},
+	{"-//w3c//dtd xhtml 1.0 transitional//", "/xhtml1-transitional.dtd", "XHTML 1.0 Transitional"},
+	{"-//w3c//dtd xhtml 1.0 frameset//", "/xhtml1-frameset.dtd", "XHTML 1.0 Frameset"},
+	{"-//w3c//dtd xhtml 1.1//", "/xhtml11.dtd", "XHTML 1.1"},
+	{"-//w3c//dtd xhtml basic 1.0//", "/xhtml-basic10.dtd", "XHTML Basic 1.0"},
+	{"-//w3c//dtd xhtml basic 1.1//", "/xhtml-basic11.dtd", "XHTML Basic 1.1"},
+	{"-//w3c//dtd xhtml+rdfa 1.0//", "/xhtml-rdfa-1.dtd", "XHTML+RDFa 1.0"},
+	{"-//w3c//dtd xhtml+rdfa 1.1//", "/xhtml-rdfa-2.dtd", "XHTML+RDFa 1.1"},
+	{"-//wapforum//dtd xhtml mobile 1.0//", "/xhtml-mobile10.dtd", "XHTML Mobile 1.0"},
+	{"-//wapforum//dtd xhtml mobile 1.1//", "/xhtml-mobile11.dtd", "XHTML Mobile 1.1"},
+	{"-//wapforum//dtd xhtml mobile 1.2//", "/xhtml-mobile12.dtd", "XHTML Mobile 1.2"},
+	{"-//w3c//dtd html 3.2", "", "HTML 3.2"},
+	{"-//ietf//dtd html 2.0", "", "HTML 2.0"},
+}
+
+// public identifier prefixes that put browsers in quirks mode, from the HTML standard
+var quirksPublicIDPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+// detects the HTML version and document mode of a page from the DOCTYPE that starts its body.
+// Only comments and whitespace may come before it; anything else means there is none.
+func detectDoctype(body []byte) doctypeInfo {
+	// A byte order mark is not content
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	z := html.NewTokenizer(bytes.NewReader(body))
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return doctypeInfo{version: VersionNoDoctype, documentMode: ModeQuirks}
+		case html.CommentToken:
+			continue
+		case html.TextToken:
+			if len(bytes.TrimSpace(z.Text())) == 0 {
+				continue
+			}
+			return doctypeInfo{version: VersionNoDoctype, documentMode: ModeQuirks}
+		case html.DoctypeToken:
+			raw := strings.TrimSpace(string(z.Raw()))
+			if len(raw) > maxDoctypeLength {
+				raw = raw[:maxDoctypeLength]
+			}
+			info := classifyDoctype(string(z.Text()))
+			info.raw = raw
+			return info
+		default:
+			return doctypeInfo{version: VersionNoDoctype, documentMode: ModeQuirks}
+		}
+	}
+}
+
+// classifies the contents of a doctype token, e.g. `html PUBLIC "-//W3C//DTD HTML 4.01//EN"`
+func classifyDoctype(doctype string) doctypeInfo {
+	name, publicID, systemID, hasSystemID, ok := parseDoctype(doctype)
+	if !ok {
+		return doctypeInfo{version: VersionUnknown, documentMode: ModeQuirks}
+	}
+
+	publicID = strings.ToLower(publicID)
+	systemID = strings.ToLower(systemID)
+
+	info := doctypeInfo{
+		version:      VersionUnknown,
+		documentMode: documentMode(name, publicID, systemID, hasSystemID),
+	}
+
+	if name != "html" {
+		return info
+	}
+
+	if publicID == "" && (systemID == "" || systemID == "about:legacy-compat") {
+		info.version = "HTML5"
+		return info
+	}
+
+	for _, known := range knownDoctypes {
+		if publicID != "" {
+			if strings.HasPrefix(publicID, known.publicID) {
+				info.version = known.version
+				return info
+			}
+		} else if known.systemID != "" && strings.HasSuffix(systemID, known.systemID) {
+			info.version = known.version
+			return info
+		}
+	}
+
+	return info
+}
+
+// returns the rendering mode a browser picks for a doctype, following the HTML standard's rules
+func documentMode(name, publicID, systemID string, hasSystemID bool) string {
+	if name != "html" {
+		return ModeQuirks
+	}
+
+	switch publicID {
+	case "-//w3o//dtd w3 html strict 3.0//en//", "-/w3c/dtd html 4.0 transitional/en", "html":
+		return ModeQuirks
+	}
+	if systemID == "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd" {
+		return ModeQuirks
+	}
+	for _, prefix := range quirksPublicIDPrefixes {
+		if strings.HasPrefix(publicID, prefix) {
+			return ModeQuirks
+		}
+	}
+
+	html401Loose := strings.HasPrefix(publicID, "-//w3c//dtd html 4.01 frameset//") ||
+		strings.HasPrefix(publicID, "-//w3c//dtd html 4.01 transitional//")
+	if html401Loose && !hasSystemID {
+		return ModeQuirks
+	}
+	if html401Loose ||
+		strings.HasPrefix(publicID, "-//w3c//dtd xhtml 1.0 frameset//") ||
+		strings.HasPrefix(publicID, "-//w3c//dtd xhtml 1.0 transitional//") {
+		return ModeLimitedQuirks
+	}
+
+	return ModeNoQuirks
+}
+
+// splits the contents of a doctype token into its lower-cased name and its identifiers.
+// ok is false when the token is malformed.
+func parseDoctype(doctype string) (name, publicID, systemID string, hasSystemID bool, ok bool) {
+	rest := strings.TrimSpace(doctype)
+
+	end := strings.IndexFunc(rest, isDoctypeSpace)
+	if end < 0 {
+		return strings.ToLower(rest), "", "", false, rest != ""
+	}
+	name = strings.ToLower(rest[:end])
+	rest = strings.TrimSpace(rest[end:])
+
+	keyword := rest
+	if end := strings.IndexFunc(rest, func(r rune) bool { return isDoctypeSpace(r) || r == '"' || r == '\'' }); end >= 0 {
+		keyword = rest[:end]
+	}
+	rest = strings.TrimSpace(rest[len(keyword):])
+
+	switch strings.ToUpper(keyword) {
+	case "PUBLIC":
+		publicID, rest, ok = quotedIdentifier(rest)
+		if !ok {
+			return name, "", "", false, false
+		}
+		if rest == "" {
+			return name, publicID, "", false, true
+		}
+		systemID, rest, ok = quotedIdentifier(rest)
+		return name, publicID, systemID, ok, ok && rest == ""
+	case "SYSTEM":
+		systemID, rest, ok = quotedIdentifier(rest)
+		return name, "", systemID, ok, ok && rest == ""
+	default:
+		return name, "", "", false, false
+	}
+}
+
+// reads a single- or double-quoted identifier from the start of s
+func quotedIdentifier(s string) (identifier, rest string, ok bool) {
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return "", s, false
+	}
+	end := strings.IndexByte(s[1:], s[0])
+	if end < 0 {
+		return "", s, false
+	}
+	return s[1 : end+1], strings.TrimSpace(s[end+2:]), true
+}
+
+func isDoctypeSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
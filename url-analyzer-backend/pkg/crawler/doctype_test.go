@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectDoctype(t *testing.T) {
+	testCases := []struct {
+		name    string
+		html    string
+		version string
+		mode    string
+	}{
+		{"HTML5", `<!DOCTYPE html><html></html>`, "HTML5", ModeNoQuirks},
+		{"HTML5 lower case after a comment", "<!-- generated -->\n  <!doctype html>\n<html></html>", "HTML5", ModeNoQuirks},
+		{"HTML5 legacy compat", `<!DOCTYPE html SYSTEM "about:legacy-compat"><html></html>`, "HTML5", ModeNoQuirks},
+		{"HTML 4.01 Strict", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`, "HTML 4.01 Strict", ModeNoQuirks},
+		{"HTML 4.01 Transitional", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`, "HTML 4.01 Transitional", ModeLimitedQuirks},
+		{"HTML 4.01 Transitional without system ID", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN">`, "HTML 4.01 Transitional", ModeQuirks},
+		{"HTML 4.01 Frameset", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN" "http://www.w3.org/TR/html4/frameset.dtd">`, "HTML 4.01 Frameset", ModeLimitedQuirks},
+		{"HTML 4.0 Transitional", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.0 Transitional//EN">`, "HTML 4.0 Transitional", ModeQuirks},
+		{"XHTML 1.0 Strict after an XML declaration", `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`, "XHTML 1.0 Strict", ModeNoQuirks},
+		{"XHTML 1.0 Transitional", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`, "XHTML 1.0 Transitional", ModeLimitedQuirks},
+		{"XHTML 1.0 Frameset", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Frameset//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-frameset.dtd">`, "XHTML 1.0 Frameset", ModeLimitedQuirks},
+		{"XHTML 1.1", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`, "XHTML 1.1", ModeNoQuirks},
+		{"XHTML 1.1 by system ID only", `<!DOCTYPE html SYSTEM "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`, "XHTML 1.1", ModeNoQuirks},
+		{"HTML 3.2", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`, "HTML 3.2", ModeQuirks},
+		{"HTML 2.0", `<!DOCTYPE HTML PUBLIC "-//IETF//DTD HTML 2.0//EN">`, "HTML 2.0", ModeQuirks},
+		{"No doctype", `<html><body>Test</body></html>`, VersionNoDoctype, ModeQuirks},
+		{"Doctype after content", `<p>Hi</p><!DOCTYPE html>`, VersionNoDoctype, ModeQuirks},
+		{"Empty body", ``, VersionNoDoctype, ModeQuirks},
+		{"Unknown public ID", `<!DOCTYPE html PUBLIC "-//Example//DTD Custom//EN">`, VersionUnknown, ModeNoQuirks},
+		{"Not an HTML doctype", `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN">`, VersionUnknown, ModeQuirks},
+		{"Malformed doctype", `<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN>`, VersionUnknown, ModeQuirks},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info := detectDoctype([]byte(tc.html))
+			assert.Equal(t, tc.version, info.version)
+			assert.Equal(t, tc.mode, info.documentMode)
+		})
+	}
+}
+
+func TestDetectDoctype_KeepsRawDoctype(t *testing.T) {
+	doctype := `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`
+
+	info := detectDoctype([]byte("\xef\xbb\xbf" + doctype + "\n<html></html>"))
+	assert.Equal(t, doctype, info.raw)
+
+	info = detectDoctype([]byte(`<html></html>`))
+	assert.Empty(t, info.raw)
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"url-analyzer/internal/models"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Fetcher loads the page the crawler analyzes. Robots.txt and the link and resource checks
+// always use plain HTTP requests, whichever fetcher loads the page.
+type Fetcher interface {
+	Name() string
+	Fetch(ctx context.Context, targetURL string) (*FetchedPage, error)
+}
+
+// FetchedPage is a page as a fetcher loaded it. A failed fetch may still return the page with
+// the redirects followed before it failed.
+type FetchedPage struct {
+	Response      *http.Response       // the final response, its body already read. Request.URL is the URL the page was served from.
+	Body          []byte               // the HTML of the page
+	RedirectChain models.RedirectChain // the redirects followed to the final response
+}
+
+// returns the fetcher the options ask for
+func newFetcher(options models.CrawlOptions, client *resty.Client) Fetcher {
+	if options.Fetcher == models.FetcherBrowser {
+		return newBrowserFetcher(options)
+	}
+	return &httpFetcher{client: client}
+}
+
+// loads pages with a GET request, the HTML as served
+type httpFetcher struct {
+	client *resty.Client
+}
+
+func (f *httpFetcher) Name() string { return models.FetcherHTTP }
+
+func (f *httpFetcher) Fetch(ctx context.Context, targetURL string) (*FetchedPage, error) {
+	fetchCtx, redirects := recordRedirects(ctx)
+	resp, err := f.client.R().SetContext(fetchCtx).Get(targetURL)
+	page := &FetchedPage{RedirectChain: redirects.chain}
+	if err != nil {
+		return page, err
+	}
+
+	page.Response = resp.RawResponse
+	page.Body = resp.Body()
+	return page, nil
+}
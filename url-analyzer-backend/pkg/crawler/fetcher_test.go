@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a fetcher that serves canned pages
+type stubFetcher struct {
+	pages    map[string]*FetchedPage
+	requests []string
+}
+
+func (f *stubFetcher) Name() string { return "stub" }
+
+func (f *stubFetcher) Fetch(ctx context.Context, targetURL string) (*FetchedPage, error) {
+	f.requests = append(f.requests, targetURL)
+	page, ok := f.pages[targetURL]
+	if !ok {
+		return &FetchedPage{RedirectChain: models.RedirectChain{}}, errors.New("connection refused")
+	}
+	return page, nil
+}
+
+// returns a fetched page served from the given URL
+func stubPage(t *testing.T, pageURL string, html string) *FetchedPage {
+	finalURL, err := url.Parse(pageURL)
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/html")
+	return &FetchedPage{
+		Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Request:    &http.Request{Method: http.MethodGet, URL: finalURL},
+		},
+		Body:          []byte(html),
+		RedirectChain: models.RedirectChain{},
+	}
+}
+
+func TestCrawler_UsesFetcher(t *testing.T) {
+	options := models.DefaultCrawlOptions()
+	options.FollowRobotsTxt = false
+	options.CheckBrokenLinks = false
+
+	// What a single-page application looks like once its scripts ran
+	page := stubPage(t, "http://spa.test/home", `<!DOCTYPE html><html lang="en"><head><title>Rendered</title></head>
+<body><div id="root"><h1>Products</h1><h2>Shoes</h2><a href="/products/1">Shoe</a><a href="https://example.com">Partner</a></div></body></html>`)
+	page.RedirectChain = models.RedirectChain{{URL: "http://spa.test/", StatusCode: http.StatusFound, Location: "/home"}}
+	fetcher := &stubFetcher{pages: map[string]*FetchedPage{"http://spa.test/": page}}
+
+	crawler := NewCrawler(options)
+	crawler.SetFetcher(fetcher)
+	result := crawler.CrawlURL(context.Background(), "http://spa.test/")
+	require.NoError(t, result.Error)
+
+	assert.Equal(t, []string{"http://spa.test/"}, fetcher.requests)
+	assert.Equal(t, "stub", result.Fetcher)
+	assert.Equal(t, "Rendered", result.Title)
+	assert.Equal(t, 1, result.HeadingCounts["h1"])
+	assert.Equal(t, 1, result.HeadingCounts["h2"])
+	assert.Equal(t, 1, result.InternalLinks)
+	assert.Equal(t, 1, result.ExternalLinks)
+	assert.Equal(t, "http://spa.test/products/1", result.Links[0].URL, "links resolve against the URL the page was served from")
+	assert.Equal(t, page.RedirectChain, result.RedirectChain)
+	assert.Equal(t, int64(len(page.Body)), result.ContentLength)
+	assert.Equal(t, "text/html", result.ResponseHeaders["Content-Type"])
+	assert.Equal(t, "stub", result.ToCrawlResult(1).Fetcher)
+	assert.Equal(t, "stub", crawler.GetStats()["fetcher"])
+	assert.NoError(t, crawler.Close())
+}
+
+func TestCrawler_FetcherFails(t *testing.T) {
+	options := models.DefaultCrawlOptions()
+	options.FollowRobotsTxt = false
+
+	crawler := NewCrawler(options)
+	crawler.SetFetcher(&stubFetcher{})
+	result := crawler.CrawlURL(context.Background(), "http://down.test/")
+
+	require.Error(t, result.Error)
+	assert.Contains(t, result.Error.Error(), "failed to fetch URL: connection refused")
+	assert.Equal(t, "stub", result.Fetcher)
+}
+
+func TestNewCrawler_SelectsFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Served</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+	assert.Equal(t, models.FetcherHTTP, result.Fetcher)
+
+	// Without a browser binary the browser fetcher fails the crawl instead of falling back
+	options.Fetcher = models.FetcherBrowser
+	options.BrowserPath = "/nonexistent/chromium"
+	crawler := NewCrawler(options)
+	defer crawler.Close()
+	result = crawler.CrawlURL(context.Background(), server.URL)
+	require.Error(t, result.Error)
+	assert.Contains(t, result.Error.Error(), "browser unavailable")
+	assert.Equal(t, models.FetcherBrowser, result.Fetcher)
+}
@@ -0,0 +1,261 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// the score a form needs before it is given a type other than "other"
+const minFormScore = 3
+
+var (
+	loginTextPattern    = regexp.MustCompile(`\b(log ?in|sign ?in|log on|sign on)\b`)
+	signupTextPattern   = regexp.MustCompile(`\b(sign ?up|register|create (an |your )?account|join( now| us)?|get started)\b`)
+	resetTextPattern    = regexp.MustCompile(`\b(reset|forgot|recover|send (reset |me a )?link|new password)\b`)
+	searchTextPattern   = regexp.MustCompile(`\bsearch\b`)
+	loginActionPattern  = regexp.MustCompile(`(log_?in|sign_?in|auth|session)`)
+	signupActionPattern = regexp.MustCompile(`(sign_?up|register|join|create_?account)`)
+	resetActionPattern  = regexp.MustCompile(`(reset|forgot|recover|lost_?password)`)
+	searchActionPattern = regexp.MustCompile(`search`)
+	userFieldPattern    = regexp.MustCompile(`(user|login|email|account)`)
+	signupFieldPattern  = regexp.MustCompile(`(first_?name|last_?name|full_?name|phone|birth|terms|agree|confirm)`)
+	searchFieldPattern  = regexp.MustCompile(`^(q|s|query|search|keywords?|term)$`)
+	rememberPattern     = regexp.MustCompile(`remember`)
+	oauthTextPattern    = regexp.MustCompile(`\b(sign ?in|log ?in|sign ?up|continue) (with|using|via) (google|facebook|apple|github|gitlab|microsoft|twitter|x|linkedin|amazon|okta)\b`)
+)
+
+// the scores and reasons collected for one form type
+type formTypeScore struct {
+	score   int
+	signals []string
+}
+
+// form types in the order ties are decided
+var scoredFormTypes = []models.FormType{
+	models.FormTypeLogin,
+	models.FormTypeSignup,
+	models.FormTypePasswordReset,
+	models.FormTypeSearch,
+}
+
+// finds the forms of a page and classifies each one. Password fields outside of any
+// form, as on script-driven login pages, are analyzed together as one more form.
+func analyzeForms(doc *goquery.Document, pageURL *url.URL) []models.CrawlForm {
+	forms := []models.CrawlForm{}
+	pageProviders := oauthProviders(doc.Selection)
+
+	doc.Find("form").Each(func(i int, form *goquery.Selection) {
+		forms = append(forms, analyzeForm(form, form.Find("input, select, textarea"), pageURL, pageProviders))
+	})
+
+	// Inputs that belong to no form
+	formless := doc.Find("input").FilterFunction(func(i int, s *goquery.Selection) bool {
+		return s.Closest("form").Length() == 0
+	})
+	if formless.Filter("input[type='password' i]").Length() > 0 {
+		body := doc.Find("body")
+		if body.Length() == 0 {
+			body = doc.Selection
+		}
+		forms = append(forms, analyzeForm(body, formless, nil, pageProviders))
+	}
+
+	return forms
+}
+
+// scores a form for each form type and records where it submits to. scope holds the buttons and
+// links of the form. Without a page URL the form has no element and so no target.
+func analyzeForm(scope *goquery.Selection, fields *goquery.Selection, pageURL *url.URL, pageProviders []string) models.CrawlForm {
+	form := models.CrawlForm{
+		OAuthProviders: oauthProviders(scope),
+		Signals:        []string{},
+	}
+
+	if pageURL != nil {
+		form.Method = strings.ToUpper(strings.TrimSpace(scope.AttrOr("method", "")))
+		if form.Method == "" {
+			form.Method = "GET"
+		}
+
+		// An empty action submits to the page itself
+		action := strings.TrimSpace(scope.AttrOr("action", ""))
+		if actionURL, err := pageURL.Parse(action); err == nil {
+			form.Action = actionURL.String()
+			form.PostsOverHTTP = strings.EqualFold(actionURL.Scheme, "http")
+		}
+	}
+
+	passwords := fields.Filter("input[type='password' i]")
+	form.PasswordFields = passwords.Length()
+
+	autocomplete := map[string]bool{}
+	fieldNames := []string{}
+	hasEmailField := false
+	hasSearchField := false
+	fields.Each(func(i int, field *goquery.Selection) {
+		for _, token := range strings.Fields(strings.ToLower(field.AttrOr("autocomplete", ""))) {
+			autocomplete[token] = true
+		}
+		for _, attr := range []string{"name", "id"} {
+			if value := strings.ToLower(field.AttrOr(attr, "")); value != "" {
+				fieldNames = append(fieldNames, value)
+			}
+		}
+
+		inputType := strings.ToLower(field.AttrOr("type", ""))
+		if inputType == "email" {
+			hasEmailField = true
+		}
+		if inputType == "search" {
+			hasSearchField = true
+		}
+	})
+
+	buttonText := strings.ToLower(submitText(scope))
+	action := ""
+	if pageURL != nil {
+		action = strings.ToLower(scope.AttrOr("action", ""))
+	}
+
+	scores := map[models.FormType]*formTypeScore{}
+	for _, formType := range scoredFormTypes {
+		scores[formType] = &formTypeScore{}
+	}
+	add := func(formType models.FormType, points int, signal string) {
+		scores[formType].score += points
+		scores[formType].signals = append(scores[formType].signals, signal)
+	}
+
+	// Login
+	if form.PasswordFields == 1 {
+		add(models.FormTypeLogin, 3, "single password field")
+	}
+	if autocomplete["current-password"] {
+		add(models.FormTypeLogin, 4, "autocomplete=current-password")
+	}
+	if autocomplete["username"] || anyMatch(userFieldPattern, fieldNames) || hasEmailField {
+		add(models.FormTypeLogin, 1, "username field")
+	}
+	if loginTextPattern.MatchString(buttonText) {
+		add(models.FormTypeLogin, 3, "login button text")
+	}
+	if loginActionPattern.MatchString(action) {
+		add(models.FormTypeLogin, 2, "login action URL")
+	}
+	if anyMatch(rememberPattern, fieldNames) {
+		add(models.FormTypeLogin, 1, "remember me field")
+	}
+	if len(form.OAuthProviders) > 0 {
+		add(models.FormTypeLogin, 2, "OAuth sign-in button")
+	} else if len(pageProviders) > 0 && (form.PasswordFields > 0 || hasEmailField) {
+		add(models.FormTypeLogin, 1, "OAuth sign-in button on page")
+	}
+
+	// Signup
+	if form.PasswordFields >= 2 {
+		add(models.FormTypeSignup, 3, "password confirmation field")
+	}
+	if autocomplete["new-password"] {
+		add(models.FormTypeSignup, 2, "autocomplete=new-password")
+		add(models.FormTypePasswordReset, 1, "autocomplete=new-password")
+	}
+	if signupTextPattern.MatchString(buttonText) {
+		add(models.FormTypeSignup, 5, "signup button text")
+	}
+	if signupActionPattern.MatchString(action) {
+		add(models.FormTypeSignup, 2, "signup action URL")
+	}
+	if anyMatch(signupFieldPattern, fieldNames) || autocomplete["given-name"] || autocomplete["family-name"] || autocomplete["tel"] {
+		add(models.FormTypeSignup, 1, "profile fields")
+	}
+
+	// Password reset
+	if resetTextPattern.MatchString(buttonText) {
+		add(models.FormTypePasswordReset, 5, "reset button text")
+	}
+	if resetActionPattern.MatchString(action) {
+		add(models.FormTypePasswordReset, 2, "reset action URL")
+	}
+	if form.PasswordFields == 0 && (hasEmailField || autocomplete["email"]) && fields.Filter("input:not([type='hidden' i])").Length() == 1 {
+		add(models.FormTypePasswordReset, 1, "single email field")
+	}
+
+	// Search
+	if strings.EqualFold(scope.AttrOr("role", ""), "search") || scope.Closest("[role='search' i]").Length() > 0 {
+		add(models.FormTypeSearch, 4, "role=search")
+	}
+	if hasSearchField {
+		add(models.FormTypeSearch, 4, "search input")
+	}
+	if anyMatch(searchFieldPattern, fieldNames) {
+		add(models.FormTypeSearch, 3, "search field name")
+	}
+	if searchTextPattern.MatchString(buttonText) {
+		add(models.FormTypeSearch, 2, "search button text")
+	}
+	if searchActionPattern.MatchString(action) {
+		add(models.FormTypeSearch, 2, "search action URL")
+	}
+	if form.PasswordFields > 0 {
+		// Nobody searches with a password
+		scores[models.FormTypeSearch].score = 0
+	}
+
+	form.Type = models.FormTypeOther
+	for _, formType := range scoredFormTypes {
+		candidate := scores[formType]
+		if candidate.score >= minFormScore && candidate.score > form.Score {
+			form.Type = formType
+			form.Score = candidate.score
+			form.Signals = candidate.signals
+		}
+	}
+
+	return form
+}
+
+// collects the text of the buttons that submit a form
+func submitText(scope *goquery.Selection) string {
+	texts := []string{}
+	scope.Find("button, input[type='submit' i], input[type='image' i], input[type='button' i], [role='button' i]").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "input" {
+			texts = append(texts, s.AttrOr("value", ""), s.AttrOr("alt", ""))
+			return
+		}
+		texts = append(texts, s.Text(), s.AttrOr("aria-label", ""))
+	})
+	return strings.Join(strings.Fields(strings.Join(texts, " ")), " ")
+}
+
+// lists the providers of "Sign in with ..." buttons and links in the selection
+func oauthProviders(scope *goquery.Selection) []string {
+	seen := map[string]bool{}
+	scope.Find("a, button, [role='button' i]").Each(func(i int, s *goquery.Selection) {
+		text := strings.ToLower(strings.Join(strings.Fields(s.Text()+" "+s.AttrOr("aria-label", "")), " "))
+		for _, match := range oauthTextPattern.FindAllStringSubmatch(text, -1) {
+			seen[match[3]] = true
+		}
+	})
+
+	providers := make([]string, 0, len(seen))
+	for provider := range seen {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// reports whether the pattern matches any of the values
+func anyMatch(pattern *regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
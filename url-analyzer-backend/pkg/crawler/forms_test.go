@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// analyzes the forms of an HTML snippet served at pageURL
+func formsOf(t *testing.T, pageURL string, html string) []models.CrawlForm {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+	base, err := url.Parse(pageURL)
+	require.NoError(t, err)
+	return analyzeForms(doc, base)
+}
+
+func TestAnalyzeForms_ClassifiesForms(t *testing.T) {
+	testCases := []struct {
+		name     string
+		html     string
+		expected models.FormType
+	}{
+		{
+			name: "Login with autocomplete",
+			html: `<form action="/session" method="post">
+				<input name="email" type="email" autocomplete="username">
+				<input name="pass" type="password" autocomplete="current-password">
+				<label><input type="checkbox" name="remember_me"> Remember me</label>
+				<button>Log in</button></form>`,
+			expected: models.FormTypeLogin,
+		},
+		{
+			name: "Signup with confirmation",
+			html: `<form action="/users" method="post">
+				<input name="first_name"><input name="email" type="email">
+				<input type="password" name="password" autocomplete="new-password">
+				<input type="password" name="password_confirmation" autocomplete="new-password">
+				<button type="submit">Create account</button></form>`,
+			expected: models.FormTypeSignup,
+		},
+		{
+			name: "Signup with a single password",
+			html: `<form method="post"><input type="email" name="email"><input type="password" name="password">
+				<input type="submit" value="Sign up"></form>`,
+			expected: models.FormTypeSignup,
+		},
+		{
+			name: "Forgot password",
+			html: `<form action="/password/forgot" method="post"><input type="email" name="email">
+				<button>Send reset link</button></form>`,
+			expected: models.FormTypePasswordReset,
+		},
+		{
+			name: "Choose a new password",
+			html: `<form action="/password/reset" method="post"><input type="hidden" name="token" value="abc">
+				<input type="password" name="password" autocomplete="new-password"><button>Reset password</button></form>`,
+			expected: models.FormTypePasswordReset,
+		},
+		{
+			name:     "Site search",
+			html:     `<form action="/search" role="search"><input type="search" name="q"><button>Go</button></form>`,
+			expected: models.FormTypeSearch,
+		},
+		{
+			name:     "Search by field name only",
+			html:     `<form><input type="text" name="q" id="site-query"></form>`,
+			expected: models.FormTypeSearch,
+		},
+		{
+			name:     "Newsletter",
+			html:     `<form action="/newsletter" method="post"><input type="text" name="city"><textarea name="message"></textarea><button>Send</button></form>`,
+			expected: models.FormTypeOther,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forms := formsOf(t, "https://example.com/account", `<html><body>`+tc.html+`</body></html>`)
+			require.Len(t, forms, 1)
+			assert.Equal(t, tc.expected, forms[0].Type, "signals: %v", forms[0].Signals)
+			if tc.expected != models.FormTypeOther {
+				assert.GreaterOrEqual(t, forms[0].Score, minFormScore)
+				assert.NotEmpty(t, forms[0].Signals)
+			}
+		})
+	}
+}
+
+func TestAnalyzeForms_RecordsWhereCredentialsGo(t *testing.T) {
+	forms := formsOf(t, "https://example.com/login", `<html><body>
+		<form method="post" action="http://auth.example.com/login"><input type="password"></form>
+		<form><input type="password"></form>
+	</body></html>`)
+
+	require.Len(t, forms, 2)
+
+	assert.Equal(t, models.FormTypeLogin, forms[0].Type)
+	assert.Equal(t, "POST", forms[0].Method)
+	assert.Equal(t, "http://auth.example.com/login", forms[0].Action)
+	assert.True(t, forms[0].PostsOverHTTP)
+	assert.Equal(t, 1, forms[0].PasswordFields)
+
+	// Without an action the form submits to the page itself
+	assert.Equal(t, "GET", forms[1].Method)
+	assert.Equal(t, "https://example.com/login", forms[1].Action)
+	assert.False(t, forms[1].PostsOverHTTP)
+}
+
+func TestAnalyzeForms_OAuthButtons(t *testing.T) {
+	forms := formsOf(t, "https://example.com/", `<html><body>
+		<form action="/continue" method="post">
+			<input type="email" name="email">
+			<button type="button">Sign in with Google</button>
+			<a href="/auth/github" aria-label="Continue with GitHub"><img src="gh.svg"></a>
+			<button>Next</button>
+		</form>
+	</body></html>`)
+
+	require.Len(t, forms, 1)
+	assert.Equal(t, models.FormTypeLogin, forms[0].Type)
+	assert.Equal(t, []string{"github", "google"}, forms[0].OAuthProviders)
+}
+
+func TestAnalyzeForms_PasswordFieldsOutsideForms(t *testing.T) {
+	forms := formsOf(t, "https://example.com/", `<html><body>
+		<div id="app"><input name="username"><input type="password"><button>Sign in</button></div>
+		<form role="search"><input type="search" name="q"></form>
+	</body></html>`)
+
+	require.Len(t, forms, 2)
+	assert.Equal(t, models.FormTypeSearch, forms[0].Type)
+
+	// Where scripts send the credentials is unknown
+	assert.Equal(t, models.FormTypeLogin, forms[1].Type)
+	assert.Empty(t, forms[1].Action)
+	assert.Empty(t, forms[1].Method)
+}
@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"url-analyzer/internal/models"
+)
+
+// how long to wait for a 429 that does not say
+const defaultRetryAfter = time.Second
+
+// classifies the status code of a response to a link check
+func classifyStatus(statusCode int) models.LinkCheckCategory {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden || statusCode == 999:
+		// 999 is LinkedIn's answer to crawlers
+		return models.LinkCheckForbidden
+	case statusCode == http.StatusTooManyRequests:
+		return models.LinkCheckRateLimited
+	case statusCode >= 400:
+		return models.LinkCheckBroken
+	default:
+		return models.LinkCheckOK
+	}
+}
+
+// classifies the error of a link check request that got no response
+func classifyError(err error) models.LinkCheckCategory {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var verificationErr *tls.CertificateVerificationError
+	var recordHeaderErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+
+	switch {
+	case errors.Is(err, errRedirectLoop) || errors.Is(err, errTooManyRedirects):
+		return models.LinkCheckBroken
+	case errors.As(err, &dnsErr):
+		return models.LinkCheckDNSFailure
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return models.LinkCheckTimeout
+	case errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &verificationErr) || errors.As(err, &recordHeaderErr) || errors.As(err, &alertErr):
+		return models.LinkCheckTLSError
+	default:
+		return models.LinkCheckConnectionError
+	}
+}
+
+// returns how long a Retry-After header asks to wait, in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
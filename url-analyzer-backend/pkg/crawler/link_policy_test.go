@@ -0,0 +1,206 @@
+package crawler
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_LinkCheckCategories(t *testing.T) {
+	// A host with a certificate nobody trusts
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer untrusted.Close()
+
+	// A host the policy ignores, reached through its other name
+	ignored := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("ignored host was requested: %s", r.URL)
+	}))
+	defer ignored.Close()
+	ignoredURL := strings.Replace(ignored.URL, "127.0.0.1", "localhost", 1)
+
+	var rateLimitedOnce, headRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/linkedin", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(999)
+	})
+	mux.HandleFunc("/busy-once", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&rateLimitedOnce, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/busy", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			atomic.AddInt32(&headRequests, 1)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<html lang="en"><head><title>Links</title></head><body>
+			<a href="/forbidden">Forbidden</a>
+			<a href="/linkedin">LinkedIn</a>
+			<a href="/busy-once">Busy once</a>
+			<a href="/busy">Busy</a>
+			<a href="/no-head">No HEAD</a>
+			<a href="/missing">Missing</a>
+			<a href="/slow">Slow</a>
+			<a href="%s/untrusted">Untrusted</a>
+			<a href="%s/ignored">Ignored</a>
+		</body></html>`, untrusted.URL, ignoredURL)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.Timeout = 100 * time.Millisecond
+	options.LinkPolicy.HostRules = []models.LinkHostRule{{Host: "localhost", Action: models.LinkHostIgnore}}
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	byURL := map[string]models.CrawlLink{}
+	for _, link := range result.Links {
+		byURL[link.URL] = link
+	}
+	tests := []struct {
+		url        string
+		category   models.LinkCheckCategory
+		status     models.LinkStatus
+		statusCode int
+	}{
+		{server.URL + "/forbidden", models.LinkCheckForbidden, models.LinkStatusUnverified, http.StatusForbidden},
+		{server.URL + "/linkedin", models.LinkCheckForbidden, models.LinkStatusUnverified, 999},
+		{server.URL + "/busy-once", models.LinkCheckOK, models.LinkStatusOK, http.StatusOK},
+		{server.URL + "/busy", models.LinkCheckRateLimited, models.LinkStatusUnverified, http.StatusTooManyRequests},
+		{server.URL + "/no-head", models.LinkCheckOK, models.LinkStatusOK, http.StatusOK},
+		{server.URL + "/missing", models.LinkCheckBroken, models.LinkStatusBroken, http.StatusNotFound},
+		{server.URL + "/slow", models.LinkCheckTimeout, models.LinkStatusBroken, 0},
+		{untrusted.URL + "/untrusted", models.LinkCheckTLSError, models.LinkStatusBroken, 0},
+		{ignoredURL + "/ignored", models.LinkCheckIgnored, models.LinkStatusUnchecked, 0},
+	}
+	for _, tt := range tests {
+		link, ok := byURL[tt.url]
+		require.True(t, ok, tt.url)
+		assert.Equal(t, tt.category, link.Category, tt.url)
+		assert.Equal(t, tt.status, link.Status, tt.url)
+		assert.Equal(t, tt.statusCode, link.StatusCode, tt.url)
+	}
+	assert.Equal(t, int32(1), headRequests)
+
+	// Only the links the policy counts as broken are reported
+	brokenURLs := []string{}
+	for _, link := range result.BrokenLinks {
+		brokenURLs = append(brokenURLs, link.URL)
+	}
+	assert.ElementsMatch(t, []string{server.URL + "/missing", server.URL + "/slow", untrusted.URL + "/untrusted"}, brokenURLs)
+}
+
+func TestCrawler_LinkCheckPolicy_BrokenCategories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/forbidden">Forbidden</a><a href="/missing">Missing</a></body></html>`))
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.LinkPolicy.BrokenCategories = []models.LinkCheckCategory{models.LinkCheckBroken, models.LinkCheckForbidden}
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	assert.Len(t, result.BrokenLinks, 2)
+	for _, link := range result.Links {
+		assert.Equal(t, models.LinkStatusBroken, link.Status, link.URL)
+	}
+
+	// Broken links keep their category, so a 403 is told apart from a 404
+	categories := map[string]models.LinkCheckCategory{}
+	for _, link := range result.BrokenLinks {
+		categories[link.URL] = link.Category
+	}
+	assert.Equal(t, map[string]models.LinkCheckCategory{
+		server.URL + "/forbidden": models.LinkCheckForbidden,
+		server.URL + "/missing":   models.LinkCheckBroken,
+	}, categories)
+}
+
+func TestLinkCheckPolicy_Ignores(t *testing.T) {
+	policy := models.LinkCheckPolicy{HostRules: []models.LinkHostRule{
+		{Host: "linkedin.com", Action: models.LinkHostIgnore},
+		{Host: "blog.linkedin.com", Action: models.LinkHostAllow},
+	}}
+
+	assert.True(t, policy.Ignores("linkedin.com"))
+	assert.True(t, policy.Ignores("www.LinkedIn.com"))
+	assert.False(t, policy.Ignores("blog.linkedin.com"))
+	assert.False(t, policy.Ignores("news.blog.linkedin.com"))
+	assert.False(t, policy.Ignores("notlinkedin.com"))
+	assert.False(t, models.LinkCheckPolicy{}.Ignores("linkedin.com"))
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected models.LinkCheckCategory
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "nowhere.invalid", IsNotFound: true}, models.LinkCheckDNSFailure},
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), models.LinkCheckTimeout},
+		{"certificate", fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), models.LinkCheckTLSError},
+		{"redirect loop", fmt.Errorf("%w back to /a", errRedirectLoop), models.LinkCheckBroken},
+		{"refused", errors.New("connect: connection refused"), models.LinkCheckConnectionError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 5*time.Second, retryAfter("5", now))
+	assert.Equal(t, time.Duration(0), retryAfter("-3", now))
+	assert.Equal(t, 30*time.Second, retryAfter("Wed, 01 May 2024 12:00:30 GMT", now))
+	assert.Equal(t, time.Duration(0), retryAfter("Wed, 01 May 2024 11:00:00 GMT", now))
+	assert.Equal(t, defaultRetryAfter, retryAfter("", now))
+	assert.Equal(t, defaultRetryAfter, retryAfter("soon", now))
+}
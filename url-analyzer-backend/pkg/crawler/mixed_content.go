@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// the most mixed content findings kept per code, the rest are summed up in one more finding
+const maxMixedContentFindingsPerCode = 25
+
+// @import rules of inline CSS, with or without url()
+var cssImportPattern = regexp.MustCompile(`@import\s+(?:url\(\s*)?['"]?([^'")\s;]+)`)
+
+// the elements that load a subresource, and whether browsers block it over HTTP (active
+// content) or only images and media, which they upgrade or show with a warning (passive content)
+var mixedContentSources = []struct {
+	selector string
+	attr     string
+	kind     string
+	active   bool
+}{
+	{"script[src]", "src", "Script", true},
+	{"link[rel~='stylesheet' i][href]", "href", "Stylesheet", true},
+	{"link[rel~='preload' i][as='script' i][href], link[rel~='modulepreload' i][href]", "href", "Script", true},
+	{"link[rel~='preload' i][as='style' i][href]", "href", "Stylesheet", true},
+	{"link[rel~='preload' i][as='font' i][href]", "href", "Font", true},
+	{"iframe[src], frame[src]", "src", "Iframe", true},
+	{"object[data]", "data", "Object", true},
+	{"embed[src]", "src", "Embed", true},
+	{"track[src]", "src", "Text track", true},
+	{"img[src], input[type='image' i][src]", "src", "Image", false},
+	{"link[rel~='icon' i][href], link[rel~='apple-touch-icon' i][href]", "href", "Icon", false},
+	{"video[poster]", "poster", "Image", false},
+	{"video[src], audio[src], video source[src], audio source[src]", "src", "Media", false},
+}
+
+// flags the subresources an HTTPS page loads over plain HTTP. Scripts, stylesheets, fonts and
+// frames are active mixed content that browsers block; images and media are passive mixed
+// content that browsers upgrade to HTTPS or load with a warning. Srcset candidates and url()
+// references of inline CSS are checked too. Links to other pages are navigation, not mixed content.
+func mixedContentFindings(doc *goquery.Document, pageURL *url.URL) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	if pageURL.Scheme != "https" {
+		return findings
+	}
+
+	perCode := map[string]int{}
+	seen := map[string]bool{}
+	add := func(s *goquery.Selection, kind string, active bool, ref string) {
+		resourceURL, err := pageURL.Parse(strings.TrimSpace(ref))
+		if err != nil || resourceURL.Scheme != "http" {
+			return
+		}
+
+		code, severity, outcome := "mixed_passive_content", models.SeverityWarning, "browsers upgrade or warn about it"
+		if active {
+			code, severity, outcome = "mixed_active_content", models.SeverityError, "browsers block it"
+		}
+		key := code + " " + resourceURL.String()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		perCode[code]++
+		if perCode[code] > maxMixedContentFindingsPerCode {
+			return
+		}
+		findings = append(findings, models.CrawlFinding{
+			Category: models.FindingCategoryMixedContent,
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf("%s %s is loaded over HTTP on an HTTPS page, %s", kind, resourceURL.String(), outcome),
+			Selector: cssPath(s),
+		})
+	}
+
+	for _, source := range mixedContentSources {
+		doc.Find(source.selector).Each(func(i int, s *goquery.Selection) {
+			add(s, source.kind, source.active, s.AttrOr(source.attr, ""))
+		})
+	}
+
+	doc.Find("img[srcset], picture source[srcset]").Each(func(i int, s *goquery.Selection) {
+		for _, candidate := range srcsetURLs(s.AttrOr("srcset", "")) {
+			add(s, "Image", false, candidate)
+		}
+	})
+
+	// Inline CSS: imported stylesheets and fonts are active, background images passive
+	doc.Find("style, [style]").Each(func(i int, s *goquery.Selection) {
+		css := s.AttrOr("style", "")
+		if goquery.NodeName(s) == "style" {
+			css = s.Text()
+		}
+		for _, match := range cssImportPattern.FindAllStringSubmatch(css, -1) {
+			add(s, "Stylesheet", true, match[1])
+		}
+		for _, match := range cssURLPattern.FindAllStringSubmatch(cssImportPattern.ReplaceAllString(css, ""), -1) {
+			extension := ""
+			if refURL, err := url.Parse(strings.TrimSpace(match[1])); err == nil {
+				extension = strings.ToLower(path.Ext(refURL.Path))
+			}
+			if fontExtensions[extension] {
+				add(s, "Font", true, match[1])
+			} else {
+				add(s, "Image", false, match[1])
+			}
+		}
+	})
+
+	// Sum up what was cut
+	for _, code := range []string{"mixed_active_content", "mixed_passive_content"} {
+		if perCode[code] > maxMixedContentFindingsPerCode {
+			severity := models.SeverityWarning
+			if code == "mixed_active_content" {
+				severity = models.SeverityError
+			}
+			findings = append(findings, models.CrawlFinding{
+				Category: models.FindingCategoryMixedContent,
+				Code:     code,
+				Severity: severity,
+				Message:  fmt.Sprintf("%d more resources are loaded over HTTP", perCode[code]-maxMixedContentFindingsPerCode),
+			})
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// finds the mixed content of a page served from the given URL
+func mixedContentOf(t *testing.T, pageURL string, page string) []models.CrawlFinding {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	require.NoError(t, err)
+	parsed, err := url.Parse(pageURL)
+	require.NoError(t, err)
+	return mixedContentFindings(doc, parsed)
+}
+
+func TestMixedContentFindings(t *testing.T) {
+	findings := mixedContentOf(t, "https://example.com/shop/", `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <title>Shop</title>
+    <script src="http://cdn.example.com/app.js"></script>
+    <script src="//cdn.example.com/lib.js"></script>
+    <link rel="stylesheet" href="http://cdn.example.com/style.css">
+    <link rel="icon" href="http://example.com/favicon.ico">
+    <style>
+        @import url("http://fonts.example.com/css");
+        @font-face { font-family: Title; src: url(http://fonts.example.com/title.woff2); }
+        .hero { background: url('http://example.com/hero.jpg'); }
+    </style>
+</head>
+<body>
+    <main id="content">
+        <img src="logo.png" srcset="logo.png 1x, http://example.com/logo@2x.png 2x" alt="Logo">
+        <img src="http://example.com/banner.png" alt="Banner">
+        <img src="http://example.com/banner.png" alt="Banner again">
+        <video poster="http://example.com/poster.jpg"><source src="http://example.com/clip.mp4"></video>
+        <iframe src="http://maps.example.com/embed"></iframe>
+        <div style="background-image: url(http://example.com/tile.png)"></div>
+        <a href="http://example.org/">A link is navigation, not mixed content</a>
+        <form action="http://example.com/search"><input name="q" aria-label="Search"></form>
+    </main>
+</body>
+</html>`)
+
+	messages := map[string][]string{}
+	for _, finding := range findings {
+		assert.Equal(t, models.FindingCategoryMixedContent, finding.Category)
+		messages[finding.Code] = append(messages[finding.Code], finding.Message)
+	}
+
+	assert.Equal(t, []string{
+		"Script http://cdn.example.com/app.js is loaded over HTTP on an HTTPS page, browsers block it",
+		"Stylesheet http://cdn.example.com/style.css is loaded over HTTP on an HTTPS page, browsers block it",
+		"Iframe http://maps.example.com/embed is loaded over HTTP on an HTTPS page, browsers block it",
+		"Stylesheet http://fonts.example.com/css is loaded over HTTP on an HTTPS page, browsers block it",
+		"Font http://fonts.example.com/title.woff2 is loaded over HTTP on an HTTPS page, browsers block it",
+	}, messages["mixed_active_content"])
+
+	assert.Equal(t, []string{
+		"Image http://example.com/banner.png is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+		"Icon http://example.com/favicon.ico is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+		"Image http://example.com/poster.jpg is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+		"Media http://example.com/clip.mp4 is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+		"Image http://example.com/logo@2x.png is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+		"Image http://example.com/hero.jpg is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+		"Image http://example.com/tile.png is loaded over HTTP on an HTTPS page, browsers upgrade or warn about it",
+	}, messages["mixed_passive_content"])
+
+	// Findings point at the element that loads the resource
+	for _, finding := range findings {
+		if strings.Contains(finding.Message, "banner.png") {
+			assert.Equal(t, "main#content > img:nth-of-type(2)", finding.Selector)
+		}
+	}
+}
+
+func TestMixedContentFindings_PlainHTTPPage(t *testing.T) {
+	findings := mixedContentOf(t, "http://example.com/", `<html><head><script src="http://cdn.example.com/app.js"></script></head><body></body></html>`)
+	assert.Empty(t, findings)
+}
+
+func TestMixedContentFindings_Capped(t *testing.T) {
+	images := strings.Builder{}
+	for i := 0; i < maxMixedContentFindingsPerCode+5; i++ {
+		fmt.Fprintf(&images, `<img src="http://example.com/%d.png" alt="%d">`, i, i)
+	}
+	findings := mixedContentOf(t, "https://example.com/", "<html><body>"+images.String()+"</body></html>")
+
+	require.Len(t, findings, maxMixedContentFindingsPerCode+1)
+	assert.Equal(t, "5 more resources are loaded over HTTP", findings[maxMixedContentFindingsPerCode].Message)
+	assert.Empty(t, findings[maxMixedContentFindingsPerCode].Selector)
+}
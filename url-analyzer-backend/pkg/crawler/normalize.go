@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// NormalizeURL returns a canonical form of a URL so equivalent addresses compare equal.
+// The scheme and host are lowercased, default ports and fragments are dropped,
+// an empty path becomes "/" and query parameters are sorted.
+func NormalizeURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	// Drop default ports
+	if (parsed.Scheme == "http" && strings.HasSuffix(parsed.Host, ":80")) ||
+		(parsed.Scheme == "https" && strings.HasSuffix(parsed.Host, ":443")) {
+		parsed.Host = parsed.Host[:strings.LastIndex(parsed.Host, ":")]
+	}
+
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	} else if len(parsed.Path) > 1 {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	}
+	parsed.RawPath = ""
+
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			values := query[key]
+			sort.Strings(values)
+			for _, value := range values {
+				parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+		parsed.RawQuery = strings.Join(parts, "&")
+	}
+
+	return parsed.String(), nil
+}
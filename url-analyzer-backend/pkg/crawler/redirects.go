@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// the most redirect findings kept per code, the rest are summed up in one more finding
+const maxRedirectFindingsPerCode = 25
+
+// collects the redirects followed by one request. The redirect policy finds it in the request's context.
+type redirectRecorder struct {
+	chain models.RedirectChain
+}
+
+type redirectRecorderKey struct{}
+
+// returns a context whose requests record the redirects they follow
+func recordRedirects(ctx context.Context) (context.Context, *redirectRecorder) {
+	recorder := &redirectRecorder{chain: models.RedirectChain{}}
+	return context.WithValue(ctx, redirectRecorderKey{}, recorder), recorder
+}
+
+// why a request stopped following redirects
+var (
+	errRedirectLoop     = errors.New("redirect loop")
+	errTooManyRedirects = errors.New("too many redirects")
+)
+
+// a redirect policy that records each hop in the recorder of the request's context, if it has
+// one, and stops at redirects back to a URL already requested or after maxRedirects
+func recordingRedirectPolicy(maxRedirects int) resty.RedirectPolicy {
+	return resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+		if recorder, ok := req.Context().Value(redirectRecorderKey{}).(*redirectRecorder); ok && req.Response != nil {
+			recorder.chain = append(recorder.chain, models.RedirectHop{
+				URL:        via[len(via)-1].URL.String(),
+				StatusCode: req.Response.StatusCode,
+				Location:   req.Response.Header.Get("Location"),
+			})
+		}
+
+		for _, visited := range via {
+			if visited.URL.String() == req.URL.String() {
+				return fmt.Errorf("%w back to %s", errRedirectLoop, req.URL)
+			}
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("%w, stopped after %d", errTooManyRedirects, maxRedirects)
+		}
+		return nil
+	})
+}
+
+// returns where a hop of a redirect chain leads, with a relative Location resolved
+func redirectTarget(hop models.RedirectHop) string {
+	from, err := url.Parse(hop.URL)
+	if err != nil {
+		return hop.Location
+	}
+	target, err := from.Parse(hop.Location)
+	if err != nil {
+		return hop.Location
+	}
+	return target.String()
+}
+
+// reports whether a redirect chain ends by pointing back to a URL it already went through
+func isRedirectLoop(chain models.RedirectChain) bool {
+	if len(chain) == 0 {
+		return false
+	}
+	last := redirectTarget(chain[len(chain)-1])
+	for _, hop := range chain {
+		if hop.URL == last {
+			return true
+		}
+	}
+	return false
+}
+
+// reports the problems of the redirect chains of the page and its links: loops, chains of more
+// than hopLimit hops, redirects from HTTPS to plain HTTP and 302 redirects that may be permanent
+func redirectFindings(pageChain models.RedirectChain, links []models.CrawlLink, hopLimit int) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	perCode := map[string]int{}
+	severities := map[string]models.FindingSeverity{}
+	add := func(code string, severity models.FindingSeverity, format string, args ...interface{}) {
+		perCode[code]++
+		severities[code] = severity
+		if perCode[code] > maxRedirectFindingsPerCode {
+			return
+		}
+		findings = append(findings, models.CrawlFinding{
+			Category: models.FindingCategoryRedirects,
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	check := func(subject string, chain models.RedirectChain) {
+		if len(chain) == 0 {
+			return
+		}
+
+		path := make([]string, 0, len(chain)+1)
+		for _, hop := range chain {
+			path = append(path, hop.URL)
+		}
+		destination := redirectTarget(chain[len(chain)-1])
+		path = append(path, destination)
+
+		switch {
+		case isRedirectLoop(chain):
+			add("redirect_loop", models.SeverityError, "%s redirects in a loop: %s", subject, strings.Join(path, " → "))
+		case hopLimit > 0 && len(chain) > hopLimit:
+			add("long_redirect_chain", models.SeverityWarning, "%s takes %d redirects to reach %s: %s", subject, len(chain), destination, strings.Join(path, " → "))
+		}
+
+		for _, hop := range chain {
+			target := redirectTarget(hop)
+			if strings.HasPrefix(hop.URL, "https://") && strings.HasPrefix(target, "http://") {
+				add("redirect_downgrade", models.SeverityError, "%s is redirected from HTTPS to plain HTTP: %s → %s", subject, hop.URL, target)
+			}
+			if hop.StatusCode == http.StatusFound {
+				add("temporary_redirect", models.SeverityWarning, "%s is redirected with 302 Found from %s to %s, use 301 if the move is permanent", subject, hop.URL, target)
+			}
+		}
+	}
+
+	check("Page", pageChain)
+	for _, link := range links {
+		check("Link "+link.URL, link.Redirects)
+	}
+
+	// Sum up what was cut
+	for _, code := range []string{"redirect_loop", "long_redirect_chain", "redirect_downgrade", "temporary_redirect"} {
+		if perCode[code] > maxRedirectFindingsPerCode {
+			findings = append(findings, models.CrawlFinding{
+				Category: models.FindingCategoryRedirects,
+				Code:     code,
+				Severity: severities[code],
+				Message:  fmt.Sprintf("%d more redirects have the same problem", perCode[code]-maxRedirectFindingsPerCode),
+			})
+		}
+	}
+
+	return findings
+}
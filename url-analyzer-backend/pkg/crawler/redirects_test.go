@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_RecordsRedirectChains(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/newer", http.StatusFound)
+	})
+	mux.HandleFunc("/newer", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html lang="en"><head><title>Page</title></head><body>
+			<a href="/moved">Moved</a>
+			<a href="/loop-a">Loop</a>
+			<a href="/page">Direct</a>
+		</body></html>`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/loop-a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-b", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/loop-b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-a", http.StatusMovedPermanently)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.RedirectHopLimit = 1
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL+"/old")
+	require.NoError(t, result.Error)
+
+	// The page itself
+	assert.Equal(t, models.RedirectChain{
+		{URL: server.URL + "/old", StatusCode: http.StatusFound, Location: "/newer"},
+		{URL: server.URL + "/newer", StatusCode: http.StatusMovedPermanently, Location: "/page"},
+	}, result.RedirectChain)
+
+	// Its links
+	byURL := map[string]models.CrawlLink{}
+	for _, link := range result.Links {
+		byURL[link.URL] = link
+	}
+	assert.Equal(t, models.RedirectChain{
+		{URL: server.URL + "/moved", StatusCode: http.StatusMovedPermanently, Location: "/page"},
+	}, byURL[server.URL+"/moved"].Redirects)
+	assert.Equal(t, models.LinkStatusOK, byURL[server.URL+"/moved"].Status)
+	assert.Empty(t, byURL[server.URL+"/page"].Redirects)
+
+	loop := byURL[server.URL+"/loop-a"]
+	assert.Equal(t, models.LinkStatusBroken, loop.Status)
+	assert.Contains(t, loop.ErrorMessage, "redirect loop")
+	require.Len(t, loop.Redirects, 2)
+	assert.Equal(t, "/loop-a", loop.Redirects[1].Location)
+
+	messages := map[string][]string{}
+	for _, finding := range result.Findings {
+		if finding.Category == models.FindingCategoryRedirects {
+			messages[finding.Code] = append(messages[finding.Code], finding.Message)
+		}
+	}
+	assert.Equal(t, []string{
+		"Page takes 2 redirects to reach " + server.URL + "/page: " + server.URL + "/old → " + server.URL + "/newer → " + server.URL + "/page",
+	}, messages["long_redirect_chain"])
+	assert.Equal(t, []string{
+		"Page is redirected with 302 Found from " + server.URL + "/old to " + server.URL + "/newer, use 301 if the move is permanent",
+	}, messages["temporary_redirect"])
+	assert.Equal(t, []string{
+		"Link " + server.URL + "/loop-a redirects in a loop: " + server.URL + "/loop-a → " + server.URL + "/loop-b → " + server.URL + "/loop-a",
+	}, messages["redirect_loop"])
+	assert.Empty(t, messages["redirect_downgrade"])
+}
+
+func TestRedirectFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    models.RedirectChain
+		expected []string
+	}{
+		{"no redirects", models.RedirectChain{}, []string{}},
+		{"permanent", models.RedirectChain{{URL: "http://example.com/", StatusCode: 301, Location: "https://example.com/"}}, []string{}},
+		{"downgrade", models.RedirectChain{{URL: "https://example.com/a", StatusCode: 301, Location: "http://example.com/a"}}, []string{"redirect_downgrade"}},
+		{"temporary", models.RedirectChain{{URL: "https://example.com/a", StatusCode: 302, Location: "/b"}}, []string{"temporary_redirect"}},
+		{"temporary 307 is explicit", models.RedirectChain{{URL: "https://example.com/a", StatusCode: 307, Location: "/b"}}, []string{}},
+		{"long chain", models.RedirectChain{
+			{URL: "http://example.com/", StatusCode: 301, Location: "https://example.com/"},
+			{URL: "https://example.com/", StatusCode: 301, Location: "https://www.example.com/"},
+			{URL: "https://www.example.com/", StatusCode: 308, Location: "/home"},
+		}, []string{"long_redirect_chain"}},
+		{"self loop", models.RedirectChain{{URL: "https://example.com/a", StatusCode: 301, Location: "/a"}}, []string{"redirect_loop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := []string{}
+			for _, finding := range redirectFindings(tt.chain, nil, 2) {
+				codes = append(codes, finding.Code)
+			}
+			assert.Equal(t, tt.expected, codes)
+		})
+	}
+}
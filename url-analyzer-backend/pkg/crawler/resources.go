@@ -0,0 +1,226 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// url() references in inline CSS
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// file extensions that give away the type of a resource referenced from CSS
+var (
+	fontExtensions  = map[string]bool{".woff2": true, ".woff": true, ".ttf": true, ".otf": true, ".eot": true}
+	imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".svg": true, ".ico": true}
+)
+
+// the resource types of <link rel="preload"> by their "as" attribute
+var preloadTypes = map[string]models.ResourceType{
+	"script": models.ResourceTypeScript,
+	"style":  models.ResourceTypeStylesheet,
+	"image":  models.ResourceTypeImage,
+	"font":   models.ResourceTypeFont,
+}
+
+// lists the scripts, stylesheets, images, fonts and iframes a page references, once per type and URL.
+// Resources on another host than the page are third-party. Fonts and images loaded from external
+// stylesheets are not found, only those referenced by inline CSS.
+func extractResources(doc *goquery.Document, baseURL *url.URL) []models.CrawlResource {
+	resources := []models.CrawlResource{}
+	seen := map[string]bool{}
+
+	add := func(resourceType models.ResourceType, ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
+		}
+		resourceURL, err := baseURL.Parse(ref)
+		if err != nil || (resourceURL.Scheme != "http" && resourceURL.Scheme != "https") {
+			return
+		}
+
+		key := string(resourceType) + " " + resourceURL.String()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		resources = append(resources, models.CrawlResource{
+			URL:          resourceURL.String(),
+			Type:         resourceType,
+			IsThirdParty: !strings.EqualFold(resourceURL.Hostname(), baseURL.Hostname()),
+			Status:       models.LinkStatusUnchecked,
+		})
+	}
+
+	doc.Find("script[src]").Each(func(i int, s *goquery.Selection) {
+		add(models.ResourceTypeScript, s.AttrOr("src", ""))
+	})
+
+	doc.Find("link[href]").Each(func(i int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		rels := map[string]bool{}
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			rels[rel] = true
+		}
+
+		switch {
+		case rels["stylesheet"]:
+			add(models.ResourceTypeStylesheet, href)
+		case rels["icon"] || rels["apple-touch-icon"]:
+			add(models.ResourceTypeImage, href)
+		case rels["preload"] || rels["prefetch"]:
+			if resourceType, ok := preloadTypes[strings.ToLower(s.AttrOr("as", ""))]; ok {
+				add(resourceType, href)
+			}
+		}
+	})
+
+	doc.Find("img[src], input[type='image' i][src]").Each(func(i int, s *goquery.Selection) {
+		add(models.ResourceTypeImage, s.AttrOr("src", ""))
+	})
+	doc.Find("img[srcset], picture source[srcset]").Each(func(i int, s *goquery.Selection) {
+		for _, candidate := range srcsetURLs(s.AttrOr("srcset", "")) {
+			add(models.ResourceTypeImage, candidate)
+		}
+	})
+	doc.Find("video[poster]").Each(func(i int, s *goquery.Selection) {
+		add(models.ResourceTypeImage, s.AttrOr("poster", ""))
+	})
+
+	doc.Find("iframe[src], frame[src]").Each(func(i int, s *goquery.Selection) {
+		add(models.ResourceTypeIframe, s.AttrOr("src", ""))
+	})
+
+	// Fonts and background images of inline CSS
+	css := []string{}
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		css = append(css, s.Text())
+	})
+	doc.Find("[style]").Each(func(i int, s *goquery.Selection) {
+		css = append(css, s.AttrOr("style", ""))
+	})
+	for _, match := range cssURLPattern.FindAllStringSubmatch(strings.Join(css, "\n"), -1) {
+		ref := match[1]
+		extension := ""
+		if refURL, err := url.Parse(strings.TrimSpace(ref)); err == nil {
+			extension = strings.ToLower(path.Ext(refURL.Path))
+		}
+		switch {
+		case fontExtensions[extension]:
+			add(models.ResourceTypeFont, ref)
+		case imageExtensions[extension]:
+			add(models.ResourceTypeImage, ref)
+		}
+	}
+
+	return resources
+}
+
+// returns the URLs of the candidates of a srcset attribute, e.g. "a.png 1x, b.png 2x", parsed the
+// way the HTML standard does: a URL runs up to whitespace and may contain commas except trailing
+// ones, and its descriptors end at the next comma outside parentheses
+func srcsetURLs(srcset string) []string {
+	urls := []string{}
+	for i := 0; i < len(srcset); {
+		// Candidates are separated by commas and whitespace
+		for i < len(srcset) && (isHTMLSpace(srcset[i]) || srcset[i] == ',') {
+			i++
+		}
+		if i == len(srcset) {
+			break
+		}
+
+		start := i
+		for i < len(srcset) && !isHTMLSpace(srcset[i]) {
+			i++
+		}
+		url := srcset[start:i]
+		if trimmed := strings.TrimRight(url, ","); trimmed != url {
+			// Trailing commas end a candidate without descriptors
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, url)
+
+		// Skip the descriptors, e.g. "2x" or "100w"
+		inParens := false
+		for ; i < len(srcset); i++ {
+			c := srcset[i]
+			if inParens {
+				inParens = c != ')'
+				continue
+			}
+			if c == '(' {
+				inParens = true
+			} else if c == ',' {
+				i++
+				break
+			}
+		}
+	}
+	return urls
+}
+
+// reports whether a byte is ASCII whitespace as HTML defines it
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// sums up the resources of a page by type, size, host and status
+func summarizeResources(resources []models.CrawlResource) models.ResourceSummary {
+	summary := models.ResourceSummary{
+		Counts:          make(map[models.ResourceType]int, len(models.ResourceTypes)),
+		Total:           len(resources),
+		ThirdPartyHosts: []string{},
+	}
+	for _, resourceType := range models.ResourceTypes {
+		summary.Counts[resourceType] = 0
+	}
+
+	hosts := map[string]bool{}
+	for _, resource := range resources {
+		summary.Counts[resource.Type]++
+		summary.TotalBytes += resource.Size
+
+		if resource.Status == models.LinkStatusOK && resource.Size == 0 {
+			summary.UnknownSizes++
+		}
+		if resource.Status == models.LinkStatusBroken {
+			summary.Broken++
+		}
+		if resource.IsThirdParty {
+			summary.ThirdParty++
+			if resourceURL, err := url.Parse(resource.URL); err == nil {
+				hosts[strings.ToLower(resourceURL.Hostname())] = true
+			}
+		}
+	}
+	summary.ThirdPartyHosts = sortedKeys(hosts)
+
+	return summary
+}
+
+// reports the resources that failed to load
+func resourceFindings(resources []models.CrawlResource) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	for _, resource := range resources {
+		if resource.Status != models.LinkStatusBroken {
+			continue
+		}
+		resourceType := string(resource.Type)
+		findings = append(findings, models.CrawlFinding{
+			Category: models.FindingCategoryResources,
+			Code:     "broken_resource",
+			Severity: models.SeverityError,
+			Message:  fmt.Sprintf("%s%s %s is broken: %s", strings.ToUpper(resourceType[:1]), resourceType[1:], resource.URL, resource.ErrorMessage),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_ResourceInventory(t *testing.T) {
+	// A second host for third-party resources
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/lib.js" {
+			w.Write([]byte("console.log(1)"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer cdn.Close()
+	cdnURL := strings.Replace(cdn.URL, "127.0.0.1", "localhost", 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <title>Shop</title>
+    <link rel="stylesheet" href="/style.css">
+    <link rel="icon" href="/favicon.ico">
+    <link rel="preload" href="/fonts/body.woff2" as="font">
+    <script src="/app.js"></script>
+    <script src="/app.js"></script>
+    <script src="` + cdnURL + `/lib.js"></script>
+    <style>
+        @font-face { font-family: Title; src: url('/fonts/title.woff2') format('woff2'); }
+        .hero { background: url(/hero.jpg); }
+    </style>
+</head>
+<body>
+    <h1>Shop</h1>
+    <img src="/logo.png" srcset="/logo.png 1x, /logo@2x.png 2x" alt="Logo">
+    <img src="/missing.png" alt="Gone">
+    <img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" alt="">
+    <iframe src="` + cdnURL + `/widget"></iframe>
+</body>
+</html>`))
+	})
+	mux.HandleFunc("/style.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write([]byte("body { color: black; }"))
+	})
+	for _, path := range []string{"/app.js", "/favicon.ico", "/logo.png", "/logo@2x.png", "/hero.jpg", "/fonts/body.woff2", "/fonts/title.woff2"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("0123456789"))
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.CheckBrokenLinks = false
+	options.CheckResources = true
+	options.RespectRateLimit = false
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	byURL := map[string]models.CrawlResource{}
+	for _, resource := range result.Resources {
+		byURL[strings.TrimPrefix(strings.TrimPrefix(resource.URL, server.URL), cdnURL)] = resource
+	}
+	assert.Len(t, result.Resources, 11, "duplicates and data: URLs are left out")
+
+	assert.Equal(t, models.ResourceTypeStylesheet, byURL["/style.css"].Type)
+	assert.Equal(t, models.LinkStatusOK, byURL["/style.css"].Status)
+	assert.Equal(t, int64(len("body { color: black; }")), byURL["/style.css"].Size)
+	assert.Equal(t, "text/css", byURL["/style.css"].ContentType)
+	assert.Equal(t, models.ResourceTypeImage, byURL["/favicon.ico"].Type)
+	assert.Equal(t, models.ResourceTypeFont, byURL["/fonts/body.woff2"].Type)
+	assert.Equal(t, models.ResourceTypeFont, byURL["/fonts/title.woff2"].Type)
+	assert.Equal(t, models.ResourceTypeImage, byURL["/hero.jpg"].Type)
+	assert.Equal(t, models.ResourceTypeImage, byURL["/logo@2x.png"].Type)
+	assert.Equal(t, models.ResourceTypeIframe, byURL["/widget"].Type)
+
+	assert.Equal(t, models.LinkStatusBroken, byURL["/missing.png"].Status)
+	assert.Equal(t, http.StatusNotFound, byURL["/missing.png"].StatusCode)
+	assert.Equal(t, models.LinkStatusBroken, byURL["/widget"].Status)
+
+	assert.True(t, byURL["/lib.js"].IsThirdParty)
+	assert.Equal(t, models.LinkStatusOK, byURL["/lib.js"].Status)
+	assert.False(t, byURL["/app.js"].IsThirdParty)
+
+	summary := result.ResourceSummary
+	require.NotNil(t, summary)
+	assert.Equal(t, 11, summary.Total)
+	assert.Equal(t, map[models.ResourceType]int{
+		models.ResourceTypeScript:     2,
+		models.ResourceTypeStylesheet: 1,
+		models.ResourceTypeImage:      5,
+		models.ResourceTypeFont:       2,
+		models.ResourceTypeIframe:     1,
+	}, summary.Counts)
+	assert.Equal(t, int64(len("body { color: black; }")+7*10+len("console.log(1)")), summary.TotalBytes)
+	assert.Equal(t, 2, summary.ThirdParty)
+	assert.Equal(t, []string{"localhost"}, summary.ThirdPartyHosts)
+	assert.Equal(t, 2, summary.Broken)
+
+	broken := []string{}
+	for _, finding := range result.Findings {
+		if finding.Category == models.FindingCategoryResources {
+			assert.Equal(t, "broken_resource", finding.Code)
+			broken = append(broken, finding.Message)
+		}
+	}
+	require.Len(t, broken, 2)
+	assert.Contains(t, broken[0], "Image "+server.URL+"/missing.png is broken")
+}
+
+func TestCrawler_ResourcesUncheckedByDefault(t *testing.T) {
+	result := crawlHTML(t, `<html><head><script src="/app.js"></script></head><body><img src="/a.png" alt="a"></body></html>`, nil)
+
+	require.Len(t, result.Resources, 2)
+	for _, resource := range result.Resources {
+		assert.Equal(t, models.LinkStatusUnchecked, resource.Status)
+		assert.Zero(t, resource.Size)
+	}
+	assert.Equal(t, 2, result.ResourceSummary.Total)
+	assert.Zero(t, result.ResourceSummary.Broken)
+	assert.Zero(t, result.ResourceSummary.TotalBytes)
+}
+
+func TestSrcsetURLs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		srcset string
+		want   []string
+	}{
+		{"density descriptors", "a.png 1x, b.png 2x", []string{"a.png", "b.png"}},
+		{"without descriptors", "a.png, b.png", []string{"a.png", "b.png"}},
+		{"comma inside a URL", "/img/w_100,h_50/a.jpg 100w, /img/w_200,h_100/a.jpg 200w", []string{"/img/w_100,h_50/a.jpg", "/img/w_200,h_100/a.jpg"}},
+		{"trailing commas", "a.png,, b.png 2x,", []string{"a.png", "b.png"}},
+		{"comma inside parentheses", "a.png (1x, 2x), b.png", []string{"a.png", "b.png"}},
+		{"extra whitespace", "\n  a.png   1x ,\t b.png  ", []string{"a.png", "b.png"}},
+		{"empty", " , ", []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, srcsetURLs(tc.srcset))
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// how long a fetched robots.txt is trusted before it is fetched again
+	robotsCacheTTL = 1 * time.Hour
+	// upper bound for Crawl-delay so a single host cannot stall a crawl indefinitely
+	maxCrawlDelay = 30 * time.Second
+	// how many hosts a RobotsCache keeps the robots.txt of
+	maxCachedRobots = 1000
+)
+
+// a single Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// the rules that apply to a set of user agents
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parsed contents of a robots.txt file
+type robotsData struct {
+	groups   []*robotsGroup
+	sitemaps []string
+	// set when robots.txt could not be retrieved and the host must be treated as off limits
+	disallowAll bool
+}
+
+// parses a robots.txt body into groups of rules
+func parseRobots(body string) *robotsData {
+	data := &robotsData{}
+
+	var current *robotsGroup
+	// consecutive user-agent lines share one group
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				data.groups = append(data.groups, current)
+			}
+			current.agents = append(current.agents, userAgentToken(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			if current != nil {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					current.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			// Sitemap lines apply to the whole file, not the current group
+			if value != "" {
+				data.sitemaps = append(data.sitemaps, value)
+			}
+		}
+		lastWasAgent = false
+	}
+
+	return data
+}
+
+// returns the product token of a user agent, e.g. "url-analyzer-bot" for "URL-Analyzer-Bot/1.0 (...)"
+func userAgentToken(userAgent string) string {
+	token := strings.ToLower(strings.TrimSpace(userAgent))
+	if idx := strings.IndexAny(token, "/ "); idx >= 0 {
+		token = token[:idx]
+	}
+	return token
+}
+
+// finds the rules for a user agent: the groups naming its product token, compared
+// case-insensitively as a whole, are merged, falling back to the "*" group
+func (rd *robotsData) rulesFor(userAgent string) ([]robotsRule, time.Duration) {
+	token := userAgentToken(userAgent)
+
+	bestLength := -1
+	var rules []robotsRule
+	var delay time.Duration
+
+	for _, group := range rd.groups {
+		for _, agent := range group.agents {
+			length := -1
+			if agent == "*" {
+				length = 0
+			} else if agent != "" && agent == token {
+				length = len(agent)
+			}
+			if length < 0 || length < bestLength {
+				continue
+			}
+
+			if length > bestLength {
+				bestLength = length
+				rules = nil
+				delay = 0
+			}
+			rules = append(rules, group.rules...)
+			if group.crawlDelay > delay {
+				delay = group.crawlDelay
+			}
+			break
+		}
+	}
+
+	return rules, delay
+}
+
+// reports whether a user agent may fetch the path (including query) of a URL.
+// The longest matching pattern wins and Allow wins a tie.
+func (rd *robotsData) allowed(userAgent string, path string) bool {
+	if rd.disallowAll {
+		return false
+	}
+
+	rules, _ := rd.rulesFor(userAgent)
+
+	bestLength := -1
+	allow := true
+	for _, rule := range rules {
+		// An empty Disallow allows everything
+		if rule.pattern == "" {
+			continue
+		}
+		if !robotsPatternMatch(rule.pattern, path) {
+			continue
+		}
+
+		length := len(rule.pattern)
+		if length > bestLength || (length == bestLength && rule.allow) {
+			bestLength = length
+			allow = rule.allow
+		}
+	}
+
+	return allow
+}
+
+// returns the Crawl-delay requested for a user agent, capped at maxCrawlDelay
+func (rd *robotsData) crawlDelay(userAgent string) time.Duration {
+	_, delay := rd.rulesFor(userAgent)
+	if delay > maxCrawlDelay {
+		return maxCrawlDelay
+	}
+	return delay
+}
+
+// matches a robots.txt path pattern supporting the "*" wildcard and "$" end anchor
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	// The first part is a prefix of the path
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	remaining := path[len(parts[0]):]
+
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+		if i == len(parts)-1 && anchored {
+			return strings.HasSuffix(remaining, part)
+		}
+		idx := strings.Index(remaining, part)
+		if idx < 0 {
+			return false
+		}
+		remaining = remaining[idx+len(part):]
+	}
+
+	if anchored {
+		return remaining == ""
+	}
+	return true
+}
+
+// a cached robots.txt together with the time the host may next be requested
+type robotsEntry struct {
+	// nil until robots.txt was read; guarded by load
+	data        *robotsData
+	createdAt   time.Time
+	load        sync.Mutex
+	nextAllowed time.Time
+	mu          sync.Mutex
+}
+
+// fetches robots.txt once per host and caches the parsed rules
+type robotsCache struct {
+	client    *resty.Client
+	userAgent string
+	hosts     *RobotsCache
+	// the outcome of fetches that failed, trusted by this crawler only
+	failed   map[string]*robotsData
+	failedMu sync.Mutex
+}
+
+func newRobotsCache(client *resty.Client, userAgent string) *robotsCache {
+	return &robotsCache{
+		client:    client,
+		userAgent: userAgent,
+		hosts:     NewRobotsCache(robotsCacheTTL),
+		failed:    make(map[string]*robotsData),
+	}
+}
+
+// returns the cached robots.txt entry for the URL's host and its rules, fetching them if needed.
+// A fetch that failed or was cancelled is not cached for other crawlers; they fetch again.
+func (rc *robotsCache) entry(ctx context.Context, target *url.URL) (*robotsEntry, *robotsData) {
+	key := strings.ToLower(target.Scheme + "://" + target.Host)
+	entry := rc.hosts.entry(key)
+
+	// Concurrent lookups for the same host wait for a single fetch
+	entry.load.Lock()
+	defer entry.load.Unlock()
+
+	if entry.data != nil {
+		return entry, entry.data
+	}
+
+	rc.failedMu.Lock()
+	data, failed := rc.failed[key]
+	rc.failedMu.Unlock()
+	if failed {
+		return entry, data
+	}
+
+	data, ok := rc.fetch(ctx, key+"/robots.txt")
+	if ok {
+		entry.data = data
+	} else {
+		rc.failedMu.Lock()
+		rc.failed[key] = data
+		rc.failedMu.Unlock()
+	}
+
+	return entry, data
+}
+
+// RobotsCache keeps the robots.txt of hosts for a while, together with when each host may
+// next be requested, so crawlers sharing it read robots.txt once per host and keep to its
+// Crawl-delay between them. It is safe for concurrent use.
+type RobotsCache struct {
+	ttl     time.Duration
+	entries map[string]*robotsEntry
+	mu      sync.Mutex
+}
+
+// creates a cache keeping robots.txt files for ttl after they were read
+func NewRobotsCache(ttl time.Duration) *RobotsCache {
+	return &RobotsCache{ttl: ttl, entries: make(map[string]*robotsEntry)}
+}
+
+// returns the entry of an origin, starting a new one when there is none or it expired.
+// Room is made by dropping expired and then the oldest entries.
+func (c *RobotsCache) entry(origin string) *robotsEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[origin]
+	if exists && time.Since(entry.createdAt) < c.ttl {
+		return entry
+	}
+
+	if !exists && len(c.entries) >= maxCachedRobots {
+		oldest := ""
+		for key, cached := range c.entries {
+			if time.Since(cached.createdAt) >= c.ttl {
+				delete(c.entries, key)
+			} else if oldest == "" || cached.createdAt.Before(c.entries[oldest].createdAt) {
+				oldest = key
+			}
+		}
+		if len(c.entries) >= maxCachedRobots {
+			delete(c.entries, oldest)
+		}
+	}
+
+	entry = &robotsEntry{createdAt: time.Now()}
+	c.entries[origin] = entry
+	return entry
+}
+
+// downloads and parses a robots.txt file. A missing file (4xx) allows everything
+// and a server error (5xx) disallows everything. When the host cannot be reached
+// at all there are no rules to honor, so the request itself is left to fail.
+// A cancelled fetch disallows everything so nothing else is requested.
+// ok is false when the fetch failed, so the outcome says nothing about the file.
+func (rc *robotsCache) fetch(ctx context.Context, robotsURL string) (data *robotsData, ok bool) {
+	resp, err := rc.client.R().SetContext(ctx).Get(robotsURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return &robotsData{disallowAll: true}, false
+		}
+		return &robotsData{}, false
+	}
+
+	status := resp.StatusCode()
+	switch {
+	case status >= 500:
+		return &robotsData{disallowAll: true}, false
+	case status >= 400:
+		return &robotsData{}, true
+	default:
+		return parseRobots(string(resp.Body())), true
+	}
+}
+
+// reports whether robots.txt allows fetching a URL
+func (rc *robotsCache) allowed(ctx context.Context, target *url.URL) bool {
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+
+	_, data := rc.entry(ctx, target)
+	return data.allowed(rc.userAgent, path)
+}
+
+// waits until the host's Crawl-delay has passed since the previous request to it,
+// or until ctx is cancelled
+func (rc *robotsCache) waitTurn(ctx context.Context, target *url.URL) {
+	entry, data := rc.entry(ctx, target)
+	delay := data.crawlDelay(rc.userAgent)
+	if delay <= 0 {
+		return
+	}
+
+	entry.mu.Lock()
+	now := time.Now()
+	wait := entry.nextAllowed.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	entry.nextAllowed = now.Add(wait + delay)
+	entry.mu.Unlock()
+
+	sleepContext(ctx, wait)
+}
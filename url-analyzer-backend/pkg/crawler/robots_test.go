@@ -0,0 +1,223 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRobotsTxt = `
+# Rules for everyone
+User-agent: *
+Disallow: /private/
+Allow: /private/public-page
+Disallow: /*.pdf$
+
+# Rules for our bot
+User-agent: SomeOtherBot
+User-agent: URL-Analyzer-Bot
+Disallow: /no-analyzer
+Crawl-delay: 2
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestParseRobots_UserAgentGroups(t *testing.T) {
+	data := parseRobots(testRobotsTxt)
+
+	require.Len(t, data.groups, 2)
+	assert.Equal(t, []string{"someotherbot", "url-analyzer-bot"}, data.groups[1].agents)
+	assert.Equal(t, []string{"https://example.com/sitemap.xml"}, data.sitemaps)
+
+	// Our bot gets its own group and not the "*" rules
+	ourBot := "URL-Analyzer-Bot/1.0 (Educational Purpose)"
+	assert.False(t, data.allowed(ourBot, "/no-analyzer"))
+	assert.True(t, data.allowed(ourBot, "/private/secret"))
+	assert.Equal(t, 2*time.Second, data.crawlDelay(ourBot))
+
+	// Other agents fall back to "*"
+	otherBot := "Mozilla/5.0"
+	assert.True(t, data.allowed(otherBot, "/no-analyzer"))
+	assert.False(t, data.allowed(otherBot, "/private/secret"))
+	assert.Equal(t, time.Duration(0), data.crawlDelay(otherBot))
+}
+
+func TestRobotsData_MatchesWholeProductToken(t *testing.T) {
+	data := parseRobots("User-agent: bot\nDisallow: /bot\n\nUser-agent: URL-Analyzer\nDisallow: /prefix\n\nUser-agent: url-analyzer-bot/2.0\nDisallow: /ours\n")
+
+	// Neither a part nor a prefix of our product token names us; case and versions do not matter
+	ourBot := "URL-ANALYZER-BOT/1.0 (Educational Purpose)"
+	assert.True(t, data.allowed(ourBot, "/bot"))
+	assert.True(t, data.allowed(ourBot, "/prefix"))
+	assert.False(t, data.allowed(ourBot, "/ours"))
+}
+
+func TestRobotsData_AllowDisallowPrecedence(t *testing.T) {
+	data := parseRobots(testRobotsTxt)
+	agent := "Mozilla/5.0"
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"/", true},
+		{"/private/", false},
+		{"/private/public-page", true},  // longer Allow beats shorter Disallow
+		{"/private/public-pages", true}, // Allow patterns are prefixes too
+		{"/docs/file.pdf", false},
+		{"/docs/file.pdf?download=1", true}, // "$" anchors the end
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, data.allowed(agent, tc.path))
+		})
+	}
+
+	// Equal length rules resolve in favor of Allow
+	tie := parseRobots("User-agent: *\nDisallow: /page\nAllow: /page\n")
+	assert.True(t, tie.allowed(agent, "/page"))
+
+	// An empty Disallow allows everything
+	empty := parseRobots("User-agent: *\nDisallow:\n")
+	assert.True(t, empty.allowed(agent, "/anything"))
+}
+
+func TestRobotsCache_FetchOutcomes(t *testing.T) {
+	var robotsStatus int
+	var robotsFetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		robotsFetches++
+		w.WriteHeader(robotsStatus)
+		w.Write([]byte("User-agent: *\nDisallow: /\n"))
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL + "/page")
+
+	// A missing robots.txt allows everything
+	robotsStatus = 404
+	cache := newRobotsCache(resty.New(), "URL-Analyzer-Bot/1.0")
+	assert.True(t, cache.allowed(context.Background(), target))
+
+	// A server error disallows everything
+	robotsStatus = 503
+	cache = newRobotsCache(resty.New(), "URL-Analyzer-Bot/1.0")
+	assert.False(t, cache.allowed(context.Background(), target))
+
+	// robots.txt is fetched once per host
+	robotsStatus = 200
+	robotsFetches = 0
+	cache = newRobotsCache(resty.New(), "URL-Analyzer-Bot/1.0")
+	assert.False(t, cache.allowed(context.Background(), target))
+	assert.False(t, cache.allowed(context.Background(), target))
+	assert.Equal(t, 1, robotsFetches)
+}
+
+func TestRobotsCache_SharedKeepsOnlySuccessfulFetches(t *testing.T) {
+	var robotsStatus int
+	var robotsFetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		robotsFetches++
+		w.WriteHeader(robotsStatus)
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL + "/page")
+	shared := NewRobotsCache(time.Hour)
+	newShared := func() *robotsCache {
+		cache := newRobotsCache(resty.New(), "URL-Analyzer-Bot/1.0")
+		cache.hosts = shared
+		return cache
+	}
+
+	// A cancelled crawl requests nothing, but other crawls still read robots.txt
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	robotsStatus = 404
+	assert.False(t, newShared().allowed(cancelled, target))
+
+	// A server error keeps the crawl that saw it off the host, without fetching again
+	robotsStatus = 503
+	robotsFetches = 0
+	failing := newShared()
+	assert.False(t, failing.allowed(context.Background(), target))
+	assert.False(t, failing.allowed(context.Background(), target))
+	assert.Equal(t, 1, robotsFetches)
+
+	// Later crawls fetch robots.txt again, and keep the answer once there is one
+	robotsStatus = 404
+	assert.True(t, newShared().allowed(context.Background(), target))
+	assert.True(t, newShared().allowed(context.Background(), target))
+	assert.Equal(t, 2, robotsFetches)
+}
+
+func TestCrawler_CrawlURL_BlockedByRobots(t *testing.T) {
+	pageFetched := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /\n"))
+			return
+		}
+		pageFetched = true
+		w.Write([]byte("<html><body>Secret</body></html>"))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.CheckBrokenLinks = false
+	crawler := NewCrawler(options)
+
+	var lastStatus models.CrawlStatus
+	crawler.SetProgressCallback(func(status models.CrawlStatus, message string, progress float64) {
+		lastStatus = status
+	})
+
+	result := crawler.CrawlURL(context.Background(), server.URL)
+
+	assert.NoError(t, result.Error)
+	assert.True(t, result.BlockedByRobots)
+	assert.False(t, pageFetched)
+	assert.Equal(t, models.CrawlStatusBlocked, lastStatus)
+
+	// The rules are ignored when FollowRobotsTxt is off
+	options.FollowRobotsTxt = false
+	result = NewCrawler(options).CrawlURL(context.Background(), server.URL)
+
+	assert.NoError(t, result.Error)
+	assert.False(t, result.BlockedByRobots)
+	assert.True(t, pageFetched)
+}
+
+func TestCrawler_CheckBrokenLinks_BlockedByRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/":
+			w.Write([]byte(`<html><body><a href="/private/missing">Private</a><a href="/missing">Missing</a></body></html>`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	crawler := NewCrawler(options)
+
+	result := crawler.CrawlURL(context.Background(), server.URL)
+
+	require.NoError(t, result.Error)
+	assert.Equal(t, 1, result.LinksBlockedByRobots)
+	require.Len(t, result.BrokenLinks, 1)
+	assert.Contains(t, result.BrokenLinks[0].URL, "/missing")
+}
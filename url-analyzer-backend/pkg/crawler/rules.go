@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// evaluates the enabled custom rules against a page. Rules that do not compile fail with the reason.
+func evaluateRules(doc *goquery.Document, title string, rules []models.Rule) []models.CrawlRuleResult {
+	results := []models.CrawlRuleResult{}
+	var text *string
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		result := models.CrawlRuleResult{RuleID: rule.ID, Name: rule.Name, Severity: rule.Severity}
+		switch rule.Type {
+		case models.RuleSelectorExists, models.RuleSelectorAbsent, models.RuleElementCount:
+			matcher, err := cascadia.Compile(rule.Selector)
+			if err != nil {
+				result.Message = fmt.Sprintf("Invalid selector %s: %v", rule.Selector, err)
+				break
+			}
+			count := doc.FindMatcher(matcher).Length()
+			result.Passed, result.Message = evaluateCount(rule, count)
+
+		case models.RuleTitleMatches:
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				result.Message = fmt.Sprintf("Invalid pattern %s: %v", rule.Pattern, err)
+				break
+			}
+			result.Passed = pattern.MatchString(title)
+			if result.Passed {
+				result.Message = fmt.Sprintf("Title %q matches %s", title, rule.Pattern)
+			} else {
+				result.Message = fmt.Sprintf("Title %q does not match %s", title, rule.Pattern)
+			}
+
+		case models.RuleTextContains, models.RuleTextAbsent:
+			if text == nil {
+				lowered := strings.ToLower(visibleText(doc))
+				text = &lowered
+			}
+			found := strings.Contains(*text, strings.ToLower(strings.Join(strings.Fields(rule.Pattern), " ")))
+			result.Passed = found == (rule.Type == models.RuleTextContains)
+			if found {
+				result.Message = fmt.Sprintf("The page contains %q", rule.Pattern)
+			} else {
+				result.Message = fmt.Sprintf("The page does not contain %q", rule.Pattern)
+			}
+
+		default:
+			result.Message = fmt.Sprintf("Unknown rule type %s", rule.Type)
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// checks the number of elements a selector rule matched
+func evaluateCount(rule models.Rule, count int) (bool, string) {
+	matches := fmt.Sprintf("%s matches %d %s", rule.Selector, count, pluralize(count, "element", "elements"))
+	switch rule.Type {
+	case models.RuleSelectorExists:
+		return count > 0, matches
+	case models.RuleSelectorAbsent:
+		if count == 0 {
+			return true, matches
+		}
+		return false, matches + ", expected none"
+	}
+
+	var passed bool
+	var expected string
+	switch rule.Operator {
+	case models.RuleOperatorNotEqual:
+		passed, expected = count != rule.Count, fmt.Sprintf("anything but %d", rule.Count)
+	case models.RuleOperatorLess:
+		passed, expected = count < rule.Count, fmt.Sprintf("fewer than %d", rule.Count)
+	case models.RuleOperatorLessOrEqual:
+		passed, expected = count <= rule.Count, fmt.Sprintf("at most %d", rule.Count)
+	case models.RuleOperatorGreater:
+		passed, expected = count > rule.Count, fmt.Sprintf("more than %d", rule.Count)
+	case models.RuleOperatorGreaterOrEqual:
+		passed, expected = count >= rule.Count, fmt.Sprintf("at least %d", rule.Count)
+	default:
+		passed, expected = count == rule.Count, fmt.Sprintf("%d", rule.Count)
+	}
+	if passed {
+		return true, matches
+	}
+	return false, matches + ", expected " + expected
+}
+
+// returns the text of the page body without scripts, styles and templates, whitespace collapsed
+func visibleText(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+	return strings.Join(strings.Fields(body.Text()), " ")
+}
+
+// returns the singular or plural form for a count
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
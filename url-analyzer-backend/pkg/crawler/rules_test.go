@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	html := `<html><head><title>Acme - Home</title><script>var lorem = "ipsum";</script></head>
+<body><h1>Welcome</h1><h1>Again</h1><main><p>Free   shipping
+on all orders</p></main></body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		rule    models.Rule
+		passed  bool
+		message string
+	}{
+		{
+			name:    "selector exists",
+			rule:    models.Rule{Type: models.RuleSelectorExists, Selector: "main p"},
+			passed:  true,
+			message: "main p matches 1 element",
+		},
+		{
+			name:    "selector missing",
+			rule:    models.Rule{Type: models.RuleSelectorExists, Selector: "footer"},
+			message: "footer matches 0 elements",
+		},
+		{
+			name:    "selector present but must be absent",
+			rule:    models.Rule{Type: models.RuleSelectorAbsent, Selector: "h1"},
+			message: "h1 matches 2 elements, expected none",
+		},
+		{
+			name:    "exactly one h1",
+			rule:    models.Rule{Type: models.RuleElementCount, Selector: "h1", Operator: models.RuleOperatorEqual, Count: 1},
+			message: "h1 matches 2 elements, expected 1",
+		},
+		{
+			name:    "at most two h1",
+			rule:    models.Rule{Type: models.RuleElementCount, Selector: "h1", Operator: models.RuleOperatorLessOrEqual, Count: 2},
+			passed:  true,
+			message: "h1 matches 2 elements",
+		},
+		{
+			name:    "at least one image",
+			rule:    models.Rule{Type: models.RuleElementCount, Selector: "img", Operator: models.RuleOperatorGreaterOrEqual, Count: 1},
+			message: "img matches 0 elements, expected at least 1",
+		},
+		{
+			name:    "title matches",
+			rule:    models.Rule{Type: models.RuleTitleMatches, Pattern: "^Acme"},
+			passed:  true,
+			message: `Title "Acme - Home" matches ^Acme`,
+		},
+		{
+			name:    "text contains across whitespace and case",
+			rule:    models.Rule{Type: models.RuleTextContains, Pattern: "free shipping ON"},
+			passed:  true,
+			message: `The page contains "free shipping ON"`,
+		},
+		{
+			name:    "scripts are not visible text",
+			rule:    models.Rule{Type: models.RuleTextAbsent, Pattern: "lorem"},
+			passed:  true,
+			message: `The page does not contain "lorem"`,
+		},
+		{
+			name:    "invalid selector",
+			rule:    models.Rule{Type: models.RuleSelectorExists, Selector: "h1[["},
+			message: "Invalid selector h1[[",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.ID = 7
+			tt.rule.Name = tt.name
+			tt.rule.Severity = models.SeverityError
+			tt.rule.Enabled = true
+
+			results := evaluateRules(doc, "Acme - Home", []models.Rule{tt.rule})
+			require.Len(t, results, 1)
+			assert.Equal(t, 7, results[0].RuleID)
+			assert.Equal(t, tt.name, results[0].Name)
+			assert.Equal(t, models.SeverityError, results[0].Severity)
+			assert.Equal(t, tt.passed, results[0].Passed)
+			assert.True(t, strings.HasPrefix(results[0].Message, tt.message), results[0].Message)
+		})
+	}
+}
+
+func TestEvaluateRules_SkipsDisabled(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body></body></html>`))
+	require.NoError(t, err)
+
+	results := evaluateRules(doc, "", []models.Rule{{ID: 1, Type: models.RuleSelectorExists, Selector: "h1"}})
+	assert.Empty(t, results)
+}
+
+func TestCrawler_EvaluatesRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Checked</title></head><body><h1>Only heading</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.Rules = []models.Rule{
+		{ID: 1, Name: "One h1", Type: models.RuleElementCount, Selector: "h1", Operator: models.RuleOperatorEqual, Count: 1, Severity: models.SeverityError, Enabled: true},
+		{ID: 2, Name: "Branded title", Type: models.RuleTitleMatches, Pattern: "^Acme", Severity: models.SeverityWarning, Enabled: true},
+	}
+
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	require.Len(t, result.RuleResults, 2)
+	assert.True(t, result.RuleResults[0].Passed)
+	assert.False(t, result.RuleResults[1].Passed)
+
+	crawlResult := result.ToCrawlResult(1)
+	assert.Equal(t, 1, crawlResult.RulesPassed)
+	assert.Equal(t, 1, crawlResult.RulesFailed)
+}
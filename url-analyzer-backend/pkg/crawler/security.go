@@ -0,0 +1,357 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"url-analyzer/internal/models"
+)
+
+// certificates expiring sooner than this are reported
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// the HSTS max-age below which the policy is considered too short, 180 days
+const minHSTSMaxAge = 180 * 24 * 60 * 60
+
+// referrer policies by how much they leak to other sites
+var referrerPolicyStatus = map[string]models.SecurityCheckStatus{
+	"no-referrer":                     models.SecurityCheckPass,
+	"same-origin":                     models.SecurityCheckPass,
+	"strict-origin":                   models.SecurityCheckPass,
+	"strict-origin-when-cross-origin": models.SecurityCheckPass,
+	"origin":                          models.SecurityCheckWarn,
+	"origin-when-cross-origin":        models.SecurityCheckWarn,
+	"no-referrer-when-downgrade":      models.SecurityCheckWarn,
+	"unsafe-url":                      models.SecurityCheckFail,
+}
+
+// grades the security headers, cookies and TLS connection of a response
+func analyzeSecurity(resp *http.Response, pageURL *url.URL, now time.Time) models.SecurityReport {
+	report := models.SecurityReport{
+		Checks:  models.SecurityChecks{},
+		Cookies: models.CookieReports{},
+	}
+	add := func(name string, weight int, status models.SecurityCheckStatus, value, format string, args ...interface{}) {
+		report.Checks = append(report.Checks, models.SecurityCheck{
+			Name:    name,
+			Status:  status,
+			Weight:  weight,
+			Value:   value,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Redirects may have moved the page to another scheme
+	if resp.Request != nil && resp.Request.URL != nil {
+		pageURL = resp.Request.URL
+	}
+	isHTTPS := pageURL.Scheme == "https"
+	header := resp.Header
+
+	// HTTPS and TLS
+	if isHTTPS {
+		add("https", 15, models.SecurityCheckPass, pageURL.Scheme, "Page is served over HTTPS")
+	} else {
+		add("https", 15, models.SecurityCheckFail, pageURL.Scheme, "Page is served over plain HTTP")
+	}
+
+	if state := resp.TLS; state != nil {
+		version := tls.VersionName(state.Version)
+		report.TLSVersion = &version
+
+		status := models.SecurityCheckPass
+		messages := []string{}
+		if state.Version < tls.VersionTLS12 {
+			status = models.SecurityCheckFail
+			messages = append(messages, version+" is outdated, use TLS 1.2 or later")
+		}
+
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			issuer := cert.Issuer.CommonName
+			if issuer == "" {
+				issuer = strings.Join(cert.Issuer.Organization, ", ")
+			}
+			subject := cert.Subject.CommonName
+			expiresAt := cert.NotAfter.UTC()
+			report.CertIssuer = &issuer
+			report.CertSubject = &subject
+			report.CertExpiresAt = &expiresAt
+
+			switch left := expiresAt.Sub(now); {
+			case left <= 0:
+				status = models.SecurityCheckFail
+				messages = append(messages, "certificate expired on "+expiresAt.Format("2006-01-02"))
+			case left < certificateExpiryWarning:
+				if status == models.SecurityCheckPass {
+					status = models.SecurityCheckWarn
+				}
+				messages = append(messages, fmt.Sprintf("certificate expires in %d days", daysUntil(expiresAt, now)))
+			}
+		}
+
+		if len(messages) == 0 {
+			messages = append(messages, version+" with a valid certificate")
+		}
+		add("tls", 10, status, version, "%s", strings.Join(messages, "; "))
+	} else {
+		add("tls", 10, models.SecurityCheckFail, "", "No TLS connection")
+	}
+
+	// Strict-Transport-Security
+	hsts := header.Get("Strict-Transport-Security")
+	switch maxAge, ok := hstsMaxAge(hsts); {
+	case !isHTTPS:
+		add("hsts", 15, models.SecurityCheckFail, hsts, "HSTS only takes effect over HTTPS")
+	case hsts == "":
+		add("hsts", 15, models.SecurityCheckFail, hsts, "Strict-Transport-Security header is missing")
+	case !ok:
+		add("hsts", 15, models.SecurityCheckFail, hsts, "Strict-Transport-Security header has no valid max-age")
+	case maxAge < minHSTSMaxAge:
+		add("hsts", 15, models.SecurityCheckWarn, hsts, "HSTS max-age of %d seconds is below 180 days", maxAge)
+	default:
+		add("hsts", 15, models.SecurityCheckPass, hsts, "HSTS is enabled")
+	}
+
+	// Content-Security-Policy
+	csp := header.Get("Content-Security-Policy")
+	directives := cspDirectives(csp)
+	switch {
+	case csp == "" && header.Get("Content-Security-Policy-Report-Only") != "":
+		add("csp", 15, models.SecurityCheckWarn, header.Get("Content-Security-Policy-Report-Only"), "Content security policy is only reported, not enforced")
+	case csp == "":
+		add("csp", 15, models.SecurityCheckFail, csp, "Content-Security-Policy header is missing")
+	case allowsUnsafeScripts(directives):
+		add("csp", 15, models.SecurityCheckWarn, csp, "Content security policy allows unsafe-inline or unsafe-eval scripts")
+	default:
+		add("csp", 15, models.SecurityCheckPass, csp, "Content security policy is enforced")
+	}
+
+	// X-Frame-Options, or its CSP successor frame-ancestors
+	xfo := strings.ToUpper(strings.TrimSpace(header.Get("X-Frame-Options")))
+	_, hasFrameAncestors := directives["frame-ancestors"]
+	switch {
+	case xfo == "DENY" || xfo == "SAMEORIGIN":
+		add("x_frame_options", 10, models.SecurityCheckPass, xfo, "Framing by other sites is blocked")
+	case hasFrameAncestors:
+		add("x_frame_options", 10, models.SecurityCheckPass, "frame-ancestors "+directives["frame-ancestors"], "Framing is limited by the CSP frame-ancestors directive")
+	case xfo != "":
+		add("x_frame_options", 10, models.SecurityCheckWarn, xfo, "X-Frame-Options value %s is not supported by current browsers", xfo)
+	default:
+		add("x_frame_options", 10, models.SecurityCheckFail, xfo, "X-Frame-Options header is missing, the page can be framed for clickjacking")
+	}
+
+	// X-Content-Type-Options
+	if xcto := strings.TrimSpace(header.Get("X-Content-Type-Options")); strings.EqualFold(xcto, "nosniff") {
+		add("x_content_type_options", 10, models.SecurityCheckPass, xcto, "MIME type sniffing is disabled")
+	} else {
+		add("x_content_type_options", 10, models.SecurityCheckFail, xcto, "X-Content-Type-Options: nosniff is missing")
+	}
+
+	// Referrer-Policy, of which browsers use the last value they understand
+	referrer := strings.TrimSpace(header.Get("Referrer-Policy"))
+	policy := ""
+	for _, token := range strings.Split(referrer, ",") {
+		if token = strings.ToLower(strings.TrimSpace(token)); referrerPolicyStatus[token] != "" {
+			policy = token
+		}
+	}
+	if policy == "" {
+		add("referrer_policy", 10, models.SecurityCheckWarn, referrer, "Referrer-Policy is not set, browsers fall back to strict-origin-when-cross-origin")
+	} else {
+		add("referrer_policy", 10, referrerPolicyStatus[policy], referrer, "Referrer policy is %s", policy)
+	}
+
+	// Permissions-Policy
+	if permissions := strings.TrimSpace(header.Get("Permissions-Policy")); permissions != "" {
+		add("permissions_policy", 5, models.SecurityCheckPass, permissions, "Browser features are restricted")
+	} else {
+		add("permissions_policy", 5, models.SecurityCheckFail, permissions, "Permissions-Policy header is missing")
+	}
+
+	// Cookies
+	status := models.SecurityCheckPass
+	problems := []string{}
+	for _, cookie := range resp.Cookies() {
+		cookieReport := models.CookieReport{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		}
+		report.Cookies = append(report.Cookies, cookieReport)
+
+		issues := []string{}
+		if isHTTPS && !cookie.Secure {
+			status = models.SecurityCheckFail
+			issues = append(issues, "not Secure")
+		}
+		if cookieReport.SameSite == "None" && !cookie.Secure {
+			status = models.SecurityCheckFail
+			issues = append(issues, "SameSite=None without Secure")
+		}
+		if !cookie.HttpOnly {
+			issues = append(issues, "not HttpOnly")
+		}
+		if cookieReport.SameSite == "" {
+			issues = append(issues, "no SameSite")
+		}
+		if len(issues) > 0 {
+			if status == models.SecurityCheckPass {
+				status = models.SecurityCheckWarn
+			}
+			problems = append(problems, cookie.Name+": "+strings.Join(issues, ", "))
+		}
+	}
+	cookieCount := strconv.Itoa(len(report.Cookies)) + " cookies"
+	switch {
+	case len(report.Cookies) == 0:
+		add("cookies", 10, status, cookieCount, "No cookies are set")
+	case len(problems) == 0:
+		add("cookies", 10, status, cookieCount, "All cookies are Secure, HttpOnly and have SameSite")
+	default:
+		add("cookies", 10, status, cookieCount, "%s", strings.Join(problems, "; "))
+	}
+
+	report.Score, report.Grade = securityScore(report.Checks)
+	return report
+}
+
+// adds up the weights of the checks, half for warnings, and grades the score
+func securityScore(checks []models.SecurityCheck) (int, string) {
+	earned, total := 0.0, 0
+	for _, check := range checks {
+		total += check.Weight
+		switch check.Status {
+		case models.SecurityCheckPass:
+			earned += float64(check.Weight)
+		case models.SecurityCheckWarn:
+			earned += float64(check.Weight) / 2
+		}
+	}
+	if total == 0 {
+		return 0, "F"
+	}
+
+	score := int(math.Round(earned * 100 / float64(total)))
+	switch {
+	case score >= 90:
+		return score, "A"
+	case score >= 80:
+		return score, "B"
+	case score >= 70:
+		return score, "C"
+	case score >= 60:
+		return score, "D"
+	default:
+		return score, "F"
+	}
+}
+
+// reports expired or soon expiring certificates and outdated TLS versions
+func securityFindings(report *models.SecurityReport, now time.Time) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	add := func(code string, severity models.FindingSeverity, format string, args ...interface{}) {
+		findings = append(findings, models.CrawlFinding{
+			Category: models.FindingCategorySecurity,
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if report.CertExpiresAt != nil {
+		expiresAt := *report.CertExpiresAt
+		switch left := expiresAt.Sub(now); {
+		case left <= 0:
+			add("certificate_expired", models.SeverityError, "TLS certificate expired on %s", expiresAt.Format("2006-01-02"))
+		case left < certificateExpiryWarning:
+			add("certificate_expiring", models.SeverityWarning, "TLS certificate expires in %d days, on %s", daysUntil(expiresAt, now), expiresAt.Format("2006-01-02"))
+		}
+	}
+
+	for _, check := range report.Checks {
+		if check.Name == "https" && check.Status == models.SecurityCheckFail {
+			add("not_https", models.SeverityWarning, "Page is served over plain HTTP")
+		}
+	}
+
+	if report.TLSVersion != nil {
+		switch *report.TLSVersion {
+		case "SSLv3", "TLS 1.0", "TLS 1.1":
+			add("outdated_tls", models.SeverityError, "Connection uses %s, use TLS 1.2 or later", *report.TLSVersion)
+		}
+	}
+
+	return findings
+}
+
+// returns the whole days left until a time, 0 once it has passed
+func daysUntil(t, now time.Time) int {
+	if !t.After(now) {
+		return 0
+	}
+	return int(t.Sub(now).Hours() / 24)
+}
+
+// reads the max-age of a Strict-Transport-Security header
+func hstsMaxAge(value string) (int, bool) {
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		maxAge, err := strconv.Atoi(strings.Trim(strings.TrimSpace(arg), `"`))
+		return maxAge, err == nil && maxAge >= 0
+	}
+	return 0, false
+}
+
+// splits a content security policy into its directives, keyed by lower-cased name
+func cspDirectives(policy string) map[string]string {
+	directives := map[string]string{}
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := directives[name]; !seen {
+			directives[name] = strings.Join(fields[1:], " ")
+		}
+	}
+	return directives
+}
+
+// reports whether a content security policy lets inline or eval'd scripts run
+func allowsUnsafeScripts(directives map[string]string) bool {
+	// script-src replaces default-src for scripts
+	sources, ok := directives["script-src"]
+	if !ok {
+		sources, ok = directives["default-src"]
+	}
+	if !ok {
+		return true
+	}
+	sources = strings.ToLower(sources)
+	return strings.Contains(sources, "'unsafe-inline'") || strings.Contains(sources, "'unsafe-eval'")
+}
+
+// names a SameSite mode as written in Set-Cookie, empty when the cookie does not set it
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
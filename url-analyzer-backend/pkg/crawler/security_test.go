@@ -0,0 +1,192 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// builds a response for the page with the given headers and TLS state
+func securityResponse(t *testing.T, pageURL string, header http.Header, state *tls.ConnectionState) *http.Response {
+	parsed, err := url.Parse(pageURL)
+	require.NoError(t, err)
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Request:    &http.Request{Method: http.MethodGet, URL: parsed},
+		TLS:        state,
+	}
+}
+
+// a TLS connection whose certificate expires at the given time
+func tlsState(version uint16, expiresAt time.Time) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		Version: version,
+		PeerCertificates: []*x509.Certificate{{
+			Issuer:   pkix.Name{CommonName: "Example CA"},
+			Subject:  pkix.Name{CommonName: "example.com"},
+			NotAfter: expiresAt,
+		}},
+	}
+}
+
+// returns the statuses of the checks by name
+func checkStatuses(report models.SecurityReport) map[string]models.SecurityCheckStatus {
+	statuses := map[string]models.SecurityCheckStatus{}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+func TestAnalyzeSecurity_HardenedSite(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	header.Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Referrer-Policy", "no-referrer, strict-origin-when-cross-origin")
+	header.Set("Permissions-Policy", "camera=(), geolocation=()")
+	header.Add("Set-Cookie", "session=abc; Path=/; Secure; HttpOnly; SameSite=Lax")
+
+	report := analyzeSecurity(securityResponse(t, "https://example.com/", header, tlsState(tls.VersionTLS13, now.AddDate(1, 0, 0))), nil, now)
+
+	assert.Equal(t, 100, report.Score)
+	assert.Equal(t, "A", report.Grade)
+	for name, status := range checkStatuses(report) {
+		assert.Equal(t, models.SecurityCheckPass, status, name)
+	}
+
+	require.NotNil(t, report.TLSVersion)
+	assert.Equal(t, "TLS 1.3", *report.TLSVersion)
+	assert.Equal(t, "Example CA", *report.CertIssuer)
+	assert.Equal(t, "example.com", *report.CertSubject)
+	assert.Equal(t, now.AddDate(1, 0, 0), *report.CertExpiresAt)
+	assert.Equal(t, models.CookieReports{{Name: "session", Secure: true, HttpOnly: true, SameSite: "Lax"}}, report.Cookies)
+	assert.Empty(t, securityFindings(&report, now))
+}
+
+func TestAnalyzeSecurity_PlainHTTP(t *testing.T) {
+	header := http.Header{}
+	header.Set("Strict-Transport-Security", "max-age=63072000")
+	header.Add("Set-Cookie", "id=1")
+
+	report := analyzeSecurity(securityResponse(t, "http://example.com/", header, nil), nil, time.Now())
+
+	statuses := checkStatuses(report)
+	assert.Equal(t, models.SecurityCheckFail, statuses["https"])
+	assert.Equal(t, models.SecurityCheckFail, statuses["tls"])
+	assert.Equal(t, models.SecurityCheckFail, statuses["hsts"], "HSTS is ignored over plain HTTP")
+	assert.Equal(t, models.SecurityCheckWarn, statuses["cookies"], "Secure is not expected without HTTPS")
+	assert.Nil(t, report.TLSVersion)
+	assert.Nil(t, report.CertExpiresAt)
+	assert.Equal(t, 10, report.Score)
+	assert.Equal(t, "F", report.Grade)
+
+	findings := securityFindings(&report, time.Now())
+	require.Len(t, findings, 1)
+	assert.Equal(t, "not_https", findings[0].Code)
+}
+
+func TestAnalyzeSecurity_HeaderChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		cookies  []string
+		check    string
+		expected models.SecurityCheckStatus
+	}{
+		{"short hsts", map[string]string{"Strict-Transport-Security": "max-age=86400"}, nil, "hsts", models.SecurityCheckWarn},
+		{"hsts without max-age", map[string]string{"Strict-Transport-Security": "includeSubDomains"}, nil, "hsts", models.SecurityCheckFail},
+		{"csp report only", map[string]string{"Content-Security-Policy-Report-Only": "default-src 'self'"}, nil, "csp", models.SecurityCheckWarn},
+		{"csp unsafe inline scripts", map[string]string{"Content-Security-Policy": "script-src 'self' 'unsafe-inline'"}, nil, "csp", models.SecurityCheckWarn},
+		{"csp without script sources", map[string]string{"Content-Security-Policy": "img-src 'self'"}, nil, "csp", models.SecurityCheckWarn},
+		{"csp script-src overrides default-src", map[string]string{"Content-Security-Policy": "default-src * 'unsafe-eval'; script-src 'self'"}, nil, "csp", models.SecurityCheckPass},
+		{"x-frame-options sameorigin", map[string]string{"X-Frame-Options": "sameorigin"}, nil, "x_frame_options", models.SecurityCheckPass},
+		{"x-frame-options allow-from", map[string]string{"X-Frame-Options": "ALLOW-FROM https://a.example"}, nil, "x_frame_options", models.SecurityCheckWarn},
+		{"x-frame-options missing", nil, nil, "x_frame_options", models.SecurityCheckFail},
+		{"nosniff missing", nil, nil, "x_content_type_options", models.SecurityCheckFail},
+		{"referrer unsafe-url", map[string]string{"Referrer-Policy": "unsafe-url"}, nil, "referrer_policy", models.SecurityCheckFail},
+		{"referrer origin", map[string]string{"Referrer-Policy": "origin"}, nil, "referrer_policy", models.SecurityCheckWarn},
+		{"referrer missing", nil, nil, "referrer_policy", models.SecurityCheckWarn},
+		{"permissions policy missing", nil, nil, "permissions_policy", models.SecurityCheckFail},
+		{"no cookies", nil, nil, "cookies", models.SecurityCheckPass},
+		{"cookie without httponly", nil, []string{"theme=dark; Secure; SameSite=Strict"}, "cookies", models.SecurityCheckWarn},
+		{"cookie without samesite", nil, []string{"a=1; Secure; HttpOnly"}, "cookies", models.SecurityCheckWarn},
+		{"cookie not secure", nil, []string{"a=1; HttpOnly; SameSite=Lax"}, "cookies", models.SecurityCheckFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.headers {
+				header.Set(key, value)
+			}
+			for _, cookie := range tt.cookies {
+				header.Add("Set-Cookie", cookie)
+			}
+
+			report := analyzeSecurity(securityResponse(t, "https://example.com/", header, tlsState(tls.VersionTLS12, time.Now().AddDate(1, 0, 0))), nil, time.Now())
+			assert.Equal(t, tt.expected, checkStatuses(report)[tt.check])
+		})
+	}
+}
+
+func TestAnalyzeSecurity_Certificates(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		state            *tls.ConnectionState
+		expectedStatus   models.SecurityCheckStatus
+		expectedFindings []string
+	}{
+		{"valid", tlsState(tls.VersionTLS12, now.AddDate(0, 3, 0)), models.SecurityCheckPass, []string{}},
+		{"expiring", tlsState(tls.VersionTLS13, now.AddDate(0, 0, 12)), models.SecurityCheckWarn, []string{"certificate_expiring"}},
+		{"expired", tlsState(tls.VersionTLS13, now.AddDate(0, 0, -1)), models.SecurityCheckFail, []string{"certificate_expired"}},
+		{"outdated tls", tlsState(tls.VersionTLS10, now.AddDate(1, 0, 0)), models.SecurityCheckFail, []string{"outdated_tls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := analyzeSecurity(securityResponse(t, "https://example.com/", nil, tt.state), nil, now)
+			assert.Equal(t, tt.expectedStatus, checkStatuses(report)["tls"])
+
+			codes := []string{}
+			for _, finding := range securityFindings(&report, now) {
+				assert.Equal(t, models.FindingCategorySecurity, finding.Category)
+				codes = append(codes, finding.Code)
+			}
+			assert.Equal(t, tt.expectedFindings, codes)
+		})
+	}
+
+	report := analyzeSecurity(securityResponse(t, "https://example.com/", nil, tlsState(tls.VersionTLS13, now.AddDate(0, 0, 12))), nil, now)
+	findings := securityFindings(&report, now)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "TLS certificate expires in 12 days, on 2026-10-13", findings[0].Message)
+}
+
+func TestCrawler_GradesSecurity(t *testing.T) {
+	result := crawlHTML(t, `<html lang="en"><head><title>Secure</title></head><body><h1>Hi</h1></body></html>`, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+	})
+
+	require.NotNil(t, result.Security)
+	statuses := checkStatuses(*result.Security)
+	assert.Equal(t, models.SecurityCheckPass, statuses["x_content_type_options"])
+	assert.Equal(t, models.SecurityCheckPass, statuses["x_frame_options"])
+	assert.Equal(t, models.SecurityCheckFail, statuses["https"])
+	assert.Len(t, result.Security.Checks, 9)
+}
@@ -0,0 +1,177 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// lengths past which search results cut titles and descriptions
+const (
+	maxTitleLength       = 60
+	maxDescriptionLength = 160
+)
+
+// extracts what a page tells search engines and social networks about itself
+func extractSEOMetadata(doc *goquery.Document, baseURL *url.URL) models.SEOMetadata {
+	seo := models.SEOMetadata{
+		Robots:      []string{},
+		Hreflang:    []models.HreflangAlternate{},
+		OpenGraph:   make(map[string]string),
+		TwitterCard: make(map[string]string),
+		Lang:        strings.TrimSpace(doc.Find("html").First().AttrOr("lang", "")),
+	}
+
+	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
+		content, exists := s.Attr("content")
+		if !exists {
+			return
+		}
+		content = strings.TrimSpace(content)
+
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", "")))
+		property := strings.ToLower(strings.TrimSpace(s.AttrOr("property", "")))
+
+		switch {
+		case name == "description" && seo.Description == "":
+			seo.Description = content
+		case name == "robots":
+			seo.Robots = append(seo.Robots, robotsDirectives(content)...)
+		case strings.HasPrefix(property, "og:"):
+			// Repeated tags such as og:image keep their first value
+			if _, seen := seo.OpenGraph[property]; !seen {
+				seo.OpenGraph[property] = content
+			}
+		case strings.HasPrefix(name, "twitter:") || strings.HasPrefix(property, "twitter:"):
+			key := name
+			if key == "" {
+				key = property
+			}
+			if _, seen := seo.TwitterCard[key]; !seen {
+				seo.TwitterCard[key] = content
+			}
+		}
+	})
+
+	seo.Canonical = canonicalURL(doc, baseURL)
+
+	doc.Find("link[rel~='alternate' i][hreflang][href]").Each(func(i int, s *goquery.Selection) {
+		alternate, err := baseURL.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil {
+			return
+		}
+		seo.Hreflang = append(seo.Hreflang, models.HreflangAlternate{
+			Lang: strings.TrimSpace(s.AttrOr("hreflang", "")),
+			URL:  alternate.String(),
+		})
+	})
+
+	return seo
+}
+
+// returns the absolute canonical URL a page declares, empty when it declares none
+func canonicalURL(doc *goquery.Document, baseURL *url.URL) string {
+	if href, exists := doc.Find("link[rel~='canonical' i][href]").First().Attr("href"); exists {
+		if canonical, err := baseURL.Parse(strings.TrimSpace(href)); err == nil {
+			return canonical.String()
+		}
+	}
+	return ""
+}
+
+// splits a robots meta tag or X-Robots-Tag header into lower-cased directives
+func robotsDirectives(value string) []string {
+	directives := []string{}
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// robots directives that take a value after a colon. Before any other colon comes a user agent.
+var robotsValueDirectives = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// splits an X-Robots-Tag header into its directives. Directives for one crawler, as in
+// "googlebot: noindex", are kept without the user agent: they still keep the page out of an index.
+func headerRobotsDirectives(value string) []string {
+	directives := []string{}
+	for _, directive := range robotsDirectives(value) {
+		if agent, rest, found := strings.Cut(directive, ":"); found && !robotsValueDirectives[strings.TrimSpace(agent)] {
+			directive = strings.TrimSpace(rest)
+		}
+		if directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// reports the SEO problems of a crawled page served from pageURL, after any redirects
+func seoFindings(result *models.CrawlJobResult, pageURL *url.URL) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	add := func(code string, severity models.FindingSeverity, format string, args ...interface{}) {
+		findings = append(findings, models.CrawlFinding{
+			Category: models.FindingCategorySEO,
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if result.Title == "" {
+		add("missing_title", models.SeverityError, "Page has no title")
+	} else if length := utf8.RuneCountInString(result.Title); length > maxTitleLength {
+		add("title_too_long", models.SeverityWarning, "Title is %d characters long, search results show about %d", length, maxTitleLength)
+	}
+
+	switch h1Count := result.HeadingCounts["h1"]; {
+	case h1Count == 0:
+		add("missing_h1", models.SeverityWarning, "Page has no H1 heading")
+	case h1Count > 1:
+		add("multiple_h1", models.SeverityWarning, "Page has %d H1 headings", h1Count)
+	}
+
+	seo := result.SEO
+	if seo == nil {
+		return findings
+	}
+
+	if seo.Description == "" {
+		add("missing_description", models.SeverityWarning, "Page has no meta description")
+	} else if length := utf8.RuneCountInString(seo.Description); length > maxDescriptionLength {
+		add("description_too_long", models.SeverityInfo, "Meta description is %d characters long, search results show about %d", length, maxDescriptionLength)
+	}
+
+	if seo.Canonical != "" {
+		canonical, canonicalErr := NormalizeURL(seo.Canonical)
+		page, pageErr := NormalizeURL(pageURL.String())
+		if canonicalErr == nil && pageErr == nil && canonical != page {
+			add("canonical_elsewhere", models.SeverityWarning, "Canonical URL points to %s", seo.Canonical)
+		}
+	}
+
+	for _, directive := range seo.Robots {
+		if directive == "noindex" || directive == "none" {
+			add("noindex", models.SeverityInfo, "Page asks search engines not to index it")
+			break
+		}
+	}
+
+	if seo.Lang == "" {
+		add("missing_lang", models.SeverityInfo, "The html element has no lang attribute")
+	}
+
+	return findings
+}
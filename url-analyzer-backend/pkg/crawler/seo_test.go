@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// crawls a page serving the given HTML and headers
+func crawlHTML(t *testing.T, html string, headers map[string]string) *models.CrawlJobResult {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		w.Write([]byte(html))
+	}))
+	t.Cleanup(server.Close)
+
+	options := models.DefaultCrawlOptions()
+	options.CheckBrokenLinks = false
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL+"/page")
+	require.NoError(t, result.Error)
+	return result
+}
+
+// lists the codes of the findings in a category
+func findingCodes(findings []models.CrawlFinding, category string) []string {
+	codes := []string{}
+	for _, finding := range findings {
+		if finding.Category == category {
+			codes = append(codes, finding.Code)
+		}
+	}
+	return codes
+}
+
+func TestCrawler_ExtractsSEOMetadata(t *testing.T) {
+	result := crawlHTML(t, `<!DOCTYPE html>
+<html lang="en-GB">
+<head>
+    <title>Widgets</title>
+    <meta name="description" content=" Hand-made widgets. ">
+    <meta name="robots" content="NoIndex, follow">
+    <link rel="canonical" href="/page">
+    <link rel="alternate" hreflang="de" href="https://example.de/seite">
+    <link rel="alternate" hreflang="x-default" href="/page">
+    <meta property="og:title" content="Widgets">
+    <meta property="og:image" content="https://example.com/a.png">
+    <meta property="og:image" content="https://example.com/b.png">
+    <meta name="twitter:card" content="summary">
+</head>
+<body><h1>Widgets</h1></body>
+</html>`, map[string]string{"X-Robots-Tag": "noarchive"})
+
+	require.NotNil(t, result.SEO)
+	seo := result.SEO
+	assert.Equal(t, "Hand-made widgets.", seo.Description)
+	assert.Equal(t, []string{"noindex", "follow", "noarchive"}, seo.Robots)
+	assert.True(t, strings.HasSuffix(seo.Canonical, "/page"))
+	require.Len(t, seo.Hreflang, 2)
+	assert.Equal(t, models.HreflangAlternate{Lang: "de", URL: "https://example.de/seite"}, seo.Hreflang[0])
+	assert.Equal(t, "x-default", seo.Hreflang[1].Lang)
+	assert.Equal(t, map[string]string{"og:title": "Widgets", "og:image": "https://example.com/a.png"}, seo.OpenGraph)
+	assert.Equal(t, map[string]string{"twitter:card": "summary"}, seo.TwitterCard)
+	assert.Equal(t, "en-GB", seo.Lang)
+
+	// A canonical URL pointing at the page itself is fine
+	assert.Equal(t, []string{"noindex"}, findingCodes(result.Findings, models.FindingCategorySEO))
+}
+
+func TestCrawler_SEOFindings(t *testing.T) {
+	result := crawlHTML(t, `<html>
+<head>
+    <title>A title that goes on and on well past what any search result page will ever show</title>
+    <link rel="canonical" href="https://example.com/other">
+</head>
+<body><h1>One</h1><h1>Two</h1></body>
+</html>`, nil)
+
+	assert.ElementsMatch(t, []string{
+		"title_too_long",
+		"multiple_h1",
+		"missing_description",
+		"canonical_elsewhere",
+		"missing_lang",
+	}, findingCodes(result.Findings, models.FindingCategorySEO))
+
+	for _, finding := range result.Findings {
+		assert.NotEmpty(t, finding.Message)
+	}
+
+	result = crawlHTML(t, `<html lang="en"><head><meta name="description" content="x"></head><body></body></html>`, nil)
+	assert.ElementsMatch(t, []string{"missing_title", "missing_h1"}, findingCodes(result.Findings, models.FindingCategorySEO))
+
+	// Directives for a single crawler keep the page out of its index too
+	result = crawlHTML(t, `<html lang="en"><head><title>T</title><meta name="description" content="x"></head><body><h1>T</h1></body></html>`,
+		map[string]string{"X-Robots-Tag": "googlebot: noindex, unavailable_after: 2030-01-01"})
+	assert.Equal(t, []string{"noindex", "unavailable_after: 2030-01-01"}, result.SEO.Robots)
+	assert.Equal(t, []string{"noindex"}, findingCodes(result.Findings, models.FindingCategorySEO))
+}
+
+func TestCrawler_CanonicalAfterRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		w.Write([]byte(`<html lang="en"><head><title>New</title><meta name="description" content="x">
+<link rel="canonical" href="/new"></head><body><h1>New</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.CheckBrokenLinks = false
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL+"/old")
+	require.NoError(t, result.Error)
+
+	// The canonical URL is compared with the page the redirect ended on
+	assert.NotContains(t, findingCodes(result.Findings, models.FindingCategorySEO), "canonical_elsewhere")
+}
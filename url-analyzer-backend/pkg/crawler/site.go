@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+	"url-analyzer/internal/models"
+)
+
+// remembers link check outcomes so a site crawl checks each link only once
+type linkCheckCache struct {
+	mu      sync.Mutex
+	results map[string]linkCheck
+}
+
+func newLinkCheckCache() *linkCheckCache {
+	return &linkCheckCache{results: make(map[string]linkCheck)}
+}
+
+// returns the cached outcome for a link, running the check on a cache miss.
+// Cancelled checks are not remembered.
+func (lc *linkCheckCache) check(linkURL string, checkFn func(string) linkCheck) linkCheck {
+	key, err := NormalizeURL(linkURL)
+	if err != nil {
+		key = linkURL
+	}
+
+	lc.mu.Lock()
+	cached, exists := lc.results[key]
+	lc.mu.Unlock()
+
+	if !exists {
+		cached = checkFn(linkURL)
+		if !cached.cancelled {
+			lc.mu.Lock()
+			lc.results[key] = cached
+			lc.mu.Unlock()
+		}
+	}
+
+	return cached
+}
+
+// a page waiting to be crawled during a site crawl
+type sitePage struct {
+	url       string
+	parentURL string
+	depth     int
+}
+
+// crawls a site breadth-first starting at rootURL, following internal links
+// up to options.MaxDepth levels deep and visiting at most options.MaxPages pages.
+// Cancelling ctx stops the crawl and sets the site's error.
+func (c *Crawler) CrawlSite(ctx context.Context, rootURL string) *models.SiteCrawlResult {
+	startTime := time.Now()
+
+	site := &models.SiteCrawlResult{
+		RootURL: rootURL,
+		Pages:   []models.SitePageResult{},
+	}
+
+	rootKey, err := NormalizeURL(rootURL)
+	if err != nil {
+		site.Error = fmt.Errorf("invalid URL: %w", err)
+		c.reportProgress(models.CrawlStatusFailed, "Invalid URL", 100.0)
+		return site
+	}
+
+	maxPages := c.options.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+
+	cache := newLinkCheckCache()
+	seen := map[string]bool{rootKey: true}
+	queue := []sitePage{{url: rootURL}}
+
+	// The pages crawled and the pages they link to, for comparing them with the sitemap
+	crawled := map[string]bool{}
+	linked := []string{}
+	linkedSeen := map[string]bool{}
+
+	c.reportProgress(models.CrawlStatusStarted, "Starting site crawl", 0.0)
+
+	for len(queue) > 0 && len(site.Pages) < maxPages {
+		page := queue[0]
+		queue = queue[1:]
+
+		if len(site.Pages) > 0 && c.options.RespectRateLimit {
+			sleepContext(ctx, c.options.RateLimitDelay)
+		}
+
+		if ctx.Err() != nil {
+			site.Error = fmt.Errorf("crawl cancelled: %w", ctx.Err())
+			return site
+		}
+
+		progress := float64(len(site.Pages)) / float64(maxPages) * 100.0
+		c.reportProgress(models.CrawlStatusFetching, fmt.Sprintf("Crawling page %d: %s", len(site.Pages)+1, page.url), progress)
+
+		result, links := c.crawlPage(ctx, page.url, discardProgress, cache)
+		if ctx.Err() != nil {
+			site.Error = result.Error
+			return site
+		}
+
+		site.Pages = append(site.Pages, models.SitePageResult{
+			URL:       page.url,
+			ParentURL: page.parentURL,
+			Depth:     page.depth,
+			Result:    result,
+		})
+
+		if result.BlockedByRobots {
+			// Nothing can be crawled when robots.txt keeps us off the root page
+			if page.depth == 0 {
+				site.BlockedByRobots = true
+				c.reportProgress(models.CrawlStatusBlocked, "Blocked by robots.txt", 100.0)
+				return site
+			}
+			continue
+		}
+
+		if result.Error != nil {
+			// A site crawl cannot continue without its root page
+			if page.depth == 0 {
+				site.Error = result.Error
+				c.reportProgress(models.CrawlStatusFailed, "Failed to crawl root page", 100.0)
+				return site
+			}
+			continue
+		}
+
+		if key, err := NormalizeURL(page.url); err == nil {
+			crawled[key] = true
+		}
+		for _, key := range linkedPages(links) {
+			if !linkedSeen[key] {
+				linkedSeen[key] = true
+				linked = append(linked, key)
+			}
+		}
+
+		if page.depth >= c.options.MaxDepth {
+			continue
+		}
+
+		for _, link := range links {
+			if !link.IsInternal {
+				continue
+			}
+
+			key, err := NormalizeURL(link.URL)
+			if err != nil || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			queue = append(queue, sitePage{
+				url:       link.URL,
+				parentURL: page.url,
+				depth:     page.depth + 1,
+			})
+		}
+	}
+
+	site.PagesDiscovered = len(seen)
+
+	// Compare all pages the crawl saw linked with the sitemap, which also shows the listed pages none of them links to
+	if root := site.Pages[0].Result; root.Sitemap != nil {
+		if parsedRoot, err := url.Parse(rootURL); err == nil {
+			site.Sitemap = c.sitemaps.site(ctx, parsedRoot).coverage(linked, crawled)
+			site.Sitemap.InSitemap = root.Sitemap.InSitemap
+		}
+	}
+	site.CrawlDuration = time.Since(startTime)
+	c.reportProgress(models.CrawlStatusCompleted, fmt.Sprintf("Site crawl completed: %d pages", len(site.Pages)), 100.0)
+
+	return site
+}
@@ -0,0 +1,201 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// creates a small site: / -> /a, /b ; /a -> /a/deep, / ; /a/deep -> /a/deeper.
+// Its sitemap lists /, /a, /b and /orphan, which nothing links to.
+func createTestSite(hits *int32) *httptest.Server {
+	pages := map[string]string{
+		"/":         `<html><head><title>Home</title></head><body><a href="/a">A</a><a href="/b#top">B</a><a href="https://external.example.com">Ext</a></body></html>`,
+		"/a":        `<html><head><title>A</title></head><body><a href="/a/deep">Deep</a><a href="/">Home</a></body></html>`,
+		"/b":        `<html><head><title>B</title></head><body><a href="/a/">A again</a></body></html>`,
+		"/a/deep":   `<html><head><title>Deep</title></head><body><a href="/a/deeper">Deeper</a></body></html>`,
+		"/a/deeper": `<html><head><title>Deeper</title></head><body></body></html>`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		if r.URL.Path == "/sitemap.xml" {
+			w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+				`<url><loc>http://` + r.Host + `/</loc></url><url><loc>http://` + r.Host + `/a</loc></url>` +
+				`<url><loc>http://` + r.Host + `/b</loc></url><url><loc>http://` + r.Host + `/orphan</loc></url></urlset>`))
+			return
+		}
+		atomic.AddInt32(hits, 1)
+		html, exists := pages[r.URL.Path]
+		if !exists {
+			w.WriteHeader(404)
+			return
+		}
+		w.Write([]byte(html))
+	}))
+}
+
+func siteCrawlOptions(maxDepth, maxPages int) models.CrawlOptions {
+	options := models.DefaultCrawlOptions()
+	options.CheckBrokenLinks = false
+	options.RespectRateLimit = false
+	options.MaxDepth = maxDepth
+	options.MaxPages = maxPages
+	return options
+}
+
+func TestCrawler_CrawlSite_BreadthFirstWithDepthLimit(t *testing.T) {
+	var hits int32
+	server := createTestSite(&hits)
+	defer server.Close()
+
+	crawler := NewCrawler(siteCrawlOptions(1, 50))
+	site := crawler.CrawlSite(context.Background(), server.URL)
+
+	require.NoError(t, site.Error)
+	require.Len(t, site.Pages, 3)
+
+	assert.Equal(t, "Home", site.Pages[0].Result.Title)
+	assert.Equal(t, 0, site.Pages[0].Depth)
+	assert.Equal(t, "A", site.Pages[1].Result.Title)
+	assert.Equal(t, "B", site.Pages[2].Result.Title)
+	assert.Equal(t, server.URL, site.Pages[1].ParentURL)
+	assert.Equal(t, 1, site.Pages[2].Depth)
+
+	// Fragments don't produce a second visit and nothing beyond depth 1 is fetched
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestCrawler_CrawlSite_DeduplicatesNormalizedURLs(t *testing.T) {
+	var hits int32
+	server := createTestSite(&hits)
+	defer server.Close()
+
+	crawler := NewCrawler(siteCrawlOptions(5, 50))
+	site := crawler.CrawlSite(context.Background(), server.URL)
+
+	require.NoError(t, site.Error)
+
+	visited := map[string]bool{}
+	for _, page := range site.Pages {
+		key, err := NormalizeURL(page.URL)
+		require.NoError(t, err)
+		assert.False(t, visited[key], "page visited twice: %s", page.URL)
+		visited[key] = true
+	}
+	assert.Len(t, site.Pages, 5)
+	assert.Equal(t, "Deeper", site.Pages[4].Result.Title)
+	assert.Equal(t, 3, site.Pages[4].Depth)
+}
+
+func TestCrawler_CrawlSite_SitemapCoverage(t *testing.T) {
+	var hits int32
+	server := createTestSite(&hits)
+	defer server.Close()
+
+	crawler := NewCrawler(siteCrawlOptions(5, 50))
+	site := crawler.CrawlSite(context.Background(), server.URL)
+	require.NoError(t, site.Error)
+	require.NotNil(t, site.Sitemap)
+
+	assert.Equal(t, []string{server.URL + "/sitemap.xml"}, site.Sitemap.Sitemaps)
+	assert.Equal(t, 4, site.Sitemap.SitemapURLs)
+	assert.True(t, site.Sitemap.InSitemap)
+	assert.Equal(t, 5, site.Sitemap.LinkedURLs)
+	assert.Equal(t, []string{server.URL + "/a/deep", server.URL + "/a/deeper"}, site.Sitemap.NotInSitemap)
+	assert.Equal(t, 2, site.Sitemap.NotInSitemapCount)
+	assert.Equal(t, []string{server.URL + "/orphan"}, site.Sitemap.NotLinked)
+	assert.Equal(t, 1, site.Sitemap.NotLinkedCount)
+
+	// Each page is compared on its own too: /a/deep is not listed and links to a page that is not either
+	deep := site.Pages[3].Result
+	assert.Equal(t, "Deep", deep.Title)
+	require.NotNil(t, deep.Sitemap)
+	assert.False(t, deep.Sitemap.InSitemap)
+	assert.Equal(t, []string{server.URL + "/a/deeper"}, deep.Sitemap.NotInSitemap)
+	assert.Empty(t, deep.Sitemap.NotLinked)
+
+	codes := []string{}
+	for _, finding := range deep.Findings {
+		if finding.Category == models.FindingCategorySitemap {
+			codes = append(codes, finding.Code)
+		}
+	}
+	assert.Equal(t, []string{"not_in_sitemap", "linked_pages_not_in_sitemap"}, codes)
+}
+
+func TestCrawler_CrawlSite_PageBudget(t *testing.T) {
+	var hits int32
+	server := createTestSite(&hits)
+	defer server.Close()
+
+	crawler := NewCrawler(siteCrawlOptions(5, 2))
+	site := crawler.CrawlSite(context.Background(), server.URL)
+
+	require.NoError(t, site.Error)
+	assert.Len(t, site.Pages, 2)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestCrawler_CrawlSite_RootFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(404)
+			return
+		}
+		w.WriteHeader(500)
+	}))
+	defer server.Close()
+
+	crawler := NewCrawler(siteCrawlOptions(2, 10))
+	site := crawler.CrawlSite(context.Background(), server.URL)
+
+	assert.Error(t, site.Error)
+	assert.Len(t, site.Pages, 1)
+}
+
+func TestCrawler_CrawlSite_Cancelled(t *testing.T) {
+	var hits int32
+	server := createTestSite(&hits)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	crawler := NewCrawler(siteCrawlOptions(5, 50))
+	site := crawler.CrawlSite(ctx, server.URL)
+
+	assert.ErrorIs(t, site.Error, context.Canceled)
+	assert.Empty(t, site.Pages)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+}
+
+func TestNormalizeURL(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"HTTP://Example.COM", "http://example.com/"},
+		{"https://example.com:443/path/", "https://example.com/path"},
+		{"http://example.com:80/a#section", "http://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"https://example.com/?b=2&a=1", "https://example.com/?a=1&b=2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			normalized, err := NormalizeURL(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, normalized)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"url-analyzer/internal/models"
+	"url-analyzer/pkg/sitemap"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// how many pages of a site's sitemaps are compared with the links of a crawl
+	maxSitemapURLs = 50000
+	// how many sitemap files of a site are read, indexes included
+	maxSitemapFiles = 20
+	// how many URLs a sitemap coverage lists, the counts cover all of them
+	maxCoverageURLs = 500
+	// how many sites a SitemapCache keeps the sitemaps of
+	maxSharedSitemaps = 100
+)
+
+// the pages a site's sitemaps list
+type siteSitemap struct {
+	sitemaps  []string
+	urls      []string        // as listed, in order
+	keys      map[string]bool // normalized
+	truncated bool
+	errors    []string
+}
+
+// reports whether a page is listed
+func (s *siteSitemap) lists(pageURL string) bool {
+	key, err := NormalizeURL(pageURL)
+	return err == nil && s.keys[key]
+}
+
+// compares pages linked from the crawled pages with the sitemap. linked holds the pages a crawl
+// saw linked, normalized and in the order they were found. When crawled is given, the listed
+// pages neither linked nor crawled are reported too.
+func (s *siteSitemap) coverage(linked []string, crawled map[string]bool) *models.SitemapCoverage {
+	coverage := &models.SitemapCoverage{
+		Sitemaps:     s.sitemaps,
+		SitemapURLs:  len(s.urls),
+		LinkedURLs:   len(linked),
+		NotInSitemap: []string{},
+		NotLinked:    []string{},
+		Truncated:    s.truncated,
+		Errors:       s.errors,
+	}
+
+	linkedKeys := make(map[string]bool, len(linked))
+	for _, key := range linked {
+		linkedKeys[key] = true
+		if s.keys[key] {
+			continue
+		}
+		coverage.NotInSitemapCount++
+		if len(coverage.NotInSitemap) < maxCoverageURLs {
+			coverage.NotInSitemap = append(coverage.NotInSitemap, key)
+		}
+	}
+
+	if crawled != nil {
+		for _, listed := range s.urls {
+			key, err := NormalizeURL(listed)
+			if err != nil || linkedKeys[key] || crawled[key] {
+				continue
+			}
+			coverage.NotLinkedCount++
+			if len(coverage.NotLinked) < maxCoverageURLs {
+				coverage.NotLinked = append(coverage.NotLinked, listed)
+			}
+		}
+	}
+
+	return coverage
+}
+
+// loads the sitemaps of each site once
+type sitemapCache struct {
+	loader  *sitemap.Loader
+	robots  *robotsCache
+	entries map[string]*sitemapEntry
+	mu      sync.Mutex
+
+	// sitemaps loaded by other crawlers, nil when the crawler has none
+	shared *SitemapCache
+}
+
+type sitemapEntry struct {
+	load    sync.Once
+	sitemap *siteSitemap
+}
+
+func newSitemapCache(client *resty.Client, robots *robotsCache, userAgent string) *sitemapCache {
+	return &sitemapCache{
+		loader: &sitemap.Loader{
+			Client:      client.GetClient(),
+			UserAgent:   userAgent,
+			MaxURLs:     maxSitemapURLs,
+			MaxSitemaps: maxSitemapFiles,
+		},
+		robots:  robots,
+		entries: make(map[string]*sitemapEntry),
+	}
+}
+
+// returns the sitemap of the URL's site, loading it if needed
+func (sc *sitemapCache) site(ctx context.Context, target *url.URL) *siteSitemap {
+	key := strings.ToLower(target.Scheme + "://" + target.Host)
+
+	sc.mu.Lock()
+	entry, exists := sc.entries[key]
+	if !exists {
+		entry = &sitemapEntry{}
+		sc.entries[key] = entry
+	}
+	sc.mu.Unlock()
+
+	entry.load.Do(func() {
+		if shared := sc.shared.get(key); shared != nil {
+			entry.sitemap = shared
+			return
+		}
+		entry.sitemap = sc.fetch(ctx, target, key)
+		// Sitemaps that failed to load are read again by the next crawl
+		if ctx.Err() == nil && len(entry.sitemap.errors) == 0 {
+			sc.shared.put(key, entry.sitemap)
+		}
+	})
+	return entry.sitemap
+}
+
+// SitemapCache keeps the sitemaps of sites for a while, so crawls of pages of the same site
+// read them once even when each has its own crawler. It is safe for concurrent use.
+type SitemapCache struct {
+	ttl     time.Duration
+	entries map[string]sharedSitemap
+	mu      sync.Mutex
+}
+
+type sharedSitemap struct {
+	sitemap  *siteSitemap
+	loadedAt time.Time
+}
+
+// creates a cache keeping sitemaps for ttl after they were read
+func NewSitemapCache(ttl time.Duration) *SitemapCache {
+	return &SitemapCache{ttl: ttl, entries: make(map[string]sharedSitemap)}
+}
+
+// returns the sitemap of an origin unless there is none or it expired
+func (c *SitemapCache) get(origin string) *siteSitemap {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[origin]
+	if !exists || time.Since(entry.loadedAt) >= c.ttl {
+		return nil
+	}
+	return entry.sitemap
+}
+
+// keeps the sitemap of an origin, making room by dropping expired and then the oldest entries
+func (c *SitemapCache) put(origin string, site *siteSitemap) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[origin]; !exists && len(c.entries) >= maxSharedSitemaps {
+		oldest := ""
+		for key, entry := range c.entries {
+			if time.Since(entry.loadedAt) >= c.ttl {
+				delete(c.entries, key)
+			} else if oldest == "" || entry.loadedAt.Before(c.entries[oldest].loadedAt) {
+				oldest = key
+			}
+		}
+		if len(c.entries) >= maxSharedSitemaps {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[origin] = sharedSitemap{sitemap: site, loadedAt: time.Now()}
+}
+
+// reads the sitemaps robots.txt names, or /sitemap.xml when it names none. A missing
+// /sitemap.xml means the site has no sitemap, a sitemap named in robots.txt has to be there.
+func (sc *sitemapCache) fetch(ctx context.Context, target *url.URL, origin string) *siteSitemap {
+	site := &siteSitemap{sitemaps: []string{}, urls: []string{}, keys: map[string]bool{}, errors: []string{}}
+
+	_, robots := sc.robots.entry(ctx, target)
+	locations := robots.sitemaps
+	fallback := len(locations) == 0
+	if fallback {
+		locations = []string{origin + "/sitemap.xml"}
+	}
+
+	for _, location := range locations {
+		if len(site.sitemaps) >= maxSitemapFiles || len(site.urls) >= maxSitemapURLs {
+			site.truncated = true
+			break
+		}
+
+		loader := *sc.loader
+		loader.MaxURLs -= len(site.urls)
+		loader.MaxSitemaps -= len(site.sitemaps)
+		result, err := loader.Load(ctx, location)
+		if err != nil {
+			if !fallback || !noSitemapAt(err) {
+				site.errors = append(site.errors, err.Error())
+			}
+			continue
+		}
+
+		site.sitemaps = append(site.sitemaps, result.Sitemaps...)
+		site.errors = append(site.errors, result.Errors...)
+		site.truncated = site.truncated || result.Truncated
+		for _, entry := range result.URLs {
+			key, err := NormalizeURL(entry.Loc)
+			if err != nil || site.keys[key] {
+				continue
+			}
+			site.keys[key] = true
+			site.urls = append(site.urls, entry.Loc)
+		}
+	}
+
+	return site
+}
+
+// reports whether a sitemap request failed because there is no sitemap at its URL, as opposed to a broken one
+func noSitemapAt(err error) bool {
+	var statusErr *sitemap.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
+	}
+	// Sites that answer every path with a page of their own
+	return errors.Is(err, sitemap.ErrNotSitemap)
+}
+
+// returns the internal pages among links, normalized and without duplicates, in the order found
+func linkedPages(links []models.LinkInfo) []string {
+	pages := []string{}
+	seen := map[string]bool{}
+	for _, link := range links {
+		if !link.IsInternal {
+			continue
+		}
+		key, err := NormalizeURL(link.URL)
+		if err != nil || seen[key] {
+			continue
+		}
+		seen[key] = true
+		pages = append(pages, key)
+	}
+	return pages
+}
+
+// the findings of a sitemap coverage
+func sitemapFindings(coverage *models.SitemapCoverage) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	if coverage == nil {
+		return findings
+	}
+
+	if len(coverage.Errors) > 0 {
+		findings = append(findings, models.CrawlFinding{
+			Code:     "sitemap_unreadable",
+			Severity: models.SeverityWarning,
+			Message:  fmt.Sprintf("%d %s could not be read: %s", len(coverage.Errors), pluralize(len(coverage.Errors), "sitemap", "sitemaps"), coverage.Errors[0]),
+		})
+	}
+	if len(coverage.Sitemaps) == 0 {
+		if len(coverage.Errors) == 0 {
+			findings = append(findings, models.CrawlFinding{
+				Code:     "missing_sitemap",
+				Severity: models.SeverityInfo,
+				Message:  "The site has no sitemap: robots.txt names none and there is no /sitemap.xml",
+			})
+		}
+		return findings
+	}
+
+	// A sitemap that was not read completely may list what seems missing
+	if coverage.Truncated || len(coverage.Errors) > 0 {
+		return findings
+	}
+
+	if !coverage.InSitemap {
+		findings = append(findings, models.CrawlFinding{
+			Code:     "not_in_sitemap",
+			Severity: models.SeverityWarning,
+			Message:  "The page is not listed in the sitemap",
+		})
+	}
+	if coverage.NotInSitemapCount > 0 {
+		findings = append(findings, models.CrawlFinding{
+			Code:     "linked_pages_not_in_sitemap",
+			Severity: models.SeverityInfo,
+			Message: fmt.Sprintf("%d internal %s to pages the sitemap does not list, e.g. %s",
+				coverage.NotInSitemapCount, pluralize(coverage.NotInSitemapCount, "link points", "links point"), coverage.NotInSitemap[0]),
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sitemapFindingCodes(result *models.CrawlJobResult) []string {
+	codes := []string{}
+	for _, finding := range result.Findings {
+		if finding.Category == models.FindingCategorySitemap {
+			codes = append(codes, finding.Code)
+		}
+	}
+	return codes
+}
+
+func TestCrawler_SitemapFromRobots(t *testing.T) {
+	requested := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nAllow: /\nSitemap: http://" + r.Host + "/maps/index.xml\n"))
+		case "/maps/index.xml":
+			w.Write([]byte(`<sitemapindex><sitemap><loc>http://` + r.Host + `/maps/pages.xml</loc></sitemap>` +
+				`<sitemap><loc>http://` + r.Host + `/maps/gone.xml</loc></sitemap></sitemapindex>`))
+		case "/maps/pages.xml":
+			w.Write([]byte(`<urlset><url><loc>http://` + r.Host + `/home</loc></url><url><loc>http://` + r.Host + `/about/</loc></url></urlset>`))
+		case "/home":
+			w.Write([]byte(`<html><head><title>Home</title><link rel="canonical" href="/home"></head>
+<body><a href="/about">About</a><a href="/contact#form">Contact</a><a href="/contact">Contact again</a></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.CheckBrokenLinks = false
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL+"/home?ref=nav")
+	require.NoError(t, result.Error)
+	require.NotNil(t, result.Sitemap)
+
+	assert.Equal(t, []string{server.URL + "/maps/index.xml", server.URL + "/maps/pages.xml"}, result.Sitemap.Sitemaps)
+	assert.Equal(t, 2, result.Sitemap.SitemapURLs)
+	assert.True(t, result.Sitemap.InSitemap, "the canonical URL is listed")
+	assert.Equal(t, 2, result.Sitemap.LinkedURLs)
+	assert.Equal(t, []string{server.URL + "/contact"}, result.Sitemap.NotInSitemap)
+	assert.Empty(t, result.Sitemap.NotLinked)
+	require.Len(t, result.Sitemap.Errors, 1)
+	assert.Contains(t, result.Sitemap.Errors[0], "/maps/gone.xml: HTTP 404")
+	assert.NotContains(t, requested, "/sitemap.xml", "robots.txt names the sitemaps")
+
+	// Part of the sitemap is missing, so nothing is reported as not listed
+	assert.Equal(t, []string{"sitemap_unreadable"}, sitemapFindingCodes(result))
+}
+
+func TestCrawler_NoSitemap(t *testing.T) {
+	// Every path answers with the same page, /sitemap.xml included
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>App</title></head><body><a href="/settings">Settings</a></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.CheckBrokenLinks = false
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+	require.NotNil(t, result.Sitemap)
+
+	assert.Empty(t, result.Sitemap.Sitemaps)
+	assert.Empty(t, result.Sitemap.Errors)
+	assert.Equal(t, []string{"missing_sitemap"}, sitemapFindingCodes(result))
+}
+
+func TestCrawler_SitemapAnalyzerDisabled(t *testing.T) {
+	requested := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		w.Write([]byte(`<html><head><title>Page</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.FollowRobotsTxt = false
+	options.RespectRateLimit = false
+	options.Analyzers = map[string]bool{models.FindingCategorySitemap: false}
+	result := NewCrawler(options).CrawlURL(context.Background(), server.URL)
+	require.NoError(t, result.Error)
+
+	assert.Nil(t, result.Sitemap)
+	assert.Equal(t, []string{"/"}, requested)
+}
+
+func TestCrawler_SharedSitemapCache(t *testing.T) {
+	var sitemapRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+		case "/sitemap.xml":
+			atomic.AddInt32(&sitemapRequests, 1)
+			w.Write([]byte(`<urlset><url><loc>http://` + r.Host + `/a</loc></url><url><loc>http://` + r.Host + `/b</loc></url></urlset>`))
+		default:
+			w.Write([]byte(`<html><head><title>Page</title></head><body><a href="/a">A</a></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	options := models.DefaultCrawlOptions()
+	options.RespectRateLimit = false
+	options.CheckBrokenLinks = false
+	crawl := func(cache *SitemapCache, path string) *models.CrawlJobResult {
+		crawler := NewCrawler(options)
+		crawler.SetSitemapCache(cache)
+		result := crawler.CrawlURL(context.Background(), server.URL+path)
+		require.NoError(t, result.Error)
+		require.NotNil(t, result.Sitemap)
+		return result
+	}
+
+	// Crawlers of different jobs read the sitemap of a site once
+	cache := NewSitemapCache(time.Hour)
+	crawl(cache, "/a")
+	result := crawl(cache, "/b")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sitemapRequests))
+	assert.True(t, result.Sitemap.InSitemap)
+	assert.Equal(t, 2, result.Sitemap.SitemapURLs)
+
+	// Expired sitemaps are read again
+	expired := NewSitemapCache(0)
+	crawl(expired, "/a")
+	crawl(expired, "/b")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&sitemapRequests))
+}
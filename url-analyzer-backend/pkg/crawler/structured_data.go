@@ -0,0 +1,328 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"url-analyzer/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// the largest JSON-LD block whose data is kept, larger ones only report their types
+const maxJSONLDSize = 64 << 10
+
+// prefixes that mark a schema.org type or property
+var schemaOrgPrefixes = []string{"https://schema.org/", "http://schema.org/", "schema:"}
+
+// extracts the JSON-LD blocks, microdata items and RDFa items of a page
+func extractStructuredData(doc *goquery.Document, baseURL *url.URL) models.StructuredData {
+	data := models.StructuredData{
+		Types:     []string{},
+		JSONLD:    []models.JSONLDBlock{},
+		Microdata: []models.StructuredItem{},
+		RDFa:      []models.StructuredItem{},
+	}
+	types := map[string]bool{}
+
+	doc.Find("script[type]").Each(func(i int, s *goquery.Selection) {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.Split(s.AttrOr("type", ""), ";")[0]))
+		if mediaType != "application/ld+json" {
+			return
+		}
+		block := parseJSONLD(s.Text())
+		for _, t := range block.Types {
+			types[t] = true
+		}
+		data.JSONLD = append(data.JSONLD, block)
+	})
+
+	// Items that are the property of another item are found through it
+	doc.Find("[itemscope]").Each(func(i int, s *goquery.Selection) {
+		if _, nested := s.Attr("itemprop"); nested {
+			return
+		}
+		item := microdataItem(s, baseURL)
+		collectItemTypes(item, types)
+		data.Microdata = append(data.Microdata, item)
+	})
+
+	doc.Find("[typeof]").Each(func(i int, s *goquery.Selection) {
+		if _, nested := s.Attr("property"); nested && s.Parent().Closest("[typeof]").Length() > 0 {
+			return
+		}
+		item := rdfaItem(s, baseURL)
+		collectItemTypes(item, types)
+		data.RDFa = append(data.RDFa, item)
+	})
+
+	data.Types = sortedKeys(types)
+	return data
+}
+
+// parses a JSON-LD block and lists the types of the nodes in it
+func parseJSONLD(text string) models.JSONLDBlock {
+	block := models.JSONLDBlock{Types: []string{}}
+
+	raw := bytes.TrimSpace([]byte(text))
+	if len(raw) == 0 {
+		block.Error = "empty block"
+		return block
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			block.Error = fmt.Sprintf("%s at offset %d", syntaxErr.Error(), syntaxErr.Offset)
+		} else {
+			block.Error = err.Error()
+		}
+		return block
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		block.Error = "block is neither an object nor an array"
+		return block
+	}
+
+	block.Valid = true
+	types := map[string]bool{}
+	collectJSONLDTypes(value, "", types)
+	block.Types = sortedKeys(types)
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err == nil && compact.Len() <= maxJSONLDSize {
+		block.Data = compact.Bytes()
+	}
+	return block
+}
+
+// collects the @type of every node in a JSON-LD value. Nodes inherit the vocabulary of their parents.
+func collectJSONLDTypes(value interface{}, vocab string, types map[string]bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, element := range v {
+			collectJSONLDTypes(element, vocab, types)
+		}
+	case map[string]interface{}:
+		if context, ok := v["@context"]; ok {
+			vocab = jsonLDVocab(context, vocab)
+		}
+
+		switch t := v["@type"].(type) {
+		case string:
+			types[schemaType(t, vocab)] = true
+		case []interface{}:
+			for _, element := range t {
+				if name, ok := element.(string); ok {
+					types[schemaType(name, vocab)] = true
+				}
+			}
+		}
+
+		for key, element := range v {
+			if key != "@context" && key != "@type" {
+				collectJSONLDTypes(element, vocab, types)
+			}
+		}
+	}
+}
+
+// returns the vocabulary a JSON-LD @context sets, e.g. "https://schema.org"
+func jsonLDVocab(context interface{}, inherited string) string {
+	switch c := context.(type) {
+	case string:
+		return c
+	case map[string]interface{}:
+		if vocab, ok := c["@vocab"].(string); ok {
+			return vocab
+		}
+	case []interface{}:
+		for _, element := range c {
+			inherited = jsonLDVocab(element, inherited)
+		}
+	}
+	return inherited
+}
+
+// reads a microdata item and, through its properties, the items nested in it
+func microdataItem(scope *goquery.Selection, baseURL *url.URL) models.StructuredItem {
+	item := models.StructuredItem{
+		Types:      []string{},
+		ID:         strings.TrimSpace(scope.AttrOr("itemid", "")),
+		Properties: make(map[string][]interface{}),
+	}
+	for _, t := range strings.Fields(scope.AttrOr("itemtype", "")) {
+		item.Types = append(item.Types, schemaType(t, ""))
+	}
+
+	scope.Find("[itemprop]").Each(func(i int, prop *goquery.Selection) {
+		// Properties of nested items belong to them
+		if prop.Parent().Closest("[itemscope]").Get(0) != scope.Get(0) {
+			return
+		}
+
+		var value interface{}
+		if _, isItem := prop.Attr("itemscope"); isItem {
+			value = microdataItem(prop, baseURL)
+		} else {
+			value = microdataValue(prop, baseURL)
+		}
+
+		for _, name := range strings.Fields(prop.AttrOr("itemprop", "")) {
+			name = schemaType(name, "")
+			item.Properties[name] = append(item.Properties[name], value)
+		}
+	})
+
+	return item
+}
+
+// returns the value of a microdata property, which depends on the element that carries it
+func microdataValue(prop *goquery.Selection, baseURL *url.URL) string {
+	if content, exists := prop.Attr("content"); exists {
+		return strings.TrimSpace(content)
+	}
+
+	switch goquery.NodeName(prop) {
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return resolveURL(baseURL, prop.AttrOr("src", ""))
+	case "a", "area", "link":
+		return resolveURL(baseURL, prop.AttrOr("href", ""))
+	case "object":
+		return resolveURL(baseURL, prop.AttrOr("data", ""))
+	case "data", "meter":
+		return strings.TrimSpace(prop.AttrOr("value", ""))
+	case "time":
+		if datetime, exists := prop.Attr("datetime"); exists {
+			return strings.TrimSpace(datetime)
+		}
+	}
+	return strings.Join(strings.Fields(prop.Text()), " ")
+}
+
+// reads an RDFa item and, through its properties, the items nested in it
+func rdfaItem(scope *goquery.Selection, baseURL *url.URL) models.StructuredItem {
+	vocab := scope.Closest("[vocab]").AttrOr("vocab", "")
+	item := models.StructuredItem{
+		Types:      []string{},
+		Properties: make(map[string][]interface{}),
+	}
+	for _, t := range strings.Fields(scope.AttrOr("typeof", "")) {
+		item.Types = append(item.Types, schemaType(t, vocab))
+	}
+	for _, attr := range []string{"resource", "about"} {
+		if id := strings.TrimSpace(scope.AttrOr(attr, "")); id != "" {
+			item.ID = resolveURL(baseURL, id)
+			break
+		}
+	}
+
+	scope.Find("[property]").Each(func(i int, prop *goquery.Selection) {
+		// Properties of nested items belong to them
+		if prop.Parent().Closest("[typeof]").Get(0) != scope.Get(0) {
+			return
+		}
+
+		var value interface{}
+		if _, isItem := prop.Attr("typeof"); isItem {
+			value = rdfaItem(prop, baseURL)
+		} else {
+			value = rdfaValue(prop, baseURL)
+		}
+
+		for _, name := range strings.Fields(prop.AttrOr("property", "")) {
+			name = schemaType(name, vocab)
+			item.Properties[name] = append(item.Properties[name], value)
+		}
+	})
+
+	return item
+}
+
+// returns the value of an RDFa property: its content, the resource it links to or its text
+func rdfaValue(prop *goquery.Selection, baseURL *url.URL) string {
+	if content, exists := prop.Attr("content"); exists {
+		return strings.TrimSpace(content)
+	}
+	for _, attr := range []string{"resource", "href", "src"} {
+		if target, exists := prop.Attr(attr); exists {
+			return resolveURL(baseURL, target)
+		}
+	}
+	return strings.Join(strings.Fields(prop.Text()), " ")
+}
+
+// adds the types of an item and of the items nested in it to the set
+func collectItemTypes(item models.StructuredItem, types map[string]bool) {
+	for _, t := range item.Types {
+		types[t] = true
+	}
+	for _, values := range item.Properties {
+		for _, value := range values {
+			if nested, ok := value.(models.StructuredItem); ok {
+				collectItemTypes(nested, types)
+			}
+		}
+	}
+}
+
+// shortens a schema.org type or property to its name, e.g. "https://schema.org/Product" to "Product".
+// Terms of other vocabularies are returned as full IRIs when the vocabulary is known.
+func schemaType(term, vocab string) string {
+	term = strings.TrimSpace(term)
+	for _, prefix := range schemaOrgPrefixes {
+		if strings.HasPrefix(term, prefix) {
+			return strings.TrimPrefix(term, prefix)
+		}
+	}
+	if vocab == "" || strings.Contains(term, ":") || strings.Contains(vocab, "schema.org") {
+		return term
+	}
+	if !strings.HasSuffix(vocab, "/") && !strings.HasSuffix(vocab, "#") {
+		vocab += "/"
+	}
+	return vocab + term
+}
+
+// resolves a possibly relative reference against the page URL, keeping it as written when it does not parse
+func resolveURL(baseURL *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if resolved, err := baseURL.Parse(ref); err == nil {
+		return resolved.String()
+	}
+	return ref
+}
+
+// reports JSON-LD blocks that do not parse
+func structuredDataFindings(data *models.StructuredData) []models.CrawlFinding {
+	findings := []models.CrawlFinding{}
+	for i, block := range data.JSONLD {
+		if !block.Valid {
+			findings = append(findings, models.CrawlFinding{
+				Category: models.FindingCategoryStructuredData,
+				Code:     "invalid_json_ld",
+				Severity: models.SeverityError,
+				Message:  fmt.Sprintf("JSON-LD block %d is invalid: %s", i+1, block.Error),
+			})
+		}
+	}
+	return findings
+}
+
+// returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
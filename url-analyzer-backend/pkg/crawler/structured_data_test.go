@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"testing"
+	"url-analyzer/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_ExtractsStructuredData(t *testing.T) {
+	result := crawlHTML(t, `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <title>Widget</title>
+    <script type="application/ld+json">
+    {
+        "@context": "https://schema.org",
+        "@type": "Product",
+        "name": "Widget",
+        "offers": {"@type": "Offer", "price": "9.99", "priceCurrency": "EUR"}
+    }
+    </script>
+    <script type="application/ld+json">{"@context": "https://schema.org", "@type": "BreadcrumbList",}</script>
+    <script type="application/json">{"not": "structured data"}</script>
+</head>
+<body>
+    <h1>Widget</h1>
+    <div itemscope itemtype="https://schema.org/Product" itemid="urn:sku:42">
+        <span itemprop="name">Widget  Deluxe</span>
+        <img itemprop="image" src="/widget.png" alt="Widget">
+        <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+            <meta itemprop="priceCurrency" content="EUR">
+            <span itemprop="price" content="19.99">€19.99</span>
+            <link itemprop="availability" href="https://schema.org/InStock">
+        </div>
+    </div>
+    <div vocab="https://schema.org/" typeof="Organization">
+        <span property="name">Widgets Inc.</span>
+        <a property="url" href="/about">About</a>
+        <div property="address" typeof="PostalAddress">
+            <span property="addressLocality">Berlin</span>
+        </div>
+    </div>
+</body>
+</html>`, nil)
+
+	data := result.StructuredData
+	require.NotNil(t, data)
+
+	// The invalid block contributes no types
+	assert.Equal(t, []string{"Offer", "Organization", "PostalAddress", "Product"}, data.Types)
+
+	// JSON-LD
+	require.Len(t, data.JSONLD, 2)
+	assert.True(t, data.JSONLD[0].Valid)
+	assert.Equal(t, []string{"Offer", "Product"}, data.JSONLD[0].Types)
+	assert.JSONEq(t, `{"@context":"https://schema.org","@type":"Product","name":"Widget","offers":{"@type":"Offer","price":"9.99","priceCurrency":"EUR"}}`, string(data.JSONLD[0].Data))
+	assert.False(t, data.JSONLD[1].Valid)
+	assert.NotEmpty(t, data.JSONLD[1].Error)
+	assert.Empty(t, data.JSONLD[1].Data)
+
+	// Microdata, with the offer nested in the product
+	require.Len(t, data.Microdata, 1)
+	product := data.Microdata[0]
+	assert.Equal(t, []string{"Product"}, product.Types)
+	assert.Equal(t, "urn:sku:42", product.ID)
+	assert.Equal(t, []interface{}{"Widget Deluxe"}, product.Properties["name"])
+	assert.Contains(t, product.Properties["image"][0], "/widget.png")
+	require.Len(t, product.Properties["offers"], 1)
+	offer, ok := product.Properties["offers"][0].(models.StructuredItem)
+	require.True(t, ok)
+	assert.Equal(t, []string{"Offer"}, offer.Types)
+	assert.Equal(t, []interface{}{"19.99"}, offer.Properties["price"])
+	assert.Equal(t, []interface{}{"EUR"}, offer.Properties["priceCurrency"])
+	assert.Equal(t, []interface{}{"https://schema.org/InStock"}, offer.Properties["availability"])
+	assert.NotContains(t, product.Properties, "price")
+
+	// RDFa
+	require.Len(t, data.RDFa, 1)
+	organization := data.RDFa[0]
+	assert.Equal(t, []string{"Organization"}, organization.Types)
+	assert.Equal(t, []interface{}{"Widgets Inc."}, organization.Properties["name"])
+	assert.Contains(t, organization.Properties["url"][0], "/about")
+	address, ok := organization.Properties["address"][0].(models.StructuredItem)
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"Berlin"}, address.Properties["addressLocality"])
+
+	// The broken JSON-LD block is reported
+	var structuredFindings []models.CrawlFinding
+	for _, finding := range result.Findings {
+		if finding.Category == models.FindingCategoryStructuredData {
+			structuredFindings = append(structuredFindings, finding)
+		}
+	}
+	require.Len(t, structuredFindings, 1)
+	assert.Equal(t, "invalid_json_ld", structuredFindings[0].Code)
+	assert.Contains(t, structuredFindings[0].Message, "block 2")
+}
+
+func TestParseJSONLD(t *testing.T) {
+	tests := []struct {
+		name          string
+		block         string
+		expectedValid bool
+		expectedTypes []string
+	}{
+		{"graph", `{"@context": "https://schema.org", "@graph": [{"@type": "WebSite"}, {"@type": ["Organization", "LocalBusiness"]}]}`, true, []string{"LocalBusiness", "Organization", "WebSite"}},
+		{"array of nodes", `[{"@type": "http://schema.org/Product"}, {"@type": "schema:Offer"}]`, true, []string{"Offer", "Product"}},
+		{"other vocabulary", `{"@context": {"@vocab": "http://example.com/ns#"}, "@type": "Thing"}`, true, []string{"http://example.com/ns#Thing"}},
+		{"no types", `{"name": "x"}`, true, []string{}},
+		{"empty", `   `, false, []string{}},
+		{"scalar", `"Product"`, false, []string{}},
+		{"syntax error", `{"@type": "Product"`, false, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := parseJSONLD(tt.block)
+			assert.Equal(t, tt.expectedValid, block.Valid)
+			assert.Equal(t, tt.expectedTypes, block.Types)
+			if !tt.expectedValid {
+				assert.NotEmpty(t, block.Error)
+			}
+		})
+	}
+}
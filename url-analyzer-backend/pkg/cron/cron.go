@@ -0,0 +1,221 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// how far ahead Next looks for a matching time before giving up, e.g. for "0 0 30 2 *"
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// shorthand expressions accepted in place of the five fields
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// the allowed range and value names of a field
+type fieldSpec struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteSpec = fieldSpec{name: "minute", min: 0, max: 59}
+	hourSpec   = fieldSpec{name: "hour", min: 0, max: 23}
+	domSpec    = fieldSpec{name: "day of month", min: 1, max: 31}
+	monthSpec  = fieldSpec{name: "month", min: 1, max: 12, names: monthNames}
+	// 7 is accepted as Sunday and folded onto 0
+	dowSpec = fieldSpec{name: "day of week", min: 0, max: 7, names: dayNames}
+)
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of month, month and day of week
+type Schedule struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+	// a restricted day of month or day of week; when both are restricted either one matching is enough
+	domRestricted bool
+	dowRestricted bool
+}
+
+// Parse parses a standard five-field cron expression such as "*/15 2-6 * * mon-fri",
+// or one of the macros @hourly, @daily, @midnight, @weekly, @monthly, @yearly and @annually
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, exists := macros[strings.ToLower(expr)]; exists {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	s := &Schedule{}
+
+	if err := parseField(fields[0], minuteSpec, s.minutes[:]); err != nil {
+		return nil, err
+	}
+	if err := parseField(fields[1], hourSpec, s.hours[:]); err != nil {
+		return nil, err
+	}
+	if err := parseField(fields[2], domSpec, s.days[:]); err != nil {
+		return nil, err
+	}
+	if err := parseField(fields[3], monthSpec, s.months[:]); err != nil {
+		return nil, err
+	}
+
+	var weekdays [8]bool
+	if err := parseField(fields[4], dowSpec, weekdays[:]); err != nil {
+		return nil, err
+	}
+	copy(s.weekdays[:], weekdays[:7])
+	if weekdays[7] {
+		s.weekdays[0] = true
+	}
+
+	s.domRestricted = restricted(fields[2])
+	s.dowRestricted = restricted(fields[4])
+
+	return s, nil
+}
+
+// reports whether a day field restricts the days; like in cron, a field starting with "*", such as "*/2", does not
+func restricted(field string) bool {
+	return !strings.HasPrefix(field, "*") && !strings.HasPrefix(field, "?")
+}
+
+// parses a comma separated list of values, ranges and steps into the set of allowed values
+func parseField(field string, spec fieldSpec, allowed []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		if err := parsePart(part, spec, allowed); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", spec.name, field, err)
+		}
+	}
+	return nil
+}
+
+// parses a single "*", "value", "a-b" or any of them followed by "/step"
+func parsePart(part string, spec fieldSpec, allowed []bool) error {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepPart)
+		if err != nil || step <= 0 {
+			return fmt.Errorf("invalid step %q", stepPart)
+		}
+	}
+
+	var start, end int
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		start, end = spec.min, spec.max
+	case strings.Contains(rangePart, "-"):
+		low, high, _ := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = parseValue(low, spec); err != nil {
+			return err
+		}
+		if end, err = parseValue(high, spec); err != nil {
+			return err
+		}
+		if start > end {
+			return fmt.Errorf("range %q is reversed", rangePart)
+		}
+	default:
+		value, err := parseValue(rangePart, spec)
+		if err != nil {
+			return err
+		}
+		start, end = value, value
+		// "5/15" means every 15 starting at 5
+		if hasStep {
+			end = spec.max
+		}
+	}
+
+	for value := start; value <= end; value += step {
+		allowed[value] = true
+	}
+	return nil
+}
+
+// parses a number or name within the field's range
+func parseValue(value string, spec fieldSpec) (int, error) {
+	if number, exists := spec.names[strings.ToLower(value)]; exists {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if number < spec.min || number > spec.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", number, spec.min, spec.max)
+	}
+	return number, nil
+}
+
+// reports whether the schedule allows the day of t
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.days[t.Day()]
+	dowMatch := s.weekdays[int(t.Weekday())]
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first time after t, truncated to the minute, that matches the schedule.
+// The zero time is returned when nothing matches within the next five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for t.Before(limit) {
+		if !s.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,254 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// the largest sitemap file the protocol allows, uncompressed
+const MaxFileSize = 50 << 20
+
+var (
+	// ErrTooLarge is returned for sitemap files larger than MaxFileSize once uncompressed
+	ErrTooLarge = fmt.Errorf("sitemap is larger than %d MB", MaxFileSize>>20)
+	// ErrNotSitemap is returned for documents that are not a sitemap, e.g. an HTML error page
+	ErrNotSitemap = errors.New("not a sitemap")
+)
+
+// StatusError is returned when the server answers a sitemap request with another status than 200 OK
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// URL is a page listed in a <urlset>. Only Loc is required, the others are passed on as written.
+type URL struct {
+	Loc        string `xml:"loc" json:"loc"`
+	LastMod    string `xml:"lastmod" json:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq" json:"changefreq,omitempty"`
+	Priority   string `xml:"priority" json:"priority,omitempty"`
+}
+
+// Document is a parsed sitemap file: a <urlset> lists pages, a <sitemapindex> lists other sitemaps
+type Document struct {
+	IsIndex  bool
+	URLs     []URL
+	Sitemaps []string
+}
+
+// Parse reads a sitemap or sitemap index, gzip compressed or not. Entries without a <loc> are skipped.
+func Parse(r io.Reader) (*Document, error) {
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = buffered
+	}
+
+	limited := &io.LimitedReader{R: r, N: MaxFileSize + 1}
+	decoder := xml.NewDecoder(limited)
+	// Sitemaps must be UTF-8, but the declaration of another charset should not stop the import
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	doc := &Document{}
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if limited.N <= 0 {
+				return nil, ErrTooLarge
+			}
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if root == "" {
+			root = start.Name.Local
+			switch root {
+			case "urlset":
+			case "sitemapindex":
+				doc.IsIndex = true
+			default:
+				return nil, fmt.Errorf("%w: the root element is <%s>, expected <urlset> or <sitemapindex>", ErrNotSitemap, root)
+			}
+			continue
+		}
+
+		switch {
+		case root == "urlset" && start.Name.Local == "url":
+			var entry URL
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				if limited.N <= 0 {
+					return nil, ErrTooLarge
+				}
+				return nil, fmt.Errorf("invalid XML: %w", err)
+			}
+			entry.Loc = strings.TrimSpace(entry.Loc)
+			entry.LastMod = strings.TrimSpace(entry.LastMod)
+			entry.ChangeFreq = strings.TrimSpace(entry.ChangeFreq)
+			entry.Priority = strings.TrimSpace(entry.Priority)
+			if entry.Loc != "" {
+				doc.URLs = append(doc.URLs, entry)
+			}
+		case root == "sitemapindex" && start.Name.Local == "sitemap":
+			var entry struct {
+				Loc string `xml:"loc"`
+			}
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				if limited.N <= 0 {
+					return nil, ErrTooLarge
+				}
+				return nil, fmt.Errorf("invalid XML: %w", err)
+			}
+			if loc := strings.TrimSpace(entry.Loc); loc != "" {
+				doc.Sitemaps = append(doc.Sitemaps, loc)
+			}
+		}
+	}
+
+	if root == "" {
+		return nil, fmt.Errorf("%w: the document is empty", ErrNotSitemap)
+	}
+	return doc, nil
+}
+
+// Result is what a Loader found: the pages of every sitemap it read, in the order listed
+type Result struct {
+	URLs      []URL    `json:"urls"`
+	Sitemaps  []string `json:"sitemaps"`  // the sitemap files read, indexes included
+	Errors    []string `json:"errors"`    // sitemaps of an index that could not be read
+	Truncated bool     `json:"truncated"` // whether MaxURLs or MaxSitemaps cut the result short
+}
+
+// Loader reads sitemaps and follows sitemap indexes to the sitemaps they list
+type Loader struct {
+	Client      *http.Client // http.DefaultClient when nil
+	UserAgent   string
+	MaxURLs     int // the most URLs returned, no limit when 0
+	MaxSitemaps int // the most sitemap files read, indexes included, no limit when 0
+}
+
+// Load reads the sitemap or sitemap index at sitemapURL. Only a failure to read that
+// sitemap is an error, sitemaps an index lists that cannot be read end up in Result.Errors.
+func (l *Loader) Load(ctx context.Context, sitemapURL string) (*Result, error) {
+	doc, err := l.fetch(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	return l.collect(ctx, sitemapURL, doc), nil
+}
+
+// Read parses a sitemap or sitemap index from r, e.g. an uploaded file, and loads the sitemaps
+// it lists. name stands in for the file's URL in Result.Sitemaps.
+func (l *Loader) Read(ctx context.Context, r io.Reader, name string) (*Result, error) {
+	doc, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return l.collect(ctx, name, doc), nil
+}
+
+// gathers the URLs of a parsed sitemap and of the sitemaps it lists, breadth-first.
+// Indexes are not supposed to list other indexes, but nested ones are followed too.
+func (l *Loader) collect(ctx context.Context, source string, doc *Document) *Result {
+	result := &Result{URLs: []URL{}, Sitemaps: []string{source}, Errors: []string{}}
+	seen := map[string]bool{source: true}
+	var queue []string // sitemaps listed by the indexes read so far, still to be read
+
+	for {
+		for _, entry := range doc.URLs {
+			if l.MaxURLs > 0 && len(result.URLs) >= l.MaxURLs {
+				result.Truncated = true
+				return result
+			}
+			result.URLs = append(result.URLs, entry)
+		}
+		for _, loc := range doc.Sitemaps {
+			if !seen[loc] {
+				seen[loc] = true
+				queue = append(queue, loc)
+			}
+		}
+
+		// Move on to the next listed sitemap that can be read
+		doc = nil
+		for doc == nil {
+			if len(queue) == 0 {
+				return result
+			}
+			if l.MaxSitemaps > 0 && len(result.Sitemaps) >= l.MaxSitemaps {
+				result.Truncated = true
+				return result
+			}
+
+			loc := queue[0]
+			queue = queue[1:]
+			next, err := l.fetch(ctx, loc)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				if ctx.Err() != nil {
+					return result
+				}
+				continue
+			}
+			result.Sitemaps = append(result.Sitemaps, loc)
+			doc = next
+		}
+	}
+}
+
+// downloads and parses a sitemap file
+func (l *Loader) fetch(ctx context.Context, sitemapURL string) (*Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sitemapURL, err)
+	}
+	if l.UserAgent != "" {
+		req.Header.Set("User-Agent", l.UserAgent)
+	}
+	req.Header.Set("Accept", "application/xml,text/xml;q=0.9,*/*;q=0.8")
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sitemapURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: sitemapURL, StatusCode: resp.StatusCode}
+	}
+
+	doc, err := Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sitemapURL, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,158 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc> https://example.com/ </loc>
+    <lastmod>2024-05-01</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>1.0</priority>
+  </url>
+  <url><loc>https://example.com/about</loc></url>
+  <url><lastmod>2024-05-01</lastmod></url>
+</urlset>`
+
+func gzipped(t *testing.T, content string) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(urlset))
+	require.NoError(t, err)
+
+	assert.False(t, doc.IsIndex)
+	assert.Equal(t, []URL{
+		{Loc: "https://example.com/", LastMod: "2024-05-01", ChangeFreq: "daily", Priority: "1.0"},
+		{Loc: "https://example.com/about"},
+	}, doc.URLs)
+	assert.Empty(t, doc.Sitemaps)
+}
+
+func TestParse_Index(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-pages.xml</loc><lastmod>2024-05-01</lastmod></sitemap>
+  <sitemap><loc>https://example.com/sitemap-posts.xml.gz</loc></sitemap>
+</sitemapindex>`))
+	require.NoError(t, err)
+
+	assert.True(t, doc.IsIndex)
+	assert.Empty(t, doc.URLs)
+	assert.Equal(t, []string{"https://example.com/sitemap-pages.xml", "https://example.com/sitemap-posts.xml.gz"}, doc.Sitemaps)
+}
+
+func TestParse_Gzip(t *testing.T) {
+	doc, err := Parse(bytes.NewReader(gzipped(t, urlset)))
+	require.NoError(t, err)
+	assert.Len(t, doc.URLs, 2)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"html", `<html><body>Not found</body></html>`, "not a sitemap: the root element is <html>"},
+		{"empty", ``, "not a sitemap: the document is empty"},
+		{"broken xml", `<urlset><url><loc>https://example.com/</url></urlset>`, "invalid XML"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestParse_TooLarge(t *testing.T) {
+	content := "<urlset><!--" + strings.Repeat(" ", MaxFileSize) + "--></urlset>"
+
+	_, err := Parse(bytes.NewReader(gzipped(t, content)))
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestLoader_Load(t *testing.T) {
+	var userAgents []string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		w.Write([]byte(`<sitemapindex>
+  <sitemap><loc>` + server.URL + `/pages.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/posts.xml.gz</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/missing.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap.xml</loc></sitemap>
+</sitemapindex>`))
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<urlset><url><loc>https://example.com/</loc></url><url><loc>https://example.com/about</loc></url></urlset>`))
+	})
+	mux.HandleFunc("/posts.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(gzipped(t, `<urlset><url><loc>https://example.com/posts/1</loc></url></urlset>`))
+	})
+
+	loader := &Loader{UserAgent: "URL-Analyzer/1.0"}
+	result, err := loader.Load(context.Background(), server.URL+"/sitemap.xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []URL{{Loc: "https://example.com/"}, {Loc: "https://example.com/about"}, {Loc: "https://example.com/posts/1"}}, result.URLs)
+	assert.Equal(t, []string{server.URL + "/sitemap.xml", server.URL + "/pages.xml", server.URL + "/posts.xml.gz"}, result.Sitemaps)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "/missing.xml: HTTP 404 Not Found")
+	assert.False(t, result.Truncated)
+	assert.Equal(t, []string{"URL-Analyzer/1.0"}, userAgents, "the index is read once even though it lists itself")
+
+	// Limits cut the result short
+	loader.MaxURLs = 2
+	result, err = loader.Load(context.Background(), server.URL+"/sitemap.xml")
+	require.NoError(t, err)
+	assert.Len(t, result.URLs, 2)
+	assert.True(t, result.Truncated)
+
+	loader.MaxURLs = 0
+	loader.MaxSitemaps = 2
+	result, err = loader.Load(context.Background(), server.URL+"/sitemap.xml")
+	require.NoError(t, err)
+	assert.Len(t, result.URLs, 2)
+	assert.Len(t, result.Sitemaps, 2)
+	assert.True(t, result.Truncated)
+
+	// Only the sitemap asked for has to be readable
+	_, err = loader.Load(context.Background(), server.URL+"/missing.xml")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+func TestLoader_Read(t *testing.T) {
+	loader := &Loader{}
+	result, err := loader.Read(context.Background(), bytes.NewReader(gzipped(t, urlset)), "sitemap.xml.gz")
+	require.NoError(t, err)
+
+	assert.Len(t, result.URLs, 2)
+	assert.Equal(t, []string{"sitemap.xml.gz"}, result.Sitemaps)
+	assert.Empty(t, result.Errors)
+}